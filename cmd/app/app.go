@@ -1,73 +1,540 @@
 package app
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"expvar"
+	"fmt"
+	"ivanjabrony/refstudy/cmd/config"
+	"ivanjabrony/refstudy/internal/blob"
+	"ivanjabrony/refstudy/internal/cache"
 	"ivanjabrony/refstudy/internal/controller"
+	"ivanjabrony/refstudy/internal/controller/graph"
+	"ivanjabrony/refstudy/internal/controller/rpc"
 	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/mailer"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/ratelimit"
 	"ivanjabrony/refstudy/internal/repository"
+	"ivanjabrony/refstudy/internal/token"
 	"ivanjabrony/refstudy/internal/usecase"
+	"ivanjabrony/refstudy/internal/worker"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
 )
 
 type App struct {
 	Router *gin.Engine
-	db     *pgxpool.Pool
+	GRPC   *grpc.Server
+	// Metrics serves expvar counters on an internal port; nil when
+	// METRICS_PORT isn't set.
+	Metrics *http.Server
+	Workers *worker.Pool
+	db      *pgxpool.Pool
 }
 
-func New(db *pgxpool.Pool) *App {
+// New wires the app over the primary database and, when replica isn't nil,
+// a read replica.
+func New(cfg *config.Config, db, replica *pgxpool.Pool) *App {
 	logger := logger.New(getLogLevel(), logger.LogFormatText)
-	repositories := mustInitRepositories(db, logger)
-	usecases := mustInitUsecases(repositories, logger)
+	repositories := mustInitRepositories(db, replica, logger)
+	mustInitUserCache(cfg, repositories, logger)
+	usecases := mustInitUsecases(cfg, repositories, mustInitBlobStore(cfg), logger)
 	validator := validator.New(validator.WithRequiredStructEnabled())
 
+	limits, err := ratelimit.ParseLimits(cfg.RateLimit.Default, cfg.RateLimit.Routes)
+	if err != nil {
+		log.Fatalf("couldn't parse rate limits: %v", err)
+	}
+	limiter := mustInitRateLimitStore(cfg, repositories)
+	guard, err := ratelimit.NewGuard(usecases.user, limiter, ratelimit.Lockout{
+		Threshold: cfg.Lockout.Threshold,
+		Base:      cfg.Lockout.Base,
+		Max:       cfg.Lockout.Max,
+		Window:    cfg.Lockout.Window,
+	})
+	if err != nil {
+		log.Fatalf("couldn't init login guard: %v", err)
+	}
+
 	router := controller.SetupRouter(
 		logger,
-		usecases.user,
+		controller.Usecases{
+			User:          usecases.user,
+			Admin:         usecases.user,
+			Job:           usecases.job,
+			Audit:         usecases.audit,
+			Account:       usecases.account,
+			Gallery:       usecases.gallery,
+			Authenticator: guard,
+			Graph:         graph.Usecases{User: usecases.user, Gallery: usecases.gallery},
+		},
+		controller.Options{
+			RateLimiter:    limiter,
+			RateLimits:     limits,
+			TrustedProxies: cfg.RateLimit.TrustedProxies,
+			GraphLimits: graph.Limits{
+				MaxDepth:      cfg.GraphQL.MaxDepth,
+				MaxComplexity: cfg.GraphQL.MaxComplexity,
+			},
+		},
+		validator,
+	)
+	grpcServer := rpc.NewServer(
+		logger,
+		rpc.Usecases{
+			User:          usecases.user,
+			Authenticator: guard,
+		},
+		rpc.Options{
+			RateLimiter: limiter,
+			RateLimits:  limits,
+		},
 		validator,
 	)
 
+	workers, err := worker.NewPool(repositories.job, logger, worker.Config{
+		Concurrency:  cfg.Jobs.Workers,
+		PollInterval: cfg.Jobs.PollInterval,
+		StaleAfter:   cfg.Jobs.StaleAfter,
+	})
+	if err != nil {
+		log.Fatalf("couldn't init worker pool: %v", err)
+	}
+	registerJobs(cfg, workers, usecases)
+	if cfg.RateLimit.Store == rateLimitStorePostgres {
+		workers.Every(time.Hour, func(ctx context.Context) error {
+			return repositories.rateLimit.PruneRateLimits(ctx, time.Now().Add(-max(cfg.Lockout.Window, cfg.Lockout.Max)))
+		})
+	}
+
 	return &App{
-		Router: router,
-		db:     db,
+		Router:  router,
+		GRPC:    grpcServer,
+		Metrics: newMetricsServer(cfg.Server.MetricsPort),
+		Workers: workers,
+		db:      db,
+	}
+}
+
+// Operations are the usecases refstudyctl runs against the database directly,
+// without going through the API.
+type Operations struct {
+	User *usecase.UserUsecase
+	Job  *usecase.JobUsecase
+	// Accounts changes what no usecase lets an operator change, like
+	// verifying an email without the emailed token.
+	Accounts usecase.AccountRepository
+}
+
+// NewOperations wires the usecases like New does, over the primary database
+// only and logging to logger.
+func NewOperations(cfg *config.Config, db *pgxpool.Pool, logger *logger.MyLogger) *Operations {
+	repositories := mustInitRepositories(db, nil, logger)
+	mustInitUserCache(cfg, repositories, logger)
+	usecases := mustInitUsecases(cfg, repositories, mustInitBlobStore(cfg), logger)
+	return &Operations{User: usecases.user, Job: usecases.job, Accounts: repositories.user}
+}
+
+// Run serves HTTP on addr, gRPC on grpcAddr and metrics if enabled, and
+// processes jobs until ctx is cancelled, then shuts everything down
+// gracefully.
+func (a *App) Run(ctx context.Context, addr, grpcAddr string) error {
+	listener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		return err
+	}
+	if err := a.Workers.Start(ctx); err != nil {
+		listener.Close()
+		return err
+	}
+	defer a.Workers.Stop()
+
+	servers := []*http.Server{{Addr: addr, Handler: a.Router}}
+	if a.Metrics != nil {
+		servers = append(servers, a.Metrics)
+	}
+	serverErr := make(chan error, len(servers)+1)
+	for _, server := range servers {
+		go func() {
+			serverErr <- server.ListenAndServe()
+		}()
+	}
+	go func() {
+		serverErr <- a.GRPC.Serve(listener)
+	}()
+
+	select {
+	case err := <-serverErr:
+		for _, server := range servers {
+			server.Close()
+		}
+		a.GRPC.Stop()
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	grpcStopped := make(chan struct{})
+	go func() {
+		a.GRPC.GracefulStop()
+		close(grpcStopped)
+	}()
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			a.GRPC.Stop()
+			return err
+		}
+	}
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		a.GRPC.Stop()
 	}
+	for range len(servers) + 1 {
+		if err := <-serverErr; err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, grpc.ErrServerStopped) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newMetricsServer exposes expvar counters at /debug/vars on port, which
+// shouldn't be reachable from outside.
+func newMetricsServer(port string) *http.Server {
+	if port == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	return &http.Server{Addr: ":" + port, Handler: mux}
 }
 
-func (a *App) Run(addr string) error {
-	return a.Router.Run(addr)
+const purgeDeletedUsersJob = "purge_deleted_users"
+
+func registerJobs(cfg *config.Config, workers *worker.Pool, usecases *usecases) {
+	worker.Handle(workers, purgeDeletedUsersJob, 1, func(ctx context.Context, _ struct{}, _ worker.ProgressFunc) error {
+		_, err := usecases.user.PurgeDeletedUsers(ctx, cfg.Purge.Retention)
+		return err
+	})
+	workers.Register(usecase.GalleryImportJob, 2, func(ctx context.Context, job *model.Job, progress worker.ProgressFunc) error {
+		return usecases.gallery.RunGalleryImport(ctx, job.Id, progress)
+	})
+	// Every replica runs the schedule, so the job is keyed by the period to
+	// enqueue it only once per interval.
+	workers.Every(cfg.Purge.Interval, func(ctx context.Context) error {
+		period := time.Now().Truncate(cfg.Purge.Interval).Unix()
+		key := fmt.Sprintf("%s:%d", purgeDeletedUsersJob, period)
+		_, err := usecases.job.EnqueueUniqueJob(ctx, purgeDeletedUsersJob, key, struct{}{})
+		if errors.Is(err, model.ErrAlreadyExists) {
+			return nil
+		}
+		return err
+	})
 }
 
 type repositories struct {
-	user *repository.UserRepository
+	tx    usecase.Transactor
+	user  cache.Users
+	job   *repository.JobRepository
+	audit *repository.AuditRepository
+	token *repository.TokenRepository
+
+	gallery *repository.GalleryRepository
+
+	rateLimit *repository.RateLimitRepository
 }
 
 type usecases struct {
-	user *usecase.UserUsecase
+	user    *usecase.UserUsecase
+	account *usecase.AccountUsecase
+	job     *usecase.JobUsecase
+	audit   *usecase.AuditUsecase
+	gallery *usecase.GalleryUsecase
 }
 
-func mustInitRepositories(db *pgxpool.Pool, logger *logger.MyLogger) *repositories {
-	repository, err := repository.NewUserRepository(db, logger)
+// mustInitRepositories routes reads outside of transactions to the replica
+// if there is one. Login lockouts and rate limits always use the primary, as
+// a lagging replica would let clients past them.
+func mustInitRepositories(db, replica *pgxpool.Pool, logger *logger.MyLogger) *repositories {
+	var pool repository.PgxIface = db
+	if replica != nil {
+		router, err := repository.NewRouter(db, replica)
+		if err != nil {
+			panic(err)
+		}
+		pool = router
+	}
+
+	user, err := repository.NewUserRepository(pool, logger)
+	if err != nil {
+		panic(err)
+	}
+	job, err := repository.NewJobRepository(pool, logger)
+	if err != nil {
+		panic(err)
+	}
+	audit, err := repository.NewAuditRepository(pool, logger)
+	if err != nil {
+		panic(err)
+	}
+	token, err := repository.NewTokenRepository(pool, logger)
+	if err != nil {
+		panic(err)
+	}
+	gallery, err := repository.NewGalleryRepository(pool, logger)
+	if err != nil {
+		panic(err)
+	}
+	rateLimit, err := repository.NewRateLimitRepository(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	tx, err := repository.NewTransactor(pool)
 	if err != nil {
 		panic(err)
 	}
 	return &repositories{
-		user: repository,
+		tx:        tx,
+		user:      user,
+		job:       job,
+		audit:     audit,
+		token:     token,
+		gallery:   gallery,
+		rateLimit: rateLimit,
 	}
 }
 
-func mustInitUsecases(r *repositories, logger *logger.MyLogger) *usecases {
-	if r == nil || logger == nil {
+func mustInitUsecases(cfg *config.Config, r *repositories, blobs blob.Store, logger *logger.MyLogger) *usecases {
+	if r == nil || blobs == nil || logger == nil {
 		log.Fatal("couldn't init usecases: nil values in constructor")
 	}
-	user, err := usecase.NewUserUsecase(r.user, logger)
+	access := policy.UnverifiedAccess(cfg.Account.UnverifiedAccess)
+	if !access.Valid() {
+		log.Fatalf("couldn't init usecases: unknown unverified access %q", access)
+	}
+	policy := policy.VerifiedPolicy{Policy: policy.RolePolicy{}, Access: access}
+	auditor, err := usecase.NewAuditor(r.tx, r.audit)
+	if err != nil {
+		log.Fatalf("couldn't init usecases: %v", err)
+	}
+	account, err := usecase.NewAccountUsecase(
+		r.user,
+		r.token,
+		mustInitSigner(cfg, logger),
+		mustInitMailer(cfg, logger),
+		auditor,
+		usecase.AccountConfig{
+			FrontendURL:     mustGetFrontendURL(cfg, logger),
+			VerificationTTL: cfg.Account.VerificationTTL,
+			ResetTTL:        cfg.Account.ResetTTL,
+		},
+		logger,
+	)
+	if err != nil {
+		log.Fatalf("couldn't init usecases: %v", err)
+	}
+	user, err := usecase.NewUserUsecase(r.user, auditor, account, policy, logger)
+	if err != nil {
+		log.Fatalf("couldn't init usecases: %v", err)
+	}
+	job, err := usecase.NewJobUsecase(r.job, policy, logger)
+	if err != nil {
+		log.Fatalf("couldn't init usecases: %v", err)
+	}
+
+	audit, err := usecase.NewAuditUsecase(r.audit, policy, logger)
+	if err != nil {
+		log.Fatalf("couldn't init usecases: %v", err)
+	}
+
+	gallery, err := usecase.NewGalleryUsecase(r.gallery, r.job, blobs, r.tx, policy, logger)
+	if err != nil {
+		log.Fatalf("couldn't init usecases: %v", err)
+	}
+
+	return &usecases{user: user, account: account, job: job, audit: audit, gallery: gallery}
+}
+
+const (
+	cacheStoreNone   = "none"
+	cacheStoreMemory = "memory"
+	cacheStoreRedis  = "redis"
+)
+
+// mustInitUserCache puts a read-through cache in front of the user
+// repository, in process by default or shared through Redis, and publishes
+// its hit and miss counts as the "user_cache" expvar.
+func mustInitUserCache(cfg *config.Config, r *repositories, logger *logger.MyLogger) {
+	var backend cache.Cache
+	var err error
+	switch cfg.Cache.Store {
+	case cacheStoreNone:
+		return
+	case cacheStoreMemory:
+		backend, err = cache.NewLRU(cfg.Cache.Size)
+	case cacheStoreRedis:
+		var options *redis.Options
+		options, err = redis.ParseURL(cfg.Cache.RedisURL)
+		if err == nil {
+			backend, err = cache.NewRedis(redis.NewClient(options), "refstudy:")
+		}
+	default:
+		err = fmt.Errorf("unknown store %q", cfg.Cache.Store)
+	}
+	if err != nil {
+		log.Fatalf("couldn't init user cache: %v", err)
+	}
+
+	users, err := cache.NewUserRepository(r.user, backend, cfg.Cache.TTL, logger)
+	if err != nil {
+		log.Fatalf("couldn't init user cache: %v", err)
+	}
+	tx, err := cache.NewTransactor(r.tx)
 	if err != nil {
-		log.Fatalf("couldn't init usecases: %w", err)
+		log.Fatalf("couldn't init user cache: %v", err)
+	}
+	r.user, r.tx = users, tx
+	expvar.Publish("user_cache", expvar.Func(func() any { return users.Stats() }))
+}
+
+const (
+	rateLimitStoreMemory   = "memory"
+	rateLimitStorePostgres = "postgres"
+)
+
+// mustInitRateLimitStore keeps limiter state in process by default; replicas
+// behind one load balancer should share it through Postgres.
+func mustInitRateLimitStore(cfg *config.Config, r *repositories) ratelimit.Store {
+	switch cfg.RateLimit.Store {
+	case rateLimitStoreMemory:
+		return ratelimit.NewMemoryStore()
+	case rateLimitStorePostgres:
+		return r.rateLimit
+	default:
+		log.Fatalf("couldn't init rate limiter: unknown store %q", cfg.RateLimit.Store)
+		return nil
+	}
+}
+
+const (
+	blobStoreLocal = "local"
+	blobStoreS3    = "s3"
+)
+
+// mustInitBlobStore keeps blobs on local disk by default; several replicas
+// need a shared S3-compatible bucket instead.
+func mustInitBlobStore(cfg *config.Config) blob.Store {
+	switch cfg.Blob.Store {
+	case blobStoreLocal:
+		store, err := blob.NewLocalStore(cfg.Blob.Dir)
+		if err != nil {
+			log.Fatalf("couldn't init blob store: %v", err)
+		}
+		return store
+	case blobStoreS3:
+		store, err := blob.NewS3Store(blob.S3Config{
+			Endpoint:  cfg.Blob.S3.Endpoint,
+			Region:    cfg.Blob.S3.Region,
+			Bucket:    cfg.Blob.S3.Bucket,
+			AccessKey: cfg.Blob.S3.AccessKey,
+			SecretKey: cfg.Blob.S3.SecretKey,
+			UseSSL:    cfg.Blob.S3.UseSSL,
+		})
+		if err != nil {
+			log.Fatalf("couldn't init blob store: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := store.EnsureBucket(ctx); err != nil {
+			log.Fatalf("couldn't init blob store: %v", err)
+		}
+		return store
+	default:
+		log.Fatalf("couldn't init blob store: unknown store %q", cfg.Blob.Store)
+		return nil
+	}
+}
+
+// mustInitSigner uses the configured token secret, or a random one when it
+// isn't set; tokens issued with a random secret stop working on restart.
+func mustInitSigner(cfg *config.Config, logger *logger.MyLogger) *token.Signer {
+	secret := []byte(cfg.Account.TokenSecret)
+	if len(secret) == 0 {
+		logger.Warn("TOKEN_SECRET is not set, emailed links won't survive a restart")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("couldn't generate token secret: %v", err)
+		}
 	}
 
-	return &usecases{user: user}
+	signer, err := token.NewSigner(secret)
+	if err != nil {
+		log.Fatalf("couldn't init token signer: %v", err)
+	}
+	return signer
+}
+
+// devFrontendURL is where a frontend started next to the service in
+// development listens.
+const devFrontendURL = "http://localhost:3000"
+
+// mustGetFrontendURL returns where the emailed links point to. Links to the
+// API itself would lead nowhere, since it only takes tokens by POST, so
+// outside of development the frontend has to be configured unless mail is
+// disabled and the links are only logged.
+func mustGetFrontendURL(cfg *config.Config, logger *logger.MyLogger) string {
+	if cfg.Account.FrontendURL != "" {
+		return strings.TrimSuffix(cfg.Account.FrontendURL, "/")
+	}
+	if !cfg.Dev() && !cfg.SMTP.Disabled {
+		log.Fatalf("couldn't init usecases: FRONTEND_URL is required outside of APP_ENV=%s", config.EnvDev)
+	}
+	logger.Warn("FRONTEND_URL is not set, emailed links will point to a local frontend", "url", devFrontendURL)
+	return devFrontendURL
+}
+
+// mustInitMailer delivers mail over SMTP. Without a server it only logs
+// mail, which outside of development would lose every emailed link, so it
+// refuses to start there unless mail is disabled on purpose.
+func mustInitMailer(cfg *config.Config, logger *logger.MyLogger) mailer.Mailer {
+	if cfg.SMTP.Disabled {
+		return mailer.NewLogMailer(logger)
+	}
+	if cfg.SMTP.Host == "" {
+		if !cfg.Dev() {
+			log.Fatalf("couldn't init mailer: SMTP_HOST is required outside of APP_ENV=%s", config.EnvDev)
+		}
+		logger.Warn("SMTP_HOST is not set, emails will be logged instead of sent")
+		return mailer.NewLogMailer(logger)
+	}
+
+	smtp, err := mailer.NewSMTPMailer(mailer.SMTPConfig{
+		Host:     cfg.SMTP.Host,
+		Port:     cfg.SMTP.Port,
+		Username: cfg.SMTP.Username,
+		Password: cfg.SMTP.Password,
+		From:     cfg.SMTP.From,
+	})
+	if err != nil {
+		log.Fatalf("couldn't init mailer: %v", err)
+	}
+	return smtp
 }
 
 func getLogLevel() logger.LoggerLevel {
@@ -3,41 +3,231 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// EnvDev is the environment for local development, where shortcuts like
+// logging emails instead of sending them are allowed.
+const EnvDev = "dev"
+
+// minJobStaleAfter keeps running jobs from being requeued while their
+// workers are still busy sending heartbeats.
+const minJobStaleAfter = 30 * time.Second
+
 type Config struct {
+	// Env is the environment the service runs in, "production" unless set.
+	Env      string
 	Database struct {
 		Host     string
 		Port     string
 		User     string
 		Password string
 		Name     string
+		// ReplicaHost serves reads outside of transactions when set.
+		ReplicaHost string
+		ReplicaPort string
 	}
 	Server struct {
-		Port string
+		Port        string
+		GRPCPort    string
+		MetricsPort string
+	}
+	Jobs struct {
+		Workers      int
+		PollInterval time.Duration
+		StaleAfter   time.Duration
+	}
+	Purge struct {
+		Retention time.Duration
+		Interval  time.Duration
+	}
+	Account struct {
+		// FrontendURL serves the pages the emailed links open; the API has
+		// no pages of its own.
+		FrontendURL      string
+		TokenSecret      string
+		VerificationTTL  time.Duration
+		ResetTTL         time.Duration
+		UnverifiedAccess string
+	}
+	RateLimit struct {
+		Store          string
+		Default        string
+		Routes         string
+		TrustedProxies []string
+	}
+	Lockout struct {
+		Threshold int
+		Base      time.Duration
+		Max       time.Duration
+		Window    time.Duration
+	}
+	Cache struct {
+		Store    string
+		Size     int
+		TTL      time.Duration
+		RedisURL string
+	}
+	GraphQL struct {
+		MaxDepth      int
+		MaxComplexity int
+	}
+	Blob struct {
+		Store string
+		Dir   string
+		S3    struct {
+			Endpoint  string
+			Region    string
+			Bucket    string
+			AccessKey string
+			SecretKey string
+			UseSSL    bool
+		}
+	}
+	SMTP struct {
+		Host     string
+		Port     int
+		Username string
+		Password string
+		From     string
+		// Disabled logs emails instead of sending them, whatever the
+		// environment.
+		Disabled bool
 	}
 }
 
 func New() *Config {
 	cfg := &Config{}
 
+	cfg.Env = getEnv("APP_ENV", "production")
+
 	cfg.Database.Host = os.Getenv("DATABASE_HOST")
 	cfg.Database.Port = os.Getenv("DATABASE_PORT")
 	cfg.Database.User = os.Getenv("DATABASE_USER")
 	cfg.Database.Password = os.Getenv("DATABASE_PASSWORD")
 	cfg.Database.Name = os.Getenv("DATABASE_NAME")
+	cfg.Database.ReplicaHost = os.Getenv("DATABASE_REPLICA_HOST")
+	cfg.Database.ReplicaPort = getEnv("DATABASE_REPLICA_PORT", cfg.Database.Port)
 	cfg.Server.Port = ":" + os.Getenv("SERVER_PORT")
+	cfg.Server.GRPCPort = ":" + getEnv("GRPC_PORT", "9090")
+	cfg.Server.MetricsPort = os.Getenv("METRICS_PORT")
+	cfg.Jobs.Workers = getEnvInt("JOB_WORKERS", 4)
+	cfg.Jobs.PollInterval = getEnvDuration("JOB_POLL_INTERVAL", time.Second)
+	cfg.Jobs.StaleAfter = max(getEnvPositiveDuration("JOB_STALE_AFTER", time.Hour), minJobStaleAfter)
+	cfg.Purge.Retention = getEnvDuration("PURGE_RETENTION", 30*24*time.Hour)
+	cfg.Purge.Interval = getEnvPositiveDuration("PURGE_INTERVAL", time.Hour)
+	cfg.Account.FrontendURL = os.Getenv("FRONTEND_URL")
+	cfg.Account.TokenSecret = os.Getenv("TOKEN_SECRET")
+	cfg.Account.VerificationTTL = getEnvDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+	cfg.Account.ResetTTL = getEnvDuration("PASSWORD_RESET_TTL", time.Hour)
+	cfg.Account.UnverifiedAccess = getEnv("UNVERIFIED_ACCESS", "full")
+	cfg.RateLimit.Store = getEnv("RATE_LIMIT_STORE", "memory")
+	cfg.RateLimit.Default = getEnv("RATE_LIMIT_DEFAULT", "300/1m")
+	cfg.RateLimit.Routes = getEnv("RATE_LIMIT_ROUTES", "POST /api/v1/users=10/1h,POST /api/v1/auth/forgot-password=5/1h,POST /api/v1/auth/verify-email/resend=5/1h,POST /api/v1/galleries/import=10/1h")
+	cfg.RateLimit.TrustedProxies = getEnvList("TRUSTED_PROXIES")
+	cfg.Lockout.Threshold = getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 5)
+	cfg.Lockout.Base = getEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute)
+	cfg.Lockout.Max = getEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour)
+	cfg.Lockout.Window = getEnvDuration("LOGIN_LOCKOUT_WINDOW", 24*time.Hour)
+	cfg.Cache.Store = getEnv("CACHE_STORE", "memory")
+	cfg.Cache.Size = getEnvInt("CACHE_SIZE", 10000)
+	cfg.Cache.TTL = getEnvDuration("CACHE_TTL", time.Minute)
+	cfg.Cache.RedisURL = os.Getenv("REDIS_URL")
+	cfg.GraphQL.MaxDepth = getEnvInt("GRAPHQL_MAX_DEPTH", 8)
+	cfg.GraphQL.MaxComplexity = getEnvInt("GRAPHQL_MAX_COMPLEXITY", 2000)
+	cfg.Blob.Store = getEnv("BLOB_STORE", "local")
+	cfg.Blob.Dir = getEnv("BLOB_DIR", "data/blobs")
+	cfg.Blob.S3.Endpoint = os.Getenv("S3_ENDPOINT")
+	cfg.Blob.S3.Region = os.Getenv("S3_REGION")
+	cfg.Blob.S3.Bucket = getEnv("S3_BUCKET", "refstudy")
+	cfg.Blob.S3.AccessKey = os.Getenv("S3_ACCESS_KEY")
+	cfg.Blob.S3.SecretKey = os.Getenv("S3_SECRET_KEY")
+	cfg.Blob.S3.UseSSL = getEnvBool("S3_USE_SSL", true)
+	cfg.SMTP.Host = os.Getenv("SMTP_HOST")
+	cfg.SMTP.Port = getEnvInt("SMTP_PORT", 587)
+	cfg.SMTP.Username = os.Getenv("SMTP_USERNAME")
+	cfg.SMTP.Password = os.Getenv("SMTP_PASSWORD")
+	cfg.SMTP.From = os.Getenv("SMTP_FROM")
 
 	return cfg
 }
 
+func (c *Config) Dev() bool {
+	return c.Env == EnvDev
+}
+
 func (c *Config) GetDB() string {
+	return c.dsn(c.Database.Host, c.Database.Port)
+}
+
+// GetReplicaDB returns the replica's connection string, or "" when no
+// replica is configured.
+func (c *Config) GetReplicaDB() string {
+	if c.Database.ReplicaHost == "" {
+		return ""
+	}
+	return c.dsn(c.Database.ReplicaHost, c.Database.ReplicaPort)
+}
+
+func (c *Config) dsn(host, port string) string {
 	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		c.Database.Host,
-		c.Database.Port,
+		host,
+		port,
 		c.Database.User,
 		c.Database.Password,
 		c.Database.Name,
 	)
 }
+
+func getEnv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvPositiveDuration falls back for durations that must be positive,
+// like ticker intervals.
+func getEnvPositiveDuration(key string, fallback time.Duration) time.Duration {
+	if value := getEnvDuration(key, fallback); value > 0 {
+		return value
+	}
+	return fallback
+}
@@ -13,9 +13,23 @@ import (
 )
 
 func InitDatabase(cfg *config.Config) (*pgxpool.Pool, error) {
+	return connect(cfg.GetDB())
+}
+
+// InitReplica connects to the read replica, returning nil when none is
+// configured.
+func InitReplica(cfg *config.Config) (*pgxpool.Pool, error) {
+	dsn := cfg.GetReplicaDB()
+	if dsn == "" {
+		return nil, nil
+	}
+	return connect(dsn)
+}
+
+func connect(dsn string) (*pgxpool.Pool, error) {
 	ctx := context.Background()
 
-	config, err := pgxpool.ParseConfig(cfg.GetDB())
+	config, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, err
 	}
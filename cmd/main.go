@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"ivanjabrony/refstudy/cmd/app"
 	"ivanjabrony/refstudy/cmd/config"
 	"ivanjabrony/refstudy/cmd/initDB"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	_ "ivanjabrony/refstudy/docs"
 
@@ -15,6 +19,9 @@ import (
 // @title           Refstudy API
 // @version         1.0
 // @description     Refstude managing API
+// @BasePath        /api/v1
+//
+// @securityDefinitions.basic BasicAuth
 func main() {
 	cfg := config.New()
 
@@ -23,12 +30,20 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	replica, err := initDB.InitReplica(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize database replica: %v", err)
+	}
+
 	if err := initDB.RunMigrations(db, cfg.Database.Name, "file://migrations"); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
-	application := app.New(db)
-	if err := application.Run(cfg.Server.Port); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	application := app.New(cfg, db, replica)
+	if err := application.Run(ctx, cfg.Server.Port, cfg.Server.GRPCPort); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// parse parses args into the flags of fs and returns the positional
+// arguments, of which there have to be exactly n.
+func parse(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != n {
+		return nil, fmt.Errorf("expected %d arguments, got %d", n, fs.NArg())
+	}
+	return fs.Args(), nil
+}
+
+func parseId[T int32 | int64](arg string) (T, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 || int64(T(id)) != id {
+		return 0, fmt.Errorf("invalid id %q", arg)
+	}
+	return T(id), nil
+}
+
+// readPassword reads the first line of stdin into password unless it is
+// already set, so passwords don't end up in the shell history.
+func readPassword(password *string) error {
+	if *password != "" {
+		return nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+	*password = strings.TrimRight(line, "\r\n")
+	if *password == "" {
+		return errors.New("empty password")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+)
+
+func getJob(ctx context.Context, env *env, args []string) error {
+	positional, err := parse(flag.NewFlagSet("jobs get", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseId[int64](positional[0])
+	if err != nil {
+		return err
+	}
+
+	job, err := env.ops.Job.GetJobById(ctx, id)
+	if err != nil {
+		return err
+	}
+	return env.out.jobs(*job)
+}
+
+// enqueueJob queues a job for the server's workers, with a JSON payload or
+// an empty object.
+func enqueueJob(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("jobs enqueue", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return fmt.Errorf("expected TYPE and an optional PAYLOAD, got %d arguments", fs.NArg())
+	}
+
+	payload := json.RawMessage(`{}`)
+	if fs.NArg() == 2 {
+		payload = json.RawMessage(fs.Arg(1))
+		if !json.Valid(payload) {
+			return fmt.Errorf("payload is not valid JSON: %s", payload)
+		}
+	}
+
+	job, err := env.ops.Job.EnqueueJob(ctx, fs.Arg(0), payload)
+	if err != nil {
+		return err
+	}
+	return env.out.jobs(*job)
+}
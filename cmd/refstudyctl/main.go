@@ -0,0 +1,159 @@
+// Command refstudyctl operates the service without going through its API.
+// It reads the same environment as the server, connects to the primary
+// database and runs the usecases as an admin:
+//
+//	refstudyctl [-o table|json] users list
+//	refstudyctl users create-admin -username root -email root@example.com
+//	refstudyctl users reset-password 7
+//	refstudyctl jobs get 42
+//	refstudyctl maintenance purge -retention 720h
+//	refstudyctl seed load fixtures/dev.yaml
+//	refstudyctl seed generate -count 10000
+//
+// Passwords are read from stdin unless given with -password. Changes are
+// audited with the admin role and no actor id. Emails are logged rather than
+// sent, so it needs no mail server.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"ivanjabrony/refstudy/cmd/app"
+	"ivanjabrony/refstudy/cmd/config"
+	"ivanjabrony/refstudy/cmd/initDB"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/policy"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgxpool"
+	_ "github.com/lib/pq"
+)
+
+// operator is who refstudyctl acts as; it has no account of its own.
+var operator = &model.Actor{Role: model.RoleAdmin, EmailVerified: true}
+
+// command runs with the arguments following its name.
+type command struct {
+	usage string
+	run   func(ctx context.Context, env *env, args []string) error
+}
+
+var commands = map[string]command{
+	"users list":           {"", listUsers},
+	"users get":            {"ID", getUser},
+	"users create-admin":   {"-username NAME -email EMAIL [-password PASSWORD]", createAdmin},
+	"users reset-password": {"[-password PASSWORD] ID", resetPassword},
+	"users set-role":       {"ID user|moderator|admin", setRole},
+	"users disable":        {"ID", disableUser},
+	"users enable":         {"ID", enableUser},
+	"users delete":         {"ID", deleteUser},
+	"users restore":        {"ID", restoreUser},
+	"jobs get":             {"ID", getJob},
+	"jobs enqueue":         {"TYPE [PAYLOAD]", enqueueJob},
+	"maintenance migrate":  {"[-source file://migrations]", migrate},
+	"maintenance purge":    {"[-retention DURATION]", purge},
+	"seed load":            {"FILE.yaml|FILE.json", loadFixtures},
+	"seed generate":        {"[-seed N] [-count N] [-print]", generateFixtures},
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run returns the exit code: 2 for misuse and 1 for failed commands.
+func run() int {
+	output := flag.String("o", formatTable, "output format, table or json")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		usage()
+		return 2
+	}
+	name := flag.Arg(0) + " " + flag.Arg(1)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "refstudyctl: unknown command %q\n", name)
+		usage()
+		return 2
+	}
+	if *output != formatTable && *output != formatJSON {
+		fmt.Fprintf(os.Stderr, "refstudyctl: unknown output format %q\n", *output)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	env, err := newEnv(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "refstudyctl:", err)
+		return 1
+	}
+	defer env.close()
+
+	if err := cmd.run(policy.WithActor(ctx, operator), env, flag.Args()[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "refstudyctl: %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: refstudyctl [-o table|json] COMMAND [ARGS]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, name := range names {
+		fmt.Fprintln(out, "  "+strings.TrimSpace(name+" "+commands[name].usage))
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// env is what commands run with.
+type env struct {
+	cfg    *config.Config
+	ops    *app.Operations
+	db     *pgxpool.Pool
+	out    printer
+	logger *logger.MyLogger
+}
+
+// newEnv connects to the database. Logs go to stderr so they don't mix with
+// the output. No command needs to email anyone: admins are created without
+// a verification email and seeded users have made-up addresses.
+func newEnv(format string) (*env, error) {
+	cfg := config.New()
+	cfg.SMTP.Disabled = true
+	db, err := initDB.InitDatabase(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	logger := &logger.MyLogger{Logger: slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))}
+	return &env{
+		cfg:    cfg,
+		ops:    app.NewOperations(cfg, db, logger),
+		db:     db,
+		out:    printer{w: os.Stdout, format: format},
+		logger: logger,
+	}, nil
+}
+
+func (e *env) close() {
+	e.db.Close()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"ivanjabrony/refstudy/cmd/initDB"
+)
+
+func migrate(_ context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("maintenance migrate", flag.ContinueOnError)
+	source := fs.String("source", "file://migrations", "where the migrations are")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	return initDB.RunMigrations(env.db, env.cfg.Database.Name, *source)
+}
+
+// purge removes deleted users for good right away instead of waiting for the
+// server's next scheduled purge.
+func purge(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("maintenance purge", flag.ContinueOnError)
+	retention := fs.Duration("retention", env.cfg.Purge.Retention, "purge users deleted longer ago than this")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	purged, err := env.ops.User.PurgeDeletedUsers(ctx, *retention)
+	if err != nil {
+		return err
+	}
+	return env.out.value(map[string]int64{"purged": purged}, fmt.Sprintf("purged %d users", purged))
+}
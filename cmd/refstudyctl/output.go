@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// printer writes results as aligned tables for people or as JSON, in the
+// shape the API uses, for scripts.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p printer) users(users ...dto.UserDto) error {
+	if p.format == formatJSON {
+		return p.json(users)
+	}
+
+	rows := make([][]string, len(users))
+	for i, user := range users {
+		rows[i] = []string{
+			strconv.Itoa(int(user.Id)),
+			user.Username,
+			user.Email,
+			user.Role,
+			strconv.FormatBool(user.Disabled),
+			strconv.FormatBool(user.EmailVerified),
+		}
+	}
+	return p.table([]string{"ID", "USERNAME", "EMAIL", "ROLE", "DISABLED", "VERIFIED"}, rows)
+}
+
+func (p printer) jobs(jobs ...dto.JobDto) error {
+	if p.format == formatJSON {
+		return p.json(jobs)
+	}
+
+	rows := make([][]string, len(jobs))
+	for i, job := range jobs {
+		rows[i] = []string{
+			strconv.FormatInt(job.Id, 10),
+			job.Type,
+			job.Status,
+			fmt.Sprintf("%d%%", job.Progress),
+			fmt.Sprintf("%d/%d", job.Attempts, job.MaxAttempts),
+			job.RunAt.Format(time.RFC3339),
+			job.LastError,
+		}
+	}
+	return p.table([]string{"ID", "TYPE", "STATUS", "PROGRESS", "ATTEMPTS", "RUN AT", "LAST ERROR"}, rows)
+}
+
+// value prints v as JSON or summary as a line of text.
+func (p printer) value(v any, summary string) error {
+	if p.format == formatJSON {
+		return p.json(v)
+	}
+	_, err := fmt.Fprintln(p.w, summary)
+	return err
+}
+
+func (p printer) json(v any) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (p printer) table(header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"ivanjabrony/refstudy/internal/seed"
+	"os"
+)
+
+// loadFixtures brings the users in a fixture file into the database; loading
+// it again changes nothing.
+func loadFixtures(ctx context.Context, env *env, args []string) error {
+	positional, err := parse(flag.NewFlagSet("seed load", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(positional[0])
+	if err != nil {
+		return err
+	}
+	fixtures, err := seed.Parse(positional[0], data)
+	if err != nil {
+		return err
+	}
+
+	return load(ctx, env, fixtures)
+}
+
+// generateFixtures loads generated users, or prints them as JSON fixtures
+// with -print.
+func generateFixtures(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("seed generate", flag.ContinueOnError)
+	seedValue := fs.Uint64("seed", 1, "seed of the generator; the same seed gives the same users")
+	count := fs.Int("count", 1000, "number of users")
+	printOnly := fs.Bool("print", false, "print the fixtures instead of loading them")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *count < 0 {
+		return fmt.Errorf("invalid count %d", *count)
+	}
+
+	fixtures := seed.Generate(*seedValue, *count)
+	if *printOnly {
+		return env.out.json(fixtures)
+	}
+	return load(ctx, env, fixtures)
+}
+
+func load(ctx context.Context, env *env, fixtures *seed.Fixtures) error {
+	loader, err := seed.NewLoader(env.ops.User, env.ops.Accounts, env.logger)
+	if err != nil {
+		return err
+	}
+
+	report, err := loader.Load(ctx, fixtures)
+	if err != nil {
+		return err
+	}
+	return env.out.value(report, fmt.Sprintf("created %d, updated %d, unchanged %d users", report.Created, report.Updated, report.Unchanged))
+}
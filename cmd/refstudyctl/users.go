@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+)
+
+func listUsers(ctx context.Context, env *env, args []string) error {
+	if _, err := parse(flag.NewFlagSet("users list", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	users, err := env.ops.User.GetAllUsers(ctx)
+	if err != nil {
+		return err
+	}
+	return env.out.users(users...)
+}
+
+func getUser(ctx context.Context, env *env, args []string) error {
+	id, err := parseUserId("users get", args)
+	if err != nil {
+		return err
+	}
+
+	user, err := env.ops.User.GetUserById(ctx, id)
+	if err != nil {
+		return err
+	}
+	return env.out.users(*user)
+}
+
+// createAdmin signs up a user and makes it an admin right away, which is how
+// the first admin gets created. Its email still has to be verified.
+func createAdmin(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("users create-admin", flag.ContinueOnError)
+	username := fs.String("username", "", "username of the admin")
+	email := fs.String("email", "", "email of the admin")
+	password := fs.String("password", "", "password of the admin, read from stdin when empty")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *username == "" || *email == "" {
+		return errors.New("-username and -email are required")
+	}
+	if err := readPassword(password); err != nil {
+		return err
+	}
+
+	user, err := env.ops.User.CreateAdmin(ctx, &dto.CreateUserDto{Username: *username, Email: *email, Password: *password})
+	if err != nil {
+		return err
+	}
+	return env.out.users(*user)
+}
+
+// resetPassword sets a new password without the emailed reset link.
+func resetPassword(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("users reset-password", flag.ContinueOnError)
+	password := fs.String("password", "", "new password, read from stdin when empty")
+	positional, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseId[int32](positional[0])
+	if err != nil {
+		return err
+	}
+	if err := readPassword(password); err != nil {
+		return err
+	}
+
+	return env.ops.User.UpdateUser(ctx, &dto.UpdateUserDto{Id: id, Password: password})
+}
+
+func setRole(ctx context.Context, env *env, args []string) error {
+	positional, err := parse(flag.NewFlagSet("users set-role", flag.ContinueOnError), args, 2)
+	if err != nil {
+		return err
+	}
+	id, err := parseId[int32](positional[0])
+	if err != nil {
+		return err
+	}
+
+	return env.ops.User.SetUserRole(ctx, id, model.Role(positional[1]))
+}
+
+func disableUser(ctx context.Context, env *env, args []string) error {
+	id, err := parseUserId("users disable", args)
+	if err != nil {
+		return err
+	}
+	return env.ops.User.DisableUser(ctx, id)
+}
+
+func enableUser(ctx context.Context, env *env, args []string) error {
+	id, err := parseUserId("users enable", args)
+	if err != nil {
+		return err
+	}
+	return env.ops.User.EnableUser(ctx, id)
+}
+
+func deleteUser(ctx context.Context, env *env, args []string) error {
+	id, err := parseUserId("users delete", args)
+	if err != nil {
+		return err
+	}
+	return env.ops.User.DeleteUserById(ctx, id)
+}
+
+func restoreUser(ctx context.Context, env *env, args []string) error {
+	id, err := parseUserId("users restore", args)
+	if err != nil {
+		return err
+	}
+	return env.ops.User.RestoreUser(ctx, id)
+}
+
+// parseUserId parses the arguments of commands that take nothing but a user
+// id.
+func parseUserId(name string, args []string) (int32, error) {
+	positional, err := parse(flag.NewFlagSet(name, flag.ContinueOnError), args, 1)
+	if err != nil {
+		return 0, err
+	}
+	return parseId[int32](positional[0])
+}
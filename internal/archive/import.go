@@ -0,0 +1,292 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/phash"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	MaxPictureSize = 50 << 20
+	// MaxPicturePixels bounds the decoded size of a picture, which a small
+	// file can blow up far beyond MaxPictureSize.
+	MaxPicturePixels   = 50_000_000
+	DuplicateThreshold = 4
+	galleryMetaFile    = "gallery.json"
+)
+
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+type Failure = model.ImportFailure
+
+type Import struct {
+	Galleries []model.Gallery
+	Failures  []Failure
+	files     map[string]*zip.File
+}
+
+type galleryMeta struct {
+	Description string `json:"description"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+type pictureMeta struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// ReadImport turns a ZIP archive into galleries: every top-level folder is a
+// gallery, images inside it become pictures and nested folder names become
+// tags. An optional sidecar "<picture>.json" overrides the name and adds
+// tags, and "gallery.json" in a gallery folder sets its metadata. Files that
+// can't be imported are reported in Failures instead of aborting the import.
+// Archives produced by WriteExport are recognised by their manifest.json and
+// imported according to it. Picture paths point into the archive and can be
+// read with Open.
+func ReadImport(r io.ReaderAt, size int64) (*Import, error) {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	result := &Import{files: make(map[string]*zip.File)}
+	for _, f := range reader.File {
+		if !f.FileInfo().IsDir() && !isHidden(f.Name) {
+			result.files[path.Clean(f.Name)] = f
+		}
+	}
+
+	if _, ok := result.files[manifestFile]; ok {
+		manifest, err := result.readManifest()
+		if err != nil {
+			return nil, err
+		}
+		result.importManifest(manifest)
+
+		return result, nil
+	}
+
+	names := make([]string, 0, len(result.files))
+	for name := range result.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	galleries := make(map[string]*model.Gallery)
+	hashes := make(map[string][]hashedPath)
+	var order []string
+
+	for _, name := range names {
+		if strings.EqualFold(path.Ext(name), ".json") {
+			continue
+		}
+
+		galleryName, rest, found := strings.Cut(name, "/")
+		if !found || galleryName == "" || galleryName == ".." {
+			result.Failures = append(result.Failures, Failure{Path: name, Reason: "file is not inside a gallery folder"})
+			continue
+		}
+
+		picture, err := result.readPicture(name)
+		if err != nil {
+			result.Failures = append(result.Failures, Failure{Path: name, Reason: err.Error()})
+			continue
+		}
+
+		if duplicate, ok := findDuplicate(hashes[galleryName], picture.Hash); ok {
+			result.Failures = append(result.Failures, Failure{Path: name, Reason: "duplicate of " + duplicate})
+			continue
+		}
+		hashes[galleryName] = append(hashes[galleryName], hashedPath{name, picture.Hash})
+
+		tags := strings.Split(path.Dir(rest), "/")
+		if tags[0] == "." {
+			tags = nil
+		}
+		meta, err := result.readPictureMeta(galleryName, name)
+		if err != nil {
+			result.Failures = append(result.Failures, Failure{Path: sidecarName(name), Reason: err.Error()})
+		} else if meta != nil {
+			tags = append(tags, meta.Tags...)
+			if meta.Name != "" {
+				picture.Name = meta.Name
+			}
+		}
+		picture.Tags = tags
+
+		gallery, ok := galleries[galleryName]
+		if !ok {
+			gallery, err = result.newGallery(galleryName)
+			if err != nil {
+				result.Failures = append(result.Failures, Failure{Path: path.Join(galleryName, galleryMetaFile), Reason: err.Error()})
+				gallery = &model.Gallery{GalleryName: galleryName}
+			}
+			galleries[galleryName] = gallery
+			order = append(order, galleryName)
+		}
+		gallery.Pictures = append(gallery.Pictures, *picture)
+		gallery.CurrentSize++
+	}
+
+	for _, name := range order {
+		result.Galleries = append(result.Galleries, *galleries[name])
+	}
+
+	return result, nil
+}
+
+func (i *Import) Open(name string) (io.ReadCloser, error) {
+	f, ok := i.files[name]
+	if !ok {
+		return nil, fmt.Errorf("file %s not found in archive", name)
+	}
+
+	return f.Open()
+}
+
+func (i *Import) readPicture(name string) (*model.Picture, error) {
+	data, err := i.readAll(name, MaxPictureSize)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return nil, fmt.Errorf("unsupported file type %s", contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPicturePixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	hash, err := phash.DHash(img)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Picture{
+		Name:   strings.TrimSuffix(path.Base(name), path.Ext(name)),
+		Path:   name,
+		Hash:   hash,
+		Height: img.Bounds().Dy(),
+		Width:  img.Bounds().Dx(),
+	}, nil
+}
+
+// readPictureMeta reads the sidecar of picture name in gallery. A picture
+// named like the gallery metadata file has none, since its sidecar would be
+// that file.
+func (i *Import) readPictureMeta(gallery, name string) (*pictureMeta, error) {
+	if sidecarName(name) == path.Join(gallery, galleryMetaFile) {
+		return nil, nil
+	}
+	if _, ok := i.files[sidecarName(name)]; !ok {
+		return nil, nil
+	}
+
+	var meta pictureMeta
+	if err := i.readJSON(sidecarName(name), &meta); err != nil {
+		return nil, err
+	}
+
+	return &meta, nil
+}
+
+func (i *Import) newGallery(name string) (*model.Gallery, error) {
+	gallery := &model.Gallery{GalleryName: name}
+	if _, ok := i.files[path.Join(name, galleryMetaFile)]; !ok {
+		return gallery, nil
+	}
+
+	var meta galleryMeta
+	if err := i.readJSON(path.Join(name, galleryMetaFile), &meta); err != nil {
+		return nil, err
+	}
+	gallery.Description = meta.Description
+	gallery.IsPublic = meta.IsPublic
+
+	return gallery, nil
+}
+
+func (i *Import) readJSON(name string, v any) error {
+	data, err := i.readAll(name, 1<<20)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid metadata: %w", err)
+	}
+
+	return nil
+}
+
+func (i *Import) readAll(name string, limit int64) ([]byte, error) {
+	f, err := i.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, errors.New("file is too large")
+	}
+
+	return data, nil
+}
+
+type hashedPath struct {
+	path string
+	hash uint64
+}
+
+func findDuplicate(existing []hashedPath, hash uint64) (string, bool) {
+	for _, e := range existing {
+		if phash.Distance(e.hash, hash) <= DuplicateThreshold {
+			return e.path, true
+		}
+	}
+
+	return "", false
+}
+
+func sidecarName(name string) string {
+	return strings.TrimSuffix(name, path.Ext(name)) + ".json"
+}
+
+func isHidden(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,129 @@
+package archive_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"ivanjabrony/refstudy/internal/archive"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func pngBytes(t *testing.T, width, height int, frequency float64) []byte {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			u, v := float64(x)/float64(width), float64(y)/float64(height)
+			img.SetGray(x, y, color.Gray{Y: uint8(127 + 120*math.Sin(frequency*u)*math.Cos(frequency*v))})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// pngHeader is the start of a PNG claiming to be width by height pixels,
+// enough for its config to decode but not the image.
+func pngHeader(width, height uint32) []byte {
+	chunk := []byte("IHDR")
+	chunk = binary.BigEndian.AppendUint32(chunk, width)
+	chunk = binary.BigEndian.AppendUint32(chunk, height)
+	chunk = append(chunk, 8, 0, 0, 0, 0)
+
+	header := []byte("\x89PNG\r\n\x1a\n")
+	header = binary.BigEndian.AppendUint32(header, uint32(len(chunk)-4))
+	header = append(header, chunk...)
+	return binary.BigEndian.AppendUint32(header, crc32.ChecksumIEEE(chunk))
+}
+
+func zipBytes(t *testing.T, files map[string][]byte) *bytes.Reader {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReadImport(t *testing.T) {
+	portrait := pngBytes(t, 64, 48, 5)
+	archiveReader := zipBytes(t, map[string][]byte{
+		"hands/gallery.json":        []byte(`{"description": "hand studies", "is_public": true}`),
+		"hands/left.png":            portrait,
+		"hands/left copy.png":       portrait,
+		"hands/gesture/right.png":   pngBytes(t, 32, 32, 11),
+		"hands/gesture/right.json":  []byte(`{"name": "Right hand", "tags": ["anatomy"]}`),
+		"faces/notes.txt":           []byte("just some notes"),
+		"faces/broken.png":          []byte("\x89PNG\r\n\x1a\nbroken"),
+		"faces/front.png":           pngBytes(t, 16, 16, 3),
+		"faces/bomb.png":            pngHeader(100_000, 100_000),
+		"faces/gallery.png":         pngBytes(t, 24, 24, 7),
+		"faces/gallery.json":        []byte(`{"description": "faces"}`),
+		"loose.png":                 portrait,
+		"/rooted.png":               portrait,
+		"__MACOSX/hands/._left.png": []byte("resource fork"),
+	})
+
+	result, err := archive.ReadImport(archiveReader, archiveReader.Size())
+	require.NoError(t, err)
+
+	require.Len(t, result.Galleries, 2)
+
+	faces := result.Galleries[0]
+	require.Equal(t, "faces", faces.GalleryName)
+	require.Equal(t, "faces", faces.Description)
+	require.Equal(t, 2, faces.CurrentSize)
+	require.Equal(t, "front", faces.Pictures[0].Name)
+	require.Equal(t, "gallery", faces.Pictures[1].Name, "gallery.json isn't its sidecar")
+
+	hands := result.Galleries[1]
+	require.Equal(t, "hands", hands.GalleryName)
+	require.Equal(t, "hand studies", hands.Description)
+	require.True(t, hands.IsPublic)
+	require.Len(t, hands.Pictures, 2)
+	require.Equal(t, "Right hand", hands.Pictures[0].Name)
+	require.Equal(t, []string{"gesture", "anatomy"}, hands.Pictures[0].Tags)
+	require.Equal(t, 32, hands.Pictures[0].Width)
+	require.Equal(t, "left copy", hands.Pictures[1].Name)
+	require.Empty(t, hands.Pictures[1].Tags)
+	require.Equal(t, 64, hands.Pictures[1].Width)
+	require.Equal(t, 48, hands.Pictures[1].Height)
+
+	failures := make(map[string]string)
+	for _, f := range result.Failures {
+		failures[f.Path] = f.Reason
+	}
+	require.Len(t, failures, 6)
+	require.Contains(t, failures["faces/notes.txt"], "unsupported file type")
+	require.Contains(t, failures["faces/broken.png"], "failed to decode image")
+	require.Equal(t, "duplicate of hands/left copy.png", failures["hands/left.png"])
+	require.Contains(t, failures["faces/bomb.png"], "too large")
+	require.Equal(t, "file is not inside a gallery folder", failures["loose.png"])
+	require.Equal(t, "file is not inside a gallery folder", failures["/rooted.png"])
+
+	f, err := result.Open(hands.Pictures[0].Path)
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.NotEmpty(t, data)
+}
+
+func TestReadImportInvalidArchive(t *testing.T) {
+	reader := bytes.NewReader([]byte("not a zip"))
+	_, err := archive.ReadImport(reader, reader.Size())
+	require.Error(t, err)
+}
@@ -0,0 +1,178 @@
+package archive
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"ivanjabrony/refstudy/internal/model"
+	"path"
+	"strings"
+)
+
+const (
+	ManifestVersion = 1
+	manifestFile    = "manifest.json"
+)
+
+type Manifest struct {
+	Version int             `json:"version"`
+	Gallery ManifestGallery `json:"gallery"`
+}
+
+type ManifestGallery struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	IsPublic    bool              `json:"is_public"`
+	OwnerName   string            `json:"owner_name"`
+	Pictures    []ManifestPicture `json:"pictures"`
+}
+
+type ManifestPicture struct {
+	Name   string   `json:"name"`
+	File   string   `json:"file"`
+	Width  int      `json:"width"`
+	Height int      `json:"height"`
+	Tags   []string `json:"tags"`
+}
+
+// WriteExport streams gallery as a ZIP archive with a manifest.json at the
+// root and the original images under a folder named after the gallery, so
+// the result can be fed back into ReadImport. open returns the content of a
+// picture.
+func WriteExport(w io.Writer, gallery *model.Gallery, open func(model.Picture) (io.ReadCloser, error)) error {
+	zw := zip.NewWriter(w)
+
+	manifest := Manifest{
+		Version: ManifestVersion,
+		Gallery: ManifestGallery{
+			Name:        gallery.GalleryName,
+			Description: gallery.Description,
+			IsPublic:    gallery.IsPublic,
+			OwnerName:   gallery.OwnerName,
+			Pictures:    make([]ManifestPicture, 0, len(gallery.Pictures)),
+		},
+	}
+
+	folder := safeName(gallery.GalleryName)
+	used := make(map[string]bool)
+	for _, picture := range gallery.Pictures {
+		file := uniqueName(path.Join(folder, safeName(path.Base(picture.Path))), used)
+		if err := copyToArchive(zw, file, picture, open); err != nil {
+			return err
+		}
+
+		manifest.Gallery.Pictures = append(manifest.Gallery.Pictures, ManifestPicture{
+			Name:   picture.Name,
+			File:   file,
+			Width:  picture.Width,
+			Height: picture.Height,
+			Tags:   picture.Tags,
+		})
+	}
+
+	f, err := zw.Create(manifestFile)
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return zw.Close()
+}
+
+func copyToArchive(zw *zip.Writer, name string, picture model.Picture, open func(model.Picture) (io.ReadCloser, error)) error {
+	src, err := open(picture)
+	if err != nil {
+		return fmt.Errorf("failed to open picture %s: %w", picture.Path, err)
+	}
+	defer src.Close()
+
+	dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("failed to copy picture %s: %w", picture.Path, err)
+	}
+
+	return nil
+}
+
+func (i *Import) readManifest() (*Manifest, error) {
+	var manifest Manifest
+	if err := i.readJSON(manifestFile, &manifest); err != nil {
+		return nil, err
+	}
+
+	if manifest.Version < 1 || manifest.Version > ManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", manifest.Version)
+	}
+
+	return &manifest, nil
+}
+
+func (i *Import) importManifest(manifest *Manifest) {
+	gallery := model.Gallery{
+		GalleryName: manifest.Gallery.Name,
+		Description: manifest.Gallery.Description,
+		IsPublic:    manifest.Gallery.IsPublic,
+		OwnerName:   manifest.Gallery.OwnerName,
+	}
+
+	var hashes []hashedPath
+	for _, p := range manifest.Gallery.Pictures {
+		name := path.Clean(p.File)
+		picture, err := i.readPicture(name)
+		if err != nil {
+			i.Failures = append(i.Failures, Failure{Path: name, Reason: err.Error()})
+			continue
+		}
+
+		if duplicate, ok := findDuplicate(hashes, picture.Hash); ok {
+			i.Failures = append(i.Failures, Failure{Path: name, Reason: "duplicate of " + duplicate})
+			continue
+		}
+		hashes = append(hashes, hashedPath{name, picture.Hash})
+
+		if p.Name != "" {
+			picture.Name = p.Name
+		}
+		picture.Tags = p.Tags
+		gallery.Pictures = append(gallery.Pictures, *picture)
+		gallery.CurrentSize++
+	}
+
+	i.Galleries = append(i.Galleries, gallery)
+}
+
+func safeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		return "untitled"
+	}
+
+	return name
+}
+
+func uniqueName(name string, used map[string]bool) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s (%d)%s", base, n, ext)
+	}
+	used[candidate] = true
+
+	return candidate
+}
@@ -0,0 +1,72 @@
+package archive_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"ivanjabrony/refstudy/internal/archive"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/phash"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportRoundTrip(t *testing.T) {
+	blobs := map[string][]byte{
+		"/data/1.png": pngBytes(t, 40, 30, 5),
+		"/data/2.png": pngBytes(t, 20, 20, 11),
+	}
+	gallery := &model.Gallery{
+		GalleryName: "hands/feet",
+		Description: "studies",
+		IsPublic:    true,
+		OwnerName:   "ivan",
+		CurrentSize: 2,
+		Pictures: []model.Picture{
+			{Name: "left", Path: "/data/1.png", Tags: []string{"anatomy", "hands, left"}, Width: 40, Height: 30},
+			{Name: "right", Path: "/data/2.png", Width: 20, Height: 20},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := archive.WriteExport(&buf, gallery, func(p model.Picture) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(blobs[p.Path])), nil
+	})
+	require.NoError(t, err)
+
+	reader := bytes.NewReader(buf.Bytes())
+	zr, err := zip.NewReader(reader, reader.Size())
+	require.NoError(t, err)
+	f, err := zr.Open("manifest.json")
+	require.NoError(t, err)
+	var manifest archive.Manifest
+	require.NoError(t, json.NewDecoder(f).Decode(&manifest))
+	require.Equal(t, archive.ManifestVersion, manifest.Version)
+	require.Equal(t, "hands_feet/1.png", manifest.Gallery.Pictures[0].File)
+	require.Equal(t, []string{"anatomy", "hands, left"}, manifest.Gallery.Pictures[0].Tags, "tags may contain commas")
+
+	result, err := archive.ReadImport(reader, reader.Size())
+	require.NoError(t, err)
+	require.Empty(t, result.Failures)
+	require.Len(t, result.Galleries, 1)
+
+	imported := result.Galleries[0]
+	for i := range imported.Pictures {
+		imported.Pictures[i].Path = gallery.Pictures[i].Path
+		hash, err := phash.DHashFromReader(bytes.NewReader(blobs[gallery.Pictures[i].Path]))
+		require.NoError(t, err)
+		gallery.Pictures[i].Hash = hash
+	}
+	require.Equal(t, *gallery, imported)
+}
+
+func TestReadImportUnsupportedManifest(t *testing.T) {
+	reader := zipBytes(t, map[string][]byte{
+		"manifest.json": []byte(`{"version": 99, "gallery": {"name": "future"}}`),
+	})
+
+	_, err := archive.ReadImport(reader, reader.Size())
+	require.ErrorContains(t, err, "unsupported manifest version 99")
+}
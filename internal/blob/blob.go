@@ -0,0 +1,76 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid blob key")
+	// ErrChecksumMismatch means the store holds something else than what
+	// was uploaded.
+	ErrChecksumMismatch = errors.New("blob checksum mismatch")
+)
+
+// Object describes a stored blob.
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	// SHA256 is the hex digest of the contents as they were uploaded. Only
+	// Put fills it in.
+	SHA256 string
+}
+
+// Store keeps picture contents by key; model.Picture.Path holds the key.
+// Keys are slash separated relative paths such as "galleries/1/cat.png".
+type Store interface {
+	// Put uploads r, which has size bytes or -1 when unknown. The store
+	// verifies the contents arrived intact before it returns.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (Object, error)
+	// Get returns a reader for the contents, which the caller must close.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (Object, error)
+	// Delete removes the blob; deleting a missing blob isn't an error.
+	Delete(ctx context.Context, key string) error
+	// PresignGet and PresignPut return URLs a client can use to transfer the
+	// contents directly, valid for ttl. Stores that can't hand out such URLs
+	// return errors.ErrUnsupported.
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+	PresignPut(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+// checksumReader hashes everything read through it.
+type checksumReader struct {
+	r    io.Reader
+	hash hash.Hash
+}
+
+func newChecksumReader(r io.Reader) *checksumReader {
+	return &checksumReader{r: r, hash: sha256.New()}
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.hash.Write(p[:n])
+	return n, err
+}
+
+func (c *checksumReader) Sum() string {
+	return hex.EncodeToString(c.hash.Sum(nil))
+}
@@ -0,0 +1,192 @@
+package blob_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"ivanjabrony/refstudy/internal/blob"
+	"ivanjabrony/refstudy/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/stretchr/testify/require"
+)
+
+// newS3Store serves an in-memory S3 over TLS, since that is how the client
+// talks to real services: over plain HTTP it signs parts in a streaming
+// format the fake doesn't decode.
+func newS3Store(t *testing.T) (*blob.S3Store, *http.Client) {
+	t.Helper()
+
+	store, client, _ := newChecksumS3Store(t)
+	return store, client
+}
+
+// newChecksumS3Store is newS3Store also returning the checksums the fake
+// reports.
+func newChecksumS3Store(t *testing.T) (*blob.S3Store, *http.Client, *checksumS3) {
+	t.Helper()
+
+	fake := newChecksumS3(gofakes3.New(s3mem.New()).Server())
+	server := httptest.NewTLSServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := blob.NewS3Store(blob.S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "https://"),
+		Region:    "us-east-1",
+		Bucket:    "pictures",
+		AccessKey: "key",
+		SecretKey: "secret",
+		UseSSL:    true,
+		Transport: server.Client().Transport,
+		PartSize:  blob.MinPartSize,
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.EnsureBucket(context.Background()))
+	require.NoError(t, store.EnsureBucket(context.Background()))
+	return store, server.Client(), fake
+}
+
+func newLocalStore(t *testing.T) *blob.LocalStore {
+	store, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	return store
+}
+
+func TestStore(t *testing.T) {
+	stores := map[string]func(t *testing.T) blob.Store{
+		"local": func(t *testing.T) blob.Store { return newLocalStore(t) },
+		"s3": func(t *testing.T) blob.Store {
+			store, _ := newS3Store(t)
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+			contents := []byte("not really a png")
+			sum := sha256.Sum256(contents)
+
+			object, err := store.Put(ctx, "galleries/1/cat.png", bytes.NewReader(contents), int64(len(contents)), "")
+			require.NoError(t, err)
+			require.Equal(t, int64(len(contents)), object.Size)
+			require.Equal(t, "image/png", object.ContentType)
+			require.Equal(t, hex.EncodeToString(sum[:]), object.SHA256)
+
+			object, err = store.Stat(ctx, "galleries/1/cat.png")
+			require.NoError(t, err)
+			require.Equal(t, int64(len(contents)), object.Size)
+			require.Equal(t, "image/png", object.ContentType)
+
+			r, err := store.Get(ctx, "galleries/1/cat.png")
+			require.NoError(t, err)
+			read, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			require.Equal(t, contents, read)
+
+			require.NoError(t, store.Delete(ctx, "galleries/1/cat.png"))
+			require.NoError(t, store.Delete(ctx, "galleries/1/cat.png"))
+			_, err = store.Get(ctx, "galleries/1/cat.png")
+			require.ErrorIs(t, err, model.ErrNotFound)
+			_, err = store.Stat(ctx, "galleries/1/cat.png")
+			require.ErrorIs(t, err, model.ErrNotFound)
+
+			for _, key := range []string{"", "/etc/passwd", "../cat.png", "galleries/../../cat.png", "galleries//cat.png"} {
+				_, err = store.Put(ctx, key, bytes.NewReader(contents), -1, "")
+				require.ErrorIs(t, err, blob.ErrInvalidKey, key)
+			}
+		})
+	}
+}
+
+func TestS3Store_Multipart(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newS3Store(t)
+
+	// More than two parts' worth of data forces a multipart upload, which
+	// streams parts of unknown size and reads known ones at once.
+	contents := bytes.Repeat([]byte("0123456789abcdef"), (11<<20)/16)
+	sum := sha256.Sum256(contents)
+	for _, size := range []int64{-1, int64(len(contents))} {
+		object, err := store.Put(ctx, "big.bin", bytes.NewReader(contents), size, "application/octet-stream")
+		require.NoError(t, err)
+		require.Equal(t, int64(len(contents)), object.Size)
+		require.Equal(t, hex.EncodeToString(sum[:]), object.SHA256)
+
+		r, err := store.Get(ctx, "big.bin")
+		require.NoError(t, err)
+		read, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.True(t, bytes.Equal(contents, read))
+	}
+}
+
+func TestS3Store_ChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	store, _, fake := newChecksumS3Store(t)
+	fake.corrupt = true
+
+	tests := []struct {
+		name     string
+		contents []byte
+		size     int64
+	}{
+		{name: "single", contents: []byte("not really a png"), size: 16},
+		{name: "multipart", contents: []byte("not really a png"), size: -1},
+		{name: "multipart of known size", contents: bytes.Repeat([]byte("0123456789abcdef"), (11<<20)/16), size: 11 << 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.Put(ctx, "cat.png", bytes.NewReader(tt.contents), tt.size, "")
+			require.ErrorIs(t, err, blob.ErrChecksumMismatch)
+
+			_, err = store.Stat(ctx, "cat.png")
+			require.ErrorIs(t, err, model.ErrNotFound, "a blob that doesn't match is removed")
+		})
+	}
+}
+
+func TestS3Store_Presign(t *testing.T) {
+	ctx := context.Background()
+	store, client := newS3Store(t)
+
+	upload, err := store.PresignPut(ctx, "direct.png", time.Minute)
+	require.NoError(t, err)
+	require.Contains(t, upload, "X-Amz-Signature=")
+	req, err := http.NewRequest(http.MethodPut, upload, strings.NewReader("direct"))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	download, err := store.PresignGet(ctx, "direct.png", time.Minute)
+	require.NoError(t, err)
+	resp, err = client.Get(download)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	read, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "direct", string(read))
+}
+
+func TestLocalStore_Presign(t *testing.T) {
+	store := newLocalStore(t)
+
+	_, err := store.PresignGet(context.Background(), "cat.png", time.Minute)
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+	_, err = store.PresignPut(context.Background(), "cat.png", time.Minute)
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
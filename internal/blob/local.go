@@ -0,0 +1,136 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"ivanjabrony/refstudy/internal/model"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// LocalStore keeps blobs as files under a directory. It only suits a single
+// replica and can't presign URLs.
+type LocalStore struct {
+	root string
+}
+
+func NewLocalStore(root string) (*LocalStore, error) {
+	if root == "" {
+		return nil, errors.New("nil values in LocalStore constructor")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	return &LocalStore{root: root}, nil
+}
+
+func (s *LocalStore) Put(_ context.Context, key string, r io.Reader, size int64, contentType string) (Object, error) {
+	if err := validateKey(key); err != nil {
+		return Object{}, err
+	}
+
+	name := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return Object{}, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	// Write next to the destination and rename, so readers never see a
+	// partial file.
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	checksum := newChecksumReader(r)
+	written, err := io.Copy(tmp, checksum)
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to write blob: %w", err)
+	}
+	if size >= 0 && written != size {
+		return Object{}, fmt.Errorf("failed to write blob: got %d bytes, expected %d", written, size)
+	}
+	if err := tmp.Sync(); err != nil {
+		return Object{}, fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return Object{}, fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return Object{}, fmt.Errorf("failed to write blob: %w", err)
+	}
+
+	return Object{Key: key, Size: written, ContentType: contentTypeOf(key, contentType), SHA256: checksum.Sum()}, nil
+}
+
+func (s *LocalStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("blob %q: %w", key, model.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return f, nil
+}
+
+func (s *LocalStore) Stat(_ context.Context, key string) (Object, error) {
+	if err := validateKey(key); err != nil {
+		return Object{}, err
+	}
+
+	info, err := os.Stat(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Object{}, fmt.Errorf("blob %q: %w", key, model.ErrNotFound)
+	}
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to stat blob: %w", err)
+	}
+	return Object{Key: key, Size: info.Size(), ContentType: contentTypeOf(key, "")}, nil
+}
+
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) PresignGet(context.Context, string, time.Duration) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+func (s *LocalStore) PresignPut(context.Context, string, time.Duration) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+// contentTypeOf falls back to the type implied by the key's extension, since
+// files on disk don't keep one.
+func contentTypeOf(key, contentType string) string {
+	if contentType != "" {
+		return contentType
+	}
+	if byExtension := mime.TypeByExtension(path.Ext(key)); byExtension != "" {
+		return byExtension
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,249 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"ivanjabrony/refstudy/internal/model"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+const (
+	// DefaultPartSize is the size of the parts large uploads are split into,
+	// and of the buffer used for uploads of unknown size.
+	DefaultPartSize = 16 << 20
+	// MinPartSize is the smallest part S3 accepts.
+	MinPartSize = 5 << 20
+)
+
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// Transport is used for requests to the service; nil means the default
+	// one. Set it to trust a private CA.
+	Transport http.RoundTripper
+	// PartSize is at least MinPartSize; zero means DefaultPartSize.
+	PartSize uint64
+}
+
+// S3Store keeps blobs in a bucket of any S3-compatible service, uploading
+// large blobs in parts. Uploads carry SHA256 checksums, so the service has
+// to support additional checksums the way S3 and MinIO do.
+type S3Store struct {
+	client   *minio.Client
+	bucket   string
+	partSize uint64
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("nil values in S3Store constructor")
+	}
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	if cfg.PartSize < MinPartSize {
+		return nil, fmt.Errorf("part size %d is below the minimum of %d", cfg.PartSize, MinPartSize)
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:     credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:    cfg.UseSSL,
+		Region:    cfg.Region,
+		Transport: cfg.Transport,
+		// Single uploads send their checksum after the contents, so they
+		// can be streamed.
+		TrailingHeaders: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	return &S3Store{client: client, bucket: cfg.Bucket, partSize: cfg.PartSize}, nil
+}
+
+// EnsureBucket creates the bucket unless it already exists.
+func (s *S3Store) EnsureBucket(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	if err := s.client.MakeBucket(ctx, s.bucket, minio.MakeBucketOptions{}); err != nil {
+		return fmt.Errorf("failed to create bucket: %w", err)
+	}
+	return nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (Object, error) {
+	if err := validateKey(key); err != nil {
+		return Object{}, err
+	}
+
+	checksum := newPartChecksumReader(r, s.partSize)
+	info, err := s.client.PutObject(ctx, s.bucket, key, checksum, size, minio.PutObjectOptions{
+		ContentType: contentTypeOf(key, contentType),
+		PartSize:    s.partSize,
+		// The upload, or every part of it, carries its SHA256 and the
+		// service rejects contents that don't match it.
+		Checksum: minio.ChecksumSHA256,
+	})
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to upload blob: %w", err)
+	}
+	// The service reports the checksum of what it stored, which catches
+	// one that ignored the checksum sent along.
+	if err := checksum.verify(info.ChecksumSHA256); err != nil {
+		_ = s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+		return Object{}, fmt.Errorf("failed to upload blob %q: %w", key, err)
+	}
+
+	return Object{Key: key, Size: info.Size, ContentType: contentTypeOf(key, contentType), SHA256: checksum.Sum()}, nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	// GetObject is lazy, so stat first to report a missing blob here rather
+	// than on the first read.
+	if _, err := s.Stat(ctx, key); err != nil {
+		return nil, err
+	}
+
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download blob: %w", err)
+	}
+	return object, nil
+}
+
+func (s *S3Store) Stat(ctx context.Context, key string) (Object, error) {
+	if err := validateKey(key); err != nil {
+		return Object{}, err
+	}
+
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return Object{}, s.wrap(key, "failed to stat blob", err)
+	}
+	return Object{Key: key, Size: info.Size, ContentType: info.ContentType}, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return s.wrap(key, "failed to delete blob", err)
+	}
+	return nil
+}
+
+func (s *S3Store) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, ttl, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download: %w", err)
+	}
+	return u.String(), nil
+}
+
+func (s *S3Store) PresignPut(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+
+	u, err := s.client.PresignedPutObject(ctx, s.bucket, key, ttl)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign upload: %w", err)
+	}
+	return u.String(), nil
+}
+
+func (s *S3Store) wrap(key, message string, err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return fmt.Errorf("blob %q: %w", key, model.ErrNotFound)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// partChecksumReader also hashes every partSize bytes on their own, like a
+// multipart upload of them checksums its parts.
+type partChecksumReader struct {
+	*checksumReader
+	partSize int64
+	part     hash.Hash
+	partLen  int64
+	parts    [][]byte
+}
+
+func newPartChecksumReader(r io.Reader, partSize uint64) *partChecksumReader {
+	return &partChecksumReader{checksumReader: newChecksumReader(r), partSize: int64(partSize), part: sha256.New()}
+}
+
+func (c *partChecksumReader) Read(p []byte) (int, error) {
+	n, err := c.checksumReader.Read(p)
+	for data := p[:n]; len(data) > 0; {
+		k := min(int64(len(data)), c.partSize-c.partLen)
+		c.part.Write(data[:k])
+		c.partLen += k
+		data = data[k:]
+		if c.partLen == c.partSize {
+			c.parts = append(c.parts, c.part.Sum(nil))
+			c.part.Reset()
+			c.partLen = 0
+		}
+	}
+	return n, err
+}
+
+// verify compares the checksum the service reported with the contents read:
+// the base64 SHA256 of all of them for a single upload, or of the
+// concatenated part checksums followed by "-<parts>" for a multipart one.
+func (c *partChecksumReader) verify(reported string) error {
+	if reported == "" {
+		return fmt.Errorf("%w: the service reported no checksum", ErrChecksumMismatch)
+	}
+
+	want := c.hash.Sum(nil)
+	if encoded, count, multipart := strings.Cut(reported, "-"); multipart {
+		parts := slices.Clone(c.parts)
+		if c.partLen > 0 || len(parts) == 0 {
+			parts = append(parts, c.part.Sum(nil))
+		}
+		composite := sha256.New()
+		for _, part := range parts {
+			composite.Write(part)
+		}
+		want = composite.Sum(nil)
+		if count != strconv.Itoa(len(parts)) {
+			return fmt.Errorf("%w: uploaded %d parts, the service has %s", ErrChecksumMismatch, len(parts), count)
+		}
+		reported = encoded
+	}
+
+	if base64.StdEncoding.EncodeToString(want) != reported {
+		return fmt.Errorf("%w: uploaded %s, the service has %s", ErrChecksumMismatch, base64.StdEncoding.EncodeToString(want), reported)
+	}
+	return nil
+}
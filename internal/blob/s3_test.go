@@ -0,0 +1,146 @@
+package blob_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// checksumS3 adds the SHA256 checksums of S3 to a fake that ignores them.
+// It decodes uploads sending their checksum after the contents, rejects
+// contents that don't match the checksum sent along and reports the
+// checksum of what was stored. With corrupt set it reports the checksum of
+// other contents instead.
+type checksumS3 struct {
+	next    http.Handler
+	corrupt bool
+
+	mu sync.Mutex
+	// parts holds the checksums of the parts of every multipart upload by
+	// part number.
+	parts map[string]map[int][]byte
+}
+
+func newChecksumS3(next http.Handler) *checksumS3 {
+	return &checksumS3{next: next, parts: map[string]map[int][]byte{}}
+}
+
+func (s *checksumS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPut:
+		contents, sent, err := readUpload(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sum := sha256.Sum256(contents)
+		if sent != "" && sent != base64.StdEncoding.EncodeToString(sum[:]) {
+			http.Error(w, "BadDigest", http.StatusBadRequest)
+			return
+		}
+		if uploadId := query.Get("uploadId"); uploadId != "" {
+			part, err := strconv.Atoi(query.Get("partNumber"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s.mu.Lock()
+			if s.parts[uploadId] == nil {
+				s.parts[uploadId] = map[int][]byte{}
+			}
+			s.parts[uploadId][part] = sum[:]
+			s.mu.Unlock()
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(contents))
+		r.ContentLength = int64(len(contents))
+		r.Header.Set("Content-Length", strconv.Itoa(len(contents)))
+		r.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+		r.Header.Del("Content-Encoding")
+		w.Header().Set("X-Amz-Checksum-Sha256", s.report(sum[:], ""))
+		s.next.ServeHTTP(w, r)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		s.mu.Lock()
+		parts := s.parts[query.Get("uploadId")]
+		composite := sha256.New()
+		for _, part := range slices.Sorted(maps.Keys(parts)) {
+			composite.Write(parts[part])
+		}
+		s.mu.Unlock()
+
+		recorder := httptest.NewRecorder()
+		s.next.ServeHTTP(recorder, r)
+		checksum := "<ChecksumSHA256>" + s.report(composite.Sum(nil), fmt.Sprintf("-%d", len(parts))) + "</ChecksumSHA256>"
+		body := strings.Replace(recorder.Body.String(), "</CompleteMultipartUploadResult>", checksum+"</CompleteMultipartUploadResult>", 1)
+		for name, values := range recorder.Header() {
+			w.Header()[name] = values
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(recorder.Code)
+		io.WriteString(w, body)
+	default:
+		s.next.ServeHTTP(w, r)
+	}
+}
+
+func (s *checksumS3) report(sum []byte, suffix string) string {
+	if s.corrupt {
+		other := sha256.Sum256(sum)
+		sum = other[:]
+	}
+	return base64.StdEncoding.EncodeToString(sum) + suffix
+}
+
+// readUpload returns the contents of an upload and the checksum sent along
+// in a header or after the contents.
+func readUpload(r *http.Request) ([]byte, string, error) {
+	if r.Header.Get("X-Amz-Content-Sha256") != "STREAMING-UNSIGNED-PAYLOAD-TRAILER" {
+		contents, err := io.ReadAll(r.Body)
+		return contents, r.Header.Get("X-Amz-Checksum-Sha256"), err
+	}
+
+	// The contents come in chunks of "<hex size>\r\n<data>\r\n", ending
+	// with an empty one followed by "<name>:<value>\n" trailers.
+	body := bufio.NewReader(r.Body)
+	var contents []byte
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			return nil, "", err
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(line), 16, 64)
+		if err != nil {
+			return nil, "", err
+		}
+		if size == 0 {
+			break
+		}
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(body, chunk); err != nil {
+			return nil, "", err
+		}
+		contents = append(contents, chunk[:size]...)
+	}
+
+	trailer := http.Header{}
+	for {
+		line, err := body.ReadString('\n')
+		name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || err != nil {
+			break
+		}
+		trailer.Set(name, value)
+	}
+	return contents, trailer.Get("X-Amz-Checksum-Sha256"), nil
+}
@@ -0,0 +1,341 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"ivanjabrony/refstudy/internal/consistency"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lru, err := NewLRU(2)
+	require.NoError(t, err)
+	lru.now = func() time.Time { return now }
+
+	require.NoError(t, lru.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, lru.Set(ctx, "b", []byte("2"), time.Minute))
+	_, ok, _ := lru.Get(ctx, "a")
+	require.True(t, ok)
+
+	// "b" is now the least recently used one.
+	require.NoError(t, lru.Set(ctx, "c", []byte("3"), time.Minute))
+	require.Equal(t, 2, lru.Len())
+	_, ok, _ = lru.Get(ctx, "b")
+	require.False(t, ok)
+
+	require.NoError(t, lru.Set(ctx, "a", []byte("4"), time.Second))
+	value, ok, _ := lru.Get(ctx, "a")
+	require.True(t, ok)
+	require.Equal(t, []byte("4"), value)
+
+	now = now.Add(time.Second)
+	_, ok, _ = lru.Get(ctx, "a")
+	require.False(t, ok)
+	require.Equal(t, 1, lru.Len())
+
+	require.NoError(t, lru.Delete(ctx, "c", "missing"))
+	require.Zero(t, lru.Len())
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	cache, err := NewRedis(client, "refstudy:")
+	require.NoError(t, err)
+
+	require.NoError(t, cache.Set(ctx, "a", []byte("1"), time.Minute))
+	require.True(t, server.Exists("refstudy:a"))
+	value, ok, err := cache.Get(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("1"), value)
+
+	server.FastForward(time.Minute)
+	_, ok, err = cache.Get(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, cache.Set(ctx, "b", []byte("2"), time.Minute))
+	require.NoError(t, cache.Delete(ctx, "b"))
+	_, ok, _ = cache.Get(ctx, "b")
+	require.False(t, ok)
+
+	server.Close()
+	_, _, err = cache.Get(ctx, "a")
+	require.Error(t, err)
+}
+
+// countingUsers serves users from memory and counts the lookups that reach
+// it. A lookup blocks while release is set and not yet closed, and returns
+// the row it read only once stall is closed, if set. Lookups that may go to
+// a replica read replica instead, if set, which writes don't reach.
+type countingUsers struct {
+	Users
+	mu      sync.Mutex
+	users   map[int32]model.User
+	replica map[int32]model.User
+	queries atomic.Int32
+	release chan struct{}
+	stall   chan struct{}
+}
+
+func (f *countingUsers) GetUserById(ctx context.Context, id int32) (*model.User, error) {
+	f.queries.Add(1)
+	if f.release != nil {
+		<-f.release
+	}
+
+	f.mu.Lock()
+	users := f.users
+	if f.replica != nil && consistency.ReplicaSafe(ctx) {
+		users = f.replica
+	}
+	user, ok := users[id]
+	f.mu.Unlock()
+	if f.stall != nil {
+		<-f.stall
+	}
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return &user, nil
+}
+
+func (f *countingUsers) GetUserByUsername(_ context.Context, username string) (*model.User, error) {
+	f.queries.Add(1)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, user := range f.users {
+		if user.Username == username {
+			return &user, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (f *countingUsers) UpdateUser(_ context.Context, update *model.UserUpdate) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user := f.users[update.Id]
+	if update.Username != nil {
+		user.Username = *update.Username
+	}
+	f.users[update.Id] = user
+	return nil
+}
+
+func (f *countingUsers) SetUserDisabled(_ context.Context, id int32, disabled bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user := f.users[id]
+	user.Disabled = disabled
+	f.users[id] = user
+	return nil
+}
+
+type passthroughTx struct{}
+
+func (passthroughTx) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+func newCachedUsers(t *testing.T) (*UserRepository, *countingUsers) {
+	t.Helper()
+
+	users := &countingUsers{users: map[int32]model.User{
+		1: {Id: 1, Username: "ivan", Email: "ivan@example.com", Password: "hash", Role: model.RoleUser},
+	}}
+	lru, err := NewLRU(100)
+	require.NoError(t, err)
+	cached, err := NewUserRepository(users, lru, time.Minute, logger.New(logger.Test, logger.LogFormatText))
+	require.NoError(t, err)
+	return cached, users
+}
+
+func TestUserRepository_ReadThrough(t *testing.T) {
+	ctx := context.Background()
+	cached, users := newCachedUsers(t)
+
+	user, err := cached.GetUserById(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "ivan", user.Username)
+	user.Username = "changed by caller"
+
+	user, err = cached.GetUserById(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "ivan", user.Username)
+	user, err = cached.GetUserByUsername(ctx, "ivan")
+	require.NoError(t, err)
+	require.Equal(t, int32(1), user.Id)
+	require.Equal(t, int32(1), users.queries.Load())
+	require.Equal(t, Stats{Hits: 2, Misses: 1}, cached.Stats())
+
+	_, err = cached.GetUserById(ctx, 2)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	_, err = cached.GetUserById(ctx, 2)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	require.Equal(t, int32(3), users.queries.Load())
+}
+
+func TestUserRepository_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	cached, users := newCachedUsers(t)
+
+	_, err := cached.GetUserByUsername(ctx, "ivan")
+	require.NoError(t, err)
+	username := "ivan2"
+	require.NoError(t, cached.UpdateUser(ctx, &model.UserUpdate{Id: 1, Username: &username}))
+
+	user, err := cached.GetUserById(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "ivan2", user.Username)
+	_, err = cached.GetUserByUsername(ctx, "ivan")
+	require.ErrorIs(t, err, model.ErrNotFound)
+	require.Equal(t, int32(3), users.queries.Load())
+
+	// Inside a transaction the cache is bypassed and the write only
+	// invalidates once the transaction is over.
+	tx, err := NewTransactor(passthroughTx{})
+	require.NoError(t, err)
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		require.NoError(t, cached.SetUserDisabled(ctx, 1, true))
+		user, err := cached.GetUserById(ctx, 1)
+		require.NoError(t, err)
+		require.True(t, user.Disabled)
+		return errors.New("rolled back")
+	})
+	require.Error(t, err)
+	require.Equal(t, int32(4), users.queries.Load())
+
+	user, err = cached.GetUserById(ctx, 1)
+	require.NoError(t, err)
+	require.True(t, user.Disabled)
+	require.Equal(t, int32(5), users.queries.Load())
+}
+
+func TestUserRepository_Singleflight(t *testing.T) {
+	ctx := context.Background()
+	cached, users := newCachedUsers(t)
+	users.release = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := cached.GetUserById(ctx, 1)
+			require.NoError(t, err)
+			require.Equal(t, "ivan", user.Username)
+		}()
+	}
+	require.Eventually(t, func() bool { return users.queries.Load() == 1 }, time.Second, time.Millisecond)
+	// Give the other lookups time to join the one in flight; any that are
+	// later hit the cache instead.
+	time.Sleep(10 * time.Millisecond)
+	close(users.release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), users.queries.Load())
+	stats := cached.Stats()
+	require.Equal(t, uint64(10), stats.Hits+stats.Misses)
+}
+
+func TestUserRepository_NoPasswords(t *testing.T) {
+	ctx := context.Background()
+	cached, _ := newCachedUsers(t)
+
+	user, err := cached.GetUserById(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, user.Password)
+
+	value, ok := cached.get(ctx, idKey(1))
+	require.True(t, ok)
+	require.NotContains(t, string(value), "hash")
+}
+
+func TestUserRepository_StaleLoad(t *testing.T) {
+	ctx := context.Background()
+	cached, users := newCachedUsers(t)
+	users.stall = make(chan struct{})
+
+	loaded := make(chan *model.User)
+	go func() {
+		user, err := cached.GetUserById(ctx, 1)
+		require.NoError(t, err)
+		loaded <- user
+	}()
+	require.Eventually(t, func() bool { return users.queries.Load() == 1 }, time.Second, time.Millisecond)
+
+	// The write commits and invalidates while the lookup holds the old row.
+	username := "ivan2"
+	require.NoError(t, cached.UpdateUser(ctx, &model.UserUpdate{Id: 1, Username: &username}))
+	close(users.stall)
+	require.Equal(t, "ivan", (<-loaded).Username)
+
+	user, err := cached.GetUserById(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "ivan2", user.Username, "the old row isn't served from the cache")
+}
+
+func TestUserRepository_CancelledLookup(t *testing.T) {
+	cached, users := newCachedUsers(t)
+	users.release = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := cached.GetUserById(ctx, 1)
+		first <- err
+	}()
+	require.Eventually(t, func() bool { return users.queries.Load() == 1 }, time.Second, time.Millisecond)
+
+	second := make(chan error)
+	go func() {
+		_, err := cached.GetUserById(context.Background(), 1)
+		second <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-first, context.Canceled)
+
+	close(users.release)
+	require.NoError(t, <-second, "the lookup it joined isn't cancelled with the first caller")
+	require.Equal(t, int32(1), users.queries.Load())
+}
+
+func TestUserRepository_LaggingReplica(t *testing.T) {
+	cached, users := newCachedUsers(t)
+	users.replica = map[int32]model.User{1: users.users[1]}
+
+	// The write reaches the primary, and the replica lags behind it.
+	writer := consistency.WithSession(context.Background())
+	username := "ivan2"
+	require.NoError(t, cached.UpdateUser(writer, &model.UserUpdate{Id: 1, Username: &username}))
+	consistency.MarkWritten(writer)
+
+	// A request that hasn't written may read the replica, but the miss it
+	// caches comes from the primary.
+	reader := consistency.WithSession(context.Background())
+	user, err := cached.GetUserById(reader, 1)
+	require.NoError(t, err)
+	require.Equal(t, "ivan2", user.Username)
+
+	user, err = cached.GetUserById(writer, 1)
+	require.NoError(t, err)
+	require.Equal(t, "ivan2", user.Username, "the writer reads its own write from the cache")
+	require.Equal(t, int32(1), users.queries.Load())
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"ivanjabrony/refstudy/internal/usecase"
+	"sync"
+)
+
+type txKey struct{}
+
+// txState collects what has to happen once the transaction is over.
+type txState struct {
+	mu    sync.Mutex
+	after []func(context.Context)
+}
+
+// Transactor decorates the real transactor so cached repositories can tell
+// when they run inside a transaction: they skip the cache there, since it
+// must never hold uncommitted rows, and invalidate what was written once the
+// transaction ends. Cached repositories must only be used with it.
+type Transactor struct {
+	usecase.Transactor
+}
+
+func NewTransactor(tx usecase.Transactor) (*Transactor, error) {
+	if tx == nil {
+		return nil, errors.New("nil values in Transactor constructor")
+	}
+
+	return &Transactor{tx}, nil
+}
+
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if inTx(ctx) {
+		return t.Transactor.WithinTx(ctx, fn)
+	}
+
+	state := &txState{}
+	err := t.Transactor.WithinTx(context.WithValue(ctx, txKey{}, state), fn)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	for _, fn := range state.after {
+		fn(context.WithoutCancel(ctx))
+	}
+
+	return err
+}
+
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*txState)
+	return ok
+}
+
+// afterTx runs fn once the transaction in ctx is over, or right away outside
+// of one.
+func afterTx(ctx context.Context, fn func(context.Context)) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		fn(ctx)
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.after = append(state.after, fn)
+}
@@ -0,0 +1,292 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/consistency"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/usecase"
+	"math/rand/v2"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Users is everything the usecases read and write users through.
+type Users interface {
+	usecase.UserRepository
+	usecase.AccountRepository
+}
+
+// UserRepository caches users looked up by id or username in front of the
+// real repository. Every write through it invalidates the user it touches,
+// and concurrent misses for the same user share one query.
+//
+// Cached users carry the version of the user they were read at, which every
+// write replaces, so a lookup racing with a write can't put the old row back.
+// Misses are always read from the primary: a replica lagging behind a write
+// could otherwise cache the old row under the new version.
+// Password hashes are never cached; credentials are read through
+// GetUserCredentials, which always goes to the repository.
+type UserRepository struct {
+	Users
+	cache  Cache
+	ttl    time.Duration
+	group  singleflight.Group
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	logger *logger.MyLogger
+}
+
+func NewUserRepository(users Users, cache Cache, ttl time.Duration, logger *logger.MyLogger) (*UserRepository, error) {
+	if users == nil || cache == nil || logger == nil {
+		return nil, errors.New("nil values in UserRepository constructor")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("cache TTL must be positive")
+	}
+
+	return &UserRepository{Users: users, cache: cache, ttl: ttl, logger: logger}, nil
+}
+
+func (r *UserRepository) Stats() Stats {
+	return Stats{Hits: r.hits.Load(), Misses: r.misses.Load()}
+}
+
+func (r *UserRepository) GetUserById(ctx context.Context, id int32) (*model.User, error) {
+	if inTx(ctx) {
+		return r.Users.GetUserById(ctx, id)
+	}
+
+	if user, ok := r.cached(ctx, id); ok {
+		r.hits.Add(1)
+		return user, nil
+	}
+	r.misses.Add(1)
+
+	return r.loadById(ctx, id)
+}
+
+// GetUserByUsername caches only which id a username belongs to and reads the
+// user itself through its id, so writes only have to invalidate that.
+func (r *UserRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	if inTx(ctx) {
+		return r.Users.GetUserByUsername(ctx, username)
+	}
+
+	id, known := r.cachedId(ctx, username)
+	if known {
+		user, ok := r.cached(ctx, id)
+		if ok && user.Username == username {
+			r.hits.Add(1)
+			return user, nil
+		}
+		known = !ok
+	}
+	r.misses.Add(1)
+
+	if known {
+		user, err := r.loadById(ctx, id)
+		if err == nil && user.Username == username {
+			return user, nil
+		}
+	}
+
+	// The version is kept by id, so a user looked up by username can't be
+	// cached; only the id it has is.
+	return r.load(ctx, usernameKey(username), func(ctx context.Context) (*model.User, error) {
+		user, err := r.Users.GetUserByUsername(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		r.set(ctx, usernameKey(user.Username), []byte(strconv.Itoa(int(user.Id))))
+		return user, nil
+	})
+}
+
+func (r *UserRepository) UpdateUser(ctx context.Context, update *model.UserUpdate) error {
+	return r.invalidating(ctx, update.Id, r.Users.UpdateUser(ctx, update))
+}
+
+func (r *UserRepository) DeleteUserById(ctx context.Context, id int32) error {
+	return r.invalidating(ctx, id, r.Users.DeleteUserById(ctx, id))
+}
+
+func (r *UserRepository) SetUserDisabled(ctx context.Context, id int32, disabled bool) error {
+	return r.invalidating(ctx, id, r.Users.SetUserDisabled(ctx, id, disabled))
+}
+
+func (r *UserRepository) SetUserRole(ctx context.Context, id int32, role model.Role) error {
+	return r.invalidating(ctx, id, r.Users.SetUserRole(ctx, id, role))
+}
+
+func (r *UserRepository) RestoreUser(ctx context.Context, id int32) error {
+	return r.invalidating(ctx, id, r.Users.RestoreUser(ctx, id))
+}
+
+func (r *UserRepository) SetUserPassword(ctx context.Context, id int32, password string) error {
+	return r.invalidating(ctx, id, r.Users.SetUserPassword(ctx, id, password))
+}
+
+func (r *UserRepository) SetEmailVerified(ctx context.Context, id int32) error {
+	return r.invalidating(ctx, id, r.Users.SetEmailVerified(ctx, id))
+}
+
+// loadById reads user id and caches it with the version it had before the
+// query. A write during the query changes the version, which makes the
+// cached user stale from the start.
+func (r *UserRepository) loadById(ctx context.Context, id int32) (*model.User, error) {
+	return r.load(ctx, idKey(id), func(ctx context.Context) (*model.User, error) {
+		version, _ := r.get(ctx, versionKey(id))
+		user, err := r.Users.GetUserById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded, err := json.Marshal(cachedUser{Version: string(version), User: withoutPassword(user)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode user: %w", err)
+		}
+		r.set(ctx, idKey(user.Id), encoded)
+		r.set(ctx, usernameKey(user.Username), []byte(strconv.Itoa(int(user.Id))))
+		return user, nil
+	})
+}
+
+// load runs one query per key at a time, on the primary, since its result
+// is cached and shared with callers that may have written already. The
+// query outlives callers that give up waiting for it, so one cancelled
+// request doesn't fail the others sharing it. Every caller gets its own copy
+// of the user, without its password hash.
+func (r *UserRepository) load(ctx context.Context, key string, query func(context.Context) (*model.User, error)) (*model.User, error) {
+	shared := consistency.Primary(context.WithoutCancel(ctx))
+	result := r.group.DoChan(key, func() (any, error) {
+		user, err := query(shared)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(withoutPassword(user))
+	})
+
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		var user model.User
+		if err := json.Unmarshal(res.Val.([]byte), &user); err != nil {
+			return nil, fmt.Errorf("failed to decode user: %w", err)
+		}
+		return &user, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// cachedUser is a user as cached, with the version of the user it was read
+// at.
+type cachedUser struct {
+	Version string      `json:"version"`
+	User    *model.User `json:"user"`
+}
+
+func withoutPassword(user *model.User) *model.User {
+	copied := *user
+	copied.Password = ""
+	return &copied
+}
+
+// cached returns user id if it is cached at its current version.
+func (r *UserRepository) cached(ctx context.Context, id int32) (*model.User, bool) {
+	value, ok := r.get(ctx, idKey(id))
+	if !ok {
+		return nil, false
+	}
+
+	var entry cachedUser
+	if err := json.Unmarshal(value, &entry); err != nil {
+		r.logger.WrapError("failed to decode cached user", err, "id", id)
+		return nil, false
+	}
+	if entry.User == nil {
+		return nil, false
+	}
+	version, _ := r.get(ctx, versionKey(id))
+	if entry.Version != string(version) {
+		return nil, false
+	}
+	return entry.User, true
+}
+
+func (r *UserRepository) cachedId(ctx context.Context, username string) (int32, bool) {
+	value, ok := r.get(ctx, usernameKey(username))
+	if !ok {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(string(value), 10, 32)
+	if err != nil {
+		r.logger.WrapError("failed to decode cached user id", err, "username", username)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// get treats a failing cache as empty so lookups fall back to the
+// repository.
+func (r *UserRepository) get(ctx context.Context, key string) ([]byte, bool) {
+	value, ok, err := r.cache.Get(ctx, key)
+	if err != nil {
+		r.logger.WrapError("failed to read cache", err, "key", key)
+		return nil, false
+	}
+	return value, ok
+}
+
+func (r *UserRepository) set(ctx context.Context, key string, value []byte) {
+	if err := r.cache.Set(ctx, key, value, r.ttl); err != nil {
+		r.logger.WrapError("failed to write cache", err, "key", key)
+	}
+}
+
+// invalidating drops the cached user after a successful write. Inside a
+// transaction that waits until it's over, since lookups until then still
+// see the old row anyway.
+func (r *UserRepository) invalidating(ctx context.Context, id int32, err error) error {
+	if err != nil {
+		return err
+	}
+
+	afterTx(ctx, func(ctx context.Context) { r.invalidate(ctx, id) })
+	return nil
+}
+
+// invalidate gives user id a new version before dropping it, so a lookup
+// that read the old row can't cache it anymore. The version outlives the
+// users cached at it.
+func (r *UserRepository) invalidate(ctx context.Context, id int32) {
+	version := strconv.FormatUint(rand.Uint64(), 36)
+	if err := r.cache.Set(ctx, versionKey(id), []byte(version), 2*r.ttl); err != nil {
+		r.logger.WrapError("failed to version cached user", err, "id", id)
+	}
+	if err := r.cache.Delete(ctx, idKey(id)); err != nil {
+		r.logger.WrapError("failed to invalidate cached user", err, "id", id)
+	}
+}
+
+func idKey(id int32) string {
+	return "user:id:" + strconv.Itoa(int(id))
+}
+
+func versionKey(id int32) string {
+	return "user:version:" + strconv.Itoa(int(id))
+}
+
+func usernameKey(username string) string {
+	return "user:username:" + username
+}
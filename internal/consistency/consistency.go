@@ -0,0 +1,48 @@
+// Package consistency tracks whether a request has written to the database,
+// so reads after a write can avoid replicas that may lag behind.
+package consistency
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type key struct{}
+
+type primaryKey struct{}
+
+type session struct {
+	wrote atomic.Bool
+}
+
+// WithSession starts a session for one request. Reads in it may go to a
+// replica until something is written through it.
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, key{}, &session{})
+}
+
+// MarkWritten records that the session in ctx has written, if there is one.
+func MarkWritten(ctx context.Context) {
+	if s, ok := ctx.Value(key{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+// Primary makes reads in ctx go to the primary even in a session that may
+// use a replica. Reads deciding who the caller is or what they may do use
+// it, so a lagging replica can't accept an old password or a revoked role.
+func Primary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// ReplicaSafe reports whether a read in ctx may go to a replica: only inside
+// a session that hasn't written yet, and not for reads marked with Primary.
+// Work outside of a session, like background jobs, always reads from the
+// primary.
+func ReplicaSafe(ctx context.Context) bool {
+	if ctx.Value(primaryKey{}) != nil {
+		return false
+	}
+	s, ok := ctx.Value(key{}).(*session)
+	return ok && !s.wrote.Load()
+}
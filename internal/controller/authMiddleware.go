@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, username, password string) (*model.Actor, error)
+}
+
+// AuthMiddleware resolves HTTP Basic credentials into the actor the usecases
+// authorize against. Requests without credentials proceed anonymously, and
+// requests with wrong ones are rejected right away.
+func AuthMiddleware(authenticator Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, password, ok := c.Request.BasicAuth()
+		if !ok {
+			c.Next()
+			return
+		}
+
+		actor, err := authenticator.Authenticate(c.Request.Context(), username, password)
+		if err != nil {
+			status := response.Status(err)
+			if status == http.StatusUnauthorized {
+				c.Header("WWW-Authenticate", `Basic realm="refstudy"`)
+			}
+			var locked *ratelimit.LockedError
+			if errors.As(err, &locked) {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			}
+			response.Fail(c, status, "Failed to authenticate")
+			return
+		}
+
+		c.Request = c.Request.WithContext(policy.WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
@@ -0,0 +1,16 @@
+package controller
+
+import (
+	"ivanjabrony/refstudy/internal/consistency"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConsistencyMiddleware starts a read-your-writes session for every request,
+// so its reads may use a replica until it writes something.
+func ConsistencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(consistency.WithSession(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,185 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type swaggerDoc struct {
+	BasePath string                                `yaml:"basePath"`
+	Paths    map[string]map[string]swaggerOperation `yaml:"paths"`
+}
+
+type swaggerOperation struct {
+	Responses map[string]swaggerResponse `yaml:"responses"`
+}
+
+type swaggerResponse struct {
+	Schema  map[string]any            `yaml:"schema"`
+	Headers map[string]map[string]any `yaml:"headers"`
+}
+
+func loadSwagger(t *testing.T) swaggerDoc {
+	t.Helper()
+
+	raw, err := os.ReadFile("../../docs/swagger.yaml")
+	require.NoError(t, err)
+
+	var doc swaggerDoc
+	require.NoError(t, yaml.Unmarshal(raw, &doc))
+	return doc
+}
+
+// TestContract runs requests against the router and checks that every
+// response is documented in docs/swagger.yaml for its operation, with a body
+// exactly when the document describes one and the documented headers set.
+func TestContract(t *testing.T) {
+	doc := loadSwagger(t)
+	require.Equal(t, "/api/v1", doc.BasePath)
+
+	testcases := []struct {
+		name      string
+		method    string
+		operation string
+		path      string
+		auth      string
+		body      string
+		status    int
+		headers   map[string]string
+	}{
+		{"create user", http.MethodPost, "/users", "/users", "", `{"username":"new","email":"new@example.com","password":"12345678"}`, http.StatusCreated, map[string]string{"Location": "/api/v1/users/4"}},
+		{"create user with bad body", http.MethodPost, "/users", "/users", "", `{`, http.StatusBadRequest, nil},
+		{"create user with malformed email", http.MethodPost, "/users", "/users", "", `{"username":"new","email":"new","password":"12345678"}`, http.StatusBadRequest, nil},
+		{"get user", http.MethodGet, "/users/{id}", "/users/2", "ivan", "", http.StatusOK, nil},
+		{"get other user", http.MethodGet, "/users/{id}", "/users/1", "ivan", "", http.StatusForbidden, nil},
+		{"get user anonymously", http.MethodGet, "/users/{id}", "/users/2", "", "", http.StatusUnauthorized, nil},
+		{"get missing user", http.MethodGet, "/users/{id}", "/users/99", "admin", "", http.StatusNotFound, nil},
+		{"get user with bad id", http.MethodGet, "/users/{id}", "/users/abc", "", "", http.StatusBadRequest, nil},
+		{"list users", http.MethodGet, "/users", "/users?page=1&page_size=1", "admin", "", http.StatusOK, nil},
+		{"list users by cursor", http.MethodGet, "/users", "/users?cursor=&page_size=1", "admin", "", http.StatusOK, nil},
+		{"list users with bad cursor", http.MethodGet, "/users", "/users?cursor=%21", "admin", "", http.StatusBadRequest, nil},
+		{"list users by unknown sort", http.MethodGet, "/users", "/users?cursor=&sort=email", "admin", "", http.StatusBadRequest, nil},
+		{"list user fields", http.MethodGet, "/users", "/users?fields=id,username", "admin", "", http.StatusOK, nil},
+		{"list unknown user fields", http.MethodGet, "/users", "/users?fields=id,name", "admin", "", http.StatusBadRequest, nil},
+		{"list users with relation", http.MethodGet, "/users", "/users?include=owner", "admin", "", http.StatusBadRequest, nil},
+		{"list users anonymously", http.MethodGet, "/users", "/users", "", "", http.StatusUnauthorized, nil},
+		{"list users as user", http.MethodGet, "/users", "/users", "ivan", "", http.StatusForbidden, nil},
+		{"update user", http.MethodPut, "/users", "/users", "ivan", `{"id":2,"username":"ivan2"}`, http.StatusNoContent, nil},
+		{"update user with malformed email", http.MethodPut, "/users", "/users", "ivan", `{"id":2,"email":"ivan"}`, http.StatusBadRequest, nil},
+		{"update other user", http.MethodPut, "/users", "/users", "ivan", `{"id":1,"username":"x"}`, http.StatusForbidden, nil},
+		{"update missing user", http.MethodPut, "/users", "/users", "admin", `{"id":99,"username":"x"}`, http.StatusNotFound, nil},
+		{"delete missing user", http.MethodDelete, "/users/{id}", "/users/99", "admin", "", http.StatusNotFound, nil},
+		{"delete user", http.MethodDelete, "/users/{id}", "/users/2", "ivan", "", http.StatusNoContent, nil},
+		{"restore user", http.MethodPost, "/users/{id}/restore", "/users/3/restore", "admin", "", http.StatusNoContent, nil},
+		{"restore missing user", http.MethodPost, "/users/{id}/restore", "/users/99/restore", "admin", "", http.StatusNotFound, nil},
+		{"admin lists users", http.MethodGet, "/admin/users", "/admin/users", "admin", "", http.StatusOK, nil},
+		{"admin disables user", http.MethodPost, "/admin/users/{id}/disable", "/admin/users/2/disable", "admin", "", http.StatusNoContent, nil},
+		{"admin disables missing user", http.MethodPost, "/admin/users/{id}/disable", "/admin/users/99/disable", "admin", "", http.StatusNotFound, nil},
+		{"admin enables user", http.MethodPost, "/admin/users/{id}/enable", "/admin/users/2/enable", "admin", "", http.StatusNoContent, nil},
+		{"admin sets role", http.MethodPut, "/admin/users/{id}/role", "/admin/users/2/role", "admin", `{"role":"moderator"}`, http.StatusNoContent, nil},
+		{"admin sets unknown role", http.MethodPut, "/admin/users/{id}/role", "/admin/users/2/role", "admin", `{"role":"king"}`, http.StatusBadRequest, nil},
+		{"admin reads audit", http.MethodGet, "/admin/audit", "/admin/audit", "admin", "", http.StatusOK, nil},
+		{"get job", http.MethodGet, "/jobs/{id}", "/jobs/1", "admin", "", http.StatusOK, nil},
+		{"get missing job", http.MethodGet, "/jobs/{id}", "/jobs/2", "admin", "", http.StatusNotFound, nil},
+		{"import galleries without archive", http.MethodPost, "/galleries/import", "/galleries/import", "ivan", "", http.StatusBadRequest, nil},
+		{"get gallery import", http.MethodGet, "/galleries/imports/{id}", "/galleries/imports/1", "admin", "", http.StatusOK, nil},
+		{"get missing gallery import", http.MethodGet, "/galleries/imports/{id}", "/galleries/imports/2", "admin", "", http.StatusNotFound, nil},
+		{"export other gallery", http.MethodGet, "/galleries/{id}/export", "/galleries/1/export", "ivan", "", http.StatusForbidden, nil},
+		{"export missing gallery", http.MethodGet, "/galleries/{id}/export", "/galleries/99/export", "admin", "", http.StatusNotFound, nil},
+		{"duplicates of gallery", http.MethodGet, "/galleries/{id}/duplicates", "/galleries/1/duplicates?threshold=64", "admin", "", http.StatusOK, nil},
+		{"duplicates with bad threshold", http.MethodGet, "/galleries/{id}/duplicates", "/galleries/1/duplicates?threshold=65", "admin", "", http.StatusBadRequest, nil},
+		{"duplicates of other gallery", http.MethodGet, "/galleries/{id}/duplicates", "/galleries/1/duplicates", "ivan", "", http.StatusForbidden, nil},
+		{"get gallery import with bad id", http.MethodGet, "/galleries/imports/{id}", "/galleries/imports/abc", "ivan", "", http.StatusBadRequest, nil},
+		{"verify email", http.MethodPost, "/auth/verify-email", "/auth/verify-email", "", `{"token":"valid"}`, http.StatusNoContent, nil},
+		{"verify email with bad token", http.MethodPost, "/auth/verify-email", "/auth/verify-email", "", `{"token":"forged"}`, http.StatusBadRequest, nil},
+		{"resend verification", http.MethodPost, "/auth/verify-email/resend", "/auth/verify-email/resend", "ivan", "", http.StatusAccepted, nil},
+		{"forgot password", http.MethodPost, "/auth/forgot-password", "/auth/forgot-password", "", `{"email":"ivan@example.com"}`, http.StatusAccepted, nil},
+		{"reset password", http.MethodPost, "/auth/reset-password", "/auth/reset-password", "", `{"token":"valid","password":"12345678"}`, http.StatusNoContent, nil},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			router := newTestRouter()
+
+			req := httptest.NewRequest(testcase.method, doc.BasePath+testcase.path, strings.NewReader(testcase.body))
+			if testcase.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if testcase.auth != "" {
+				req.SetBasicAuth(testcase.auth, "secret")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, testcase.status, w.Code, w.Body.String())
+			for header, value := range testcase.headers {
+				require.Equal(t, value, w.Header().Get(header))
+			}
+
+			operation, ok := doc.Paths[testcase.operation][strings.ToLower(testcase.method)]
+			require.True(t, ok, "operation %s %s is not documented", testcase.method, testcase.operation)
+			documented, ok := operation.Responses[strconv.Itoa(w.Code)]
+			require.True(t, ok, "status %d of %s %s is not documented", w.Code, testcase.method, testcase.operation)
+
+			for header := range documented.Headers {
+				require.NotEmpty(t, w.Header().Get(header), "documented header %s is missing", header)
+			}
+			if documented.Schema == nil {
+				require.Empty(t, w.Body.String())
+				return
+			}
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			if w.Code >= http.StatusBadRequest {
+				require.Equal(t, response.ProblemContentType, w.Header().Get("Content-Type"))
+				require.Equal(t, float64(w.Code), body["status"])
+			} else {
+				require.Contains(t, body, "data")
+			}
+		})
+	}
+}
+
+func TestImplicitMethods(t *testing.T) {
+	router := newTestRouter()
+
+	head := func(path string) *http.Request {
+		req := httptest.NewRequest(http.MethodHead, path, nil)
+		req.SetBasicAuth("admin", "secret")
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, head("/api/v1/users/2"))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, head("/api/v1/users/99"))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/api/v1/users/2", nil))
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "GET, HEAD, DELETE, OPTIONS", w.Header().Get("Allow"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/v1/users/2", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	require.Equal(t, response.ProblemContentType, w.Header().Get("Content-Type"))
+	require.Contains(t, w.Header().Get("Allow"), http.MethodGet)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/nothing", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, response.ProblemContentType, w.Header().Get("Content-Type"))
+}
@@ -0,0 +1,139 @@
+package controller_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"ivanjabrony/refstudy/internal/archive"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// emptyZipForm returns a multipart form uploading an empty ZIP archive as
+// field, and its content type.
+func emptyZipForm(t *testing.T, field string) ([]byte, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile(field, "galleries.zip")
+	require.NoError(t, err)
+	require.NoError(t, zip.NewWriter(part).Close())
+	require.NoError(t, form.Close())
+	return body.Bytes(), form.FormDataContentType()
+}
+
+func TestImportGalleries(t *testing.T) {
+	router := newTestRouter()
+
+	body, contentType := emptyZipForm(t, "archive")
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/galleries/import", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.SetBasicAuth("ivan", "secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	require.Equal(t, "/api/v1/galleries/imports/2", w.Header().Get("Location"))
+
+	var started struct {
+		Data struct {
+			Id     int64  `json:"id"`
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &started))
+	require.Equal(t, int64(2), started.Data.Id)
+	require.Equal(t, "queued", started.Data.Status)
+
+	// The owner and admins may follow the import.
+	for _, user := range []string{"ivan", "admin"} {
+		req = httptest.NewRequest(http.MethodGet, w.Header().Get("Location"), nil)
+		req.SetBasicAuth(user, "secret")
+		got := httptest.NewRecorder()
+		router.ServeHTTP(got, req)
+		require.Equal(t, http.StatusOK, got.Code, user)
+	}
+}
+
+func TestExportGallery(t *testing.T) {
+	router := newTestRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/galleries/1/export", nil)
+	req.SetBasicAuth("admin", "secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	require.Equal(t, "attachment; filename=gallery-1.zip", w.Header().Get("Content-Disposition"))
+
+	body := bytes.NewReader(w.Body.Bytes())
+	zr, err := zip.NewReader(body, body.Size())
+	require.NoError(t, err)
+	f, err := zr.Open("manifest.json")
+	require.NoError(t, err)
+	defer f.Close()
+	var manifest archive.Manifest
+	require.NoError(t, json.NewDecoder(f).Decode(&manifest))
+	require.Equal(t, "hands", manifest.Gallery.Name)
+	require.Len(t, manifest.Gallery.Pictures, 1)
+	require.Equal(t, []string{"anatomy"}, manifest.Gallery.Pictures[0].Tags)
+
+	picture, err := zr.Open(manifest.Gallery.Pictures[0].File)
+	require.NoError(t, err)
+	defer picture.Close()
+	data, err := io.ReadAll(picture)
+	require.NoError(t, err)
+	require.Equal(t, "png", string(data))
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		query    string
+		want     []string
+	}{
+		{"owner finds private pictures by tag prefix", "admin", "anat", []string{"picture"}},
+		{"owner finds galleries by name", "admin", "HAND", []string{"gallery"}},
+		{"other users don't see private galleries", "ivan", "anat", []string{}},
+		{"anonymous callers don't see private galleries", "", "hands", []string{}},
+		{"every word has to match", "admin", "anat feet", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/search?q="+url.QueryEscape(tt.query), nil)
+			if tt.username != "" {
+				req.SetBasicAuth(tt.username, "secret")
+			}
+			w := httptest.NewRecorder()
+			newTestRouter().ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var body struct {
+				Data []struct {
+					Kind string `json:"kind"`
+				} `json:"data"`
+				Meta struct {
+					Total int `json:"total"`
+				} `json:"meta"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			kinds := []string{}
+			for _, hit := range body.Data {
+				kinds = append(kinds, hit.Kind)
+			}
+			require.Equal(t, tt.want, kinds)
+			require.Equal(t, len(tt.want), body.Meta.Total)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/search?q=%20", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
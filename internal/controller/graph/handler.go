@@ -0,0 +1,102 @@
+// Package graph serves users, their galleries and pictures over GraphQL.
+// Nested fields are loaded a level at a time, with one usecase call per
+// level rather than one per object.
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+type UserUsecase interface {
+	GetUsersByIds(ctx context.Context, ids []int32) ([]dto.UserDto, error)
+}
+
+type GalleryUsecase interface {
+	GetGalleriesByIds(ctx context.Context, ids []int32) ([]dto.GalleryDto, error)
+	GetGalleriesByOwners(ctx context.Context, ownerIds []int32, first int) ([]dto.GalleryDto, error)
+	GetPicturesByGalleries(ctx context.Context, galleryIds []int32, first int) ([]dto.PictureDto, error)
+}
+
+type Usecases struct {
+	User    UserUsecase
+	Gallery GalleryUsecase
+}
+
+type Handler struct {
+	usecases Usecases
+	limits   Limits
+	schema   graphql.Schema
+}
+
+func NewHandler(usecases Usecases, limits Limits) (*Handler, error) {
+	if usecases.User == nil || usecases.Gallery == nil {
+		return nil, errors.New("nil values in Handler constructor")
+	}
+	schema, err := newSchema()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build schema: %w", err)
+	}
+	return &Handler{usecases: usecases, limits: limits, schema: schema}, nil
+}
+
+type request struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Serve runs the query in the body of a POST request. Errors in the query
+// itself, including exceeding the limits, come back in the "errors" of the
+// result like those of fields do.
+func (h *Handler) Serve(c *gin.Context) {
+	var req request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to parse request")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.execute(c.Request.Context(), req))
+}
+
+func (h *Handler) execute(ctx context.Context, req request) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if result := graphql.ValidateDocument(&h.schema, doc, nil); !result.IsValid {
+		return &graphql.Result{Errors: result.Errors}
+	}
+
+	depth, complexity := measure(&h.schema, doc, req.OperationName, req.Variables)
+	if h.limits.MaxDepth > 0 && depth > h.limits.MaxDepth {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(
+			fmt.Errorf("query depth %d exceeds the limit of %d", depth, h.limits.MaxDepth),
+		)}
+	}
+	if h.limits.MaxComplexity > 0 && complexity > h.limits.MaxComplexity {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(
+			fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, h.limits.MaxComplexity),
+		)}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       context.WithValue(ctx, loadersKey{}, newLoaders(h.usecases)),
+	})
+}
@@ -0,0 +1,253 @@
+package graph_test
+
+import (
+	"context"
+	"encoding/json"
+	"ivanjabrony/refstudy/internal/controller/graph"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/testing/fakes"
+	"ivanjabrony/refstudy/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// counting serves the usecases over in-memory repositories and counts the
+// calls made to each method.
+type counting struct {
+	users     *fakes.UserUsecase
+	galleries *usecase.GalleryUsecase
+	calls     map[string]int
+}
+
+func (c *counting) GetUsersByIds(ctx context.Context, ids []int32) ([]dto.UserDto, error) {
+	c.calls["GetUsersByIds"]++
+	return c.users.GetUsersByIds(ctx, ids)
+}
+
+func (c *counting) GetGalleriesByIds(ctx context.Context, ids []int32) ([]dto.GalleryDto, error) {
+	c.calls["GetGalleriesByIds"]++
+	return c.galleries.GetGalleriesByIds(ctx, ids)
+}
+
+func (c *counting) GetGalleriesByOwners(ctx context.Context, ownerIds []int32, first int) ([]dto.GalleryDto, error) {
+	c.calls["GetGalleriesByOwners"]++
+	return c.galleries.GetGalleriesByOwners(ctx, ownerIds, first)
+}
+
+func (c *counting) GetPicturesByGalleries(ctx context.Context, galleryIds []int32, first int) ([]dto.PictureDto, error) {
+	c.calls["GetPicturesByGalleries"]++
+	return c.galleries.GetPicturesByGalleries(ctx, galleryIds, first)
+}
+
+// newUsecases holds users 1 "admin" and 2 "ivan". The admin owns the
+// private gallery 1 "hands", ivan the public gallery 2 "trees" and the
+// private gallery 3 "faces".
+func newUsecases(t *testing.T) *counting {
+	t.Helper()
+
+	users := fakes.NewUserUsecase(fakes.NewUserRepository(
+		model.User{Username: "admin", Email: "admin@example.com", Role: model.RoleAdmin, EmailVerified: true},
+		model.User{Username: "ivan", Email: "ivan@example.com", EmailVerified: true},
+	))
+	repo := fakes.NewGalleryRepository()
+	for _, gallery := range []model.Gallery{
+		{OwnerId: 1, GalleryName: "hands", Pictures: []model.Picture{
+			{Name: "left", Tags: []string{"anatomy"}},
+			{Name: "right", Tags: []string{"anatomy", "hands"}},
+		}},
+		{OwnerId: 2, GalleryName: "trees", IsPublic: true, Pictures: []model.Picture{
+			{Name: "oak", Tags: []string{"nature"}},
+			{Name: "pine"},
+		}},
+		{OwnerId: 2, GalleryName: "faces", Pictures: []model.Picture{
+			{Name: "smile", Tags: []string{"portrait"}},
+		}},
+	} {
+		_, err := repo.CreateGallery(context.Background(), &gallery)
+		require.NoError(t, err)
+	}
+	galleries, err := usecase.NewGalleryUsecase(repo, fakes.NewJobRepository(), fakes.NewBlobStore(), fakes.Transactor{}, policy.RolePolicy{}, logger.New(logger.Test, logger.LogFormatText))
+	require.NoError(t, err)
+
+	return &counting{users: users, galleries: galleries, calls: map[string]int{}}
+}
+
+type result struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// query posts body to /graphql as actor, anonymously when actor is nil.
+func query(t *testing.T, usecases *counting, limits graph.Limits, actor *model.Actor, body string) (int, result) {
+	t.Helper()
+
+	handler, err := graph.NewHandler(graph.Usecases{User: usecases, Gallery: usecases}, limits)
+	require.NoError(t, err)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/graphql", func(c *gin.Context) {
+		if actor != nil {
+			c.Request = c.Request.WithContext(policy.WithActor(c.Request.Context(), actor))
+		}
+		handler.Serve(c)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	var res result
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	}
+	return w.Code, res
+}
+
+func request(t *testing.T, query string, variables map[string]any) string {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	require.NoError(t, err)
+	return string(body)
+}
+
+var ivan = &model.Actor{Id: 2, Role: model.RoleUser, EmailVerified: true}
+
+func TestBatchesNestedFields(t *testing.T) {
+	usecases := newUsecases(t)
+
+	code, res := query(t, usecases, graph.Limits{}, ivan, request(t, `{
+		viewer {
+			username
+			info {
+				ownedGalleries {
+					name
+					pictures { name tags { name } gallery { name owner { username } } }
+				}
+			}
+		}
+		admin: user(id: 1) { username }
+	}`, nil))
+
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{
+		"viewer": {
+			"username": "ivan",
+			"info": {"ownedGalleries": [
+				{"name": "trees", "pictures": [
+					{"name": "oak", "tags": [{"name": "nature"}], "gallery": {"name": "trees", "owner": {"username": "ivan"}}},
+					{"name": "pine", "tags": [], "gallery": {"name": "trees", "owner": {"username": "ivan"}}}
+				]},
+				{"name": "faces", "pictures": [
+					{"name": "smile", "tags": [{"name": "portrait"}], "gallery": {"name": "faces", "owner": {"username": "ivan"}}}
+				]}
+			]}
+		},
+		"admin": null
+	}`, string(res.Data))
+	// The owners were loaded along with the viewer already.
+	require.Equal(t, map[string]int{
+		"GetUsersByIds":          1,
+		"GetGalleriesByOwners":   1,
+		"GetPicturesByGalleries": 1,
+		"GetGalleriesByIds":      1,
+	}, usecases.calls)
+}
+
+func TestHidesPrivateGalleriesFromAnonymousCallers(t *testing.T) {
+	usecases := newUsecases(t)
+
+	code, res := query(t, usecases, graph.Limits{}, nil, request(t, `{
+		viewer { username }
+		hands: gallery(id: 1) { name }
+		trees: gallery(id: 2) { name isPublic pictureCount owner { username } pictures(first: 1) { name } }
+	}`, nil))
+
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{
+		"viewer": null,
+		"hands": null,
+		"trees": {"name": "trees", "isPublic": true, "pictureCount": 2, "owner": null, "pictures": [{"name": "oak"}]}
+	}`, string(res.Data))
+}
+
+func TestRejectsQueriesOverLimits(t *testing.T) {
+	const nested = `query($first: Int) {
+		viewer { info { ownedGalleries(first: $first) { pictures(first: $first) { name } } } }
+	}`
+
+	tests := []struct {
+		name    string
+		limits  graph.Limits
+		first   int
+		message string
+	}{
+		{name: "within limits", limits: graph.Limits{MaxDepth: 5, MaxComplexity: 100}, first: 5},
+		{name: "too deep", limits: graph.Limits{MaxDepth: 4}, first: 5, message: "query depth 5 exceeds the limit of 4"},
+		// Each gallery costs itself and its first pictures.
+		{name: "too complex", limits: graph.Limits{MaxComplexity: 100}, first: 10, message: "query complexity 113 exceeds the limit of 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecases := newUsecases(t)
+
+			code, res := query(t, usecases, tt.limits, ivan, request(t, nested, map[string]any{"first": tt.first}))
+
+			require.Equal(t, http.StatusOK, code)
+			if tt.message == "" {
+				require.Empty(t, res.Errors)
+				return
+			}
+			require.Len(t, res.Errors, 1)
+			require.Equal(t, tt.message, res.Errors[0].Message)
+			require.Empty(t, usecases.calls)
+		})
+	}
+}
+
+func TestComplexityCountsDefaultPageSize(t *testing.T) {
+	usecases := newUsecases(t)
+
+	// 1 + 10 * (1 + 10 * 1) for the galleries and their pictures, plus
+	// viewer and info.
+	code, res := query(t, usecases, graph.Limits{MaxComplexity: 112}, ivan, request(t, `{
+		viewer { info { ownedGalleries { pictures { name } } } }
+	}`, nil))
+
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "query complexity 113 exceeds the limit of 112", res.Errors[0].Message)
+}
+
+func TestRejectsPagesOutOfRange(t *testing.T) {
+	usecases := newUsecases(t)
+
+	code, res := query(t, usecases, graph.Limits{}, ivan, request(t, `{
+		gallery(id: 2) { name pictures(first: 0) { name } }
+	}`, nil))
+
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "first must be between 1 and 100", res.Errors[0].Message)
+	require.EqualValues(t, http.StatusBadRequest, res.Errors[0].Extensions["status"])
+	require.JSONEq(t, `{"gallery": null}`, string(res.Data))
+}
+
+func TestRejectsMalformedRequests(t *testing.T) {
+	code, _ := query(t, newUsecases(t), graph.Limits{}, ivan, `{"query": 1}`)
+
+	require.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound what one query may ask for before it runs. Depth counts the
+// levels of nested fields; complexity counts every field once per object it
+// may be resolved on, so a list field multiplies what is selected on it by
+// how many items it asks for. Zero disables a limit.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// measure walks the operation of doc that would run and returns its depth
+// and complexity. Introspection fields cost nothing, as they don't touch the
+// database. doc must be valid, which rules out cycles between fragments.
+func measure(schema *graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}) (depth, complexity int) {
+	fragments := map[string]*ast.FragmentDefinition{}
+	var operations []*ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operationName == "" || definition.Name != nil && definition.Name.Value == operationName {
+				operations = append(operations, definition)
+			}
+		}
+	}
+	// Anything else fails to execute anyway.
+	if len(operations) != 1 {
+		return 0, 0
+	}
+
+	m := measurer{fragments: fragments, variables: variables}
+	return m.selections(schema.QueryType(), operations[0].SelectionSet)
+}
+
+type measurer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+func (m measurer) selections(parent *graphql.Object, set *ast.SelectionSet) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, selection := range set.Selections {
+		var d, c int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d, c = m.field(parent, selection)
+		case *ast.InlineFragment:
+			d, c = m.selections(parent, selection.SelectionSet)
+		case *ast.FragmentSpread:
+			if fragment, ok := m.fragments[selection.Name.Value]; ok {
+				d, c = m.selections(parent, fragment.SelectionSet)
+			}
+		}
+		depth = max(depth, d)
+		complexity += c
+	}
+	return depth, complexity
+}
+
+func (m measurer) field(parent *graphql.Object, field *ast.Field) (depth, complexity int) {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+	definition, ok := parent.Fields()[field.Name.Value]
+	if !ok {
+		return 1, 1
+	}
+
+	child, _ := graphql.GetNamed(definition.Type).(*graphql.Object)
+	if child == nil {
+		return 1, 1
+	}
+	depth, complexity = m.selections(child, field.SelectionSet)
+	return depth + 1, 1 + m.items(definition, field)*complexity
+}
+
+// items is how many objects a field resolves to: one unless it is a list,
+// which returns up to its first argument.
+func (m measurer) items(definition *graphql.FieldDefinition, field *ast.Field) int {
+	if _, ok := graphql.GetNullable(definition.Type).(*graphql.List); !ok {
+		return 1
+	}
+
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				return max(n, 1)
+			}
+		case *ast.Variable:
+			switch n := m.variables[value.Name.Value].(type) {
+			case float64:
+				return max(int(n), 1)
+			case int:
+				return max(n, 1)
+			}
+		}
+		return maxFirst
+	}
+	for _, arg := range definition.Args {
+		if n, ok := arg.DefaultValue.(int); ok && arg.Name() == "first" {
+			return n
+		}
+	}
+	return maxFirst
+}
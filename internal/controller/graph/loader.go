@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"context"
+	"sync"
+)
+
+// loader batches the keys resolvers ask for on one level of a query into a
+// single fetch. Resolvers get a thunk back, which the executor only calls
+// once every field of the level is resolved; the first thunk called fetches
+// all the keys queued until then.
+type loader[K comparable, V any] struct {
+	fetch func(context.Context, []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	results map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch func(context.Context, []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		queued:  map[K]bool{},
+		results: map[K]V{},
+		errs:    map[K]error{},
+	}
+}
+
+// load queues key and returns a thunk resolving to its value, or to nil if
+// the fetch didn't return one.
+func (l *loader[K, V]) load(ctx context.Context, key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if len(l.pending) > 0 {
+			l.flush(ctx)
+		}
+
+		if err := l.errs[key]; err != nil {
+			return nil, err
+		}
+		value, ok := l.results[key]
+		if !ok {
+			return nil, nil
+		}
+		return value, nil
+	}
+}
+
+// flush fetches the pending keys. l.mu must be held.
+func (l *loader[K, V]) flush(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+
+	values, err := l.fetch(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		if value, ok := values[key]; ok {
+			l.results[key] = value
+		}
+	}
+}
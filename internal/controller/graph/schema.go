@@ -0,0 +1,337 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"ivanjabrony/refstudy/internal/policy"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// defaultFirst is how many galleries or pictures a list field returns
+	// unless asked for another number, maxFirst the most it returns.
+	defaultFirst = 10
+	maxFirst     = 100
+)
+
+// Error is a field error classified the same way the HTTP API picks its
+// response status, which clients find in the "status" extension.
+type Error struct {
+	err     error
+	message string
+}
+
+func newError(err error, message string) *Error {
+	return &Error{err: err, message: message}
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{"status": response.Status(e.err)}
+}
+
+// page is the key of a list field: the first galleries or pictures of the
+// object with id.
+type page struct {
+	id    int32
+	first int
+}
+
+// loaders batch the repository queries of one request.
+type loaders struct {
+	users          *loader[int32, *dto.UserDto]
+	galleries      *loader[int32, *dto.GalleryDto]
+	ownedGalleries *loader[page, []*dto.GalleryDto]
+	pictures       *loader[page, []*dto.PictureDto]
+}
+
+type loadersKey struct{}
+
+func newLoaders(usecases Usecases) *loaders {
+	return &loaders{
+		users: newLoader(func(ctx context.Context, ids []int32) (map[int32]*dto.UserDto, error) {
+			users, err := usecases.User.GetUsersByIds(ctx, ids)
+			if err != nil {
+				return nil, newError(err, "Failed to get users")
+			}
+			byId := make(map[int32]*dto.UserDto, len(users))
+			for i := range users {
+				byId[users[i].Id] = &users[i]
+			}
+			return byId, nil
+		}),
+		galleries: newLoader(func(ctx context.Context, ids []int32) (map[int32]*dto.GalleryDto, error) {
+			galleries, err := usecases.Gallery.GetGalleriesByIds(ctx, ids)
+			if err != nil {
+				return nil, newError(err, "Failed to get galleries")
+			}
+			byId := make(map[int32]*dto.GalleryDto, len(galleries))
+			for i := range galleries {
+				byId[galleries[i].Id] = &galleries[i]
+			}
+			return byId, nil
+		}),
+		ownedGalleries: newLoader(func(ctx context.Context, pages []page) (map[page][]*dto.GalleryDto, error) {
+			byPage := make(map[page][]*dto.GalleryDto, len(pages))
+			for first, ownerIds := range groupByFirst(pages) {
+				galleries, err := usecases.Gallery.GetGalleriesByOwners(ctx, ownerIds, first)
+				if err != nil {
+					return nil, newError(err, "Failed to get galleries")
+				}
+				for i := range galleries {
+					key := page{id: galleries[i].OwnerId, first: first}
+					byPage[key] = append(byPage[key], &galleries[i])
+				}
+			}
+			return byPage, nil
+		}),
+		pictures: newLoader(func(ctx context.Context, pages []page) (map[page][]*dto.PictureDto, error) {
+			byPage := make(map[page][]*dto.PictureDto, len(pages))
+			for first, galleryIds := range groupByFirst(pages) {
+				pictures, err := usecases.Gallery.GetPicturesByGalleries(ctx, galleryIds, first)
+				if err != nil {
+					return nil, newError(err, "Failed to get pictures")
+				}
+				for i := range pictures {
+					key := page{id: pictures[i].GalleryId, first: first}
+					byPage[key] = append(byPage[key], &pictures[i])
+				}
+			}
+			return byPage, nil
+		}),
+	}
+}
+
+// groupByFirst collects the ids of pages asking for the same number of
+// items, which one query can fetch together.
+func groupByFirst(pages []page) map[int][]int32 {
+	ids := map[int][]int32{}
+	for _, p := range pages {
+		ids[p.first] = append(ids[p.first], p.id)
+	}
+	return ids
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// list resolves to an empty list rather than null for objects without any
+// items, as list fields are non-null.
+func list[V any](thunk func() (interface{}, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		value, err := thunk()
+		if err != nil || value != nil {
+			return value, err
+		}
+		return []V{}, nil
+	}
+}
+
+func parseFirst(p graphql.ResolveParams) (int, error) {
+	first, _ := p.Args["first"].(int)
+	if first < 1 || first > maxFirst {
+		return 0, newError(model.ErrInvalidField, fmt.Sprintf("first must be between 1 and %d", maxFirst))
+	}
+	return first, nil
+}
+
+func firstArgument() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultFirst},
+	}
+}
+
+// newSchema describes users, their galleries and the pictures in them. A
+// user, gallery or picture the caller may not read resolves to null or is
+// left out of its list.
+func newSchema() (graphql.Schema, error) {
+	var user, gallery *graphql.Object
+
+	tag := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tag",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(string), nil
+				},
+			},
+		},
+	})
+
+	picture := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Picture",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"name":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"width":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"height": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"createdAt": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.DateTime),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*dto.PictureDto).CreatedAt, nil
+					},
+				},
+				"tags": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tag))),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if tags := p.Source.(*dto.PictureDto).Tags; tags != nil {
+							return tags, nil
+						}
+						return []string{}, nil
+					},
+				},
+				"gallery": &graphql.Field{
+					Type: gallery,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).galleries.load(p.Context, p.Source.(*dto.PictureDto).GalleryId), nil
+					},
+				},
+			}
+		}),
+	})
+
+	gallery = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Gallery",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"isPublic": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Boolean),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*dto.GalleryDto).IsPublic, nil
+					},
+				},
+				"pictureCount": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*dto.GalleryDto).PictureCount, nil
+					},
+				},
+				"ownerName": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*dto.GalleryDto).OwnerName, nil
+					},
+				},
+				"createdAt": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.DateTime),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*dto.GalleryDto).CreatedAt, nil
+					},
+				},
+				"owner": &graphql.Field{
+					Type: user,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).users.load(p.Context, p.Source.(*dto.GalleryDto).OwnerId), nil
+					},
+				},
+				"pictures": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(picture))),
+					Args: firstArgument(),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						first, err := parseFirst(p)
+						if err != nil {
+							return nil, err
+						}
+						key := page{id: p.Source.(*dto.GalleryDto).Id, first: first}
+						return list[*dto.PictureDto](loadersFrom(p.Context).pictures.load(p.Context, key)), nil
+					},
+				},
+			}
+		}),
+	})
+
+	// UserInfo is what a user has made, kept apart from the account itself.
+	userInfo := graphql.NewObject(graphql.ObjectConfig{
+		Name: "UserInfo",
+		Fields: graphql.Fields{
+			"ownedGalleries": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(gallery))),
+				Args: firstArgument(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					first, err := parseFirst(p)
+					if err != nil {
+						return nil, err
+					}
+					key := page{id: p.Source.(*dto.UserDto).Id, first: first}
+					return list[*dto.GalleryDto](loadersFrom(p.Context).ownedGalleries.load(p.Context, key)), nil
+				},
+			},
+		},
+	})
+
+	user = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"username": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"email":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"role":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"disabled": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"emailVerified": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*dto.UserDto).EmailVerified, nil
+				},
+			},
+			"info": &graphql.Field{
+				Type: graphql.NewNonNull(userInfo),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+			},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"viewer": &graphql.Field{
+				Type: user,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					actor := policy.ActorFromContext(p.Context)
+					if actor == nil {
+						return nil, nil
+					}
+					return loadersFrom(p.Context).users.load(p.Context, actor.Id), nil
+				},
+			},
+			"user": &graphql.Field{
+				Type: user,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p.Context).users.load(p.Context, int32(p.Args["id"].(int))), nil
+				},
+			},
+			"gallery": &graphql.Field{
+				Type: gallery,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p.Context).galleries.load(p.Context, int32(p.Args["id"].(int))), nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
@@ -0,0 +1,357 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/swaggo/swag"
+)
+
+type schema = map[string]any
+
+type openAPIDoc struct {
+	BasePath    string                                `json:"basePath"`
+	Paths       map[string]map[string]openAPIOperation `json:"paths"`
+	Definitions map[string]schema                      `json:"definitions"`
+}
+
+type openAPIOperation struct {
+	Parameters []openAPIParameter `json:"parameters"`
+	Security   []map[string]any   `json:"security"`
+	Responses  map[string]struct {
+		Schema schema `json:"schema"`
+	} `json:"responses"`
+}
+
+type openAPIParameter struct {
+	In       string `json:"in"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	Example  any    `json:"example"`
+	Schema   schema `json:"schema"`
+}
+
+type silentDebugger struct{}
+
+func (silentDebugger) Printf(string, ...any) {}
+
+// TestOpenAPIUpToDate regenerates the document from the annotations the same
+// way swag init does and fails when docs/ is stale.
+func TestOpenAPIUpToDate(t *testing.T) {
+	parser := swag.New(swag.SetDebugger(silentDebugger{}))
+	parser.ParseInternal = true
+	parser.PropNamingStrategy = swag.CamelCase
+	require.NoError(t, parser.ParseAPI("../..", "./cmd/main.go", 100))
+
+	generated, err := json.MarshalIndent(parser.GetSwagger(), "", "    ")
+	require.NoError(t, err)
+	committed, err := os.ReadFile("../../docs/swagger.json")
+	require.NoError(t, err)
+
+	require.Equal(t, string(committed), string(generated),
+		"docs/ is out of date, run: swag init --parseInternal -g ./cmd/main.go")
+}
+
+// TestOpenAPIOperations calls every documented operation with the examples
+// from the document and checks that the response is documented and matches
+// its schema. Operations documenting 401, 403 or 404 are also called without
+// credentials, as a user who may not and for a missing id, and have to answer
+// with a problem matching the documented one.
+func TestOpenAPIOperations(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	// Path parameters default to 1, which exists for every resource except
+	// the ones that only act on deleted users.
+	ids := map[string]string{"/users/{id}/restore": "3"}
+
+	type errorCase struct {
+		name     string
+		status   int
+		id       string
+		username string
+	}
+
+	for path, operations := range doc.Paths {
+		for method, operation := range operations {
+			name := strings.ToUpper(method) + " " + path
+			id, ok := ids[path]
+			if !ok {
+				id = "1"
+			}
+			username := ""
+			if len(operation.Security) > 0 {
+				username = "admin"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				w := doc.call(t, method, path, operation, id, username)
+				require.Less(t, w.Code, http.StatusBadRequest, w.Body.String())
+				doc.check(t, operation, w)
+			})
+
+			errorCases := []errorCase{
+				{"without credentials", http.StatusUnauthorized, id, ""},
+				// ivan is a plain user and user 1 is the admin.
+				{"as another user", http.StatusForbidden, "1", "ivan"},
+			}
+			if strings.Contains(path, "{id}") {
+				errorCases = append(errorCases, errorCase{"for a missing id", http.StatusNotFound, "99", username})
+			}
+			for _, errorCase := range errorCases {
+				if _, ok := operation.Responses[strconv.Itoa(errorCase.status)]; !ok {
+					continue
+				}
+				t.Run(name+" "+errorCase.name, func(t *testing.T) {
+					w := doc.call(t, method, path, operation, errorCase.id, errorCase.username)
+					require.Equal(t, errorCase.status, w.Code, w.Body.String())
+					require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+					doc.check(t, operation, w)
+				})
+			}
+		}
+	}
+}
+
+// call sends a request for operation to a fresh router, with the body and
+// the required query parameters built from the document's examples and id in
+// place of the path parameter. An empty username sends no credentials.
+func (doc *openAPIDoc) call(t *testing.T, method, path string, operation openAPIOperation, id, username string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := newTestRouter()
+
+	var body []byte
+	contentType := "application/json"
+	query := url.Values{}
+	for _, parameter := range operation.Parameters {
+		switch {
+		case parameter.In == "query" && parameter.Required:
+			query.Set(parameter.Name, fmt.Sprint(parameter.Example))
+		case parameter.In == "body":
+			var err error
+			body, err = json.Marshal(doc.example(parameter.Schema))
+			require.NoError(t, err)
+		case parameter.In == "formData" && parameter.Type == "file":
+			body, contentType = emptyZipForm(t, parameter.Name)
+		}
+	}
+
+	target := doc.BasePath + strings.ReplaceAll(path, "{id}", id)
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req := httptest.NewRequest(strings.ToUpper(method), target, bytes.NewReader(body))
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if username != "" {
+		req.SetBasicAuth(username, "secret")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// check fails unless the status of w is documented for operation and its
+// body matches the documented schema.
+func (doc *openAPIDoc) check(t *testing.T, operation openAPIOperation, w *httptest.ResponseRecorder) {
+	t.Helper()
+
+	documented, ok := operation.Responses[strconv.Itoa(w.Code)]
+	require.True(t, ok, "status %d is not documented", w.Code)
+	if documented.Schema == nil {
+		require.Empty(t, w.Body.String())
+		return
+	}
+	if documented.Schema["type"] == "file" {
+		require.NotEmpty(t, w.Body.String())
+		return
+	}
+
+	var value any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &value))
+	require.NoError(t, doc.validate(documented.Schema, value, "body"))
+}
+
+// TestOpenAPICoversRoutes fails on any route the router serves that the
+// document doesn't describe.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	doc := loadOpenAPI(t)
+	router := newTestRouter()
+
+	for _, route := range router.Routes() {
+		if route.Method == http.MethodHead || route.Method == http.MethodOptions || strings.HasPrefix(route.Path, "/swagger/") {
+			continue
+		}
+		// GraphQL describes itself through introspection instead.
+		if route.Path == "/graphql" {
+			continue
+		}
+		require.True(t, strings.HasPrefix(route.Path, doc.BasePath), "%s %s is outside of %s", route.Method, route.Path, doc.BasePath)
+
+		segments := strings.Split(strings.TrimPrefix(route.Path, doc.BasePath), "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = "{" + segment[1:] + "}"
+			}
+		}
+		path := strings.Join(segments, "/")
+		if path == "" {
+			path = "/"
+		}
+
+		_, ok := doc.Paths[path][strings.ToLower(route.Method)]
+		require.True(t, ok, "%s %s is not documented", route.Method, route.Path)
+	}
+}
+
+func loadOpenAPI(t *testing.T) *openAPIDoc {
+	t.Helper()
+
+	raw, err := os.ReadFile("../../docs/swagger.json")
+	require.NoError(t, err)
+
+	var doc openAPIDoc
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	require.NotEmpty(t, doc.Paths)
+	return &doc
+}
+
+// resolve follows a reference and flattens allOf into a single object schema,
+// later members overriding the properties of earlier ones.
+func (doc *openAPIDoc) resolve(s schema) schema {
+	if ref, ok := s["$ref"].(string); ok {
+		return doc.resolve(doc.Definitions[strings.TrimPrefix(ref, "#/definitions/")])
+	}
+	all, ok := s["allOf"].([]any)
+	if !ok {
+		return s
+	}
+
+	properties := schema{}
+	var required []any
+	for _, member := range all {
+		resolved := doc.resolve(member.(schema))
+		for name, property := range asSchema(resolved["properties"]) {
+			properties[name] = property
+		}
+		if list, ok := resolved["required"].([]any); ok {
+			required = append(required, list...)
+		}
+	}
+	return schema{"type": "object", "properties": properties, "required": required}
+}
+
+func (doc *openAPIDoc) example(s schema) any {
+	s = doc.resolve(s)
+	if example, ok := s["example"]; ok {
+		return example
+	}
+
+	switch s["type"] {
+	case "object":
+		object := map[string]any{}
+		for name, property := range asSchema(s["properties"]) {
+			object[name] = doc.example(property.(schema))
+		}
+		return object
+	case "array":
+		return []any{doc.example(asSchema(s["items"]))}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	default:
+		return ""
+	}
+}
+
+func (doc *openAPIDoc) validate(s schema, value any, at string) error {
+	s = doc.resolve(s)
+
+	switch s["type"] {
+	case nil:
+		return nil
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %T", at, value)
+		}
+		if list, ok := s["required"].([]any); ok {
+			for _, name := range list {
+				if _, ok := object[name.(string)]; !ok {
+					return fmt.Errorf("%s: required property %q is missing", at, name)
+				}
+			}
+		}
+		properties := asSchema(s["properties"])
+		additional, hasAdditional := s["additionalProperties"].(schema)
+		for name, property := range object {
+			documented, ok := properties[name].(schema)
+			if !ok && hasAdditional {
+				documented, ok = additional, true
+			}
+			if !ok {
+				return fmt.Errorf("%s: property %q is not documented", at, name)
+			}
+			if err := doc.validate(documented, property, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %T", at, value)
+		}
+		for i, item := range array {
+			if err := doc.validate(asSchema(s["items"]), item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected a string, got %T", at, value)
+		}
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("%s: expected a number, got %T", at, value)
+		}
+		if s["type"] == "integer" && number != math.Trunc(number) {
+			return fmt.Errorf("%s: expected an integer, got %v", at, number)
+		}
+		if minimum, ok := s["minimum"].(float64); ok && number < minimum {
+			return fmt.Errorf("%s: %v is below the minimum %v", at, number, minimum)
+		}
+		if maximum, ok := s["maximum"].(float64); ok && number > maximum {
+			return fmt.Errorf("%s: %v is above the maximum %v", at, number, maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean, got %T", at, value)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported type %v", at, s["type"])
+	}
+
+	if enum, ok := s["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", at, value, enum)
+	}
+	return nil
+}
+
+func asSchema(value any) schema {
+	s, _ := value.(schema)
+	return s
+}
@@ -0,0 +1,121 @@
+package controller_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type cursorPage struct {
+	Data []dto.UserDto `json:"data"`
+	Meta struct {
+		PageSize   int    `json:"page_size"`
+		NextCursor string `json:"next_cursor"`
+		PrevCursor string `json:"prev_cursor"`
+	} `json:"meta"`
+}
+
+func getCursorPage(t *testing.T, handler http.Handler, cursor string) cursorPage {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users?page_size=1&cursor="+url.QueryEscape(cursor), nil)
+	req.SetBasicAuth("admin", "secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var page cursorPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	return page
+}
+
+func TestUsersByCursor(t *testing.T) {
+	router := newTestRouter()
+
+	first := getCursorPage(t, router, "")
+	require.Len(t, first.Data, 1)
+	require.Equal(t, int32(1), first.Data[0].Id)
+	require.Equal(t, 1, first.Meta.PageSize)
+	require.Empty(t, first.Meta.PrevCursor)
+	require.NotEmpty(t, first.Meta.NextCursor)
+
+	second := getCursorPage(t, router, first.Meta.NextCursor)
+	require.Len(t, second.Data, 1)
+	require.Equal(t, int32(2), second.Data[0].Id)
+	require.Empty(t, second.Meta.NextCursor, "the last page has no next page")
+	require.NotEmpty(t, second.Meta.PrevCursor)
+
+	back := getCursorPage(t, router, second.Meta.PrevCursor)
+	require.Equal(t, first.Data, back.Data)
+	require.Empty(t, back.Meta.PrevCursor)
+	require.NotEmpty(t, back.Meta.NextCursor)
+}
+
+func TestUsersStream(t *testing.T) {
+	router := newTestRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	req.SetBasicAuth("admin", "secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	var ids []int32
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var user dto.UserDto
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &user))
+		ids = append(ids, user.Id)
+	}
+	require.Equal(t, []int32{1, 2}, ids)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	req.SetBasicAuth("ivan", "secret")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code, "failures before the first user are still problems")
+}
+
+func TestUsersFields(t *testing.T) {
+	router := newTestRouter()
+
+	testcases := []struct {
+		name   string
+		path   string
+		accept string
+		want   string
+	}{
+		{"page", "/api/v1/users?page_size=1&fields=username,disabled", "", `{"data":[{"username":"admin","disabled":false}],"meta":{"page":1,"page_size":1,"total":2,"total_pages":2}}`},
+		{"cursor", "/api/v1/users?page_size=5&cursor=&fields=%20email%20", "", `{"data":[{"email":"admin@example.com"},{"email":"ivan@example.com"}],"meta":{"page_size":5}}`},
+		{"stream", "/api/v1/users?fields=id", "application/x-ndjson", "{\"id\":1}\n{\"id\":2}\n"},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, testcase.path, nil)
+			req.SetBasicAuth("admin", "secret")
+			if testcase.accept != "" {
+				req.Header.Set("Accept", testcase.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			if testcase.accept != "" {
+				require.Equal(t, testcase.want, w.Body.String())
+			} else {
+				require.JSONEq(t, testcase.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RateLimiter interface {
+	Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+}
+
+// RateLimitKey picks the bucket a request is counted against. Requests it
+// returns an empty key for are not limited.
+type RateLimitKey func(c *gin.Context) string
+
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+func KeyByUser(c *gin.Context) string {
+	actor := policy.ActorFromContext(c.Request.Context())
+	if actor == nil {
+		return ""
+	}
+	return "user:" + strconv.Itoa(int(actor.Id))
+}
+
+const rateLimitResultKey = "rateLimitResult"
+
+// RateLimitMiddleware takes a token from the bucket of the request and
+// rejects it with 429 once the bucket is empty. Routes with their own limit
+// get their own bucket. When it runs more than once per request, the
+// RateLimit headers describe the tightest of the limits. Failures of the
+// limiter let requests through.
+func RateLimitMiddleware(limiter RateLimiter, limits ratelimit.Limits, key RateLimitKey, logger *logger.MyLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bucket := key(c)
+		if bucket == "" {
+			c.Next()
+			return
+		}
+
+		route := c.Request.Method + " " + c.FullPath()
+		limit, override := limits.For(route)
+		if limit.Unlimited() {
+			c.Next()
+			return
+		}
+		if override {
+			bucket += "|" + route
+		}
+
+		result, err := limiter.Take(c.Request.Context(), bucket, limit)
+		if err != nil {
+			logger.WrapError("failed to check rate limit", err, "bucket", bucket)
+			c.Next()
+			return
+		}
+
+		if previous, ok := c.Get(rateLimitResultKey); !ok || result.Remaining <= previous.(ratelimit.Result).Remaining {
+			c.Set(rateLimitResultKey, result)
+			c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+			c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			c.Header("RateLimit-Reset", strconv.Itoa(int(result.Reset.Seconds())))
+		}
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())))
+			response.Fail(c, http.StatusTooManyRequests, "Too many requests")
+			return
+		}
+
+		c.Next()
+	}
+}
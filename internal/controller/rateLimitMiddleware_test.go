@@ -0,0 +1,78 @@
+package controller_test
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/controller"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limits := ratelimit.Limits{
+		Default: ratelimit.Limit{Requests: 2, Per: time.Minute},
+		Routes:  map[string]ratelimit.Limit{"POST /signup": {Requests: 1, Per: time.Hour}},
+	}
+
+	r := gin.New()
+	r.Use(controller.RateLimitMiddleware(ratelimit.NewMemoryStore(), limits, controller.KeyByIP, &logger.MyLogger{}))
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/signup", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	do := func(method, path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/ping", "10.0.0.1")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/signup", "10.0.0.1").Code)
+	w = do(http.MethodPost, "/signup", "10.0.0.1")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "3600", w.Header().Get("Retry-After"))
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/ping", "10.0.0.1").Code)
+	w = do(http.MethodGet, "/ping", "10.0.0.1")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/ping", "10.0.0.2").Code)
+}
+
+type lockedAuthenticator struct{}
+
+func (lockedAuthenticator) Authenticate(context.Context, string, string) (*model.Actor, error) {
+	return nil, &ratelimit.LockedError{RetryAfter: 90 * time.Second}
+}
+
+func TestAuthMiddleware_Locked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(controller.AuthMiddleware(lockedAuthenticator{}))
+	r.GET("/me", func(c *gin.Context) {
+		c.JSON(http.StatusOK, policy.ActorFromContext(c.Request.Context()))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.SetBasicAuth("ivan", "secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "90", w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"ivanjabrony/refstudy/internal/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIdMiddleware keeps the caller's X-Request-ID when it looks sane or
+// generates a new one, echoes it back and stores it in the request context.
+func RequestIdMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.WithRequestId(c.Request.Context(), id))
+		c.Next()
+	}
+}
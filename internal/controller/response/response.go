@@ -0,0 +1,161 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/requestid"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// uniqueViolation is the Postgres error code of a violated unique
+// constraint, like a taken username or email.
+const uniqueViolation = "23505"
+
+// Envelope wraps every successful response body. Failures are sent as a
+// Problem instead.
+type Envelope struct {
+	Data any   `json:"data"`
+	Meta *Meta `json:"meta,omitempty"`
+}
+
+// Meta describes the page of a paginated response. Pages picked by number
+// carry the page number and totals; pages picked by cursor carry the cursors
+// of their neighbours instead, when there are any.
+type Meta struct {
+	Page       int    `json:"page,omitempty" example:"1"`
+	PageSize   int    `json:"page_size" example:"10"`
+	Total      int    `json:"total,omitempty" example:"42"`
+	TotalPages int    `json:"total_pages,omitempty" example:"5"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJzIjoiaWQiLCJpIjoxMH0"`
+	PrevCursor string `json:"prev_cursor,omitempty" example:"eyJzIjoiaWQiLCJpIjoxLCJiIjp0cnVlfQ"`
+}
+
+func NewMeta(page, pageSize, total int) *Meta {
+	totalPages := total / pageSize
+	if total%pageSize != 0 {
+		totalPages++
+	}
+
+	return &Meta{Page: page, PageSize: pageSize, Total: total, TotalPages: totalPages}
+}
+
+func NewCursorMeta(pageSize int, next, prev string) *Meta {
+	return &Meta{PageSize: pageSize, NextCursor: next, PrevCursor: prev}
+}
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type      string `json:"type" example:"about:blank"`
+	Title     string `json:"title" example:"Bad Request"`
+	Status    int    `json:"status" example:"400"`
+	Detail    string `json:"detail,omitempty" example:"Failed to parse ID"`
+	Instance  string `json:"instance,omitempty" example:"/api/v1/users/abc"`
+	RequestId string `json:"request_id,omitempty" example:"5f0c6e1d2a7b4c3e9d8f1a2b3c4d5e6f"`
+}
+
+func JSON(c *gin.Context, status int, data any) {
+	c.JSON(status, Envelope{Data: data})
+}
+
+func Page[T any](c *gin.Context, data []T, meta *Meta) {
+	if data == nil {
+		data = []T{}
+	}
+	c.JSON(http.StatusOK, Envelope{Data: data, Meta: meta})
+}
+
+// Sparse narrows item, anything encoded as a JSON object, to fields, so the
+// fields that weren't read are left out instead of showing up as zero
+// values. Nil fields keep item whole.
+func Sparse(item any, fields model.Fields) (any, error) {
+	if fields == nil {
+		return item, nil
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	for name := range object {
+		if !fields.Has(name) {
+			delete(object, name)
+		}
+	}
+	return object, nil
+}
+
+// SparseAll is Sparse for every item.
+func SparseAll[T any](items []T, fields model.Fields) ([]any, error) {
+	sparse := make([]any, len(items))
+	for i, item := range items {
+		var err error
+		if sparse[i], err = Sparse(item, fields); err != nil {
+			return nil, err
+		}
+	}
+	return sparse, nil
+}
+
+// Fail aborts the request with a problem document for status.
+func Fail(c *gin.Context, status int, detail string) {
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(status, NewProblem(c, status, detail))
+}
+
+// NewProblem describes a failure of the request in c.
+func NewProblem(c *gin.Context, status int, detail string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		RequestId: requestid.FromContext(c.Request.Context()),
+	}
+}
+
+// Error aborts the request with a problem document for an error returned by
+// a usecase.
+func Error(c *gin.Context, err error, detail string) {
+	Fail(c, Status(err), detail)
+}
+
+// Status picks the response status for an error returned by a usecase,
+// falling back to 500 for anything unexpected.
+func Status(err error) int {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, policy.ErrUnauthenticated),
+		errors.Is(err, model.ErrInvalidCredentials),
+		errors.Is(err, model.ErrAccountDisabled):
+		return http.StatusUnauthorized
+	case errors.Is(err, model.ErrAccountLocked):
+		return http.StatusTooManyRequests
+	case errors.Is(err, policy.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, model.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, model.ErrAlreadyExists),
+		errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+		return http.StatusConflict
+	case errors.Is(err, model.ErrInvalidRole),
+		errors.Is(err, model.ErrInvalidToken),
+		errors.Is(err, model.ErrInvalidSort),
+		errors.Is(err, model.ErrInvalidField),
+		errors.Is(err, model.ErrInvalidUser):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,99 @@
+package response_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/policy"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	testcases := []struct {
+		err    error
+		status int
+	}{
+		{policy.ErrUnauthenticated, http.StatusUnauthorized},
+		{fmt.Errorf("wrapped: %w", model.ErrInvalidCredentials), http.StatusUnauthorized},
+		{policy.ErrForbidden, http.StatusForbidden},
+		{model.ErrAccountLocked, http.StatusTooManyRequests},
+		{fmt.Errorf("user with id 1: %w", model.ErrNotFound), http.StatusNotFound},
+		{model.ErrAlreadyExists, http.StatusConflict},
+		{fmt.Errorf("failed to create user: %w", &pgconn.PgError{Code: "23505", ConstraintName: "users_username_live_idx"}), http.StatusConflict},
+		{&pgconn.PgError{Code: "23514", ConstraintName: "users_role_check"}, http.StatusInternalServerError},
+		{model.ErrInvalidToken, http.StatusBadRequest},
+		{fmt.Errorf("%w: email", model.ErrInvalidSort), http.StatusBadRequest},
+		{fmt.Errorf("%w: name", model.ErrInvalidField), http.StatusBadRequest},
+		{fmt.Errorf("boom"), http.StatusInternalServerError},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.err.Error(), func(t *testing.T) {
+			require.Equal(t, testcase.status, response.Status(testcase.err))
+		})
+	}
+}
+
+func TestFail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id", func(c *gin.Context) {
+		response.Error(c, policy.ErrForbidden, "Failed to retrieve user info")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, response.ProblemContentType, w.Header().Get("Content-Type"))
+
+	var problem response.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	require.Equal(t, response.Problem{
+		Type:     "about:blank",
+		Title:    "Forbidden",
+		Status:   http.StatusForbidden,
+		Detail:   "Failed to retrieve user info",
+		Instance: "/users/1",
+	}, problem)
+}
+
+func TestPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users", func(c *gin.Context) {
+		response.Page(c, []int(nil), response.NewMeta(2, 10, 21))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"data":[],"meta":{"page":2,"page_size":10,"total":21,"total_pages":3}}`, w.Body.String())
+}
+
+func TestSparse(t *testing.T) {
+	type user struct {
+		Id       int32  `json:"id"`
+		Username string `json:"username"`
+		Disabled bool   `json:"disabled"`
+	}
+	users := []user{{Id: 1, Username: "ivan"}, {Id: 2, Username: "petr", Disabled: true}}
+
+	sparse, err := response.SparseAll(users, model.Fields{"id", "disabled"})
+	require.NoError(t, err)
+	data, err := json.Marshal(sparse)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"id":1,"disabled":false},{"id":2,"disabled":true}]`, string(data))
+
+	whole, err := response.Sparse(users[0], nil)
+	require.NoError(t, err)
+	require.Equal(t, users[0], whole)
+}
@@ -2,8 +2,14 @@ package controller
 
 import (
 	"ivanjabrony/refstudy/docs"
+	"ivanjabrony/refstudy/internal/controller/graph"
+	"ivanjabrony/refstudy/internal/controller/response"
+	v1 "ivanjabrony/refstudy/internal/controller/v1"
 	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -11,8 +17,40 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter(logger *logger.MyLogger, userUsecase UserUsecase, validator *validator.Validate) *gin.Engine {
+// Usecases are shared by every API version; each version picks what it
+// serves.
+type Usecases struct {
+	User          v1.UserUsecase
+	Admin         v1.AdminUsecase
+	Job           v1.JobUsecase
+	Audit         v1.AuditUsecase
+	Account       v1.AccountUsecase
+	Gallery       v1.GalleryUsecase
+	Authenticator Authenticator
+	// Graph backs /graphql, which isn't served without it.
+	Graph graph.Usecases
+}
+
+// Options configure the router itself rather than what it serves.
+type Options struct {
+	// RateLimiter limits requests per client IP and per user. Nil disables
+	// rate limiting.
+	RateLimiter RateLimiter
+	RateLimits  ratelimit.Limits
+	// TrustedProxies may set the client IP in X-Forwarded-For; nil trusts
+	// none and uses the remote address.
+	TrustedProxies []string
+	// GraphLimits bound the depth and complexity of GraphQL queries.
+	GraphLimits graph.Limits
+}
+
+func SetupRouter(logger *logger.MyLogger, usecases Usecases, options Options, validator *validator.Validate) *gin.Engine {
 	r := gin.Default()
+	r.HandleMethodNotAllowed = true
+	if err := r.SetTrustedProxies(options.TrustedProxies); err != nil {
+		logger.WrapError("invalid trusted proxies, trusting none", err)
+		_ = r.SetTrustedProxies(nil)
+	}
 
 	// timeoutTime := os.Getenv("TIMEOUT_TIME")
 	// if timeoutTime == "" {
@@ -26,24 +64,71 @@ func SetupRouter(logger *logger.MyLogger, userUsecase UserUsecase, validator *va
 	// r.Use(middleware.LoggerMiddleware(logger))
 	// r.Use(middleware.TimeoutMiddleware(time.Duration(timeoutParsed) * time.Second))
 
-	userCotroller := NewUserController(userUsecase, validator)
-
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
 	docs.SwaggerInfo.Host = "localhost:" + port
-	docs.SwaggerInfo.BasePath = "/api"
+	docs.SwaggerInfo.BasePath = "/api/v1"
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	api := r.Group("/api/users")
+	r.Use(RequestIdMiddleware())
+	r.Use(ConsistencyMiddleware())
+	if options.RateLimiter != nil {
+		r.Use(RateLimitMiddleware(options.RateLimiter, options.RateLimits, KeyByIP, logger))
+	}
+	r.Use(AuthMiddleware(usecases.Authenticator))
+	if options.RateLimiter != nil {
+		r.Use(RateLimitMiddleware(options.RateLimiter, options.RateLimits, KeyByUser, logger))
+	}
+	r.NoRoute(func(c *gin.Context) {
+		response.Fail(c, http.StatusNotFound, "No such route")
+	})
+	r.NoMethod(func(c *gin.Context) {
+		response.Fail(c, http.StatusMethodNotAllowed, "Method not allowed")
+	})
 
-	api.POST("/", userCotroller.CreateUser)
-	api.PUT("/", userCotroller.UpdateUser)
-	api.GET("/:id", userCotroller.GetUser)
-	api.DELETE("/:id", userCotroller.DeleteUserById)
-	api.GET("/", userCotroller.GetAllUsers)
+	v1.Register(r.Group("/api/v1"), v1.Usecases{
+		User:    usecases.User,
+		Admin:   usecases.Admin,
+		Job:     usecases.Job,
+		Audit:   usecases.Audit,
+		Account: usecases.Account,
+		Gallery: usecases.Gallery,
+	}, validator)
+	if handler, err := graph.NewHandler(usecases.Graph, options.GraphLimits); err != nil {
+		logger.WrapError("GraphQL is not served", err)
+	} else {
+		r.POST("/graphql", handler.Serve)
+	}
+	registerImplicitMethods(r)
 
 	return r
 }
+
+// registerImplicitMethods answers HEAD wherever GET is served and OPTIONS on
+// every route with the methods it allows. It has to run after all routes are
+// registered.
+func registerImplicitMethods(r *gin.Engine) {
+	allowed := map[string][]string{}
+	var paths []string
+	for _, route := range r.Routes() {
+		if _, ok := allowed[route.Path]; !ok {
+			paths = append(paths, route.Path)
+		}
+		allowed[route.Path] = append(allowed[route.Path], route.Method)
+		if route.Method == http.MethodGet {
+			r.HEAD(route.Path, route.HandlerFunc)
+			allowed[route.Path] = append(allowed[route.Path], http.MethodHead)
+		}
+	}
+
+	for _, path := range paths {
+		allow := strings.Join(append(allowed[path], http.MethodOptions), ", ")
+		r.OPTIONS(path, func(c *gin.Context) {
+			c.Header("Allow", allow)
+			c.Status(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package controller_test
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/controller"
+	"ivanjabrony/refstudy/internal/controller/graph"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/password"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/testing/fakes"
+	"ivanjabrony/refstudy/internal/usecase"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// secret is the hash of every test user's password "secret", hashed once
+// since bcrypt is slow on purpose.
+var secret = sync.OnceValue(func() string { return password.MustHash("secret") })
+
+// newTestRouter serves the usecases over in-memory repositories. Users
+// "admin" and "ivan" exist with password "secret", user 3 "gone" was
+// deleted and job 1 has imported gallery 1, a private one of the admin with
+// a single picture. Every account token but fakes.ForgedToken is accepted.
+func newTestRouter() *gin.Engine {
+	users := fakes.NewUserRepository(
+		model.User{Username: "admin", Email: "admin@example.com", Password: secret(), Role: model.RoleAdmin, EmailVerified: true},
+		model.User{Username: "ivan", Email: "ivan@example.com", Password: secret()},
+		model.User{Username: "gone", Email: "gone@example.com", Password: secret()},
+	)
+	if err := users.DeleteUserById(context.Background(), 3); err != nil {
+		panic(err)
+	}
+	userUsecase := fakes.NewUserUsecase(users)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	jobs := fakes.NewJobRepository(model.Job{
+		Type: usecase.GalleryImportJob, Payload: []byte(`{}`), Status: model.JobSucceeded,
+		Attempts: 1, MaxAttempts: 5, Progress: 100, RunAt: now, CreatedAt: now, UpdatedAt: now,
+	})
+	log := logger.New(logger.Test, logger.LogFormatText)
+	jobUsecase, err := usecase.NewJobUsecase(jobs, policy.RolePolicy{}, log)
+	if err != nil {
+		panic(err)
+	}
+	auditUsecase, err := usecase.NewAuditUsecase(userUsecase.Audit, policy.RolePolicy{}, log)
+	if err != nil {
+		panic(err)
+	}
+	galleries := fakes.NewGalleryRepository(model.GalleryImport{
+		JobId: 1, OwnerId: 1, ArchiveKey: "imports/1.zip", GalleryIds: []int32{1}, CreatedAt: now, FinishedAt: &now,
+	})
+	blobs := fakes.NewBlobStore()
+	_, err = blobs.Put(context.Background(), "pictures/1.png", strings.NewReader("png"), 3, "image/png")
+	if err != nil {
+		panic(err)
+	}
+	_, err = galleries.CreateGallery(context.Background(), &model.Gallery{
+		OwnerId: 1, GalleryName: "hands", OwnerName: "admin",
+		Pictures: []model.Picture{{Name: "left", Path: "pictures/1.png", Tags: []string{"anatomy"}, Width: 1, Height: 1}},
+	})
+	if err != nil {
+		panic(err)
+	}
+	galleryUsecase, err := usecase.NewGalleryUsecase(galleries, jobs, blobs, fakes.Transactor{}, policy.RolePolicy{}, log)
+	if err != nil {
+		panic(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	return controller.SetupRouter(
+		&logger.MyLogger{},
+		controller.Usecases{
+			User:          userUsecase,
+			Admin:         userUsecase,
+			Job:           jobUsecase,
+			Audit:         auditUsecase,
+			Account:       fakes.AccountUsecase{},
+			Gallery:       galleryUsecase,
+			Authenticator: userUsecase,
+			Graph:         graph.Usecases{User: userUsecase, Gallery: galleryUsecase},
+		},
+		controller.Options{},
+		validator.New(validator.WithRequiredStructEnabled()),
+	)
+}
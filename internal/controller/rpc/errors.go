@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"errors"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Error converts an error returned by a usecase into a gRPC status, classified
+// the same way the HTTP API picks its response status.
+func Error(err error, message string) error {
+	st := status.New(Code(response.Status(err)), message)
+
+	var locked *ratelimit.LockedError
+	if errors.As(err, &locked) {
+		if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(locked.RetryAfter)}); err == nil {
+			st = detailed
+		}
+	}
+
+	return st.Err()
+}
+
+// Code maps an HTTP status to the closest gRPC code.
+func Code(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,171 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	refstudyv1 "ivanjabrony/refstudy/api/refstudy/v1"
+	"ivanjabrony/refstudy/internal/consistency"
+	"ivanjabrony/refstudy/internal/controller"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"ivanjabrony/refstudy/internal/requestid"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const requestIdKey = "x-request-id"
+
+// RequestIdInterceptor keeps the caller's x-request-id when it looks sane or
+// generates a new one, sends it back in the header and stores it in the
+// context.
+func RequestIdInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		id := firstValue(ctx, requestIdKey)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIdKey, id))
+		return handler(requestid.WithRequestId(ctx, id), req)
+	}
+}
+
+// ConsistencyInterceptor starts a read-your-writes session for every call,
+// like controller.ConsistencyMiddleware does for HTTP.
+func ConsistencyInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(consistency.WithSession(ctx), req)
+	}
+}
+
+// AuthInterceptor resolves HTTP Basic credentials from the "authorization"
+// metadata into the actor the usecases authorize against, like
+// controller.AuthMiddleware does for HTTP.
+func AuthInterceptor(authenticator controller.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		authorization := firstValue(ctx, "authorization")
+		if authorization == "" {
+			return handler(ctx, req)
+		}
+
+		username, password, ok := parseBasicAuth(authorization)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "Malformed credentials")
+		}
+		actor, err := authenticator.Authenticate(ctx, username, password)
+		if err != nil {
+			return nil, Error(err, "Failed to authenticate")
+		}
+
+		return handler(policy.WithActor(ctx, actor), req)
+	}
+}
+
+func firstValue(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func parseBasicAuth(authorization string) (username, password string, ok bool) {
+	const prefix = "basic "
+	if len(authorization) < len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return "", "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(authorization[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
+
+// routes are the HTTP routes the methods mirror. Methods are limited under
+// the route's name so the same overrides apply and share its buckets.
+var routes = map[string]string{
+	refstudyv1.UserService_CreateUser_FullMethodName:  "POST /api/v1/users",
+	refstudyv1.UserService_GetUser_FullMethodName:     "GET /api/v1/users/:id",
+	refstudyv1.UserService_ListUsers_FullMethodName:   "GET /api/v1/users",
+	refstudyv1.UserService_UpdateUser_FullMethodName:  "PUT /api/v1/users",
+	refstudyv1.UserService_DeleteUser_FullMethodName:  "DELETE /api/v1/users/:id",
+	refstudyv1.UserService_RestoreUser_FullMethodName: "POST /api/v1/users/:id/restore",
+}
+
+// RateLimitKey picks the bucket a call is counted against. Calls it returns
+// an empty key for are not limited.
+type RateLimitKey func(ctx context.Context) string
+
+// KeyByIP counts calls against the address of the peer, like
+// controller.KeyByIP does for requests.
+func KeyByIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "ip:" + host
+}
+
+func KeyByUser(ctx context.Context) string {
+	actor := policy.ActorFromContext(ctx)
+	if actor == nil {
+		return ""
+	}
+	return "user:" + strconv.Itoa(int(actor.Id))
+}
+
+// RateLimitInterceptor takes a token from the bucket of the call and
+// rejects it with ResourceExhausted once the bucket is empty, like
+// controller.RateLimitMiddleware does for HTTP. Failures of the limiter let
+// calls through.
+func RateLimitInterceptor(limiter controller.RateLimiter, limits ratelimit.Limits, key RateLimitKey, logger *logger.MyLogger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		bucket := key(ctx)
+		if bucket == "" {
+			return handler(ctx, req)
+		}
+
+		route, ok := routes[info.FullMethod]
+		if !ok {
+			route = info.FullMethod
+		}
+		limit, override := limits.For(route)
+		if limit.Unlimited() {
+			return handler(ctx, req)
+		}
+		if override {
+			bucket += "|" + route
+		}
+
+		result, err := limiter.Take(ctx, bucket, limit)
+		if err != nil {
+			logger.WrapError("failed to check rate limit", err, "bucket", bucket)
+			return handler(ctx, req)
+		}
+
+		if !result.Allowed {
+			st := status.New(codes.ResourceExhausted, "Too many requests")
+			if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(result.RetryAfter)}); err == nil {
+				st = detailed
+			}
+			return nil, st.Err()
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,46 @@
+package rpc
+
+import (
+	refstudyv1 "ivanjabrony/refstudy/api/refstudy/v1"
+	"ivanjabrony/refstudy/internal/controller"
+	v1 "ivanjabrony/refstudy/internal/controller/v1"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/ratelimit"
+
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+)
+
+// Usecases are the subset of the HTTP API's usecases served over gRPC.
+type Usecases struct {
+	User          v1.UserUsecase
+	Authenticator controller.Authenticator
+}
+
+// Options configure the server itself rather than what it serves.
+type Options struct {
+	// RateLimiter limits calls per peer address and per user with the
+	// limits of the HTTP routes the methods mirror. Nil disables rate
+	// limiting.
+	RateLimiter controller.RateLimiter
+	RateLimits  ratelimit.Limits
+}
+
+// NewServer serves the gRPC API over the same usecases as the HTTP router.
+// Callers authenticate with HTTP Basic credentials in the "authorization"
+// metadata and may pass an "x-request-id".
+func NewServer(logger *logger.MyLogger, usecases Usecases, options Options, validator *validator.Validate) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{RequestIdInterceptor(), ConsistencyInterceptor()}
+	if options.RateLimiter != nil {
+		interceptors = append(interceptors, RateLimitInterceptor(options.RateLimiter, options.RateLimits, KeyByIP, logger))
+	}
+	interceptors = append(interceptors, AuthInterceptor(usecases.Authenticator))
+	if options.RateLimiter != nil {
+		interceptors = append(interceptors, RateLimitInterceptor(options.RateLimiter, options.RateLimits, KeyByUser, logger))
+	}
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	refstudyv1.RegisterUserServiceServer(server, NewUserService(usecases.User, validator))
+
+	return server
+}
@@ -0,0 +1,230 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/base64"
+	refstudyv1 "ivanjabrony/refstudy/api/refstudy/v1"
+	"ivanjabrony/refstudy/internal/controller/rpc"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/password"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"ivanjabrony/refstudy/internal/testing/fakes"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newUsers serves user 1 "admin" and user 2 "ivan", both with password
+// "secret", through the real usecase over an in-memory repository.
+func newUsers() *fakes.UserUsecase {
+	return fakes.NewUserUsecase(fakes.NewUserRepository(
+		model.User{Username: "admin", Email: "admin@example.com", Password: secret(), Role: model.RoleAdmin},
+		model.User{Username: "ivan", Email: "ivan@example.com", Password: secret()},
+	))
+}
+
+// secret is the hash of the password "secret", hashed once since bcrypt is
+// slow on purpose.
+var secret = sync.OnceValue(func() string { return password.MustHash("secret") })
+
+func newClient(t *testing.T, users *fakes.UserUsecase) refstudyv1.UserServiceClient {
+	t.Helper()
+	return serve(t, rpc.Usecases{User: users, Authenticator: users}, rpc.Options{})
+}
+
+func serve(t *testing.T, usecases rpc.Usecases, options rpc.Options) refstudyv1.UserServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := rpc.NewServer(logger.New(logger.Test, logger.LogFormatText), usecases, options, validator.New(validator.WithRequiredStructEnabled()))
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return refstudyv1.NewUserServiceClient(conn)
+}
+
+func withBasicAuth(ctx context.Context, username string) context.Context {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":secret"))
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Basic "+credentials)
+}
+
+func TestUserService(t *testing.T) {
+	ctx := context.Background()
+	users := newUsers()
+	client := newClient(t, users)
+
+	var header metadata.MD
+	created, err := client.CreateUser(
+		metadata.AppendToOutgoingContext(ctx, "x-request-id", "req-1"),
+		&refstudyv1.CreateUserRequest{Username: "new", Email: "new@example.com", Password: "12345678"},
+		grpc.Header(&header),
+	)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), created.GetId())
+	require.Equal(t, "user", created.GetRole())
+	require.Equal(t, []string{"req-1"}, header.Get("x-request-id"))
+	require.Equal(t, "req-1", users.Audit.Records()[0].RequestId)
+
+	user, err := client.GetUser(withBasicAuth(ctx, "ivan"), &refstudyv1.GetUserRequest{Id: 2})
+	require.NoError(t, err)
+	require.Equal(t, "ivan", user.GetUsername())
+
+	list, err := client.ListUsers(withBasicAuth(ctx, "admin"), &refstudyv1.ListUsersRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, list.GetUsers(), 2)
+	require.Equal(t, "admin", list.GetUsers()[0].GetUsername())
+	require.NotEmpty(t, list.GetNextPageToken())
+	list, err = client.ListUsers(withBasicAuth(ctx, "admin"), &refstudyv1.ListUsersRequest{PageSize: 2, PageToken: list.GetNextPageToken()})
+	require.NoError(t, err)
+	require.Len(t, list.GetUsers(), 1)
+	require.Equal(t, "new", list.GetUsers()[0].GetUsername())
+	require.Empty(t, list.GetNextPageToken(), "the last page")
+
+	username := "ivan2"
+	_, err = client.UpdateUser(withBasicAuth(ctx, "ivan"), &refstudyv1.UpdateUserRequest{Id: 2, Username: &username})
+	require.NoError(t, err)
+	stored, err := users.Users.GetUserById(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, "ivan2", stored.Username)
+	require.Equal(t, "ivan@example.com", stored.Email)
+}
+
+// TestUserService_PartialUpdate checks fields left out of an update are
+// kept, including the password.
+func TestUserService_PartialUpdate(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t, newUsers())
+
+	username := "ivan2"
+	_, err := client.UpdateUser(withBasicAuth(ctx, "ivan"), &refstudyv1.UpdateUserRequest{Id: 2, Username: &username})
+	require.NoError(t, err)
+
+	user, err := client.GetUser(withBasicAuth(ctx, "ivan2"), &refstudyv1.GetUserRequest{Id: 2})
+	require.NoError(t, err, "the password is kept")
+	require.Equal(t, "ivan2", user.GetUsername())
+	require.Equal(t, "ivan@example.com", user.GetEmail())
+
+	empty, malformed := "", "not an email"
+	_, err = client.UpdateUser(withBasicAuth(ctx, "ivan2"), &refstudyv1.UpdateUserRequest{Id: 2, Password: &empty})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.UpdateUser(withBasicAuth(ctx, "ivan2"), &refstudyv1.UpdateUserRequest{Id: 2, Email: &malformed})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.CreateUser(ctx, &refstudyv1.CreateUserRequest{Username: "petr", Email: malformed, Password: "12345678"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUserService_Taken(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t, newUsers())
+
+	_, err := client.CreateUser(ctx, &refstudyv1.CreateUserRequest{Username: "ivan", Email: "other@example.com", Password: "12345678"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestUserService_Errors(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t, newUsers())
+
+	testcases := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"invalid argument", func() error {
+			_, err := client.GetUser(ctx, &refstudyv1.GetUserRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"malformed page token", func() error {
+			_, err := client.ListUsers(withBasicAuth(ctx, "admin"), &refstudyv1.ListUsersRequest{PageToken: "?"})
+			return err
+		}, codes.InvalidArgument},
+		{"not found", func() error {
+			_, err := client.GetUser(withBasicAuth(ctx, "admin"), &refstudyv1.GetUserRequest{Id: 99})
+			return err
+		}, codes.NotFound},
+		{"unauthenticated", func() error {
+			_, err := client.ListUsers(ctx, &refstudyv1.ListUsersRequest{})
+			return err
+		}, codes.Unauthenticated},
+		{"wrong credentials", func() error {
+			_, err := client.GetUser(withBasicAuth(ctx, "nobody"), &refstudyv1.GetUserRequest{Id: 1})
+			return err
+		}, codes.Unauthenticated},
+		{"malformed credentials", func() error {
+			_, err := client.GetUser(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer x"), &refstudyv1.GetUserRequest{Id: 1})
+			return err
+		}, codes.Unauthenticated},
+		{"permission denied", func() error {
+			_, err := client.DeleteUser(withBasicAuth(ctx, "ivan"), &refstudyv1.DeleteUserRequest{Id: 1})
+			return err
+		}, codes.PermissionDenied},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			require.Equal(t, testcase.code, status.Code(testcase.call()))
+		})
+	}
+}
+
+func TestAuthInterceptor_Locked(t *testing.T) {
+	ctx := context.Background()
+	users := newUsers()
+	guard, err := ratelimit.NewGuard(users, ratelimit.NewMemoryStore(), ratelimit.Lockout{Threshold: 1, Base: time.Minute, Max: time.Hour, Window: time.Hour})
+	require.NoError(t, err)
+	client := serve(t, rpc.Usecases{User: users, Authenticator: guard}, rpc.Options{})
+
+	_, err = guard.Authenticate(ctx, "ivan", "wrong")
+	require.ErrorIs(t, err, model.ErrInvalidCredentials)
+	_, err = client.GetUser(withBasicAuth(ctx, "ivan"), &refstudyv1.GetUserRequest{Id: 2})
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	retry, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	require.InDelta(t, time.Minute, retry.GetRetryDelay().AsDuration(), float64(time.Second))
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	ctx := context.Background()
+	users := newUsers()
+	limits := ratelimit.Limits{
+		Default: ratelimit.Limit{Requests: 100, Per: time.Minute},
+		Routes:  map[string]ratelimit.Limit{"POST /api/v1/users": {Requests: 1, Per: time.Hour}},
+	}
+	client := serve(t, rpc.Usecases{User: users, Authenticator: users}, rpc.Options{RateLimiter: ratelimit.NewMemoryStore(), RateLimits: limits})
+
+	_, err := client.CreateUser(ctx, &refstudyv1.CreateUserRequest{Username: "petr", Email: "petr@example.com", Password: "12345678"})
+	require.NoError(t, err)
+	_, err = client.CreateUser(ctx, &refstudyv1.CreateUserRequest{Username: "anna", Email: "anna@example.com", Password: "12345678"})
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	retry, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	require.Greater(t, retry.GetRetryDelay().AsDuration(), time.Duration(0))
+
+	_, err = client.GetUser(withBasicAuth(ctx, "ivan"), &refstudyv1.GetUserRequest{Id: 2})
+	require.NoError(t, err, "other methods have their own bucket")
+}
@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	refstudyv1 "ivanjabrony/refstudy/api/refstudy/v1"
+	v1 "ivanjabrony/refstudy/internal/controller/v1"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type UserService struct {
+	refstudyv1.UnimplementedUserServiceServer
+	userService v1.UserUsecase
+	validator   *validator.Validate
+}
+
+func NewUserService(userService v1.UserUsecase, validator *validator.Validate) *UserService {
+	return &UserService{userService: userService, validator: validator}
+}
+
+func (s *UserService) CreateUser(ctx context.Context, req *refstudyv1.CreateUserRequest) (*refstudyv1.User, error) {
+	if req.GetUsername() == "" || req.GetEmail() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Failed to parse data for creating")
+	}
+
+	createDto := dto.CreateUserDto{
+		Username: req.GetUsername(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}
+	if err := s.validator.StructCtx(ctx, createDto); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid data for creating")
+	}
+
+	user, err := s.userService.CreateUser(ctx, &createDto)
+	if err != nil {
+		return nil, Error(err, "Failed to create user")
+	}
+
+	return toUser(user), nil
+}
+
+func (s *UserService) GetUser(ctx context.Context, req *refstudyv1.GetUserRequest) (*refstudyv1.User, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "Failed to parse ID")
+	}
+
+	user, err := s.userService.GetUserById(ctx, req.GetId())
+	if err != nil {
+		return nil, Error(err, "Failed to retrieve user info")
+	}
+
+	return toUser(user), nil
+}
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// ListUsers pages through users by id, reading one page from the repository
+// at a time.
+func (s *UserService) ListUsers(ctx context.Context, req *refstudyv1.ListUsersRequest) (*refstudyv1.ListUsersResponse, error) {
+	pageSize := int(req.GetPageSize())
+	if pageSize < 1 || pageSize > maxPageSize {
+		pageSize = defaultPageSize
+	}
+	afterId, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Failed to parse page token")
+	}
+
+	keyset := model.Keyset{Sort: model.UserSortId, AfterId: afterId, Limit: pageSize}
+	users, more, err := s.userService.GetUsersPage(ctx, keyset)
+	if err != nil {
+		return nil, Error(err, "Failed to retrieve user info")
+	}
+
+	resp := &refstudyv1.ListUsersResponse{}
+	for _, user := range users {
+		resp.Users = append(resp.Users, toUser(&user))
+	}
+	if more && len(users) > 0 {
+		resp.NextPageToken = encodePageToken(users[len(users)-1].Id)
+	}
+
+	return resp, nil
+}
+
+// encodePageToken hides the id of the last user on a page from callers, who
+// are meant to pass it back as is.
+func encodePageToken(lastId int32) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(int(lastId))))
+}
+
+func decodePageToken(token string) (int32, error) {
+	if token == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(string(data), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, errors.New("page token out of range")
+	}
+	return int32(id), nil
+}
+
+func (s *UserService) UpdateUser(ctx context.Context, req *refstudyv1.UpdateUserRequest) (*refstudyv1.UpdateUserResponse, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "Failed to parse data for updating")
+	}
+
+	updateDto := dto.UpdateUserDto{
+		Id:       req.GetId(),
+		Username: req.Username,
+		Email:    req.Email,
+		Password: req.Password,
+	}
+	if err := s.validator.StructCtx(ctx, updateDto); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid data for updating")
+	}
+
+	if err := s.userService.UpdateUser(ctx, &updateDto); err != nil {
+		return nil, Error(err, "Failed to update user info")
+	}
+
+	return &refstudyv1.UpdateUserResponse{}, nil
+}
+
+func (s *UserService) DeleteUser(ctx context.Context, req *refstudyv1.DeleteUserRequest) (*refstudyv1.DeleteUserResponse, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "Failed to parse ID")
+	}
+
+	if err := s.userService.DeleteUserById(ctx, req.GetId()); err != nil {
+		return nil, Error(err, "Failed to delete user data")
+	}
+
+	return &refstudyv1.DeleteUserResponse{}, nil
+}
+
+func (s *UserService) RestoreUser(ctx context.Context, req *refstudyv1.RestoreUserRequest) (*refstudyv1.RestoreUserResponse, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "Failed to parse ID")
+	}
+
+	if err := s.userService.RestoreUser(ctx, req.GetId()); err != nil {
+		return nil, Error(err, "Failed to restore user")
+	}
+
+	return &refstudyv1.RestoreUserResponse{}, nil
+}
+
+func toUser(user *dto.UserDto) *refstudyv1.User {
+	return &refstudyv1.User{
+		Id:            user.Id,
+		Username:      user.Username,
+		Email:         user.Email,
+		Role:          user.Role,
+		Disabled:      user.Disabled,
+		EmailVerified: user.EmailVerified,
+	}
+}
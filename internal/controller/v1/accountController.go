@@ -0,0 +1,136 @@
+package v1
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type AccountController struct {
+	accountService AccountUsecase
+	validator      *validator.Validate
+}
+
+type AccountUsecase interface {
+	RequestEmailVerification(ctx context.Context) error
+
+	VerifyEmail(ctx context.Context, token string) error
+
+	ForgotPassword(ctx context.Context, email string) error
+
+	ResetPassword(ctx context.Context, token, password string) error
+}
+
+func NewAccountController(accountService AccountUsecase, validator *validator.Validate) *AccountController {
+	return &AccountController{
+		accountService: accountService,
+		validator:      validator}
+}
+
+// RequestEmailVerification godoc
+// @Summary      Resend verification email
+// @Description  Sends a new email verification link to the authenticated user
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Success      202
+// @Failure      401 {object} response.Problem
+// @Failure      429 {object} response.Problem
+// @Router       /auth/verify-email/resend [post]
+func (ac *AccountController) RequestEmailVerification(c *gin.Context) {
+	if err := ac.accountService.RequestEmailVerification(c.Request.Context()); err != nil {
+		response.Error(c, err, "Failed to send verification email")
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// VerifyEmail godoc
+// @Summary      Verify email
+// @Description  Marks the email address as verified using the token from the verification email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.VerifyEmailDto true "Verification token"
+// @Success      204
+// @Failure      400 {object} response.Problem
+// @Router       /auth/verify-email [post]
+func (ac *AccountController) VerifyEmail(c *gin.Context) {
+	var verifyDto dto.VerifyEmailDto
+	if err := c.ShouldBindJSON(&verifyDto); err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to parse token")
+		return
+	}
+
+	if err := ac.accountService.VerifyEmail(c.Request.Context(), verifyDto.Token); err != nil {
+		response.Error(c, err, "Failed to verify email")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ForgotPassword godoc
+// @Summary      Request password reset
+// @Description  Emails a password reset link if an account with the address exists
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.ForgotPasswordDto true "Account email"
+// @Success      202
+// @Failure      400 {object} response.Problem
+// @Failure      429 {object} response.Problem
+// @Router       /auth/forgot-password [post]
+func (ac *AccountController) ForgotPassword(c *gin.Context) {
+	var forgotDto dto.ForgotPasswordDto
+	if err := c.ShouldBindJSON(&forgotDto); err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to parse email")
+		return
+	}
+	if err := ac.validator.StructCtx(c.Request.Context(), forgotDto); err != nil {
+		response.Fail(c, http.StatusBadRequest, "Invalid email")
+		return
+	}
+
+	if err := ac.accountService.ForgotPassword(c.Request.Context(), forgotDto.Email); err != nil {
+		response.Error(c, err, "Failed to request password reset")
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Sets a new password using the token from the password reset email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.ResetPasswordDto true "Reset token and new password"
+// @Success      204
+// @Failure      400 {object} response.Problem
+// @Router       /auth/reset-password [post]
+func (ac *AccountController) ResetPassword(c *gin.Context) {
+	var resetDto dto.ResetPasswordDto
+	if err := c.ShouldBindJSON(&resetDto); err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to parse request")
+		return
+	}
+	if err := ac.validator.StructCtx(c.Request.Context(), resetDto); err != nil {
+		response.Fail(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := ac.accountService.ResetPassword(c.Request.Context(), resetDto.Token, resetDto.Password); err != nil {
+		response.Error(c, err, "Failed to reset password")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type AdminController struct {
+	adminService AdminUsecase
+	validator    *validator.Validate
+}
+
+type AdminUsecase interface {
+	GetAllUsers(ctx context.Context) ([]dto.UserDto, error)
+
+	DisableUser(ctx context.Context, id int32) error
+
+	EnableUser(ctx context.Context, id int32) error
+
+	SetUserRole(ctx context.Context, id int32, role model.Role) error
+}
+
+func NewAdminController(adminService AdminUsecase, validator *validator.Validate) *AdminController {
+	return &AdminController{
+		adminService: adminService,
+		validator:    validator}
+}
+
+// ListUsers godoc
+// @Summary      List all users
+// @Description  returning every user account, admin only
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Success      200 {object} response.Envelope{data=[]dto.UserDto}
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Router       /admin/users [get]
+func (ac *AdminController) ListUsers(c *gin.Context) {
+	users, err := ac.adminService.GetAllUsers(c.Request.Context())
+	if err != nil {
+		response.Error(c, err, "Failed to retrieve users")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, users)
+}
+
+// DisableUser godoc
+// @Summary      Disable user
+// @Description  Disables the account so it can no longer authenticate, admin only
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path int true "User ID"
+// @Success      204 "Update success"
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Failure      404 {object} response.Problem
+// @Router       /admin/users/{id}/disable [post]
+func (ac *AdminController) DisableUser(c *gin.Context) {
+	ac.setDisabled(c, ac.adminService.DisableUser)
+}
+
+// EnableUser godoc
+// @Summary      Enable user
+// @Description  Re-enables a disabled account, admin only
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path int true "User ID"
+// @Success      204 "Update success"
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Failure      404 {object} response.Problem
+// @Router       /admin/users/{id}/enable [post]
+func (ac *AdminController) EnableUser(c *gin.Context) {
+	ac.setDisabled(c, ac.adminService.EnableUser)
+}
+
+// SetUserRole godoc
+// @Summary      Set user role
+// @Description  Changes the role of a user, admin only
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path int true "User ID"
+// @Param        request body dto.SetRoleDto true "New role"
+// @Success      204 "Update success"
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Failure      404 {object} response.Problem
+// @Router       /admin/users/{id}/role [put]
+func (ac *AdminController) SetUserRole(c *gin.Context) {
+	id, ok := parseId(c)
+	if !ok {
+		return
+	}
+
+	var roleDto dto.SetRoleDto
+	if err := c.ShouldBindJSON(&roleDto); err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to parse role")
+		return
+	}
+	if err := ac.validator.StructCtx(c.Request.Context(), roleDto); err != nil {
+		response.Fail(c, http.StatusBadRequest, "Unknown role")
+		return
+	}
+
+	err := ac.adminService.SetUserRole(c.Request.Context(), id, model.Role(roleDto.Role))
+	if err != nil {
+		response.Error(c, err, "Failed to set user role")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (ac *AdminController) setDisabled(c *gin.Context, action func(context.Context, int32) error) {
+	id, ok := parseId(c)
+	if !ok {
+		return
+	}
+
+	if err := action(c.Request.Context(), id); err != nil {
+		response.Error(c, err, "Failed to update user status")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
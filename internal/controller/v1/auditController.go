@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct {
+	auditService AuditUsecase
+}
+
+type AuditUsecase interface {
+	GetAuditRecords(ctx context.Context, filter model.AuditFilter) ([]dto.AuditRecordDto, int, error)
+}
+
+func NewAuditController(auditService AuditUsecase) *AuditController {
+	return &AuditController{auditService: auditService}
+}
+
+// GetAuditRecords godoc
+// @Summary      Query audit log
+// @Description  returning audit records, newest first, admin only
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param actor_id query int false "ID of the acting user"
+// @Param action query string false "Action" Enums(create, update, delete, restore, purge)
+// @Param resource_type query string false "Resource type, e.g. user"
+// @Param resource_id query string false "Resource ID"
+// @Param from query string false "Only records at or after this RFC 3339 time"
+// @Param to query string false "Only records before this RFC 3339 time"
+// @Param page query int false "Page number (starting from 1)" default(1)
+// @Param page_size query int false "Amount of items on the page" default(10) minimum(1) maximum(100)
+// @Success      200 {object} response.Envelope{data=[]dto.AuditRecordDto}
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Router       /admin/audit [get]
+func (ac *AuditController) GetAuditRecords(c *gin.Context) {
+	page, pageSize := parsePage(c)
+
+	filter := model.AuditFilter{
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resource_type"),
+		ResourceId:   c.Query("resource_id"),
+		Limit:        pageSize,
+		Offset:       (page - 1) * pageSize,
+	}
+
+	if actorId := c.Query("actor_id"); actorId != "" {
+		parsed, err := strconv.ParseInt(actorId, 10, 32)
+		if err != nil {
+			response.Fail(c, http.StatusBadRequest, "Failed to parse actor ID")
+			return
+		}
+		id := int32(parsed)
+		filter.ActorId = &id
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				response.Fail(c, http.StatusBadRequest, "Failed to parse "+param+" time")
+				return
+			}
+			*target = &parsed
+		}
+	}
+
+	records, total, err := ac.auditService.GetAuditRecords(c.Request.Context(), filter)
+	if err != nil {
+		response.Error(c, err, "Failed to retrieve audit records")
+		return
+	}
+
+	response.Page(c, records, response.NewMeta(page, pageSize, total))
+}
@@ -0,0 +1,66 @@
+package v1
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"ivanjabrony/refstudy/internal/model"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the boundary of a page as handed to clients: base64 encoded JSON
+// they aren't meant to look into. It carries its sort, so following it keeps
+// the order the first page was requested in.
+type cursor struct {
+	Sort     string `json:"s"`
+	After    string `json:"k,omitempty"`
+	AfterId  int32  `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+func encodeCursor(keyset model.Keyset) string {
+	data, _ := json.Marshal(cursor{
+		Sort:     keyset.Sort,
+		After:    keyset.After,
+		AfterId:  keyset.AfterId,
+		Backward: keyset.Backward,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (model.Keyset, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return model.Keyset{}, errInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.AfterId <= 0 {
+		return model.Keyset{}, errInvalidCursor
+	}
+
+	return model.Keyset{Sort: c.Sort, After: c.After, AfterId: c.AfterId, Backward: c.Backward}, nil
+}
+
+// pageCursors returns the cursors of the pages next to items, the page
+// keyset picked; more tells whether there are items beyond it in keyset's
+// direction. key returns the sort key of an item and id its id.
+func pageCursors[T any](keyset model.Keyset, items []T, more bool, key func(T) string, id func(T) int32) (next, prev string) {
+	if len(items) == 0 {
+		return "", ""
+	}
+
+	// Paging backward from a cursor, there is at least the row it points
+	// at after the page, and the other way around.
+	hasNext := more && !keyset.Backward || keyset.Backward && keyset.AfterId != 0
+	hasPrev := more && keyset.Backward || !keyset.Backward && keyset.AfterId != 0
+	if hasNext {
+		last := items[len(items)-1]
+		next = encodeCursor(model.Keyset{Sort: keyset.Sort, After: key(last), AfterId: id(last)})
+	}
+	if hasPrev {
+		first := items[0]
+		prev = encodeCursor(model.Keyset{Sort: keyset.Sort, After: key(first), AfterId: id(first), Backward: true})
+	}
+	return next, prev
+}
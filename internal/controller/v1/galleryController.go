@@ -0,0 +1,233 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"ivanjabrony/refstudy/internal/archive"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize bounds the request body of an import, archive and form
+// fields together.
+const maxImportSize = 512 << 20
+
+type GalleryController struct {
+	galleryService GalleryUsecase
+}
+
+type GalleryUsecase interface {
+	ImportGalleries(ctx context.Context, file io.Reader, size int64) (*dto.GalleryImportDto, error)
+	GetGalleryImport(ctx context.Context, id int64) (*dto.GalleryImportDto, error)
+	ExportGallery(ctx context.Context, id int32, w io.Writer) error
+	FindDuplicates(ctx context.Context, id int32, threshold int) ([]dto.DuplicateClusterDto, error)
+	Search(ctx context.Context, query string, limit, offset int) ([]dto.SearchHitDto, int, error)
+}
+
+func NewGalleryController(galleryService GalleryUsecase) *GalleryController {
+	return &GalleryController{galleryService: galleryService}
+}
+
+// ImportGalleries godoc
+// @Summary      Import galleries
+// @Description  Starts importing a ZIP archive: every top-level folder becomes a gallery of the caller and nested folders become tags of its pictures. Poll the returned import for progress.
+// @Tags         gallery
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BasicAuth
+// @Param        archive formData file true "ZIP archive"
+// @Success      202 {object} response.Envelope{data=dto.GalleryImportDto}
+// @Header       202 {string} Location "URL of the import"
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      413 {object} response.Problem
+// @Failure      429 {object} response.Problem
+// @Router       /galleries/import [post]
+func (gc *GalleryController) ImportGalleries(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	header, err := c.FormFile("archive")
+	if maxBytesErr := new(http.MaxBytesError); errors.As(err, &maxBytesErr) {
+		response.Fail(c, http.StatusRequestEntityTooLarge, "Archive is too large")
+		return
+	}
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to read archive")
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to read archive")
+		return
+	}
+	defer file.Close()
+
+	galleryImport, err := gc.galleryService.ImportGalleries(c.Request.Context(), file, header.Size)
+	if err != nil {
+		response.Error(c, err, "Failed to import galleries")
+		return
+	}
+
+	c.Header("Location", path.Join(path.Dir(c.FullPath()), "imports", strconv.FormatInt(galleryImport.Id, 10)))
+	response.JSON(c, http.StatusAccepted, galleryImport)
+}
+
+// GetGalleryImport godoc
+// @Summary      Get gallery import
+// @Description  returning progress of an import and, once it finished, the created galleries and the files that failed
+// @Tags         gallery
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path int true "ID of import"
+// @Success      200 {object} response.Envelope{data=dto.GalleryImportDto}
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Failure      404 {object} response.Problem
+// @Router       /galleries/imports/{id} [get]
+func (gc *GalleryController) GetGalleryImport(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to parse ID")
+		return
+	}
+
+	galleryImport, err := gc.galleryService.GetGalleryImport(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err, "Failed to retrieve gallery import")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, galleryImport)
+}
+
+// ExportGallery godoc
+// @Summary      Export gallery
+// @Description  streaming the gallery as a ZIP archive with manifest.json and the pictures, which can be imported again
+// @Tags         gallery
+// @Produce      application/zip
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path int true "ID of gallery"
+// @Success      200 {file} file "ZIP archive"
+// @Header       200 {string} Content-Disposition "attachment; filename=gallery-<id>.zip"
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Failure      404 {object} response.Problem
+// @Router       /galleries/{id}/export [get]
+func (gc *GalleryController) ExportGallery(c *gin.Context) {
+	id, ok := parseId(c)
+	if !ok {
+		return
+	}
+
+	w := &exportWriter{c: c, filename: fmt.Sprintf("gallery-%d.zip", id)}
+	err := gc.galleryService.ExportGallery(c.Request.Context(), id, w)
+	if err != nil && !w.started {
+		response.Error(c, err, "Failed to export gallery")
+		return
+	}
+	if err != nil {
+		// The status is sent already; the archive stays without its
+		// central directory, which unzipping it will report.
+		_ = c.Error(err)
+		c.Abort()
+	}
+}
+
+// FindDuplicates godoc
+// @Summary      Find duplicate pictures
+// @Description  returning clusters of pictures of the gallery that look alike, each picture within threshold differing bits of the perceptual hash of another one in its cluster
+// @Tags         gallery
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path int true "ID of gallery"
+// @Param        threshold query int false "Largest Hamming distance between perceptual hashes of alike pictures" default(4) minimum(0) maximum(64)
+// @Success      200 {object} response.Envelope{data=[]dto.DuplicateClusterDto}
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Failure      404 {object} response.Problem
+// @Router       /galleries/{id}/duplicates [get]
+func (gc *GalleryController) FindDuplicates(c *gin.Context) {
+	id, ok := parseId(c)
+	if !ok {
+		return
+	}
+	threshold := archive.DuplicateThreshold
+	if value := c.Query("threshold"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > 64 {
+			response.Fail(c, http.StatusBadRequest, "Failed to parse threshold")
+			return
+		}
+		threshold = parsed
+	}
+
+	clusters, err := gc.galleryService.FindDuplicates(c.Request.Context(), id, threshold)
+	if err != nil {
+		response.Error(c, err, "Failed to find duplicates")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, clusters)
+}
+
+// Search godoc
+// @Summary      Search galleries and pictures
+// @Description  returning galleries whose name or description and pictures whose name or tags contain a word starting with every word of q, best matches first. Anonymous callers search public galleries only.
+// @Tags         gallery
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param q query string true "Words to search for, each matching the start of a word" example(hand stud)
+// @Param page query int false "Page number (starting from 1)" default(1)
+// @Param page_size query int false "Amount of items on the page" default(10) minimum(1) maximum(100)
+// @Success      200 {object} response.Envelope{data=[]dto.SearchHitDto}
+// @Failure      400 {object} response.Problem
+// @Router       /search [get]
+func (gc *GalleryController) Search(c *gin.Context) {
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		response.Fail(c, http.StatusBadRequest, "Missing search query")
+		return
+	}
+	page, pageSize := parsePage(c)
+
+	hits, total, err := gc.galleryService.Search(c.Request.Context(), query, pageSize, (page-1)*pageSize)
+	if err != nil {
+		response.Error(c, err, "Failed to search")
+		return
+	}
+
+	response.Page(c, hits, response.NewMeta(page, pageSize, total))
+}
+
+// exportWriter sends the headers of an archive with its first bytes, so an
+// export failing before that still answers with a problem.
+type exportWriter struct {
+	c        *gin.Context
+	filename string
+	started  bool
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", "application/zip")
+		w.c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": w.filename}))
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobController struct {
+	jobService JobUsecase
+}
+
+type JobUsecase interface {
+	GetJobById(ctx context.Context, id int64) (*dto.JobDto, error)
+}
+
+func NewJobController(jobService JobUsecase) *JobController {
+	return &JobController{jobService: jobService}
+}
+
+// GetJob godoc
+// @Summary      Get job status
+// @Description  returning state and progress of an asynchronous job
+// @Tags         job
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path int true "ID of job"
+// @Success      200 {object} response.Envelope{data=dto.JobDto}
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Failure      404 {object} response.Problem
+// @Router       /jobs/{id} [get]
+func (jc *JobController) GetJob(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to parse ID")
+		return
+	}
+
+	job, err := jc.jobService.GetJobById(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err, "Failed to retrieve job")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, job)
+}
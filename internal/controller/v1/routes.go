@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Usecases struct {
+	User    UserUsecase
+	Admin   AdminUsecase
+	Job     JobUsecase
+	Audit   AuditUsecase
+	Account AccountUsecase
+	Gallery GalleryUsecase
+}
+
+// Register mounts the v1 API on api, which is expected at /api/v1.
+func Register(api *gin.RouterGroup, usecases Usecases, validator *validator.Validate) {
+	userCotroller := NewUserController(usecases.User, validator)
+	adminController := NewAdminController(usecases.Admin, validator)
+	jobController := NewJobController(usecases.Job)
+	auditController := NewAuditController(usecases.Audit)
+	accountController := NewAccountController(usecases.Account, validator)
+	galleryController := NewGalleryController(usecases.Gallery)
+
+	users := api.Group("/users")
+
+	users.POST("", userCotroller.CreateUser)
+	users.PUT("", userCotroller.UpdateUser)
+	users.GET("/:id", userCotroller.GetUser)
+	users.DELETE("/:id", userCotroller.DeleteUserById)
+	users.POST("/:id/restore", userCotroller.RestoreUser)
+	users.GET("", userCotroller.GetAllUsers)
+
+	admin := api.Group("/admin")
+
+	admin.GET("/users", adminController.ListUsers)
+	admin.POST("/users/:id/disable", adminController.DisableUser)
+	admin.POST("/users/:id/enable", adminController.EnableUser)
+	admin.PUT("/users/:id/role", adminController.SetUserRole)
+	admin.GET("/audit", auditController.GetAuditRecords)
+
+	jobs := api.Group("/jobs")
+
+	jobs.GET("/:id", jobController.GetJob)
+
+	galleries := api.Group("/galleries")
+
+	galleries.POST("/import", galleryController.ImportGalleries)
+	galleries.GET("/imports/:id", galleryController.GetGalleryImport)
+	galleries.GET("/:id/export", galleryController.ExportGallery)
+	galleries.GET("/:id/duplicates", galleryController.FindDuplicates)
+
+	api.GET("/search", galleryController.Search)
+
+	auth := api.Group("/auth")
+
+	auth.POST("/verify-email", accountController.VerifyEmail)
+	auth.POST("/verify-email/resend", accountController.RequestEmailVerification)
+	auth.POST("/forgot-password", accountController.ForgotPassword)
+	auth.POST("/reset-password", accountController.ResetPassword)
+}
@@ -0,0 +1,420 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"ivanjabrony/refstudy/internal/controller/response"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type UserCotroller struct {
+	userService UserUsecase
+	validator   *validator.Validate
+}
+
+type UserUsecase interface {
+	CreateUser(ctx context.Context, dto *dto.CreateUserDto) (*dto.UserDto, error)
+
+	GetUserById(ctx context.Context, id int32) (*dto.UserDto, error)
+
+	GetAllUsers(ctx context.Context) ([]dto.UserDto, error)
+
+	GetUsersPage(ctx context.Context, keyset model.Keyset) ([]dto.UserDto, bool, error)
+
+	StreamUsers(ctx context.Context, keyset model.Keyset, fn func(*dto.UserDto) error) error
+
+	UpdateUser(context.Context, *dto.UpdateUserDto) error
+
+	DeleteUserById(context.Context, int32) error
+
+	RestoreUser(context.Context, int32) error
+}
+
+func NewUserController(userService UserUsecase, validator *validator.Validate) *UserCotroller {
+	return &UserCotroller{
+		userService: userService,
+		validator:   validator}
+}
+
+// GetUser godoc
+// @Summary      Get user by ID
+// @Description  Returns a user to themselves, moderators and admins
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path int true "ID of user"
+// @Success      200 {object} response.Envelope{data=dto.UserDto}
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Failure      404 {object} response.Problem
+// @Router       /users/{id} [get]
+func (pc *UserCotroller) GetUser(c *gin.Context) {
+	id, ok := parseId(c)
+	if !ok {
+		return
+	}
+
+	user, err := pc.userService.GetUserById(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err, "Failed to retrieve user info")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, user)
+}
+
+// GetUser godoc
+// @Summary      Get all users with pagination
+// @Description  returning users with pagination. Pages are picked by number, or by cursor when cursor is given,
+// @Description  empty for the first page; cursor pages link to their neighbours with next_cursor and prev_cursor
+// @Description  in meta and don't skip or repeat users when the list changes in between. With Accept
+// @Description  application/x-ndjson every user from the cursor on is streamed as one JSON object per line; a
+// @Description  failure after the first line ends the stream with a problem document line. Users are narrowed
+// @Description  to the fields given in fields; unknown fields are rejected.
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Produce      application/x-ndjson
+// @Security     BasicAuth
+// @Param page query int false "Page number (starting from 1)" default(1)
+// @Param page_size query int false "Amount of items on the page" default(10) minimum(1) maximum(100)
+// @Param cursor query string false "Cursor of the page from next_cursor or prev_cursor, empty for the first page"
+// @Param sort query string false "Order of users for cursor pages and streams" Enums(id, username) default(id)
+// @Param fields query string false "Comma separated fields of each user to return, all when empty" example(id,username)
+// @Param include query string false "Comma separated relations to embed in each user; users have none yet"
+// @Success      200 {object} response.Envelope{data=[]dto.UserDto}
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Router       /users [get]
+func (pc *UserCotroller) GetAllUsers(c *gin.Context) {
+	fields, include := parseFields(c.Query("fields")), parseFields(c.Query("include"))
+	cursor, byCursor := c.GetQuery("cursor")
+	stream := strings.Contains(c.GetHeader("Accept"), ndjsonContentType)
+	if byCursor || stream {
+		keyset, ok := parseKeyset(c, cursor)
+		if !ok {
+			return
+		}
+		keyset.Fields, keyset.Include = fields, include
+		if stream {
+			pc.streamUsers(c, keyset)
+		} else {
+			pc.getUsersPage(c, keyset)
+		}
+		return
+	}
+
+	page, pageSize := parsePage(c)
+
+	users, err := pc.getAllUsers(c.Request.Context(), fields, include)
+	if err != nil {
+		response.Error(c, err, "Failed to retrieve user info")
+		return
+	}
+
+	offset := min(len(users), (page-1)*pageSize)
+	pc.sparsePage(c, users[offset:min(len(users), offset+pageSize)], fields, response.NewMeta(page, pageSize, len(users)))
+}
+
+// getAllUsers reads every user, only the given fields of them when there
+// are any.
+func (pc *UserCotroller) getAllUsers(ctx context.Context, fields, include model.Fields) ([]dto.UserDto, error) {
+	if fields == nil && include == nil {
+		return pc.userService.GetAllUsers(ctx)
+	}
+
+	var users []dto.UserDto
+	keyset := model.Keyset{Sort: model.UserSortId, Fields: fields, Include: include}
+	err := pc.userService.StreamUsers(ctx, keyset, func(user *dto.UserDto) error {
+		users = append(users, *user)
+		return nil
+	})
+	return users, err
+}
+
+func (pc *UserCotroller) sparsePage(c *gin.Context, users []dto.UserDto, fields model.Fields, meta *response.Meta) {
+	sparse, err := response.SparseAll(users, fields)
+	if err != nil {
+		response.Error(c, err, "Failed to retrieve user info")
+		return
+	}
+	response.Page(c, sparse, meta)
+}
+
+func (pc *UserCotroller) getUsersPage(c *gin.Context, keyset model.Keyset) {
+	_, keyset.Limit = parsePage(c)
+
+	users, more, err := pc.userService.GetUsersPage(c.Request.Context(), keyset)
+	if err != nil {
+		response.Error(c, err, "Failed to retrieve user info")
+		return
+	}
+
+	next, prev := pageCursors(keyset, users, more, userSortKey(keyset.Sort), func(user dto.UserDto) int32 { return user.Id })
+	pc.sparsePage(c, users, keyset.Fields, response.NewCursorMeta(keyset.Limit, next, prev))
+}
+
+const (
+	ndjsonContentType = "application/x-ndjson"
+	// streamFlushEvery is how many users are buffered before they are
+	// flushed to the client.
+	streamFlushEvery = 100
+)
+
+// streamUsers writes users as they are read. Until the first one is written a
+// failure is still answered with a problem document; after that it can only
+// be reported as the last line.
+func (pc *UserCotroller) streamUsers(c *gin.Context, keyset model.Keyset) {
+	encoder := json.NewEncoder(c.Writer)
+	written := 0
+	start := func() {
+		c.Header("Content-Type", ndjsonContentType)
+		c.Status(http.StatusOK)
+		c.Writer.WriteHeaderNow()
+	}
+
+	err := pc.userService.StreamUsers(c.Request.Context(), keyset, func(user *dto.UserDto) error {
+		sparse, err := response.Sparse(user, keyset.Fields)
+		if err != nil {
+			return err
+		}
+		if written == 0 {
+			start()
+		}
+		if err := encoder.Encode(sparse); err != nil {
+			return err
+		}
+		written++
+		if written%streamFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	switch {
+	case err != nil && written == 0:
+		response.Error(c, err, "Failed to retrieve user info")
+		return
+	case err != nil:
+		_ = encoder.Encode(response.NewProblem(c, response.Status(err), "Failed to retrieve user info"))
+	case written == 0:
+		start()
+	}
+	c.Writer.Flush()
+}
+
+// parseKeyset reads the cursor and sort parameters, failing the request when
+// they are invalid or disagree.
+func parseKeyset(c *gin.Context, cursor string) (model.Keyset, bool) {
+	sort, sorted := c.GetQuery("sort")
+	if !sorted {
+		sort = model.UserSortId
+	}
+	if !model.ValidUserSort(sort) {
+		response.Fail(c, http.StatusBadRequest, "Unknown sort "+sort)
+		return model.Keyset{}, false
+	}
+	if cursor == "" {
+		return model.Keyset{Sort: sort}, true
+	}
+
+	keyset, err := decodeCursor(cursor)
+	if err != nil || !model.ValidUserSort(keyset.Sort) {
+		response.Fail(c, http.StatusBadRequest, "Failed to parse cursor")
+		return model.Keyset{}, false
+	}
+	if sorted && keyset.Sort != sort {
+		response.Fail(c, http.StatusBadRequest, "Cursor belongs to a list sorted by "+keyset.Sort)
+		return model.Keyset{}, false
+	}
+	return keyset, true
+}
+
+// parseFields splits a comma separated list of fields, returning nil for an
+// empty one.
+func parseFields(list string) model.Fields {
+	var fields model.Fields
+	for _, field := range strings.Split(list, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func userSortKey(sort string) func(dto.UserDto) string {
+	return func(user dto.UserDto) string {
+		if sort == model.UserSortUsername {
+			return user.Username
+		}
+		return ""
+	}
+}
+
+// CreateUser godoc
+// @Summary     Create user
+// @Description Creates new user
+// @Tags        user
+// @Accept      json
+// @Produce     json
+// @Param       request body dto.CreateUserDto true "User data"
+// @Success     201 {object} response.Envelope{data=dto.UserDto}
+// @Header      201 {string} Location "URL of the created user"
+// @Failure     400 {object} response.Problem
+// @Failure     409 {object} response.Problem "Username or email is taken"
+// @Failure     429 {object} response.Problem
+// @Router      /users [post]
+func (pc *UserCotroller) CreateUser(c *gin.Context) {
+	var createDto dto.CreateUserDto
+
+	err := c.ShouldBindJSON(&createDto)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to parse data for creating")
+		return
+	}
+	if err := pc.validator.StructCtx(c.Request.Context(), createDto); err != nil {
+		response.Fail(c, http.StatusBadRequest, "Invalid data for creating")
+		return
+	}
+
+	user, err := pc.userService.CreateUser(c.Request.Context(), &createDto)
+	if err != nil {
+		response.Error(c, err, "Failed to create user")
+		return
+	}
+
+	c.Header("Location", path.Join(c.FullPath(), strconv.Itoa(int(user.Id))))
+	response.JSON(c, http.StatusCreated, user)
+}
+
+// UpdateUser godoc
+// @Summary      Update user
+// @Description  Updates existing user
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        request body dto.UpdateUserDto true "Updated data"
+// @Success      204 "Update success"
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Failure      404 {object} response.Problem
+// @Failure      409 {object} response.Problem "Username or email is taken"
+// @Router       /users [put]
+func (pc *UserCotroller) UpdateUser(c *gin.Context) {
+	var updateDto dto.UpdateUserDto
+
+	err := c.ShouldBindJSON(&updateDto)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to parse data for updating")
+		return
+	}
+	if err := pc.validator.StructCtx(c.Request.Context(), updateDto); err != nil {
+		response.Fail(c, http.StatusBadRequest, "Invalid data for updating")
+		return
+	}
+
+	err = pc.userService.UpdateUser(c.Request.Context(), &updateDto)
+	if err != nil {
+		response.Error(c, err, "Failed to update user info")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteUser godoc
+// @Summary      Delete user
+// @Description  Deletes user by ID
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path int true "User ID"
+// @Success      204 "Delete success"
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Failure      404 {object} response.Problem
+// @Router       /users/{id} [delete]
+func (pc *UserCotroller) DeleteUserById(c *gin.Context) {
+	id, ok := parseId(c)
+	if !ok {
+		return
+	}
+
+	err := pc.userService.DeleteUserById(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err, "Failed to delete user data")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreUser godoc
+// @Summary      Restore user
+// @Description  Restores a deleted user that hasn't been purged yet, admin only
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Security     BasicAuth
+// @Param        id path int true "User ID"
+// @Success      204 "Restore success"
+// @Failure      400 {object} response.Problem
+// @Failure      401 {object} response.Problem
+// @Failure      403 {object} response.Problem
+// @Failure      404 {object} response.Problem
+// @Failure      409 {object} response.Problem "Username or email was taken since the deletion"
+// @Router       /users/{id}/restore [post]
+func (pc *UserCotroller) RestoreUser(c *gin.Context) {
+	id, ok := parseId(c)
+	if !ok {
+		return
+	}
+
+	err := pc.userService.RestoreUser(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err, "Failed to restore user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseId reads the id path parameter, failing the request when it isn't a
+// valid ID.
+func parseId(c *gin.Context) (int32, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, "Failed to parse ID")
+		return 0, false
+	}
+
+	return int32(id), true
+}
+
+func parsePage(c *gin.Context) (page, pageSize int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	return page, pageSize
+}
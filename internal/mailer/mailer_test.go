@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"ivanjabrony/refstudy/internal/logger"
+	"log/slog"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMTPMailer(t *testing.T) {
+	_, err := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com"})
+	require.Error(t, err)
+
+	m, err := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", Port: 587, Username: "bot", Password: "secret", From: "noreply@example.com"})
+	require.NoError(t, err)
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	m.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		require.NotNil(t, a)
+		gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+		return nil
+	}
+
+	err = m.Send(context.Background(), Message{To: "ivan@example.com", Subject: "Hello", Body: "line 1\nline 2"})
+	require.NoError(t, err)
+	require.Equal(t, "smtp.example.com:587", gotAddr)
+	require.Equal(t, "noreply@example.com", gotFrom)
+	require.Equal(t, []string{"ivan@example.com"}, gotTo)
+
+	headers, body, found := strings.Cut(string(gotMsg), "\r\n\r\n")
+	require.True(t, found)
+	require.Contains(t, headers, "Subject: Hello\r\n")
+	require.Contains(t, headers, "To: ivan@example.com\r\n")
+	require.Equal(t, "line 1\r\nline 2", body)
+}
+
+func TestLogMailer(t *testing.T) {
+	var out bytes.Buffer
+	m := NewLogMailer(&logger.MyLogger{Logger: slog.New(slog.NewTextHandler(&out, nil))})
+
+	err := m.Send(context.Background(), Message{To: "ivan@example.com", Subject: "Hello", Body: "?token=secret"})
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "ivan@example.com")
+	require.Contains(t, out.String(), "Hello")
+	require.NotContains(t, out.String(), "secret", "the body carries live tokens")
+}
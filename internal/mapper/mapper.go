@@ -23,30 +23,23 @@ func MapFromUserDto(dto *dto.UserDto) *model.User {
 			Id:       dto.Id,
 			Username: dto.Username,
 			Email:    dto.Email,
-			Password: dto.Password,
+			Role:     model.Role(dto.Role),
+			Disabled: dto.Disabled,
 		}
 	}
 
 	return nil
 }
 
-func MapFromUpdateUserDto(dto *dto.UpdateUserDto) *model.User {
-	var updUsername, updEmail, updPassword string
-	if dto.Username != nil {
-		updUsername = *dto.Username
-	}
-	if dto.Email != nil {
-		updEmail = *dto.Email
-	}
-	if dto.Password != nil {
-		updPassword = *dto.Password
-	}
+// MapFromUpdateUserDto keeps the fields the update leaves out nil, so they
+// aren't changed.
+func MapFromUpdateUserDto(dto *dto.UpdateUserDto) *model.UserUpdate {
 	if dto != nil {
-		return &model.User{
+		return &model.UserUpdate{
 			Id:       dto.Id,
-			Username: updUsername,
-			Email:    updEmail,
-			Password: updPassword,
+			Username: dto.Username,
+			Email:    dto.Email,
+			Password: dto.Password,
 		}
 	}
 
@@ -56,10 +49,12 @@ func MapFromUpdateUserDto(dto *dto.UpdateUserDto) *model.User {
 func MapToUserDto(model *model.User) *dto.UserDto {
 	if model != nil {
 		return &dto.UserDto{
-			Id:       model.Id,
-			Username: model.Username,
-			Email:    model.Email,
-			Password: model.Password,
+			Id:            model.Id,
+			Username:      model.Username,
+			Email:         model.Email,
+			Role:          string(model.Role),
+			Disabled:      model.Disabled,
+			EmailVerified: model.EmailVerified,
 		}
 	}
 
@@ -83,3 +78,164 @@ func MapFromManyUserDto(dtos ...dto.UserDto) []model.User {
 
 	return models
 }
+
+func MapToJobDto(model *model.Job) *dto.JobDto {
+	if model != nil {
+		return &dto.JobDto{
+			Id:          model.Id,
+			Type:        model.Type,
+			Status:      string(model.Status),
+			Attempts:    model.Attempts,
+			MaxAttempts: model.MaxAttempts,
+			Progress:    model.Progress,
+			LastError:   model.LastError,
+			RunAt:       model.RunAt,
+			CreatedAt:   model.CreatedAt,
+			UpdatedAt:   model.UpdatedAt,
+		}
+	}
+
+	return nil
+}
+
+func MapToAuditRecordDto(model *model.AuditRecord) *dto.AuditRecordDto {
+	if model != nil {
+		changes := make(map[string]dto.FieldChangeDto, len(model.Changes))
+		for name, change := range model.Changes {
+			changes[name] = dto.FieldChangeDto{Before: change.Before, After: change.After}
+		}
+
+		return &dto.AuditRecordDto{
+			Id:           model.Id,
+			ActorId:      model.ActorId,
+			ActorRole:    model.ActorRole,
+			Action:       string(model.Action),
+			ResourceType: model.ResourceType,
+			ResourceId:   model.ResourceId,
+			Changes:      changes,
+			RequestId:    model.RequestId,
+			CreatedAt:    model.CreatedAt,
+		}
+	}
+
+	return nil
+}
+
+func MapToManyAuditRecordDto(models ...model.AuditRecord) []dto.AuditRecordDto {
+	dtos := make([]dto.AuditRecordDto, len(models))
+	for i, v := range models {
+		dtos[i] = *MapToAuditRecordDto(&v)
+	}
+
+	return dtos
+}
+
+// MapToGalleryImportDto describes galleryImport along with the state of job,
+// the job running it.
+func MapToGalleryImportDto(galleryImport *model.GalleryImport, job *model.Job) *dto.GalleryImportDto {
+	if galleryImport == nil || job == nil {
+		return nil
+	}
+
+	failures := make([]dto.ImportFailureDto, 0, len(galleryImport.Failures))
+	for _, failure := range galleryImport.Failures {
+		failures = append(failures, dto.ImportFailureDto{Path: failure.Path, Reason: failure.Reason})
+	}
+	galleryIds := galleryImport.GalleryIds
+	if galleryIds == nil {
+		galleryIds = []int32{}
+	}
+
+	return &dto.GalleryImportDto{
+		Id:         galleryImport.JobId,
+		Status:     string(job.Status),
+		Progress:   job.Progress,
+		LastError:  job.LastError,
+		Failures:   failures,
+		GalleryIds: galleryIds,
+		CreatedAt:  galleryImport.CreatedAt,
+		FinishedAt: galleryImport.FinishedAt,
+	}
+}
+
+func MapToGalleryDto(model *model.Gallery) *dto.GalleryDto {
+	if model == nil {
+		return nil
+	}
+
+	return &dto.GalleryDto{
+		Id:           model.Id,
+		OwnerId:      model.OwnerId,
+		OwnerName:    model.OwnerName,
+		Name:         model.GalleryName,
+		Description:  model.Description,
+		IsPublic:     model.IsPublic,
+		PictureCount: model.CurrentSize,
+		CreatedAt:    model.CreatedAt,
+	}
+}
+
+func MapToManyGalleryDto(models ...model.Gallery) []dto.GalleryDto {
+	dtos := make([]dto.GalleryDto, len(models))
+	for i, v := range models {
+		dtos[i] = *MapToGalleryDto(&v)
+	}
+
+	return dtos
+}
+
+func MapToPictureDto(picture *model.Picture) *dto.PictureDto {
+	if picture == nil {
+		return nil
+	}
+
+	tags := picture.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return &dto.PictureDto{
+		Id:        picture.Id,
+		GalleryId: picture.GalleryId,
+		Name:      picture.Name,
+		Tags:      tags,
+		Width:     picture.Width,
+		Height:    picture.Height,
+		CreatedAt: picture.CreatedAt,
+	}
+}
+
+func MapToManyPictureDto(models ...model.Picture) []dto.PictureDto {
+	dtos := make([]dto.PictureDto, len(models))
+	for i, v := range models {
+		dtos[i] = *MapToPictureDto(&v)
+	}
+
+	return dtos
+}
+
+func MapToSearchHitDto(model *model.SearchHit) *dto.SearchHitDto {
+	if model == nil {
+		return nil
+	}
+
+	return &dto.SearchHitDto{
+		Kind:        string(model.Kind),
+		Id:          model.Id,
+		GalleryId:   model.GalleryId,
+		GalleryName: model.GalleryName,
+		Name:        model.Name,
+		Description: model.Description,
+		Tags:        model.Tags,
+		Rank:        model.Rank,
+	}
+}
+
+func MapToManySearchHitDto(models ...model.SearchHit) []dto.SearchHitDto {
+	dtos := make([]dto.SearchHitDto, len(models))
+	for i, v := range models {
+		dtos[i] = *MapToSearchHitDto(&v)
+	}
+
+	return dtos
+}
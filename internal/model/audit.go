@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+	AuditPurge   AuditAction = "purge"
+)
+
+type FieldChange struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+type AuditRecord struct {
+	Id           int64
+	ActorId      *int32
+	ActorRole    string
+	Action       AuditAction
+	ResourceType string
+	ResourceId   string
+	Changes      map[string]FieldChange
+	RequestId    string
+	CreatedAt    time.Time
+}
+
+type AuditFilter struct {
+	ActorId      *int32
+	Action       string
+	ResourceType string
+	ResourceId   string
+	From         *time.Time
+	To           *time.Time
+	Limit        int
+	Offset       int
+}
@@ -0,0 +1,14 @@
+package dto
+
+type VerifyEmailDto struct {
+	Token string `json:"token" example:"eyJqdGkiOi...J9.c2lnbmF0dXJl" binding:"required" validate:"required"`
+}
+
+type ForgotPasswordDto struct {
+	Email string `json:"email" example:"123@example.com" binding:"required" validate:"required,email"`
+}
+
+type ResetPasswordDto struct {
+	Token    string `json:"token" example:"eyJqdGkiOi...J9.c2lnbmF0dXJl" binding:"required" validate:"required"`
+	Password string `json:"password" example:"12345678" binding:"required" validate:"required"`
+}
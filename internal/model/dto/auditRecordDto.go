@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+type FieldChangeDto struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+type AuditRecordDto struct {
+	Id           int64                     `json:"id" example:"1"`
+	ActorId      *int32                    `json:"actor_id" example:"1"`
+	ActorRole    string                    `json:"actor_role" example:"admin"`
+	Action       string                    `json:"action" example:"update" enums:"create,update,delete,restore,purge"`
+	ResourceType string                    `json:"resource_type" example:"user"`
+	ResourceId   string                    `json:"resource_id" example:"1"`
+	Changes      map[string]FieldChangeDto `json:"changes"`
+	RequestId    string                    `json:"request_id" example:"5f0c6e1d2a7b4c3e9d8f1a2b3c4d5e6f"`
+	CreatedAt    time.Time                 `json:"created_at"`
+}
+
+type PaginatedAuditRecordsDto struct {
+	Data       []AuditRecordDto `json:"data"`
+	Total      int              `json:"total"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalPages int              `json:"total_pages"`
+}
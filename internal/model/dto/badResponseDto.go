@@ -1,5 +0,0 @@
-package dto
-
-type BadResponseDto struct {
-	Response string `json:"error" example:"Server error"`
-}
@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+type GalleryDto struct {
+	Id           int32     `json:"id" example:"1"`
+	OwnerId      int32     `json:"owner_id" example:"1"`
+	OwnerName    string    `json:"owner_name" example:"Ivan"`
+	Name         string    `json:"name" example:"hands"`
+	Description  string    `json:"description" example:"gesture studies"`
+	IsPublic     bool      `json:"is_public" example:"true"`
+	PictureCount int       `json:"picture_count" example:"12"`
+	CreatedAt    time.Time `json:"created_at"`
+}
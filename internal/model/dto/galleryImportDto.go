@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+type ImportFailureDto struct {
+	Path   string `json:"path" example:"cats/notes.txt"`
+	Reason string `json:"reason" example:"not a picture"`
+}
+
+// GalleryImportDto is an archive being imported by a job. Failures and
+// GalleryIds are filled in once FinishedAt is set.
+type GalleryImportDto struct {
+	Id         int64              `json:"id" example:"1"`
+	Status     string             `json:"status" example:"running" enums:"queued,running,succeeded,dead"`
+	Progress   int32              `json:"progress" example:"40" minimum:"0" maximum:"100"`
+	LastError  string             `json:"last_error,omitempty" example:"connection reset"`
+	Failures   []ImportFailureDto `json:"failures"`
+	GalleryIds []int32            `json:"gallery_ids" example:"1,2"`
+	CreatedAt  time.Time          `json:"created_at"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+}
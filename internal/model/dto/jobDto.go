@@ -0,0 +1,16 @@
+package dto
+
+import "time"
+
+type JobDto struct {
+	Id          int64     `json:"id" example:"1"`
+	Type        string    `json:"type" example:"gallery_import"`
+	Status      string    `json:"status" example:"running" enums:"queued,running,succeeded,dead"`
+	Attempts    int32     `json:"attempts" example:"1"`
+	MaxAttempts int32     `json:"max_attempts" example:"5"`
+	Progress    int32     `json:"progress" example:"40" minimum:"0" maximum:"100"`
+	LastError   string    `json:"last_error,omitempty" example:"connection reset"`
+	RunAt       time.Time `json:"run_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
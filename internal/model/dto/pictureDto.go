@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+type PictureDto struct {
+	Id        int32     `json:"id" example:"1"`
+	GalleryId int32     `json:"gallery_id" example:"1"`
+	Name      string    `json:"name" example:"hand-study"`
+	Tags      []string  `json:"tags" example:"anatomy,hands"`
+	Width     int       `json:"width" example:"1920"`
+	Height    int       `json:"height" example:"1080"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DuplicateClusterDto is a group of pictures that look alike: each is
+// within the requested distance of another one.
+type DuplicateClusterDto struct {
+	Pictures []PictureDto `json:"pictures"`
+}
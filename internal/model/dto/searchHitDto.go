@@ -0,0 +1,14 @@
+package dto
+
+// SearchHitDto is a gallery or a picture matching a search. A gallery is its
+// own gallery_id.
+type SearchHitDto struct {
+	Kind        string   `json:"kind" example:"picture" enums:"gallery,picture"`
+	Id          int32    `json:"id" example:"11"`
+	GalleryId   int32    `json:"gallery_id" example:"1"`
+	GalleryName string   `json:"gallery_name" example:"hands"`
+	Name        string   `json:"name" example:"hand-study"`
+	Description string   `json:"description,omitempty" example:"gesture studies"`
+	Tags        []string `json:"tags,omitempty" example:"anatomy,hands"`
+	Rank        float32  `json:"rank" example:"0.61"`
+}
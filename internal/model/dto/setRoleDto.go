@@ -0,0 +1,5 @@
+package dto
+
+type SetRoleDto struct {
+	Role string `json:"role" example:"moderator" binding:"required" validate:"required,oneof=user moderator admin"`
+}
@@ -1,8 +1,10 @@
 package dto
 
 type UserDto struct {
-	Id       int32  `json:"id" example:"1" validate:"gt=0"`
-	Username string `json:"username" example:"Ivan" validate:"printascii"`
-	Email    string `json:"email" example:"123@example.com" validate:"email"`
-	Password string `json:"password" example:"12345678"`
+	Id            int32  `json:"id" example:"1" validate:"gt=0"`
+	Username      string `json:"username" example:"Ivan" validate:"printascii"`
+	Email         string `json:"email" example:"123@example.com" validate:"email"`
+	Role          string `json:"role" example:"user" enums:"user,moderator,admin"`
+	Disabled      bool   `json:"disabled" example:"false"`
+	EmailVerified bool   `json:"email_verified" example:"true"`
 }
@@ -0,0 +1,16 @@
+package model
+
+import "errors"
+
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrAccountDisabled    = errors.New("account is disabled")
+	ErrAccountLocked      = errors.New("account is temporarily locked")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidSort        = errors.New("invalid sort")
+	ErrInvalidField       = errors.New("invalid field")
+	ErrInvalidUser        = errors.New("invalid user")
+	ErrAlreadyExists      = errors.New("already exists")
+)
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Fields are the fields of a resource a request reads, by their JSON names.
+// Nil reads all of them.
+type Fields []string
+
+func (f Fields) Has(field string) bool {
+	return f == nil || slices.Contains(f, field)
+}
+
+// Check returns ErrInvalidField for the first of f not in known.
+func (f Fields) Check(known ...string) error {
+	for _, field := range f {
+		if !slices.Contains(known, field) {
+			return fmt.Errorf("%w: %s", ErrInvalidField, field)
+		}
+	}
+	return nil
+}
+
+// UserFields are the fields of a user that can be selected. The password
+// hash is never one of them.
+var UserFields = []string{"id", "username", "email", "role", "disabled", "email_verified"}
+
+// UserRelations are the relations that can be included with a user. Users
+// have none yet.
+var UserRelations = []string{}
@@ -1,22 +1,63 @@
 package model
 
+import "time"
+
+// Picture is an image in a gallery. Path is the key of its contents in the
+// blob store and Hash their perceptual hash, see phash.DHash.
 type Picture struct {
-	Name   string
-	Path   string //url or on disk??
-	Tags   string
-	Height int
-	Width  int
+	Id        int32
+	GalleryId int32
+	Name      string
+	Path      string
+	Tags      []string
+	Hash      uint64
+	Height    int
+	Width     int
+	CreatedAt time.Time
 }
 
+// Gallery is a collection of pictures owned by a user. Pictures are only
+// filled in where they are read along with the gallery, CurrentSize always
+// counts them.
 type Gallery struct {
+	Id          int32
+	OwnerId     int32
 	GalleryName string
 	Description string
 	IsPublic    bool
 	Pictures    []Picture
 	CurrentSize int
 	OwnerName   string
+	CreatedAt   time.Time
+}
+
+// Visibility picks the galleries a caller sees: the public ones, those owned
+// by ViewerId and, with All, every other one too.
+type Visibility struct {
+	ViewerId *int32
+	All      bool
 }
 
 type PictureTag struct {
 	TagName string
 }
+
+// ImportFailure is a file of an imported archive that didn't make it into a
+// gallery, with why.
+type ImportFailure struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// GalleryImport tracks an archive being imported into galleries by the job
+// JobId. FinishedAt is set once the galleries are created, together with
+// the files that failed and the ids of the galleries.
+type GalleryImport struct {
+	JobId      int64
+	OwnerId    int32
+	ArchiveKey string
+	Failures   []ImportFailure
+	GalleryIds []int32
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobDead      JobStatus = "dead"
+)
+
+// Job is a unit of background work. A job with a UniqueKey is only enqueued
+// if no job with that key was before; the key is never read back.
+// ClaimToken is set each time a worker claims the job, and only the worker
+// holding the latest one may update it.
+type Job struct {
+	Id          int64
+	Type        string
+	Payload     []byte
+	Status      JobStatus
+	Attempts    int32
+	MaxAttempts int32
+	Progress    int32
+	LastError   string
+	RunAt       time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UniqueKey   string
+	ClaimToken  string
+}
@@ -0,0 +1,27 @@
+package model
+
+// Keyset picks part of a list ordered by Sort and then by id: the rows after
+// the one whose sort key and id are After and AfterId, or the rows before it
+// in reverse order when Backward is set. A zero AfterId starts at the first
+// row, or at the last one going backward. Limit caps the rows; zero doesn't.
+// Fields are the fields read of every row, though the id and sort key are
+// always read, and Include the relations read with it.
+type Keyset struct {
+	Sort     string
+	After    string
+	AfterId  int32
+	Backward bool
+	Limit    int
+	Fields   Fields
+	Include  Fields
+}
+
+// The orders users can be listed in.
+const (
+	UserSortId       = "id"
+	UserSortUsername = "username"
+)
+
+func ValidUserSort(sort string) bool {
+	return sort == UserSortId || sort == UserSortUsername
+}
@@ -0,0 +1,32 @@
+package model
+
+type SearchKind string
+
+const (
+	SearchGallery SearchKind = "gallery"
+	SearchPicture SearchKind = "picture"
+)
+
+// SearchFilter picks a page of the galleries and pictures matching Query,
+// every word of which matches the start of a word. Only the visible
+// galleries are searched, each along with its pictures.
+type SearchFilter struct {
+	Query string
+	Visibility
+	Limit  int
+	Offset int
+}
+
+// SearchHit is a gallery or a picture matching a search, the better the
+// higher its Rank. Galleries are their own GalleryId and have no Tags;
+// pictures have no Description.
+type SearchHit struct {
+	Kind        SearchKind
+	Id          int32
+	GalleryId   int32
+	GalleryName string
+	Name        string
+	Description string
+	Tags        []string
+	Rank        float32
+}
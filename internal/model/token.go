@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+type TokenPurpose string
+
+const (
+	TokenVerifyEmail   TokenPurpose = "verify_email"
+	TokenResetPassword TokenPurpose = "reset_password"
+)
+
+// UserToken is the stored record of an issued token that makes it
+// single-use.
+type UserToken struct {
+	Id        string
+	UserId    int32
+	Purpose   TokenPurpose
+	ExpiresAt time.Time
+}
@@ -1,8 +1,57 @@
 package model
 
+import "fmt"
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	return r == RoleUser || r == RoleModerator || r == RoleAdmin
+}
+
+// User is a user account. Password is the bcrypt hash of the password, never
+// the password itself.
 type User struct {
-	Id       int32  `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Id            int32  `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	Password      string `json:"password"`
+	Role          Role   `json:"role"`
+	Disabled      bool   `json:"disabled"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// UserUpdate changes the fields of user Id that aren't nil and leaves the
+// others as they are. Password is a hash, like User.Password.
+type UserUpdate struct {
+	Id       int32
+	Username *string
+	Email    *string
+	Password *string
+}
+
+// Check returns ErrInvalidUser if u sets a field to an empty value.
+func (u *UserUpdate) Check() error {
+	switch {
+	case u.Username != nil && *u.Username == "":
+		return fmt.Errorf("%w: empty username", ErrInvalidUser)
+	case u.Email != nil && *u.Email == "":
+		return fmt.Errorf("%w: empty email", ErrInvalidUser)
+	case u.Password != nil && *u.Password == "":
+		return fmt.Errorf("%w: empty password", ErrInvalidUser)
+	}
+	return nil
+}
+
+// Actor is the authenticated caller a request is made on behalf of. Operators
+// running refstudyctl act without an account and have no id.
+type Actor struct {
+	Id            int32
+	Role          Role
+	EmailVerified bool
 }
@@ -0,0 +1,59 @@
+// Package password hashes passwords for storage and checks them against the
+// stored hashes.
+package password
+
+import (
+	"fmt"
+	"ivanjabrony/refstudy/internal/model"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxLength is the longest password bcrypt can hash in full.
+const MaxLength = 72
+
+// dummy is compared against when there is no account, so a failed login
+// takes as long whether or not the username exists.
+var dummy = sync.OnceValue(func() string { return MustHash("dummy password") })
+
+// Hash returns the bcrypt hash of password. Empty passwords and ones bcrypt
+// would truncate are model.ErrInvalidUser.
+func Hash(password string) (string, error) {
+	if password == "" {
+		return "", fmt.Errorf("%w: empty password", model.ErrInvalidUser)
+	}
+	if len(password) > MaxLength {
+		return "", fmt.Errorf("%w: password is longer than %d bytes", model.ErrInvalidUser, MaxLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// MustHash is Hash for fixed passwords, such as the ones of test fixtures.
+func MustHash(password string) string {
+	hash, err := Hash(password)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+// Check reports whether password hashes to hash. An empty password never
+// matches.
+func Check(hash, password string) bool {
+	if password == "" {
+		return false
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
+}
+
+// CheckMissing spends as long as Check does, for accounts that don't exist.
+func CheckMissing(password string) {
+	_ = bcrypt.CompareHashAndPassword([]byte(dummy()), []byte(password))
+}
@@ -0,0 +1,29 @@
+package password_test
+
+import (
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/password"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	hash, err := password.Hash("12345678")
+	require.NoError(t, err)
+	require.NotContains(t, hash, "12345678")
+	require.True(t, password.Check(hash, "12345678"))
+	require.False(t, password.Check(hash, "12345679"))
+	require.False(t, password.Check(hash, ""))
+	require.False(t, password.Check("12345678", "12345678"), "plaintext isn't a hash")
+
+	other, err := password.Hash("12345678")
+	require.NoError(t, err)
+	require.NotEqual(t, hash, other, "every hash has its own salt")
+
+	_, err = password.Hash("")
+	require.ErrorIs(t, err, model.ErrInvalidUser)
+	_, err = password.Hash(strings.Repeat("x", password.MaxLength+1))
+	require.ErrorIs(t, err, model.ErrInvalidUser)
+}
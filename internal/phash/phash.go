@@ -0,0 +1,135 @@
+package phash
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+	"sort"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+const (
+	hashWidth  = 9
+	hashHeight = 8
+)
+
+// DHash computes a 64-bit difference hash: the image is reduced to a 9x8
+// grayscale grid and every bit records whether a cell is brighter than its
+// right neighbour. Similar images produce hashes with a small Hamming distance.
+func DHash(img image.Image) (uint64, error) {
+	if img == nil {
+		return 0, errors.New("nil image")
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() < 1 || bounds.Dy() < 1 {
+		return 0, errors.New("empty image")
+	}
+
+	grid := downscale(img, hashWidth, hashHeight)
+
+	var hash uint64
+	for y := 0; y < hashHeight; y++ {
+		for x := 0; x < hashWidth-1; x++ {
+			hash <<= 1
+			if grid[y][x] > grid[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash, nil
+}
+
+func DHashFromReader(r io.Reader) (uint64, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return DHash(img)
+}
+
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+type Hashed struct {
+	Id   int32
+	Hash uint64
+}
+
+// Cluster groups hashes whose distance to some other member of the group is
+// at most threshold. Only groups with more than one member are returned,
+// each sorted by id.
+func Cluster(hashes []Hashed, threshold int) [][]int32 {
+	parent := make([]int, len(hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := 0; i < len(hashes); i++ {
+		for j := i + 1; j < len(hashes); j++ {
+			if Distance(hashes[i].Hash, hashes[j].Hash) <= threshold {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	groups := make(map[int][]int32)
+	for i, h := range hashes {
+		root := find(i)
+		groups[root] = append(groups[root], h.Id)
+	}
+
+	clusters := make([][]int32, 0)
+	for _, ids := range groups {
+		if len(ids) < 2 {
+			continue
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		clusters = append(clusters, ids)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i][0] < clusters[j][0] })
+
+	return clusters
+}
+
+func downscale(img image.Image, width, height int) [][]float64 {
+	bounds := img.Bounds()
+	grid := make([][]float64, height)
+
+	for y := 0; y < height; y++ {
+		grid[y] = make([]float64, width)
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+
+			var sum float64
+			for py := y0; py < y1; py++ {
+				for px := x0; px < x1; px++ {
+					sum += float64(color.GrayModel.Convert(img.At(px, py)).(color.Gray).Y)
+				}
+			}
+			grid[y][x] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+
+	return grid
+}
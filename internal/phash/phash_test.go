@@ -0,0 +1,107 @@
+package phash_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"ivanjabrony/refstudy/internal/phash"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func waves(width, height int, shift float64) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			u, v := float64(x)/float64(width), float64(y)/float64(height)
+			value := 100 + 100*math.Sin(7*u)*math.Cos(5*v) + shift
+			img.SetGray(x, y, color.Gray{Y: uint8(math.Max(0, math.Min(255, value)))})
+		}
+	}
+	return img
+}
+
+func checkerboard(width, height int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x/8+y/8)%2 == 0 {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestDHash(t *testing.T) {
+	t.Run("resized copy is a near duplicate", func(t *testing.T) {
+		a, err := phash.DHash(waves(640, 480, 0))
+		require.NoError(t, err)
+		b, err := phash.DHash(waves(320, 240, 20))
+		require.NoError(t, err)
+
+		require.LessOrEqual(t, phash.Distance(a, b), 2)
+	})
+
+	t.Run("different images are far apart", func(t *testing.T) {
+		a, err := phash.DHash(waves(640, 480, 0))
+		require.NoError(t, err)
+		b, err := phash.DHash(checkerboard(640, 480))
+		require.NoError(t, err)
+
+		require.Greater(t, phash.Distance(a, b), 10)
+	})
+
+	t.Run("tiny image", func(t *testing.T) {
+		_, err := phash.DHash(waves(2, 2, 0))
+		require.NoError(t, err)
+	})
+
+	t.Run("empty image", func(t *testing.T) {
+		_, err := phash.DHash(image.NewGray(image.Rect(0, 0, 0, 0)))
+		require.Error(t, err)
+	})
+}
+
+func TestDHashFromReader(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, waves(64, 64, 0)))
+
+	fromReader, err := phash.DHashFromReader(&buf)
+	require.NoError(t, err)
+	direct, err := phash.DHash(waves(64, 64, 0))
+	require.NoError(t, err)
+	require.Equal(t, direct, fromReader)
+
+	_, err = phash.DHashFromReader(bytes.NewReader([]byte("not an image")))
+	require.Error(t, err)
+}
+
+func TestCluster(t *testing.T) {
+	hashes := []phash.Hashed{
+		{Id: 4, Hash: 0b0000},
+		{Id: 1, Hash: 0b0001},
+		{Id: 2, Hash: 0xFFFF_0000_0000_0000},
+		{Id: 3, Hash: 0xFFFF_0000_0000_0001},
+		{Id: 5, Hash: 0x0F0F_0F0F_0F0F_0F0F},
+	}
+
+	testcases := []struct {
+		name      string
+		threshold int
+		expected  [][]int32
+	}{
+		{name: "exact only", threshold: 0, expected: [][]int32{}},
+		{name: "one bit", threshold: 1, expected: [][]int32{{1, 4}, {2, 3}}},
+		{name: "everything", threshold: 64, expected: [][]int32{{1, 2, 3, 4, 5}}},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			require.Equal(t, testcase.expected, phash.Cluster(hashes, testcase.threshold))
+		})
+	}
+}
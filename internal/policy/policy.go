@@ -0,0 +1,150 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"ivanjabrony/refstudy/internal/model"
+)
+
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionRead    Action = "read"
+	ActionList    Action = "list"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+	ActionDisable Action = "disable"
+	ActionGrant   Action = "grant"
+)
+
+type ResourceType string
+
+const (
+	ResourceUser    ResourceType = "user"
+	ResourceGallery ResourceType = "gallery"
+	ResourcePicture ResourceType = "picture"
+	ResourceJob     ResourceType = "job"
+	ResourceAudit   ResourceType = "audit"
+)
+
+type Resource struct {
+	Type     ResourceType
+	Id       int32
+	OwnerId  int32
+	IsPublic bool
+}
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("action is not allowed")
+)
+
+// Policy answers whether actor may perform action on resource. A nil actor
+// is an anonymous caller.
+type Policy interface {
+	Can(actor *model.Actor, action Action, resource Resource) bool
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor *model.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) *model.Actor {
+	actor, _ := ctx.Value(actorKey{}).(*model.Actor)
+	return actor
+}
+
+// Authorize checks the actor stored in ctx against p. It returns
+// ErrUnauthenticated when an anonymous caller is refused and ErrForbidden
+// when an authenticated one is.
+func Authorize(ctx context.Context, p Policy, action Action, resource Resource) error {
+	actor := ActorFromContext(ctx)
+	if p.Can(actor, action, resource) {
+		return nil
+	}
+	if actor == nil {
+		return ErrUnauthenticated
+	}
+
+	return ErrForbidden
+}
+
+// RolePolicy is the default policy: admins may do anything, moderators may
+// additionally read any account and edit and remove any gallery or picture,
+// and everybody else works with what they own plus whatever is public.
+// Accounts are never public, since they carry the email address and status.
+type RolePolicy struct{}
+
+func (RolePolicy) Can(actor *model.Actor, action Action, resource Resource) bool {
+	if actor != nil && actor.Role == model.RoleAdmin {
+		return true
+	}
+
+	switch resource.Type {
+	case ResourceUser:
+		switch action {
+		case ActionCreate:
+			return true
+		case ActionRead:
+			return isOwner(actor, resource) || isModerator(actor)
+		case ActionUpdate, ActionDelete:
+			return isOwner(actor, resource)
+		}
+	case ResourceGallery, ResourcePicture:
+		switch action {
+		case ActionRead, ActionList:
+			return resource.IsPublic || isOwner(actor, resource) || isModerator(actor)
+		case ActionCreate:
+			return actor != nil
+		case ActionUpdate, ActionDelete:
+			return isOwner(actor, resource) || isModerator(actor)
+		}
+	}
+
+	return false
+}
+
+func isOwner(actor *model.Actor, resource Resource) bool {
+	return actor != nil && actor.Id == resource.OwnerId
+}
+
+func isModerator(actor *model.Actor) bool {
+	return actor != nil && actor.Role == model.RoleModerator
+}
+
+type UnverifiedAccess string
+
+const (
+	UnverifiedFull     UnverifiedAccess = "full"
+	UnverifiedReadOnly UnverifiedAccess = "read_only"
+)
+
+func (a UnverifiedAccess) Valid() bool {
+	return a == UnverifiedFull || a == UnverifiedReadOnly
+}
+
+// VerifiedPolicy restricts actors that haven't verified their email address
+// according to Access before deferring to Policy. With read-only access they
+// may still read and update their own account, so a mistyped address can be
+// fixed. Admins are never restricted.
+type VerifiedPolicy struct {
+	Policy
+	Access UnverifiedAccess
+}
+
+func (p VerifiedPolicy) Can(actor *model.Actor, action Action, resource Resource) bool {
+	if actor != nil && !actor.EmailVerified && actor.Role != model.RoleAdmin && p.Access == UnverifiedReadOnly {
+		switch {
+		case action == ActionRead, action == ActionList:
+		case action == ActionUpdate && resource.Type == ResourceUser && isOwner(actor, resource):
+		default:
+			return false
+		}
+	}
+
+	return p.Policy.Can(actor, action, resource)
+}
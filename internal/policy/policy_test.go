@@ -0,0 +1,104 @@
+package policy_test
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/policy"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolePolicy(t *testing.T) {
+	user := &model.Actor{Id: 1, Role: model.RoleUser}
+	moderator := &model.Actor{Id: 2, Role: model.RoleModerator}
+	admin := &model.Actor{Id: 3, Role: model.RoleAdmin}
+
+	ownGallery := policy.Resource{Type: policy.ResourceGallery, Id: 10, OwnerId: 1}
+	otherGallery := policy.Resource{Type: policy.ResourceGallery, Id: 11, OwnerId: 4}
+	publicGallery := policy.Resource{Type: policy.ResourceGallery, Id: 12, OwnerId: 4, IsPublic: true}
+
+	testcases := []struct {
+		name     string
+		actor    *model.Actor
+		action   policy.Action
+		resource policy.Resource
+		allowed  bool
+	}{
+		{"anonymous signs up", nil, policy.ActionCreate, policy.Resource{Type: policy.ResourceUser}, true},
+		{"anonymous can't read account", nil, policy.ActionRead, policy.Resource{Type: policy.ResourceUser, Id: 1, OwnerId: 1}, false},
+		{"user reads own account", user, policy.ActionRead, policy.Resource{Type: policy.ResourceUser, Id: 1, OwnerId: 1}, true},
+		{"user can't read other account", user, policy.ActionRead, policy.Resource{Type: policy.ResourceUser, Id: 4, OwnerId: 4}, false},
+		{"moderator reads any account", moderator, policy.ActionRead, policy.Resource{Type: policy.ResourceUser, Id: 4, OwnerId: 4}, true},
+		{"anonymous can't update profile", nil, policy.ActionUpdate, policy.Resource{Type: policy.ResourceUser, Id: 1, OwnerId: 1}, false},
+		{"user updates own profile", user, policy.ActionUpdate, policy.Resource{Type: policy.ResourceUser, Id: 1, OwnerId: 1}, true},
+		{"user can't list users", user, policy.ActionList, policy.Resource{Type: policy.ResourceUser}, false},
+		{"moderator can't disable users", moderator, policy.ActionDisable, policy.Resource{Type: policy.ResourceUser, Id: 1, OwnerId: 1}, false},
+		{"admin disables users", admin, policy.ActionDisable, policy.Resource{Type: policy.ResourceUser, Id: 1, OwnerId: 1}, true},
+		{"anonymous reads public gallery", nil, policy.ActionRead, publicGallery, true},
+		{"anonymous can't create gallery", nil, policy.ActionCreate, policy.Resource{Type: policy.ResourceGallery}, false},
+		{"user reads own private gallery", user, policy.ActionRead, ownGallery, true},
+		{"user can't read other private gallery", user, policy.ActionRead, otherGallery, false},
+		{"user can't delete public gallery of other", user, policy.ActionDelete, publicGallery, false},
+		{"moderator deletes any gallery", moderator, policy.ActionDelete, otherGallery, true},
+		{"admin manages any gallery", admin, policy.ActionUpdate, otherGallery, true},
+		{"user can't read jobs", user, policy.ActionRead, policy.Resource{Type: policy.ResourceJob}, false},
+		{"admin reads jobs", admin, policy.ActionRead, policy.Resource{Type: policy.ResourceJob}, true},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			allowed := policy.RolePolicy{}.Can(testcase.actor, testcase.action, testcase.resource)
+			require.Equal(t, testcase.allowed, allowed)
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	resource := policy.Resource{Type: policy.ResourceUser}
+
+	err := policy.Authorize(context.Background(), policy.RolePolicy{}, policy.ActionList, resource)
+	require.ErrorIs(t, err, policy.ErrUnauthenticated)
+
+	ctx := policy.WithActor(context.Background(), &model.Actor{Id: 1, Role: model.RoleUser})
+	err = policy.Authorize(ctx, policy.RolePolicy{}, policy.ActionList, resource)
+	require.ErrorIs(t, err, policy.ErrForbidden)
+
+	ctx = policy.WithActor(context.Background(), &model.Actor{Id: 1, Role: model.RoleAdmin})
+	require.NoError(t, policy.Authorize(ctx, policy.RolePolicy{}, policy.ActionList, resource))
+}
+
+func TestVerifiedPolicy(t *testing.T) {
+	unverified := &model.Actor{Id: 1, Role: model.RoleUser}
+	verified := &model.Actor{Id: 1, Role: model.RoleUser, EmailVerified: true}
+	unverifiedAdmin := &model.Actor{Id: 3, Role: model.RoleAdmin}
+
+	ownProfile := policy.Resource{Type: policy.ResourceUser, Id: 1, OwnerId: 1}
+	ownGallery := policy.Resource{Type: policy.ResourceGallery, Id: 10, OwnerId: 1}
+
+	testcases := []struct {
+		name     string
+		access   policy.UnverifiedAccess
+		actor    *model.Actor
+		action   policy.Action
+		resource policy.Resource
+		allowed  bool
+	}{
+		{"full access creates gallery", policy.UnverifiedFull, unverified, policy.ActionCreate, policy.Resource{Type: policy.ResourceGallery}, true},
+		{"read-only can't create gallery", policy.UnverifiedReadOnly, unverified, policy.ActionCreate, policy.Resource{Type: policy.ResourceGallery}, false},
+		{"read-only can't delete own gallery", policy.UnverifiedReadOnly, unverified, policy.ActionDelete, ownGallery, false},
+		{"read-only reads own gallery", policy.UnverifiedReadOnly, unverified, policy.ActionRead, ownGallery, true},
+		{"read-only fixes own profile", policy.UnverifiedReadOnly, unverified, policy.ActionUpdate, ownProfile, true},
+		{"read-only can't delete own profile", policy.UnverifiedReadOnly, unverified, policy.ActionDelete, ownProfile, false},
+		{"verified deletes own gallery", policy.UnverifiedReadOnly, verified, policy.ActionDelete, ownGallery, true},
+		{"admin isn't restricted", policy.UnverifiedReadOnly, unverifiedAdmin, policy.ActionDisable, ownProfile, true},
+		{"anonymous falls through", policy.UnverifiedReadOnly, nil, policy.ActionCreate, policy.Resource{Type: policy.ResourceUser}, true},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			p := policy.VerifiedPolicy{Policy: policy.RolePolicy{}, Access: testcase.access}
+			require.Equal(t, testcase.allowed, p.Can(testcase.actor, testcase.action, testcase.resource))
+		})
+	}
+}
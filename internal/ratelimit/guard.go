@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/model"
+	"strings"
+	"time"
+)
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, username, password string) (*model.Actor, error)
+}
+
+// LockedError is returned while an account is locked after failed logins.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", model.ErrAccountLocked, e.RetryAfter)
+}
+
+func (e *LockedError) Unwrap() error {
+	return model.ErrAccountLocked
+}
+
+// Guard protects an Authenticator against password guessing by locking
+// accounts progressively on failed logins.
+type Guard struct {
+	Authenticator
+	store   Store
+	lockout Lockout
+	now     func() time.Time
+}
+
+func NewGuard(authenticator Authenticator, store Store, lockout Lockout) (*Guard, error) {
+	if authenticator == nil || store == nil {
+		return nil, errors.New("nil values in Guard constructor")
+	}
+
+	return &Guard{authenticator, store, lockout, time.Now}, nil
+}
+
+func (g *Guard) Authenticate(ctx context.Context, username, password string) (*model.Actor, error) {
+	key := "login:" + strings.ToLower(username)
+
+	lock, err := g.store.GetLock(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check login lock: %w", err)
+	}
+	if now := g.now(); lock.Locked(now) {
+		return nil, &LockedError{RetryAfter: lock.Until.Sub(now)}
+	}
+
+	actor, err := g.Authenticator.Authenticate(ctx, username, password)
+	if errors.Is(err, model.ErrInvalidCredentials) {
+		if _, failErr := g.store.Fail(ctx, key, g.lockout); failErr != nil {
+			return nil, fmt.Errorf("failed to record failed login: %w", failErr)
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lock.Failures > 0 {
+		if err := g.store.ResetLock(ctx, key); err != nil {
+			return nil, fmt.Errorf("failed to reset login lock: %w", err)
+		}
+	}
+
+	return actor, nil
+}
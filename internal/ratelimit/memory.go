@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	sweepEvery = 1024
+	// maxKeys bounds how many buckets and how many locks a MemoryStore
+	// keeps, so clients making up keys can't exhaust memory.
+	maxKeys = 100_000
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+type lockEntry struct {
+	lock     Lock
+	forgetAt time.Time
+}
+
+// MemoryStore keeps the state in process. It suits a single replica.
+//
+// Once it holds maxKeys buckets or locks it evicts one to make room for a
+// new key. Evicted buckets start over full and evicted locks forget their
+// failures, so locks that are in force are evicted last.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	locks   map[string]lockEntry
+	calls   int
+	maxKeys int
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+		locks:   map[string]lockEntry{},
+		maxKeys: maxKeys,
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.maybeSweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= s.maxKeys {
+			s.evictBucket()
+		}
+		b = &bucket{tokens: float64(limit.Requests), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	var result Result
+	b.tokens, result = TakeToken(b.tokens, now.Sub(b.updatedAt), limit)
+	b.updatedAt = now
+	b.fullAt = now.Add(result.Reset)
+
+	return result, nil
+}
+
+func (s *MemoryStore) GetLock(_ context.Context, key string) (Lock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.locks[key].lock, nil
+}
+
+func (s *MemoryStore) Fail(_ context.Context, key string, lockout Lockout) (Lock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.maybeSweep(now)
+
+	entry, ok := s.locks[key]
+	if !ok && len(s.locks) >= s.maxKeys {
+		s.evictLock(now)
+	}
+	lock := lockout.Fail(entry.lock, now)
+	s.locks[key] = lockEntry{lock: lock, forgetAt: lockout.ForgetAt(lock)}
+	return lock, nil
+}
+
+func (s *MemoryStore) ResetLock(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.locks, key)
+	return nil
+}
+
+func (s *MemoryStore) maybeSweep(now time.Time) {
+	s.calls++
+	if s.calls%sweepEvery == 0 {
+		s.sweep(now)
+	}
+}
+
+// sweep drops full buckets and forgotten locks, which behave the same as
+// missing ones.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, entry := range s.locks {
+		if !now.Before(entry.forgetAt) {
+			delete(s.locks, key)
+		}
+	}
+}
+
+// evictBucket drops an arbitrary bucket.
+func (s *MemoryStore) evictBucket() {
+	for key := range s.buckets {
+		delete(s.buckets, key)
+		return
+	}
+}
+
+// evictLock drops an arbitrary lock that isn't in force, or any lock if
+// all of them are.
+func (s *MemoryStore) evictLock(now time.Time) {
+	victim, found := "", false
+	for key, entry := range s.locks {
+		if !entry.lock.Locked(now) {
+			delete(s.locks, key)
+			return
+		}
+		if !found {
+			victim, found = key, true
+		}
+	}
+	delete(s.locks, victim)
+}
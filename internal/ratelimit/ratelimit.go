@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Per on average, with bursts of up to Requests.
+// A limit without requests is unlimited.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Per <= 0
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// ParseLimit parses limits written as "100/1m".
+func ParseLimit(s string) (Limit, error) {
+	requests, per, found := strings.Cut(strings.TrimSpace(s), "/")
+	if !found {
+		return Limit{}, fmt.Errorf("invalid limit %q: expected requests/period", s)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil {
+		return Limit{}, fmt.Errorf("invalid limit %q: %w", s, err)
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil {
+		return Limit{}, fmt.Errorf("invalid limit %q: %w", s, err)
+	}
+
+	return Limit{Requests: n, Per: d}, nil
+}
+
+// Limits holds the default limit and overrides for single routes, keyed by
+// "METHOD /path" as registered in the router.
+type Limits struct {
+	Default Limit
+	Routes  map[string]Limit
+}
+
+// For returns the limit of route and whether it is an override.
+func (l Limits) For(route string) (Limit, bool) {
+	if limit, ok := l.Routes[route]; ok {
+		return limit, true
+	}
+	return l.Default, false
+}
+
+// ParseLimits parses the default limit and a comma separated list of route
+// overrides such as "POST /api/v1/users=10/1h,GET /api/v1/users/:id=50/1m".
+func ParseLimits(defaultLimit, routes string) (Limits, error) {
+	var limits Limits
+	var err error
+	if limits.Default, err = ParseLimit(defaultLimit); err != nil {
+		return Limits{}, err
+	}
+
+	limits.Routes = map[string]Limit{}
+	for _, entry := range strings.Split(routes, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, limit, found := strings.Cut(entry, "=")
+		if !found {
+			return Limits{}, fmt.Errorf("invalid route limit %q: expected route=requests/period", entry)
+		}
+		if limits.Routes[strings.TrimSpace(route)], err = ParseLimit(limit); err != nil {
+			return Limits{}, err
+		}
+	}
+
+	return limits, nil
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long it takes until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long to wait for the next request to be allowed.
+	RetryAfter time.Duration
+}
+
+// TakeToken refills a bucket holding tokens for the time elapsed since it
+// was last updated and takes a token from it if one is available. It
+// returns the tokens left in the bucket and the outcome. Stores keep the
+// bucket state, this is the arithmetic they share.
+func TakeToken(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	rate := limit.rate()
+	tokens = math.Min(float64(limit.Requests), tokens+elapsed.Seconds()*rate)
+
+	result := Result{Limit: limit.Requests}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	result.Remaining = int(tokens)
+	result.Reset = seconds((float64(limit.Requests) - tokens) / rate)
+
+	return tokens, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s)) * time.Second
+}
+
+// Lock is the failed login state of one key.
+type Lock struct {
+	Failures     int
+	LastFailedAt time.Time
+	Until        time.Time
+}
+
+func (l Lock) Locked(now time.Time) bool {
+	return now.Before(l.Until)
+}
+
+// Lockout locks a key once Threshold consecutive failures happened, for Base
+// at first and twice as long on every further failure, up to Max. Failures
+// are forgotten after Window without one.
+type Lockout struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+	Window    time.Duration
+}
+
+// Fail records a failure at now.
+func (l Lockout) Fail(lock Lock, now time.Time) Lock {
+	if now.Sub(lock.LastFailedAt) > l.Window {
+		lock.Failures = 0
+	}
+	lock.Failures++
+	lock.LastFailedAt = now
+
+	if l.Threshold > 0 && lock.Failures >= l.Threshold {
+		duration := l.Base
+		for i := l.Threshold; i < lock.Failures && duration < l.Max; i++ {
+			duration *= 2
+		}
+		lock.Until = now.Add(min(duration, l.Max))
+	}
+
+	return lock
+}
+
+// ForgetAt returns when lock neither locks nor counts towards one anymore,
+// so it can be dropped.
+func (l Lockout) ForgetAt(lock Lock) time.Time {
+	return maxTime(lock.Until, lock.LastFailedAt.Add(l.Window))
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// Store keeps rate limiter and lockout state. Implementations must update a
+// key atomically, so that replicas sharing a store share the limits.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	GetLock(ctx context.Context, key string) (Lock, error)
+	Fail(ctx context.Context, key string, lockout Lockout) (Lock, error)
+	ResetLock(ctx context.Context, key string) error
+}
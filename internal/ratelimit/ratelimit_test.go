@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("100/1m", "POST /api/v1/users=10/1h, POST /api/v1/auth/forgot-password=5/1h")
+	require.NoError(t, err)
+	require.Equal(t, Limit{Requests: 100, Per: time.Minute}, limits.Default)
+
+	limit, override := limits.For("POST /api/v1/users")
+	require.True(t, override)
+	require.Equal(t, Limit{Requests: 10, Per: time.Hour}, limit)
+
+	limit, override = limits.For("GET /api/v1/users/:id")
+	require.False(t, override)
+	require.Equal(t, limits.Default, limit)
+
+	for _, invalid := range []string{"100", "x/1m", "100/x"} {
+		_, err := ParseLimits(invalid, "")
+		require.Error(t, err, invalid)
+	}
+	_, err = ParseLimits("100/1m", "POST /api/users/")
+	require.Error(t, err)
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+	limit := Limit{Requests: 2, Per: 10 * time.Second}
+
+	result, err := store.Take(ctx, "ip:1", limit)
+	require.NoError(t, err)
+	require.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 5 * time.Second}, result)
+
+	result, _ = store.Take(ctx, "ip:1", limit)
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+
+	result, _ = store.Take(ctx, "ip:1", limit)
+	require.False(t, result.Allowed)
+	require.Equal(t, 5*time.Second, result.RetryAfter)
+	require.Equal(t, 10*time.Second, result.Reset)
+
+	result, _ = store.Take(ctx, "ip:2", limit)
+	require.True(t, result.Allowed)
+
+	now = now.Add(5 * time.Second)
+	result, _ = store.Take(ctx, "ip:1", limit)
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+}
+
+func TestMemoryStore_Locks(t *testing.T) {
+	store := NewMemoryStore()
+	store.maxKeys = 2
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+	lockout := Lockout{Threshold: 1, Base: time.Minute, Max: time.Hour, Window: time.Hour}
+
+	lock, err := store.Fail(ctx, "user:locked", lockout)
+	require.NoError(t, err)
+	require.True(t, lock.Locked(now))
+	_, err = store.Fail(ctx, "user:ivan", Lockout{Window: time.Hour})
+	require.NoError(t, err)
+	_, err = store.Fail(ctx, "user:new", lockout)
+	require.NoError(t, err)
+	require.Len(t, store.locks, 2)
+	require.NotContains(t, store.locks, "user:ivan", "locks that aren't in force are evicted first")
+
+	now = now.Add(2 * time.Hour)
+	store.sweep(now)
+	require.Empty(t, store.locks, "forgotten locks are swept")
+}
+
+func TestLockout_Fail(t *testing.T) {
+	lockout := Lockout{Threshold: 3, Base: time.Minute, Max: 5 * time.Minute, Window: time.Hour}
+	now := time.Now()
+
+	var lock Lock
+	lock = lockout.Fail(lock, now)
+	lock = lockout.Fail(lock, now)
+	require.False(t, lock.Locked(now))
+
+	expected := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for _, duration := range expected {
+		lock = lockout.Fail(lock, now)
+		require.Equal(t, now.Add(duration), lock.Until)
+	}
+
+	later := now.Add(2 * time.Hour)
+	lock = lockout.Fail(lock, later)
+	require.Equal(t, 1, lock.Failures)
+	require.False(t, lock.Locked(later))
+}
+
+type passwordAuthenticator map[string]string
+
+func (p passwordAuthenticator) Authenticate(_ context.Context, username, password string) (*model.Actor, error) {
+	if p[username] != password {
+		return nil, model.ErrInvalidCredentials
+	}
+	return &model.Actor{Id: 1, Role: model.RoleUser}, nil
+}
+
+func TestGuard(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	guard, err := NewGuard(passwordAuthenticator{"ivan": "secret"}, store, Lockout{Threshold: 2, Base: time.Minute, Max: time.Hour, Window: time.Hour})
+	require.NoError(t, err)
+	guard.now = store.now
+	ctx := context.Background()
+
+	_, err = guard.Authenticate(ctx, "ivan", "wrong")
+	require.ErrorIs(t, err, model.ErrInvalidCredentials)
+	_, err = guard.Authenticate(ctx, "Ivan", "wrong")
+	require.ErrorIs(t, err, model.ErrInvalidCredentials)
+
+	_, err = guard.Authenticate(ctx, "ivan", "secret")
+	require.ErrorIs(t, err, model.ErrAccountLocked)
+	var locked *LockedError
+	require.ErrorAs(t, err, &locked)
+	require.Equal(t, time.Minute, locked.RetryAfter)
+
+	now = now.Add(time.Minute)
+	actor, err := guard.Authenticate(ctx, "ivan", "secret")
+	require.NoError(t, err)
+	require.NotNil(t, actor)
+
+	lock, err := store.GetLock(ctx, "login:ivan")
+	require.NoError(t, err)
+	require.Zero(t, lock.Failures)
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var auditColumns = []string{
+	"id", "actor_id", "actor_role", "action", "resource_type",
+	"resource_id", "changes", "request_id", "created_at",
+}
+
+type AuditRepository struct {
+	pool    PgxIface
+	builder squirrel.StatementBuilderType
+	logger  *logger.MyLogger
+}
+
+func NewAuditRepository(pool PgxIface, logger *logger.MyLogger) (*AuditRepository, error) {
+	if pool == nil {
+		return nil, errors.New("nil values in AuditRepository constructor")
+	}
+
+	return &AuditRepository{
+		pool:    pool,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		logger:  logger,
+	}, nil
+}
+
+func (repo *AuditRepository) CreateAuditRecord(ctx context.Context, record *model.AuditRecord) error {
+	changes, err := json.Marshal(record.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode changes: %w", err)
+	}
+
+	query, args, err := repo.builder.
+		Insert("audit_log").
+		Columns("actor_id", "actor_role", "action", "resource_type", "resource_id", "changes", "request_id").
+		Values(record.ActorId, record.ActorRole, string(record.Action), record.ResourceType, record.ResourceId, changes, record.RequestId).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, args...).Scan(&record.Id, &record.CreatedAt); err != nil {
+			return fmt.Errorf("failed to create audit record: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetAuditRecords returns a page of records matching filter, newest first,
+// along with the total number of matches.
+func (repo *AuditRepository) GetAuditRecords(ctx context.Context, filter model.AuditFilter) ([]model.AuditRecord, int, error) {
+	where := squirrel.And{}
+	if filter.ActorId != nil {
+		where = append(where, squirrel.Eq{"actor_id": *filter.ActorId})
+	}
+	if filter.Action != "" {
+		where = append(where, squirrel.Eq{"action": filter.Action})
+	}
+	if filter.ResourceType != "" {
+		where = append(where, squirrel.Eq{"resource_type": filter.ResourceType})
+	}
+	if filter.ResourceId != "" {
+		where = append(where, squirrel.Eq{"resource_id": filter.ResourceId})
+	}
+	if filter.From != nil {
+		where = append(where, squirrel.GtOrEq{"created_at": *filter.From})
+	}
+	if filter.To != nil {
+		where = append(where, squirrel.Lt{"created_at": *filter.To})
+	}
+
+	countQuery, countArgs, err := repo.builder.Select("COUNT(*)").From("audit_log").Where(where).ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	query, args, err := repo.builder.
+		Select(auditColumns...).
+		From("audit_log").
+		Where(where).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(uint64(filter.Limit)).
+		Offset(uint64(filter.Offset)).
+		ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	db := reader(ctx, repo.pool)
+	var total int
+	if err := db.QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit records: %w", err)
+	}
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var records []model.AuditRecord
+	for rows.Next() {
+		var record model.AuditRecord
+		var action string
+		var changes []byte
+		if err := rows.Scan(
+			&record.Id,
+			&record.ActorId,
+			&record.ActorRole,
+			&action,
+			&record.ResourceType,
+			&record.ResourceId,
+			&changes,
+			&record.RequestId,
+			&record.CreatedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+		record.Action = model.AuditAction(action)
+		if err := json.Unmarshal(changes, &record.Changes); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode changes: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+
+	return records, total, nil
+}
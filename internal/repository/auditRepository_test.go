@@ -0,0 +1,89 @@
+package repository_test
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldAuditInSameTransaction(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	users, err := repository.NewUserRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+	audit, err := repository.NewAuditRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+	tx, err := repository.NewTransactor(mock)
+	require.NoError(t, err)
+
+	var id int32 = 1
+	actor := int32(2)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET deleted_at = now\\(\\)").
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery("INSERT INTO audit_log").
+		WithArgs(&actor, "admin", "delete", "user", "1", []byte(`{"username":{"before":"ivan"}}`), "req-1").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(10), now))
+	mock.ExpectCommit()
+
+	record := &model.AuditRecord{
+		ActorId:      &actor,
+		ActorRole:    "admin",
+		Action:       model.AuditDelete,
+		ResourceType: "user",
+		ResourceId:   "1",
+		Changes:      map[string]model.FieldChange{"username": {Before: "ivan"}},
+		RequestId:    "req-1",
+	}
+	err = tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := users.DeleteUserById(ctx, id); err != nil {
+			return err
+		}
+		return audit.CreateAuditRecord(ctx, record)
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), record.Id)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldRollbackWithAudit(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	users, err := repository.NewUserRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+	tx, err := repository.NewTransactor(mock)
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET deleted_at = now\\(\\)").
+		WithArgs(int32(1)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectRollback()
+
+	err = tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		return users.DeleteUserById(ctx, 1)
+	})
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,492 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"strings"
+	"unicode"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var galleryColumns = []string{
+	"g.id", "g.owner_id", "u.username", "g.name", "g.description", "g.is_public", "g.created_at",
+	"(SELECT count(*) FROM pictures p WHERE p.gallery_id = g.id)",
+}
+
+var pictureColumns = []string{"id", "gallery_id", "name", "path", "tags", "width", "height", "created_at"}
+
+var galleryImportColumns = []string{"job_id", "owner_id", "archive_key", "failures", "gallery_ids", "created_at", "finished_at"}
+
+type GalleryRepository struct {
+	pool    PgxIface
+	builder squirrel.StatementBuilderType
+	logger  *logger.MyLogger
+}
+
+func NewGalleryRepository(pool PgxIface, logger *logger.MyLogger) (*GalleryRepository, error) {
+	if pool == nil {
+		return nil, errors.New("nil values in GalleryRepository constructor")
+	}
+
+	return &GalleryRepository{
+		pool:    pool,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		logger:  logger,
+	}, nil
+}
+
+// CreateGallery stores gallery together with its pictures and fills in the
+// ids and creation times.
+func (repo *GalleryRepository) CreateGallery(ctx context.Context, gallery *model.Gallery) (*model.Gallery, error) {
+	query, args, err := repo.builder.
+		Insert("galleries").
+		Columns("owner_id", "name", "description", "is_public").
+		Values(gallery.OwnerId, gallery.GalleryName, gallery.Description, gallery.IsPublic).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	err = inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, args...).Scan(&gallery.Id, &gallery.CreatedAt); err != nil {
+			return fmt.Errorf("failed to create gallery: %w", err)
+		}
+		for i := range gallery.Pictures {
+			if err := repo.createPicture(ctx, tx, gallery.Id, &gallery.Pictures[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	gallery.CurrentSize = len(gallery.Pictures)
+
+	return gallery, nil
+}
+
+func (repo *GalleryRepository) createPicture(ctx context.Context, tx pgx.Tx, galleryId int32, picture *model.Picture) error {
+	tags := picture.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	query, args, err := repo.builder.
+		Insert("pictures").
+		Columns("gallery_id", "name", "path", "tags", "phash", "width", "height").
+		Values(galleryId, picture.Name, picture.Path, tags, int64(picture.Hash), picture.Width, picture.Height).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if err := tx.QueryRow(ctx, query, args...).Scan(&picture.Id, &picture.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create picture: %w", err)
+	}
+	picture.GalleryId = galleryId
+
+	return nil
+}
+
+// GetGalleryById returns the gallery without its pictures.
+func (repo *GalleryRepository) GetGalleryById(ctx context.Context, id int32) (*model.Gallery, error) {
+	query, args, err := repo.builder.
+		Select(galleryColumns...).
+		From("galleries g").
+		Join("users u ON u.id = g.owner_id").
+		Where(squirrel.Eq{"g.id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	gallery, err := scanGallery(reader(ctx, repo.pool).QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("gallery with id %d: %w", id, model.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gallery: %w", err)
+	}
+
+	return gallery, nil
+}
+
+// GetGalleriesByIds returns the galleries of ids that visibility sees,
+// without their pictures, ordered by id.
+func (repo *GalleryRepository) GetGalleriesByIds(ctx context.Context, ids []int32, visibility model.Visibility) ([]model.Gallery, error) {
+	query, args, err := repo.builder.
+		Select(galleryColumns...).
+		From("galleries g").
+		Join("users u ON u.id = g.owner_id").
+		Where(squirrel.Eq{"g.id": ids}).
+		Where(visibleGalleries(visibility)).
+		OrderBy("g.id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return repo.queryGalleries(ctx, query, args)
+}
+
+// GetGalleriesByOwners returns the first limit galleries by id of each of
+// ownerIds that visibility sees, without their pictures, ordered by owner
+// and id.
+func (repo *GalleryRepository) GetGalleriesByOwners(ctx context.Context, ownerIds []int32, visibility model.Visibility, limit int) ([]model.Gallery, error) {
+	ranked := squirrel.
+		Select("g.id", "row_number() OVER (PARTITION BY g.owner_id ORDER BY g.id) AS n").
+		From("galleries g").
+		Where(squirrel.Eq{"g.owner_id": ownerIds}).
+		Where(visibleGalleries(visibility))
+	first := squirrel.Select("id").FromSelect(ranked, "ranked").Where(squirrel.LtOrEq{"n": limit})
+	query, args, err := repo.builder.
+		Select(galleryColumns...).
+		From("galleries g").
+		Join("users u ON u.id = g.owner_id").
+		Where(squirrel.Expr("g.id IN (?)", first)).
+		OrderBy("g.owner_id", "g.id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return repo.queryGalleries(ctx, query, args)
+}
+
+func (repo *GalleryRepository) queryGalleries(ctx context.Context, query string, args []any) ([]model.Gallery, error) {
+	rows, err := reader(ctx, repo.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var galleries []model.Gallery
+	for rows.Next() {
+		gallery, err := scanGallery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		galleries = append(galleries, *gallery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return galleries, nil
+}
+
+func scanGallery(row pgx.Row) (*model.Gallery, error) {
+	var gallery model.Gallery
+	err := row.Scan(
+		&gallery.Id, &gallery.OwnerId, &gallery.OwnerName, &gallery.GalleryName,
+		&gallery.Description, &gallery.IsPublic, &gallery.CreatedAt, &gallery.CurrentSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &gallery, nil
+}
+
+// GetGalleryPictures returns the pictures of gallery galleryId in the order
+// they were added.
+func (repo *GalleryRepository) GetGalleryPictures(ctx context.Context, galleryId int32) ([]model.Picture, error) {
+	query, args, err := repo.builder.
+		Select(pictureColumns...).
+		From("pictures").
+		Where(squirrel.Eq{"gallery_id": galleryId}).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return repo.queryPictures(ctx, query, args)
+}
+
+// GetPicturesByGalleries returns the first limit pictures by id of each of
+// galleryIds whose gallery visibility sees, ordered by gallery and id.
+func (repo *GalleryRepository) GetPicturesByGalleries(ctx context.Context, galleryIds []int32, visibility model.Visibility, limit int) ([]model.Picture, error) {
+	ranked := squirrel.
+		Select("p.id", "row_number() OVER (PARTITION BY p.gallery_id ORDER BY p.id) AS n").
+		From("pictures p").
+		Join("galleries g ON g.id = p.gallery_id").
+		Where(squirrel.Eq{"p.gallery_id": galleryIds}).
+		Where(visibleGalleries(visibility))
+	first := squirrel.Select("id").FromSelect(ranked, "ranked").Where(squirrel.LtOrEq{"n": limit})
+	query, args, err := repo.builder.
+		Select(pictureColumns...).
+		From("pictures").
+		Where(squirrel.Expr("id IN (?)", first)).
+		OrderBy("gallery_id", "id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return repo.queryPictures(ctx, query, args)
+}
+
+func (repo *GalleryRepository) queryPictures(ctx context.Context, query string, args []any) ([]model.Picture, error) {
+	rows, err := reader(ctx, repo.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var pictures []model.Picture
+	for rows.Next() {
+		var picture model.Picture
+		err := rows.Scan(
+			&picture.Id, &picture.GalleryId, &picture.Name, &picture.Path,
+			&picture.Tags, &picture.Width, &picture.Height, &picture.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		pictures = append(pictures, picture)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return pictures, nil
+}
+
+// GetHashedPictures returns the pictures of gallery galleryId that have a
+// perceptual hash, with it.
+func (repo *GalleryRepository) GetHashedPictures(ctx context.Context, galleryId int32) ([]model.Picture, error) {
+	query, args, err := repo.builder.
+		Select(append(pictureColumns, "phash")...).
+		From("pictures").
+		Where(squirrel.Eq{"gallery_id": galleryId}).
+		Where(squirrel.NotEq{"phash": nil}).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := reader(ctx, repo.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var pictures []model.Picture
+	for rows.Next() {
+		var picture model.Picture
+		var hash int64
+		err := rows.Scan(
+			&picture.Id, &picture.GalleryId, &picture.Name, &picture.Path,
+			&picture.Tags, &picture.Width, &picture.Height, &picture.CreatedAt, &hash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		picture.Hash = uint64(hash)
+		pictures = append(pictures, picture)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return pictures, nil
+}
+
+// Search returns a page of the galleries and pictures matching filter, best
+// first, along with the total number of matches. A query without any words
+// matches nothing.
+func (repo *GalleryRepository) Search(ctx context.Context, filter model.SearchFilter) ([]model.SearchHit, int, error) {
+	terms := searchTerms(filter.Query)
+	if terms == "" {
+		return nil, 0, nil
+	}
+
+	visible := visibleGalleries(filter.Visibility)
+
+	galleries, galleryArgs, err := squirrel.
+		Select("'gallery'", "g.id", "g.id", "g.name", "g.name", "g.description", "'{}'::text[]", "ts_rank(g.search, q.query)").
+		From("galleries g").
+		CrossJoin("q").
+		Where("g.search @@ q.query").
+		Where(visible).
+		ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	pictures, pictureArgs, err := squirrel.
+		Select("'picture'", "p.id", "p.gallery_id", "g.name", "p.name", "''", "p.tags", "ts_rank(p.search, q.query)").
+		From("pictures p").
+		Join("galleries g ON g.id = p.gallery_id").
+		CrossJoin("q").
+		Where("p.search @@ q.query").
+		Where(visible).
+		ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	hits := "WITH q AS (SELECT to_tsquery('simple', ?) AS query), " +
+		"hits (kind, id, gallery_id, gallery_name, name, description, tags, rank) AS (" +
+		galleries + " UNION ALL " + pictures + ")"
+	hitsArgs := append(append([]any{terms}, galleryArgs...), pictureArgs...)
+
+	countQuery, countArgs, err := repo.builder.Select("COUNT(*)").Prefix(hits, hitsArgs...).From("hits").ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	query, args, err := repo.builder.
+		Select("kind", "id", "gallery_id", "gallery_name", "name", "description", "tags", "rank").
+		Prefix(hits, hitsArgs...).
+		From("hits").
+		OrderBy("rank DESC", "kind", "id").
+		Limit(uint64(filter.Limit)).
+		Offset(uint64(filter.Offset)).
+		ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	db := reader(ctx, repo.pool)
+	var total int
+	if err := db.QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count search hits: %w", err)
+	}
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var result []model.SearchHit
+	for rows.Next() {
+		var hit model.SearchHit
+		var kind string
+		err := rows.Scan(
+			&kind, &hit.Id, &hit.GalleryId, &hit.GalleryName,
+			&hit.Name, &hit.Description, &hit.Tags, &hit.Rank,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+		hit.Kind = model.SearchKind(kind)
+		result = append(result, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+
+	return result, total, nil
+}
+
+// visibleGalleries is the condition on galleries g that visibility sees.
+func visibleGalleries(visibility model.Visibility) squirrel.Sqlizer {
+	if visibility.All {
+		return squirrel.Expr("TRUE")
+	}
+	visible := squirrel.Or{squirrel.Eq{"g.is_public": true}}
+	if visibility.ViewerId != nil {
+		visible = append(visible, squirrel.Eq{"g.owner_id": *visibility.ViewerId})
+	}
+	return visible
+}
+
+// searchTerms turns query into a tsquery matched by text having a word
+// starting with each word of query. Words are runs of letters and digits,
+// so nothing in query is taken for tsquery syntax.
+func searchTerms(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+func (repo *GalleryRepository) CreateGalleryImport(ctx context.Context, galleryImport *model.GalleryImport) error {
+	query, args, err := repo.builder.
+		Insert("gallery_imports").
+		Columns("job_id", "owner_id", "archive_key").
+		Values(galleryImport.JobId, galleryImport.OwnerId, galleryImport.ArchiveKey).
+		Suffix("RETURNING created_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, args...).Scan(&galleryImport.CreatedAt); err != nil {
+			return fmt.Errorf("failed to create gallery import: %w", err)
+		}
+		return nil
+	})
+}
+
+func (repo *GalleryRepository) GetGalleryImport(ctx context.Context, jobId int64) (*model.GalleryImport, error) {
+	query, args, err := repo.builder.
+		Select(galleryImportColumns...).
+		From("gallery_imports").
+		Where(squirrel.Eq{"job_id": jobId}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var galleryImport model.GalleryImport
+	err = reader(ctx, repo.pool).QueryRow(ctx, query, args...).Scan(
+		&galleryImport.JobId, &galleryImport.OwnerId, &galleryImport.ArchiveKey, &galleryImport.Failures,
+		&galleryImport.GalleryIds, &galleryImport.CreatedAt, &galleryImport.FinishedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("gallery import with id %d: %w", jobId, model.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gallery import: %w", err)
+	}
+
+	return &galleryImport, nil
+}
+
+// FinishGalleryImport stores what the import produced and marks it
+// finished. It fails with model.ErrNotFound unless the import is still
+// unfinished, so it can only finish once.
+func (repo *GalleryRepository) FinishGalleryImport(ctx context.Context, galleryImport *model.GalleryImport) error {
+	failures := galleryImport.Failures
+	if failures == nil {
+		failures = []model.ImportFailure{}
+	}
+	galleryIds := galleryImport.GalleryIds
+	if galleryIds == nil {
+		galleryIds = []int32{}
+	}
+	query, args, err := repo.builder.
+		Update("gallery_imports").
+		Set("failures", failures).
+		Set("gallery_ids", galleryIds).
+		Set("finished_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"job_id": galleryImport.JobId, "finished_at": nil}).
+		Suffix("RETURNING finished_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, args...).Scan(&galleryImport.FinishedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("unfinished gallery import with id %d: %w", galleryImport.JobId, model.ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to finish gallery import: %w", err)
+		}
+		return nil
+	})
+}
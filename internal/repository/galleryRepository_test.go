@@ -0,0 +1,288 @@
+package repository_test
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/repository"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldCreateGalleryWithPictures(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewGalleryRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO galleries \\(owner_id,name,description,is_public\\)").
+		WithArgs(int32(3), "hands", "studies", true).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int32(1), now))
+	mock.ExpectQuery("INSERT INTO pictures \\(gallery_id,name,path,tags,phash,width,height\\)").
+		WithArgs(int32(1), "left", "pictures/1.png", []string{"gesture, quick"}, int64(-1), 32, 24).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int32(10), now))
+	mock.ExpectQuery("INSERT INTO pictures").
+		WithArgs(int32(1), "right", "pictures/2.png", []string{}, int64(0x0f), 16, 16).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int32(11), now))
+	mock.ExpectCommit()
+
+	gallery, err := repo.CreateGallery(context.Background(), &model.Gallery{
+		OwnerId: 3, GalleryName: "hands", Description: "studies", IsPublic: true,
+		Pictures: []model.Picture{
+			{Name: "left", Path: "pictures/1.png", Tags: []string{"gesture, quick"}, Hash: ^uint64(0), Width: 32, Height: 24},
+			{Name: "right", Path: "pictures/2.png", Hash: 0x0f, Width: 16, Height: 16},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), gallery.Id)
+	require.Equal(t, 2, gallery.CurrentSize)
+	require.Equal(t, int32(11), gallery.Pictures[1].Id)
+	require.Equal(t, int32(1), gallery.Pictures[1].GalleryId)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldFinishGalleryImportOnce(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewGalleryRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	failures := []model.ImportFailure{{Path: "notes.txt", Reason: "unsupported file type"}}
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE gallery_imports SET failures = \\$1, gallery_ids = \\$2, finished_at = now\\(\\) WHERE finished_at IS NULL AND job_id = \\$3").
+		WithArgs(failures, []int32{1}, int64(7)).
+		WillReturnRows(pgxmock.NewRows([]string{"finished_at"}).AddRow(&now))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE gallery_imports").
+		WithArgs([]model.ImportFailure{}, []int32{}, int64(7)).
+		WillReturnRows(pgxmock.NewRows([]string{"finished_at"}))
+	mock.ExpectRollback()
+
+	galleryImport := &model.GalleryImport{JobId: 7, Failures: failures, GalleryIds: []int32{1}}
+	require.NoError(t, repo.FinishGalleryImport(context.Background(), galleryImport))
+	require.NotNil(t, galleryImport.FinishedAt)
+
+	err = repo.FinishGalleryImport(context.Background(), &model.GalleryImport{JobId: 7})
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldGetGalleryPictures(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewGalleryRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	rs := pgxmock.
+		NewRows([]string{"id", "gallery_id", "name", "path", "tags", "width", "height", "created_at"}).
+		AddRow(int32(10), int32(1), "left", "pictures/1.png", []string{"anatomy", "hands, left"}, 32, 24, now).
+		AddRow(int32(11), int32(1), "right", "pictures/2.png", []string{}, 16, 16, now)
+	mock.ExpectQuery("SELECT id, gallery_id, name, path, tags, width, height, created_at FROM pictures WHERE gallery_id = \\$1 ORDER BY id").
+		WithArgs(int32(1)).
+		WillReturnRows(rs)
+
+	pictures, err := repo.GetGalleryPictures(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, pictures, 2)
+	require.Equal(t, []string{"anatomy", "hands, left"}, pictures[0].Tags)
+	require.Equal(t, "pictures/2.png", pictures[1].Path)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldGetHashedPictures(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewGalleryRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	rs := pgxmock.
+		NewRows([]string{"id", "gallery_id", "name", "path", "tags", "width", "height", "created_at", "phash"}).
+		AddRow(int32(10), int32(1), "left", "pictures/1.png", []string{}, 32, 24, now, int64(-1)).
+		AddRow(int32(11), int32(1), "right", "pictures/2.png", []string{}, 16, 16, now, int64(0x0f))
+	mock.ExpectQuery("SELECT id, gallery_id, name, path, tags, width, height, created_at, phash FROM pictures WHERE gallery_id = \\$1 AND phash IS NOT NULL ORDER BY id").
+		WithArgs(int32(1)).
+		WillReturnRows(rs)
+
+	pictures, err := repo.GetHashedPictures(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, pictures, 2)
+	require.Equal(t, ^uint64(0), pictures[0].Hash)
+	require.Equal(t, uint64(0x0f), pictures[1].Hash)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldGetFirstGalleriesOfEachOwner(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewGalleryRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	viewer := int32(2)
+	rs := pgxmock.
+		NewRows([]string{"id", "owner_id", "username", "name", "description", "is_public", "created_at", "count"}).
+		AddRow(int32(1), int32(1), "admin", "hands", "", true, now, 2).
+		AddRow(int32(3), int32(2), "ivan", "faces", "", false, now, 0)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT g.id, g.owner_id, u.username, g.name, g.description, g.is_public, g.created_at, "+
+		"(SELECT count(*) FROM pictures p WHERE p.gallery_id = g.id) FROM galleries g JOIN users u ON u.id = g.owner_id "+
+		"WHERE g.id IN (SELECT id FROM (SELECT g.id, row_number() OVER (PARTITION BY g.owner_id ORDER BY g.id) AS n FROM galleries g "+
+		"WHERE g.owner_id IN ($1,$2) AND (g.is_public = $3 OR g.owner_id = $4)) AS ranked WHERE n <= $5) ORDER BY g.owner_id, g.id")).
+		WithArgs(int32(1), int32(2), true, viewer, 5).
+		WillReturnRows(rs)
+
+	galleries, err := repo.GetGalleriesByOwners(context.Background(), []int32{1, 2}, model.Visibility{ViewerId: &viewer}, 5)
+	require.NoError(t, err)
+	require.Len(t, galleries, 2)
+	require.Equal(t, "admin", galleries[0].OwnerName)
+	require.Equal(t, 2, galleries[0].CurrentSize)
+	require.Equal(t, int32(2), galleries[1].OwnerId)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldGetFirstPicturesOfEachGallery(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewGalleryRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	rs := pgxmock.
+		NewRows([]string{"id", "gallery_id", "name", "path", "tags", "width", "height", "created_at"}).
+		AddRow(int32(10), int32(1), "left", "pictures/1.png", []string{"anatomy"}, 32, 24, now).
+		AddRow(int32(20), int32(2), "oak", "pictures/2.png", []string{}, 16, 16, now)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, gallery_id, name, path, tags, width, height, created_at FROM pictures "+
+		"WHERE id IN (SELECT id FROM (SELECT p.id, row_number() OVER (PARTITION BY p.gallery_id ORDER BY p.id) AS n FROM pictures p "+
+		"JOIN galleries g ON g.id = p.gallery_id WHERE p.gallery_id IN ($1,$2) AND TRUE) AS ranked WHERE n <= $3) ORDER BY gallery_id, id")).
+		WithArgs(int32(1), int32(2), 1).
+		WillReturnRows(rs)
+
+	pictures, err := repo.GetPicturesByGalleries(context.Background(), []int32{1, 2}, model.Visibility{All: true}, 1)
+	require.NoError(t, err)
+	require.Len(t, pictures, 2)
+	require.Equal(t, []string{"anatomy"}, pictures[0].Tags)
+	require.Equal(t, int32(2), pictures[1].GalleryId)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldSearchVisibleGalleriesAndPictures(t *testing.T) {
+	viewer := int32(3)
+	tests := []struct {
+		name   string
+		filter model.SearchFilter
+		args   []any
+	}{
+		{
+			name:   "anonymous",
+			filter: model.SearchFilter{Query: "hand-stu", Limit: 10},
+			args:   []any{"hand:* & stu:*", true, true},
+		},
+		{
+			name:   "owner",
+			filter: model.SearchFilter{Query: "hand-stu", Visibility: model.Visibility{ViewerId: &viewer}, Limit: 10},
+			args:   []any{"hand:* & stu:*", true, viewer, true, viewer},
+		},
+		{
+			name:   "moderator",
+			filter: model.SearchFilter{Query: "hand-stu", Visibility: model.Visibility{ViewerId: &viewer, All: true}, Limit: 10},
+			args:   []any{"hand:* & stu:*"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer mock.Close()
+			repo, err := repository.NewGalleryRepository(mock, &logger.MyLogger{})
+			require.NoError(t, err)
+
+			mock.ExpectQuery("WITH q AS \\(SELECT to_tsquery\\('simple', \\$1\\) AS query\\), hits .* UNION ALL .* SELECT COUNT\\(\\*\\) FROM hits").
+				WithArgs(tt.args...).
+				WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(2))
+			rs := pgxmock.
+				NewRows([]string{"kind", "id", "gallery_id", "gallery_name", "name", "description", "tags", "rank"}).
+				AddRow("gallery", int32(1), int32(1), "hand studies", "hand studies", "", []string{}, float32(0.6)).
+				AddRow("picture", int32(10), int32(1), "hand studies", "left", "", []string{"hand-study"}, float32(0.3))
+			mock.ExpectQuery("SELECT kind, id, gallery_id, gallery_name, name, description, tags, rank FROM hits ORDER BY rank DESC, kind, id LIMIT 10 OFFSET 0").
+				WithArgs(tt.args...).
+				WillReturnRows(rs)
+
+			hits, total, err := repo.Search(context.Background(), tt.filter)
+			require.NoError(t, err)
+			require.Equal(t, 2, total)
+			require.Len(t, hits, 2)
+			require.Equal(t, model.SearchPicture, hits[1].Kind)
+			require.Equal(t, []string{"hand-study"}, hits[1].Tags)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestShouldNotSearchWithoutWords(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewGalleryRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	hits, total, err := repo.Search(context.Background(), model.SearchFilter{Query: " :*&|! ", Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, hits)
+	require.Zero(t, total)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var jobColumns = []string{
+	"id", "type", "payload", "status", "attempts", "max_attempts",
+	"progress", "last_error", "run_at", "created_at", "updated_at", "claim_token",
+}
+
+type JobRepository struct {
+	pool    PgxIface
+	builder squirrel.StatementBuilderType
+	logger  *logger.MyLogger
+}
+
+func NewJobRepository(pool PgxIface, logger *logger.MyLogger) (*JobRepository, error) {
+	if pool == nil {
+		return nil, errors.New("nil values in JobRepository constructor")
+	}
+
+	return &JobRepository{
+		pool:    pool,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		logger:  logger,
+	}, nil
+}
+
+// CreateJob returns ErrAlreadyExists for a job whose UniqueKey is taken.
+func (repo *JobRepository) CreateJob(ctx context.Context, job *model.Job) (*model.Job, error) {
+	insert := repo.builder.
+		Insert("jobs").
+		Columns("type", "payload", "max_attempts", "run_at").
+		Values(job.Type, job.Payload, job.MaxAttempts, job.RunAt).
+		Suffix("RETURNING " + strings.Join(jobColumns, ", "))
+	if job.UniqueKey != "" {
+		insert = repo.builder.
+			Insert("jobs").
+			Columns("type", "payload", "max_attempts", "run_at", "unique_key").
+			Values(job.Type, job.Payload, job.MaxAttempts, job.RunAt, job.UniqueKey).
+			Suffix("ON CONFLICT (unique_key) DO NOTHING RETURNING " + strings.Join(jobColumns, ", "))
+	}
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var created *model.Job
+	err = inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		created, err = scanJob(tx.QueryRow(ctx, query, args...))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("job %s: %w", job.UniqueKey, model.ErrAlreadyExists)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create job: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (repo *JobRepository) GetJobById(ctx context.Context, id int64) (*model.Job, error) {
+	query, args, err := repo.builder.
+		Select(jobColumns...).
+		From("jobs").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	job, err := scanJob(reader(ctx, repo.pool).QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("job with id %d: %w", id, model.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ClaimJob marks the oldest due job of one of the given types as running and
+// returns it with a new ClaimToken. Rows locked by other workers are
+// skipped, so several workers and replicas can poll the table concurrently.
+// When no job is due it returns nil without an error.
+func (repo *JobRepository) ClaimJob(ctx context.Context, types []string) (*model.Job, error) {
+	next := squirrel.
+		Select("id").
+		From("jobs").
+		Where(squirrel.Eq{"status": model.JobQueued, "type": types}).
+		Where("run_at <= now()").
+		OrderBy("run_at", "id").
+		Limit(1).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	query, args, err := repo.builder.
+		Update("jobs").
+		Set("status", model.JobRunning).
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("updated_at", squirrel.Expr("now()")).
+		Set("claim_token", squirrel.Expr("gen_random_uuid()::text")).
+		Where(squirrel.Expr("id = (?)", next)).
+		Suffix("RETURNING " + strings.Join(jobColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var job *model.Job
+	err = inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		job, err = scanJob(tx.QueryRow(ctx, query, args...))
+		if errors.Is(err, pgx.ErrNoRows) {
+			job = nil
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to claim job: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// CompleteJob and the other updates of a claimed job only apply while job is
+// still running under the claim it was returned with. A worker whose job was
+// requeued as stale, and maybe claimed by another one since, gets an error
+// instead of overwriting the new attempt.
+func (repo *JobRepository) CompleteJob(ctx context.Context, job *model.Job) error {
+	return repo.updateJob(ctx, job, map[string]any{
+		"status":   model.JobSucceeded,
+		"progress": 100,
+	})
+}
+
+func (repo *JobRepository) RetryJob(ctx context.Context, job *model.Job, runAt time.Time, lastError string) error {
+	return repo.updateJob(ctx, job, map[string]any{
+		"status":     model.JobQueued,
+		"run_at":     runAt,
+		"last_error": lastError,
+	})
+}
+
+func (repo *JobRepository) BuryJob(ctx context.Context, job *model.Job, lastError string) error {
+	return repo.updateJob(ctx, job, map[string]any{
+		"status":     model.JobDead,
+		"last_error": lastError,
+	})
+}
+
+// ReleaseJob puts back a job that was interrupted before it could finish,
+// without counting the attempt.
+func (repo *JobRepository) ReleaseJob(ctx context.Context, job *model.Job, lastError string) error {
+	return repo.updateJob(ctx, job, map[string]any{
+		"status":     model.JobQueued,
+		"attempts":   squirrel.Expr("GREATEST(attempts - 1, 0)"),
+		"run_at":     squirrel.Expr("now()"),
+		"last_error": lastError,
+	})
+}
+
+// HeartbeatJob marks a running job as still being worked on, so
+// RequeueStaleJobs leaves it alone.
+func (repo *JobRepository) HeartbeatJob(ctx context.Context, job *model.Job) error {
+	return repo.updateJob(ctx, job, map[string]any{})
+}
+
+func (repo *JobRepository) UpdateJobProgress(ctx context.Context, job *model.Job, progress int32) error {
+	return repo.updateJob(ctx, job, map[string]any{
+		"progress": progress,
+	})
+}
+
+// RequeueStaleJobs puts back jobs that have been running without any update
+// or heartbeat for longer than staleAfter, which happens when a worker dies
+// mid-job. Jobs that have used up their attempts are buried instead. The
+// cutoff is taken from the database clock, so replicas with skewed clocks
+// agree on what is stale.
+func (repo *JobRepository) RequeueStaleJobs(ctx context.Context, staleAfter time.Duration) (requeued, buried int64, err error) {
+	stale := squirrel.And{
+		squirrel.Eq{"status": model.JobRunning},
+		squirrel.Expr("updated_at < now() - make_interval(secs => ?)", staleAfter.Seconds()),
+	}
+	buryQuery, buryArgs, err := repo.builder.
+		Update("jobs").
+		Set("status", model.JobDead).
+		Set("last_error", "worker stopped responding").
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(stale).
+		Where("attempts >= max_attempts").
+		ToSql()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to build query: %w", err)
+	}
+	requeueQuery, requeueArgs, err := repo.builder.
+		Update("jobs").
+		Set("status", model.JobQueued).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(stale).
+		Where("attempts < max_attempts").
+		ToSql()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	err = inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, buryQuery, buryArgs...)
+		if err != nil {
+			return fmt.Errorf("failed to bury stale jobs: %w", err)
+		}
+		buried = result.RowsAffected()
+
+		result, err = tx.Exec(ctx, requeueQuery, requeueArgs...)
+		if err != nil {
+			return fmt.Errorf("failed to requeue stale jobs: %w", err)
+		}
+		requeued = result.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return requeued, buried, nil
+}
+
+func (repo *JobRepository) updateJob(ctx context.Context, job *model.Job, values map[string]any) error {
+	query, args, err := repo.builder.
+		Update("jobs").
+		SetMap(values).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": job.Id, "status": model.JobRunning, "claim_token": job.ClaimToken}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to update job: %w", err)
+		}
+
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("running job with id %d and this claim: %w", job.Id, model.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+func scanJob(row pgx.Row) (*model.Job, error) {
+	var job model.Job
+	var status string
+	err := row.Scan(
+		&job.Id,
+		&job.Type,
+		&job.Payload,
+		&status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.Progress,
+		&job.LastError,
+		&job.RunAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.ClaimToken,
+	)
+	if err != nil {
+		return nil, err
+	}
+	job.Status = model.JobStatus(status)
+
+	return &job, nil
+}
@@ -0,0 +1,201 @@
+package repository_test
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+var jobColumns = []string{
+	"id", "type", "payload", "status", "attempts", "max_attempts",
+	"progress", "last_error", "run_at", "created_at", "updated_at", "claim_token",
+}
+
+func TestShouldCreateJob(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewJobRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	payload := []byte(`{"gallery_id":1}`)
+	rs := pgxmock.
+		NewRows(jobColumns).
+		AddRow(int64(7), "import", payload, "queued", int32(0), int32(5), int32(0), "", now, now, now, "")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO jobs \\(type,payload,max_attempts,run_at\\)").
+		WithArgs("import", payload, int32(5), now).
+		WillReturnRows(rs)
+	mock.ExpectCommit()
+
+	job, err := repo.CreateJob(context.Background(), &model.Job{
+		Type:        "import",
+		Payload:     payload,
+		MaxAttempts: 5,
+		RunAt:       now,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), job.Id)
+	require.Equal(t, model.JobQueued, job.Status)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldClaimJob(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewJobRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	claimQuery := "UPDATE jobs SET status = \\$1, attempts = attempts \\+ 1, updated_at = now\\(\\), claim_token = gen_random_uuid\\(\\)::text " +
+		"WHERE id = \\(SELECT id FROM jobs WHERE status = \\$2 AND type IN \\(\\$3,\\$4\\) AND run_at <= now\\(\\) " +
+		"ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED\\) RETURNING"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(claimQuery).
+		WithArgs(model.JobRunning, model.JobQueued, "export", "import").
+		WillReturnRows(pgxmock.NewRows(jobColumns).
+			AddRow(int64(7), "import", []byte(`{}`), "running", int32(1), int32(5), int32(0), "", now, now, now, "token"))
+	mock.ExpectCommit()
+
+	job, err := repo.ClaimJob(context.Background(), []string{"export", "import"})
+	require.NoError(t, err)
+	require.Equal(t, model.JobRunning, job.Status)
+	require.Equal(t, int32(1), job.Attempts)
+	require.Equal(t, "token", job.ClaimToken)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(claimQuery).
+		WithArgs(model.JobRunning, model.JobQueued, "export", "import").
+		WillReturnRows(pgxmock.NewRows(jobColumns))
+	mock.ExpectCommit()
+
+	job, err = repo.ClaimJob(context.Background(), []string{"export", "import"})
+	require.NoError(t, err)
+	require.Nil(t, job)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldCreateUniqueJobOnce(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	repo, err := repository.NewJobRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	insertQuery := "INSERT INTO jobs \\(type,payload,max_attempts,run_at,unique_key\\) VALUES \\(\\$1,\\$2,\\$3,\\$4,\\$5\\) " +
+		"ON CONFLICT \\(unique_key\\) DO NOTHING RETURNING"
+	job := &model.Job{Type: "purge", Payload: []byte(`{}`), MaxAttempts: 5, RunAt: now, UniqueKey: "purge:1"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(insertQuery).
+		WithArgs("purge", job.Payload, int32(5), now, "purge:1").
+		WillReturnRows(pgxmock.NewRows(jobColumns).
+			AddRow(int64(7), "purge", job.Payload, "queued", int32(0), int32(5), int32(0), "", now, now, now, ""))
+	mock.ExpectCommit()
+
+	created, err := repo.CreateJob(context.Background(), job)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), created.Id)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(insertQuery).
+		WithArgs("purge", job.Payload, int32(5), now, "purge:1").
+		WillReturnRows(pgxmock.NewRows(jobColumns))
+	mock.ExpectRollback()
+
+	_, err = repo.CreateJob(context.Background(), job)
+	require.ErrorIs(t, err, model.ErrAlreadyExists)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShouldReleaseJobWithoutCountingTheAttempt(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	repo, err := repository.NewJobRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+	job := &model.Job{Id: 7, ClaimToken: "token"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE jobs SET attempts = GREATEST\\(attempts - 1, 0\\), last_error = \\$1, run_at = now\\(\\), status = \\$2, updated_at = now\\(\\) "+
+		"WHERE claim_token = \\$3 AND id = \\$4 AND status = \\$5").
+		WithArgs("interrupted by shutdown", model.JobQueued, "token", int64(7), model.JobRunning).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	require.NoError(t, repo.ReleaseJob(context.Background(), job, "interrupted by shutdown"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE jobs SET updated_at = now\\(\\) WHERE claim_token = \\$1 AND id = \\$2 AND status = \\$3").
+		WithArgs("token", int64(7), model.JobRunning).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	require.NoError(t, repo.HeartbeatJob(context.Background(), job))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShouldRejectUpdatesFromStaleClaims(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	repo, err := repository.NewJobRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE jobs SET progress = \\$1, status = \\$2, updated_at = now\\(\\) WHERE claim_token = \\$3 AND id = \\$4 AND status = \\$5").
+		WithArgs(100, model.JobSucceeded, "old", int64(7), model.JobRunning).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectRollback()
+
+	err = repo.CompleteJob(context.Background(), &model.Job{Id: 7, ClaimToken: "old"})
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShouldRequeueOrBuryStaleJobs(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	repo, err := repository.NewJobRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	stale := "status = \\$\\d AND updated_at < now\\(\\) - make_interval\\(secs => \\$\\d\\)"
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE jobs SET status = \\$1, last_error = \\$2, updated_at = now\\(\\) WHERE \\("+stale+"\\) AND attempts >= max_attempts").
+		WithArgs(model.JobDead, "worker stopped responding", model.JobRunning, float64(90)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("UPDATE jobs SET status = \\$1, updated_at = now\\(\\) WHERE \\("+stale+"\\) AND attempts < max_attempts").
+		WithArgs(model.JobQueued, model.JobRunning, float64(90)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mock.ExpectCommit()
+
+	requeued, buried, err := repo.RequeueStaleJobs(context.Background(), 90*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), requeued)
+	require.Equal(t, int64(1), buried)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
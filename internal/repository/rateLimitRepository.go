@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/consistency"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// RateLimitRepository is a ratelimit.Store shared by every replica using the
+// database. Rows are locked while they are updated, and the database clock is
+// used so replicas with skewed clocks agree.
+type RateLimitRepository struct {
+	pool    PgxIface
+	builder squirrel.StatementBuilderType
+	logger  *logger.MyLogger
+}
+
+func NewRateLimitRepository(pool PgxIface, logger *logger.MyLogger) (*RateLimitRepository, error) {
+	if pool == nil {
+		return nil, errors.New("nil values in RateLimitRepository constructor")
+	}
+
+	return &RateLimitRepository{
+		pool:    pool,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		logger:  logger,
+	}, nil
+}
+
+func (repo *RateLimitRepository) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	insert, insertArgs, err := repo.builder.
+		Insert("rate_limit_buckets").
+		Columns("key", "tokens", "updated_at").
+		Values(key, float64(limit.Requests), squirrel.Expr("now()")).
+		Suffix("ON CONFLICT (key) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("failed to build query: %w", err)
+	}
+	query, args, err := repo.builder.
+		Select("tokens", "updated_at", "now()").
+		From("rate_limit_buckets").
+		Where(squirrel.Eq{"key": key}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var result ratelimit.Result
+	err = inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, insert, insertArgs...); err != nil {
+			return fmt.Errorf("failed to create bucket: %w", err)
+		}
+
+		var tokens float64
+		var updatedAt, now time.Time
+		if err := tx.QueryRow(ctx, query, args...).Scan(&tokens, &updatedAt, &now); err != nil {
+			return fmt.Errorf("failed to get bucket: %w", err)
+		}
+
+		tokens, result = ratelimit.TakeToken(tokens, now.Sub(updatedAt), limit)
+
+		update, updateArgs, err := repo.builder.
+			Update("rate_limit_buckets").
+			Set("tokens", tokens).
+			Set("updated_at", now).
+			Where(squirrel.Eq{"key": key}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build query: %w", err)
+		}
+		if _, err := tx.Exec(ctx, update, updateArgs...); err != nil {
+			return fmt.Errorf("failed to update bucket: %w", err)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+func (repo *RateLimitRepository) GetLock(ctx context.Context, key string) (ratelimit.Lock, error) {
+	query, args, err := repo.builder.
+		Select("failures", "last_failed_at", "locked_until").
+		From("login_failures").
+		Where(squirrel.Eq{"key": key}).
+		ToSql()
+	if err != nil {
+		return ratelimit.Lock{}, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	// A lockout must hold on every replica the moment it is recorded.
+	ctx = consistency.Primary(ctx)
+	lock, err := scanLock(reader(ctx, repo.pool).QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ratelimit.Lock{}, nil
+	}
+	if err != nil {
+		return ratelimit.Lock{}, fmt.Errorf("failed to get lock: %w", err)
+	}
+
+	return lock, nil
+}
+
+func (repo *RateLimitRepository) Fail(ctx context.Context, key string, lockout ratelimit.Lockout) (ratelimit.Lock, error) {
+	insert, insertArgs, err := repo.builder.
+		Insert("login_failures").
+		Columns("key", "last_failed_at").
+		Values(key, squirrel.Expr("now()")).
+		Suffix("ON CONFLICT (key) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return ratelimit.Lock{}, fmt.Errorf("failed to build query: %w", err)
+	}
+	query, args, err := repo.builder.
+		Select("failures", "last_failed_at", "locked_until", "now()").
+		From("login_failures").
+		Where(squirrel.Eq{"key": key}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return ratelimit.Lock{}, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var lock ratelimit.Lock
+	err = inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, insert, insertArgs...); err != nil {
+			return fmt.Errorf("failed to create lock: %w", err)
+		}
+
+		var now time.Time
+		var until *time.Time
+		err := tx.QueryRow(ctx, query, args...).Scan(&lock.Failures, &lock.LastFailedAt, &until, &now)
+		if err != nil {
+			return fmt.Errorf("failed to get lock: %w", err)
+		}
+		if until != nil {
+			lock.Until = *until
+		}
+
+		lock = lockout.Fail(lock, now)
+
+		update, updateArgs, err := repo.builder.
+			Update("login_failures").
+			Set("failures", lock.Failures).
+			Set("last_failed_at", lock.LastFailedAt).
+			Set("locked_until", nullTime(lock.Until)).
+			Where(squirrel.Eq{"key": key}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build query: %w", err)
+		}
+		if _, err := tx.Exec(ctx, update, updateArgs...); err != nil {
+			return fmt.Errorf("failed to update lock: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return ratelimit.Lock{}, err
+	}
+
+	return lock, nil
+}
+
+func (repo *RateLimitRepository) ResetLock(ctx context.Context, key string) error {
+	query, args, err := repo.builder.
+		Delete("login_failures").
+		Where(squirrel.Eq{"key": key}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to reset lock: %w", err)
+		}
+		return nil
+	})
+}
+
+// PruneRateLimits removes buckets and failed logins not touched since
+// before, which behave the same as missing ones by then.
+func (repo *RateLimitRepository) PruneRateLimits(ctx context.Context, before time.Time) error {
+	buckets, bucketArgs, err := repo.builder.
+		Delete("rate_limit_buckets").
+		Where(squirrel.Lt{"updated_at": before}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	failures, failureArgs, err := repo.builder.
+		Delete("login_failures").
+		Where(squirrel.Lt{"last_failed_at": before}).
+		Where(squirrel.Or{squirrel.Eq{"locked_until": nil}, squirrel.Lt{"locked_until": before}}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, buckets, bucketArgs...); err != nil {
+			return fmt.Errorf("failed to prune buckets: %w", err)
+		}
+		if _, err := tx.Exec(ctx, failures, failureArgs...); err != nil {
+			return fmt.Errorf("failed to prune failed logins: %w", err)
+		}
+		return nil
+	})
+}
+
+func scanLock(row pgx.Row) (ratelimit.Lock, error) {
+	var lock ratelimit.Lock
+	var until *time.Time
+	if err := row.Scan(&lock.Failures, &lock.LastFailedAt, &until); err != nil {
+		return ratelimit.Lock{}, err
+	}
+	if until != nil {
+		lock.Until = *until
+	}
+
+	return lock, nil
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
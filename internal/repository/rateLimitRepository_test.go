@@ -0,0 +1,82 @@
+package repository_test
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"ivanjabrony/refstudy/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldTakeRateLimitToken(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewRateLimitRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	limit := ratelimit.Limit{Requests: 10, Per: 10 * time.Second}
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO rate_limit_buckets \\(key,tokens,updated_at\\) VALUES \\(\\$1,\\$2,now\\(\\)\\) ON CONFLICT \\(key\\) DO NOTHING").
+		WithArgs("ip:1", float64(10)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	mock.ExpectQuery("SELECT tokens, updated_at, now\\(\\) FROM rate_limit_buckets WHERE key = \\$1 FOR UPDATE").
+		WithArgs("ip:1").
+		WillReturnRows(pgxmock.NewRows([]string{"tokens", "updated_at", "now"}).AddRow(0.5, now.Add(-time.Second), now))
+	mock.ExpectExec("UPDATE rate_limit_buckets SET tokens = \\$1, updated_at = \\$2 WHERE key = \\$3").
+		WithArgs(0.5, now, "ip:1").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	result, err := repo.Take(context.Background(), "ip:1", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldRecordLoginFailure(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewRateLimitRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	lockout := ratelimit.Lockout{Threshold: 2, Base: time.Minute, Max: time.Hour, Window: time.Hour}
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO login_failures").
+		WithArgs("login:ivan").
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	mock.ExpectQuery("SELECT failures, last_failed_at, locked_until, now\\(\\) FROM login_failures WHERE key = \\$1 FOR UPDATE").
+		WithArgs("login:ivan").
+		WillReturnRows(pgxmock.NewRows([]string{"failures", "last_failed_at", "locked_until", "now"}).AddRow(1, now.Add(-time.Minute), nil, now))
+	until := now.Add(time.Minute)
+	mock.ExpectExec("UPDATE login_failures SET failures = \\$1, last_failed_at = \\$2, locked_until = \\$3 WHERE key = \\$4").
+		WithArgs(2, now, &until, "login:ivan").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	lock, err := repo.Fail(context.Background(), "login:ivan", lockout)
+	require.NoError(t, err)
+	require.Equal(t, 2, lock.Failures)
+	require.Equal(t, until, lock.Until)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
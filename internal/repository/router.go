@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"ivanjabrony/refstudy/internal/consistency"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Router sends transactions to the primary and reads outside of them to a
+// replica. Once a request has committed a transaction, its reads go to the
+// primary too, so it never misses its own writes while the replica catches
+// up.
+type Router struct {
+	primary PgxIface
+	replica PgxIface
+}
+
+func NewRouter(primary, replica PgxIface) (*Router, error) {
+	if primary == nil || replica == nil {
+		return nil, errors.New("nil values in Router constructor")
+	}
+
+	return &Router{primary: primary, replica: replica}, nil
+}
+
+func (r *Router) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := r.primary.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &routedTx{Tx: tx, ctx: ctx}, nil
+}
+
+func (r *Router) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return r.pick(ctx).Query(ctx, sql, args...)
+}
+
+func (r *Router) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return r.pick(ctx).QueryRow(ctx, sql, args...)
+}
+
+func (r *Router) Close() {
+	r.replica.Close()
+	r.primary.Close()
+}
+
+func (r *Router) pick(ctx context.Context) PgxIface {
+	if consistency.ReplicaSafe(ctx) {
+		return r.replica
+	}
+	return r.primary
+}
+
+// routedTx marks the request it was begun in as written once it commits.
+type routedTx struct {
+	pgx.Tx
+	ctx context.Context
+}
+
+func (tx *routedTx) Commit(ctx context.Context) error {
+	if err := tx.Tx.Commit(ctx); err != nil {
+		return err
+	}
+	consistency.MarkWritten(tx.ctx)
+	return nil
+}
@@ -0,0 +1,94 @@
+package repository_test
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/consistency"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/repository"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func userRows(username string) *pgxmock.Rows {
+	return pgxmock.
+		NewRows([]string{"id", "username", "email", "password", "role", "disabled", "email_verified"}).
+		AddRow(int32(1), username, "123@example.com", "12345678", "user", false, true)
+}
+
+func TestRouterReadsYourWrites(t *testing.T) {
+	primary, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	replica, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	router, err := repository.NewRouter(primary, replica)
+	require.NoError(t, err)
+	users, err := repository.NewUserRepository(router, &logger.MyLogger{})
+	require.NoError(t, err)
+	tx, err := repository.NewTransactor(router)
+	require.NoError(t, err)
+
+	const selectUser = "SELECT id, username, email, password, role, disabled, email_verified FROM users WHERE id = \\$1"
+	replica.ExpectQuery(selectUser).WithArgs(int32(1)).WillReturnRows(userRows("stale"))
+	primary.ExpectBegin()
+	primary.ExpectExec("UPDATE users SET disabled = \\$1").
+		WithArgs(true, int32(1)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	primary.ExpectCommit()
+	primary.ExpectQuery(selectUser).WithArgs(int32(1)).WillReturnRows(userRows("fresh"))
+	primary.ExpectQuery(selectUser).WithArgs(int32(1)).WillReturnRows(userRows("fresh"))
+	replica.ExpectClose()
+	primary.ExpectClose()
+
+	ctx := consistency.WithSession(context.Background())
+	user, err := users.GetUserById(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "stale", user.Username)
+
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		return users.SetUserDisabled(ctx, 1, true)
+	})
+	require.NoError(t, err)
+
+	// After the write the session reads from the primary.
+	user, err = users.GetUserById(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "fresh", user.Username)
+
+	// Outside of a session reads always go to the primary.
+	_, err = users.GetUserById(context.Background(), 1)
+	require.NoError(t, err)
+
+	router.Close()
+	require.NoError(t, primary.ExpectationsWereMet())
+	require.NoError(t, replica.ExpectationsWereMet())
+}
+
+func TestRouterReadsCredentialsFromPrimary(t *testing.T) {
+	primary, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	replica, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	router, err := repository.NewRouter(primary, replica)
+	require.NoError(t, err)
+	users, err := repository.NewUserRepository(router, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	const selectUser = "SELECT id, username, email, password, role, disabled, email_verified FROM users WHERE username = \\$1"
+	replica.ExpectQuery(selectUser).WithArgs("ivan").WillReturnRows(userRows("ivan"))
+	primary.ExpectQuery(selectUser).WithArgs("ivan").WillReturnRows(userRows("ivan"))
+	replica.ExpectClose()
+	primary.ExpectClose()
+
+	// A fresh session may read profiles from the replica, but never credentials.
+	ctx := consistency.WithSession(context.Background())
+	_, err = users.GetUserByUsername(ctx, "ivan")
+	require.NoError(t, err)
+	_, err = users.GetUserCredentials(ctx, "ivan")
+	require.NoError(t, err)
+
+	router.Close()
+	require.NoError(t, primary.ExpectationsWereMet())
+	require.NoError(t, replica.ExpectationsWereMet())
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+type TokenRepository struct {
+	pool    PgxIface
+	builder squirrel.StatementBuilderType
+	logger  *logger.MyLogger
+}
+
+func NewTokenRepository(pool PgxIface, logger *logger.MyLogger) (*TokenRepository, error) {
+	if pool == nil {
+		return nil, errors.New("nil values in TokenRepository constructor")
+	}
+
+	return &TokenRepository{
+		pool:    pool,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		logger:  logger,
+	}, nil
+}
+
+func (repo *TokenRepository) CreateUserToken(ctx context.Context, token *model.UserToken) error {
+	query, args, err := repo.builder.
+		Insert("user_tokens").
+		Columns("id", "user_id", "purpose", "expires_at").
+		Values(token.Id, token.UserId, string(token.Purpose), token.ExpiresAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to create token: %w", err)
+		}
+		return nil
+	})
+}
+
+// ConsumeUserToken marks token id as used. Any outstanding token of the user
+// works, and using one uses up all the others with the same purpose, so a
+// link can't be followed twice even through an older email. It fails with
+// model.ErrInvalidToken if id is unknown, already used or expired.
+func (repo *TokenRepository) ConsumeUserToken(ctx context.Context, id string, userId int32, purpose model.TokenPurpose) error {
+	query, args, err := repo.builder.
+		Update("user_tokens").
+		Set("used_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"user_id": userId, "purpose": string(purpose), "used_at": nil}).
+		Suffix("RETURNING id, expires_at > now()").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to consume token: %w", err)
+		}
+		defer rows.Close()
+
+		found := false
+		for rows.Next() {
+			var used string
+			var valid bool
+			if err := rows.Scan(&used, &valid); err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			if used == id && valid {
+				found = true
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("rows error: %w", err)
+		}
+
+		if !found {
+			return model.ErrInvalidToken
+		}
+		return nil
+	})
+}
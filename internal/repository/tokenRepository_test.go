@@ -0,0 +1,64 @@
+package repository_test
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/repository"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldConsumeUserToken(t *testing.T) {
+	testcases := []struct {
+		name string
+		rows *pgxmock.Rows
+		err  error
+	}{
+		{
+			name: "valid",
+			rows: pgxmock.NewRows([]string{"id", "valid"}).AddRow("old", true).AddRow("abc", true),
+		},
+		{
+			name: "expired",
+			rows: pgxmock.NewRows([]string{"id", "valid"}).AddRow("abc", false),
+			err:  model.ErrInvalidToken,
+		},
+		{
+			name: "used",
+			rows: pgxmock.NewRows([]string{"id", "valid"}),
+			err:  model.ErrInvalidToken,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer mock.Close()
+			repo, err := repository.NewTokenRepository(mock, &logger.MyLogger{})
+			require.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("UPDATE user_tokens SET used_at = now\\(\\) WHERE purpose = \\$1 AND used_at IS NULL AND user_id = \\$2 RETURNING id, expires_at > now\\(\\)").
+				WithArgs("reset_password", int32(1)).
+				WillReturnRows(tc.rows)
+			if tc.err == nil {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err = repo.ConsumeUserToken(context.Background(), "abc", 1, model.TokenResetPassword)
+			require.ErrorIs(t, err, tc.err)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
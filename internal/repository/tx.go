@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type txKey struct{}
+
+// Transactor runs several repository calls in one transaction. Repository
+// methods called with the context passed to fn join that transaction instead
+// of starting their own.
+type Transactor struct {
+	pool PgxIface
+}
+
+func NewTransactor(pool PgxIface) (*Transactor, error) {
+	if pool == nil {
+		return nil, errors.New("nil values in Transactor constructor")
+	}
+
+	return &Transactor{pool: pool}, nil
+}
+
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, t.pool, func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+func inTx(ctx context.Context, pool PgxIface, fn func(pgx.Tx) error) (err error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(tx)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			rollbackCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if rbErr := tx.Rollback(rollbackCtx); rbErr != nil {
+				err = fmt.Errorf("rollback failed: %v, original error: %w", rbErr, err)
+			}
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+
+	return nil
+}
+
+// querier runs single statements, in a transaction or straight on a pool.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// reader returns what a read-only query runs on: the transaction in ctx if
+// there is one, otherwise the pool without starting a transaction.
+func reader(ctx context.Context, pool PgxIface) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return pool
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"ivanjabrony/refstudy/internal/consistency"
 	"ivanjabrony/refstudy/internal/logger"
 	"ivanjabrony/refstudy/internal/model"
 	"time"
@@ -14,9 +15,15 @@ import (
 
 type PgxIface interface {
 	Begin(context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	Close()
 }
 
+var userColumns = []string{"id", "username", "email", "password", "role", "disabled", "email_verified"}
+
+var notDeleted = squirrel.Eq{"deleted_at": nil}
+
 type UserRepository struct {
 	pool    PgxIface
 	builder squirrel.StatementBuilderType
@@ -36,21 +43,6 @@ func NewUserRepository(pool PgxIface, logger *logger.MyLogger) (*UserRepository,
 }
 
 func (repo UserRepository) CreateUser(ctx context.Context, user *model.User) (*model.User, error) {
-	tx, err := repo.pool.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-
-	defer func() {
-		if err != nil {
-			rollbackCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			if rbErr := tx.Rollback(rollbackCtx); rbErr != nil {
-				err = fmt.Errorf("rollback failed: %v, original error: %w", rbErr, err)
-			}
-		}
-	}()
-
 	query, args, err := repo.builder.
 		Insert("users").
 		Columns("username", "email", "password").
@@ -61,84 +53,91 @@ func (repo UserRepository) CreateUser(ctx context.Context, user *model.User) (*m
 		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
 
-	err = tx.QueryRow(ctx, query, args...).Scan(&user.Id)
+	err = inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, args...).Scan(&user.Id); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
-	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("commit failed: %w", err)
+		return nil, err
 	}
+	user.Role = model.RoleUser
 
 	return user, nil
 }
 
 func (repo UserRepository) GetUserById(ctx context.Context, id int32) (*model.User, error) {
-	tx, err := repo.pool.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-
-	defer func() {
-		if err != nil {
-			rollbackCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-			defer cancel()
-			if rbErr := tx.Rollback(rollbackCtx); rbErr != nil {
-				err = fmt.Errorf("rollback failed: %v, original error: %w", rbErr, err)
-			}
-		}
-	}()
-
 	query, args, err := repo.builder.
-		Select("id", "username", "email", "password").
+		Select(userColumns...).
 		From("users").
 		Where(squirrel.Eq{"id": id}).
+		Where(notDeleted).
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
 
-	var user model.User
-	err = tx.QueryRow(ctx, query, args...).Scan(&user.Id, &user.Username, &user.Email, &user.Password)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	user, err := scanUser(reader(ctx, repo.pool).QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("user with id %d: %w", id, model.ErrNotFound)
 	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("commit failed: %w", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (repo *UserRepository) GetAllUsers(ctx context.Context) ([]model.User, error) {
-	tx, err := repo.pool.Begin(ctx)
+	query, args, err := repo.builder.
+		Select(userColumns...).
+		From("users").
+		Where(notDeleted).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := reader(ctx, repo.pool).Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
+	defer rows.Close()
 
-	defer func() {
+	var users []model.User
+	for rows.Next() {
+		user, err := scanUser(rows)
 		if err != nil {
-			rollbackCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			if rbErr := tx.Rollback(rollbackCtx); rbErr != nil {
-				err = fmt.Errorf("rollback failed: %v, original error: %w", rbErr, err)
-			}
-		} else {
-			if commitErr := tx.Commit(ctx); commitErr != nil {
-				err = fmt.Errorf("commit failed: %w", commitErr)
-			}
-		}
-	}()
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		users = append(users, *user)
+	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetUsersByIds returns the live users of ids ordered by id, without their
+// password hashes.
+func (repo *UserRepository) GetUsersByIds(ctx context.Context, ids []int32) ([]model.User, error) {
+	columns := selectedUserColumns(nil, "id")
 	query, args, err := repo.builder.
-		Select("id", "username", "email", "password").
+		Select(columns...).
 		From("users").
+		Where(squirrel.Eq{"id": ids}).
+		Where(notDeleted).
+		OrderBy("id").
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
-	rows, err := tx.Query(ctx, query, args...)
+
+	rows, err := reader(ctx, repo.pool).Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -146,16 +145,11 @@ func (repo *UserRepository) GetAllUsers(ctx context.Context) ([]model.User, erro
 
 	var users []model.User
 	for rows.Next() {
-		var user model.User
-		if err := rows.Scan(
-			&user.Id,
-			&user.Username,
-			&user.Email,
-			&user.Password,
-		); err != nil {
+		user, err := scanUserColumns(rows, columns)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
-		users = append(users, user)
+		users = append(users, *user)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -165,88 +159,314 @@ func (repo *UserRepository) GetAllUsers(ctx context.Context) ([]model.User, erro
 	return users, nil
 }
 
-func (repo *UserRepository) UpdateUser(ctx context.Context, user *model.User) error {
-	tx, err := repo.pool.Begin(ctx)
+// userSortColumns are the columns users are sorted by; every one of them is
+// unique among live users together with id.
+var userSortColumns = map[string]string{
+	model.UserSortId:       "id",
+	model.UserSortUsername: "username",
+}
+
+// StreamUsers calls fn with the live users keyset selects, in its order, as
+// the rows are read, so the whole list is never held in memory. An error
+// from fn stops the query and is returned.
+func (repo *UserRepository) StreamUsers(ctx context.Context, keyset model.Keyset, fn func(*model.User) error) error {
+	column, ok := userSortColumns[keyset.Sort]
+	if !ok {
+		return fmt.Errorf("%w: %s", model.ErrInvalidSort, keyset.Sort)
+	}
+
+	order, compare := "ASC", ">"
+	if keyset.Backward {
+		order, compare = "DESC", "<"
+	}
+	columns := selectedUserColumns(keyset.Fields, column)
+	builder := repo.builder.
+		Select(columns...).
+		From("users").
+		Where(notDeleted)
+	switch {
+	case keyset.AfterId == 0:
+	case column == "id":
+		builder = builder.Where("id "+compare+" ?", keyset.AfterId)
+	default:
+		builder = builder.Where("("+column+", id) "+compare+" (?, ?)", keyset.After, keyset.AfterId)
+	}
+	if column != "id" {
+		builder = builder.OrderBy(column + " " + order)
+	}
+	builder = builder.OrderBy("id " + order)
+	if keyset.Limit > 0 {
+		builder = builder.Limit(uint64(keyset.Limit))
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := reader(ctx, repo.pool).Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUserColumns(rows, columns)
+		if err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateUser sets only the columns of the fields update has. Changing the
+// email drops its verification.
+func (repo *UserRepository) UpdateUser(ctx context.Context, update *model.UserUpdate) error {
+	builder := repo.builder.
+		Update("users").
+		Where(squirrel.Eq{"id": update.Id}).
+		Where(notDeleted)
+	changed := false
+	if update.Username != nil {
+		builder = builder.Set("username", *update.Username)
+		changed = true
+	}
+	if update.Email != nil {
+		builder = builder.
+			Set("email", *update.Email).
+			Set("email_verified", squirrel.Expr("email_verified AND email = ?", *update.Email))
+		changed = true
+	}
+	if update.Password != nil {
+		builder = builder.Set("password", *update.Password)
+		changed = true
+	}
+	if !changed {
+		// An empty update still fails for a missing user.
+		builder = builder.Set("id", squirrel.Expr("id"))
+	}
+
+	query, args, err := builder.ToSql()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to build query: %w", err)
 	}
 
-	defer func() {
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query, args...)
 		if err != nil {
-			rollbackCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			if rbErr := tx.Rollback(rollbackCtx); rbErr != nil {
-				err = fmt.Errorf("rollback failed: %v, original error: %w", rbErr, err)
-			}
-		} else {
-			if commitErr := tx.Commit(ctx); commitErr != nil {
-				err = fmt.Errorf("commit failed: %w", commitErr)
-			}
-		}
-	}()
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
 
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("user with id %d: %w", update.Id, model.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+func (repo *UserRepository) DeleteUserById(ctx context.Context, id int32) error {
 	query, args, err := repo.builder.
 		Update("users").
-		Set("username", user.Username).
-		Set("email", user.Email).
-		Set("password", user.Password).
-		Where(squirrel.Eq{"id": user.Id}).
+		Set("deleted_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id}).
+		Where(notDeleted).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("failed to build query: %w", err)
 	}
 
-	result, err := tx.Exec(ctx, query, args...)
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("user with id %d: %w", id, model.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+func (repo *UserRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	return repo.getUserBy(ctx, "username", username)
+}
+
+// GetUserCredentials reads from the primary, so a password or role that was
+// just changed can't be checked against a lagging replica.
+func (repo *UserRepository) GetUserCredentials(ctx context.Context, username string) (*model.User, error) {
+	return repo.getUserBy(consistency.Primary(ctx), "username", username)
+}
+
+func (repo *UserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	return repo.getUserBy(ctx, "email", email)
+}
+
+func (repo *UserRepository) getUserBy(ctx context.Context, column, value string) (*model.User, error) {
+	query, args, err := repo.builder.
+		Select(userColumns...).
+		From("users").
+		Where(squirrel.Eq{column: value}).
+		Where(notDeleted).
+		ToSql()
 	if err != nil {
-		return fmt.Errorf("failed to execute query: %w", err)
+		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
 
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("user with id %d not found", user.Id)
+	user, err := scanUser(reader(ctx, repo.pool).QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("user with %s %s: %w", column, value, model.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	return nil
+	return user, nil
 }
 
-func (repo *UserRepository) DeleteUserById(ctx context.Context, id int32) error {
-	tx, err := repo.pool.Begin(ctx)
+func (repo *UserRepository) SetUserDisabled(ctx context.Context, id int32, disabled bool) error {
+	return repo.setUserColumn(ctx, id, "disabled", disabled)
+}
+
+func (repo *UserRepository) SetUserRole(ctx context.Context, id int32, role model.Role) error {
+	return repo.setUserColumn(ctx, id, "role", string(role))
+}
+
+func (repo *UserRepository) SetUserPassword(ctx context.Context, id int32, password string) error {
+	return repo.setUserColumn(ctx, id, "password", password)
+}
+
+func (repo *UserRepository) SetEmailVerified(ctx context.Context, id int32) error {
+	return repo.setUserColumn(ctx, id, "email_verified", true)
+}
+
+func (repo *UserRepository) setUserColumn(ctx context.Context, id int32, column string, value any) error {
+	query, args, err := repo.builder.
+		Update("users").
+		Set(column, value).
+		Where(squirrel.Eq{"id": id}).
+		Where(notDeleted).
+		ToSql()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to build query: %w", err)
 	}
 
-	defer func() {
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query, args...)
 		if err != nil {
-			rollbackCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			if rbErr := tx.Rollback(rollbackCtx); rbErr != nil {
-				err = fmt.Errorf("rollback failed: %v, original error: %w", rbErr, err)
-			}
-		} else {
-			if commitErr := tx.Commit(ctx); commitErr != nil {
-				err = fmt.Errorf("commit failed: %w", commitErr)
-			}
-		}
-	}()
-
-	query, args, err := squirrel.
-		Delete("users").
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("user with id %d: %w", id, model.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+func (repo *UserRepository) RestoreUser(ctx context.Context, id int32) error {
+	query, args, err := repo.builder.
+		Update("users").
+		Set("deleted_at", nil).
 		Where(squirrel.Eq{"id": id}).
-		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.NotEq{"deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("failed to build query: %w", err)
 	}
 
-	result, err := tx.Exec(ctx, query, args...)
+	return inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to restore user: %w", err)
+		}
+
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("deleted user with id %d: %w", id, model.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+// PurgeDeletedUsers permanently removes users soft-deleted before the given
+// time and returns how many were removed.
+func (repo *UserRepository) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query, args, err := repo.builder.
+		Delete("users").
+		Where(squirrel.Lt{"deleted_at": deletedBefore}).
+		ToSql()
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return 0, fmt.Errorf("failed to build query: %w", err)
 	}
 
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("user with id %d not found", id)
+	var purged int64
+	err = inTx(ctx, repo.pool, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to purge users: %w", err)
+		}
+		purged = result.RowsAffected()
+		return nil
+	})
+
+	return purged, err
+}
+
+// selectedUserColumns returns the columns of fields, which are named after
+// them, along with id and the sort column. Listings never read the password
+// hash.
+func selectedUserColumns(fields model.Fields, sortColumn string) []string {
+	columns := make([]string, 0, len(model.UserFields))
+	for _, column := range model.UserFields {
+		if column == "id" || column == sortColumn || fields.Has(column) {
+			columns = append(columns, column)
+		}
 	}
+	return columns
+}
 
-	return nil
+func scanUser(row pgx.Row) (*model.User, error) {
+	return scanUserColumns(row, userColumns)
+}
+
+// scanUserColumns scans a row of the given user columns; the other fields of
+// the user are left zero.
+func scanUserColumns(row pgx.Row, columns []string) (*model.User, error) {
+	var user model.User
+	var role string
+	dest := make([]any, len(columns))
+	for i, column := range columns {
+		switch column {
+		case "id":
+			dest[i] = &user.Id
+		case "username":
+			dest[i] = &user.Username
+		case "email":
+			dest[i] = &user.Email
+		case "password":
+			dest[i] = &user.Password
+		case "role":
+			dest[i] = &role
+		case "disabled":
+			dest[i] = &user.Disabled
+		case "email_verified":
+			dest[i] = &user.EmailVerified
+		default:
+			return nil, fmt.Errorf("unknown user column %s", column)
+		}
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	user.Role = model.Role(role)
+
+	return &user, nil
 }
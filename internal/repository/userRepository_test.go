@@ -44,12 +44,10 @@ func TestShouldGetUser(t *testing.T) {
 
 	var id int32 = 1
 	rs := pgxmock.
-		NewRows([]string{"id", "username", "email", "password"}).
-		AddRow(id, "ivan", "123@example.com", "12345678")
+		NewRows([]string{"id", "username", "email", "password", "role", "disabled", "email_verified"}).
+		AddRow(id, "ivan", "123@example.com", "12345678", "admin", false, true)
 
-	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT id, username, email, password FROM users WHERE id = \\$1").WithArgs(id).WillReturnRows(rs)
-	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT id, username, email, password, role, disabled, email_verified FROM users WHERE id = \\$1").WithArgs(id).WillReturnRows(rs)
 
 	user, err := repo.GetUserById(context.Background(), id)
 	require.NoError(t, err)
@@ -58,6 +56,38 @@ func TestShouldGetUser(t *testing.T) {
 	require.Equal(t, user.Username, "ivan")
 	require.Equal(t, user.Email, "123@example.com")
 	require.Equal(t, user.Password, "12345678")
+	require.Equal(t, user.Role, model.RoleAdmin)
+	require.False(t, user.Disabled)
+	require.True(t, user.EmailVerified)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldGetLiveUsersByIdsWithoutPasswords(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewUserRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	rs := pgxmock.
+		NewRows([]string{"id", "username", "email", "role", "disabled", "email_verified"}).
+		AddRow(int32(1), "admin", "admin@example.com", "admin", false, true).
+		AddRow(int32(3), "ivan", "ivan@example.com", "user", false, false)
+	mock.ExpectQuery("SELECT id, username, email, role, disabled, email_verified FROM users WHERE id IN \\(\\$1,\\$2,\\$3\\) AND deleted_at IS NULL ORDER BY id").
+		WithArgs(int32(3), int32(1), int32(2)).
+		WillReturnRows(rs)
+
+	users, err := repo.GetUsersByIds(context.Background(), []int32{3, 1, 2})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	require.Equal(t, "admin", users[0].Username)
+	require.Equal(t, model.RoleUser, users[1].Role)
+	require.Empty(t, users[1].Password)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
@@ -99,3 +129,149 @@ func TestShouldCreateUser(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestShouldSoftDeleteUser(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	repo, err := repository.NewUserRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	var id int32 = 1
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET deleted_at = now\\(\\) WHERE id = \\$1 AND deleted_at IS NULL").
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.DeleteUserById(context.Background(), id))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET deleted_at = \\$1 WHERE id = \\$2 AND deleted_at IS NOT NULL").
+		WithArgs(nil, id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectRollback()
+
+	err = repo.RestoreUser(context.Background(), id)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShouldStreamSelectedUserFields(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	repo, err := repository.NewUserRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	rs := pgxmock.
+		NewRows([]string{"id", "username", "email_verified"}).
+		AddRow(int32(3), "anna", true)
+	mock.ExpectQuery("SELECT id, username, email_verified FROM users WHERE deleted_at IS NULL ORDER BY username ASC, id ASC").WillReturnRows(rs)
+
+	var users []model.User
+	keyset := model.Keyset{Sort: model.UserSortUsername, Fields: model.Fields{"email_verified"}}
+	err = repo.StreamUsers(context.Background(), keyset, func(user *model.User) error {
+		users = append(users, *user)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []model.User{{Id: 3, Username: "anna", EmailVerified: true}}, users)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShouldStreamUsersByKeyset(t *testing.T) {
+	testcases := []struct {
+		name   string
+		keyset model.Keyset
+		query  string
+		args   []any
+	}{
+		{
+			name:   "first page",
+			keyset: model.Keyset{Sort: model.UserSortId, Limit: 3},
+			query:  "SELECT id, username, email, role, disabled, email_verified FROM users WHERE deleted_at IS NULL ORDER BY id ASC LIMIT 3",
+		},
+		{
+			name:   "after a username",
+			keyset: model.Keyset{Sort: model.UserSortUsername, After: "ivan", AfterId: 2, Limit: 3},
+			query:  "SELECT (.+) FROM users WHERE deleted_at IS NULL AND \\(username, id\\) > \\(\\$1, \\$2\\) ORDER BY username ASC, id ASC LIMIT 3",
+			args:   []any{"ivan", int32(2)},
+		},
+		{
+			name:   "before an id",
+			keyset: model.Keyset{Sort: model.UserSortId, AfterId: 5, Backward: true},
+			query:  "SELECT (.+) FROM users WHERE deleted_at IS NULL AND id < \\$1 ORDER BY id DESC$",
+			args:   []any{int32(5)},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+			repo, err := repository.NewUserRepository(mock, &logger.MyLogger{})
+			require.NoError(t, err)
+
+			rs := pgxmock.
+				NewRows([]string{"id", "username", "email", "role", "disabled", "email_verified"}).
+				AddRow(int32(3), "anna", "anna@example.com", "user", false, true).
+				AddRow(int32(4), "petr", "petr@example.com", "user", false, true)
+			mock.ExpectQuery(tc.query).WithArgs(tc.args...).WillReturnRows(rs)
+
+			var ids []int32
+			err = repo.StreamUsers(context.Background(), tc.keyset, func(user *model.User) error {
+				ids = append(ids, user.Id)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, []int32{3, 4}, ids)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	repo, err := repository.NewUserRepository(&pgxpool.Pool{}, &logger.MyLogger{})
+	require.NoError(t, err)
+	err = repo.StreamUsers(context.Background(), model.Keyset{Sort: "email"}, func(*model.User) error { return nil })
+	require.ErrorIs(t, err, model.ErrInvalidSort)
+}
+
+func TestShouldUpdateOnlyGivenUserFields(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	repo, err := repository.NewUserRepository(mock, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	var id int32 = 1
+	username, email := "ivan2", "new@example.com"
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET username = \\$1 WHERE id = \\$2 AND deleted_at IS NULL").
+		WithArgs(username, id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	require.NoError(t, repo.UpdateUser(context.Background(), &model.UserUpdate{Id: id, Username: &username}))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET email = \\$1, email_verified = email_verified AND email = \\$2 WHERE id = \\$3 AND deleted_at IS NULL").
+		WithArgs(email, email, id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	require.NoError(t, repo.UpdateUser(context.Background(), &model.UserUpdate{Id: id, Email: &email}))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET id = id WHERE id = \\$1 AND deleted_at IS NULL").
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectRollback()
+	err = repo.UpdateUser(context.Background(), &model.UserUpdate{Id: id})
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
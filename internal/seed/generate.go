@@ -0,0 +1,47 @@
+package seed
+
+import (
+	"fmt"
+	"ivanjabrony/refstudy/internal/model"
+	"math/rand/v2"
+)
+
+var names = []string{
+	"ivan", "petr", "anna", "maria", "oleg", "olga", "sergey", "elena",
+	"dmitry", "irina", "alexey", "natalia", "pavel", "tatiana", "nikita", "daria",
+}
+
+// GeneratedPassword is the password of every generated user.
+const GeneratedPassword = "password"
+
+// Generate describes count users for load testing. The same seed and count
+// always give the same users, so generated fixtures can be loaded again, and
+// a larger count only adds users. About 1% are admins, 5% moderators, 90%
+// have a verified email and 2% are disabled.
+func Generate(seed uint64, count int) *Fixtures {
+	fixtures := &Fixtures{Users: make([]User, count)}
+	for i := range fixtures.Users {
+		// Every user gets its own stream, so user i doesn't depend on how
+		// many came before it.
+		r := rand.New(rand.NewPCG(seed, uint64(i)))
+		username := fmt.Sprintf("%s%06d", names[r.IntN(len(names))], i+1)
+
+		role := model.RoleUser
+		switch n := r.IntN(100); {
+		case n < 1:
+			role = model.RoleAdmin
+		case n < 6:
+			role = model.RoleModerator
+		}
+
+		fixtures.Users[i] = User{
+			Username:      username,
+			Email:         username + "@example.com",
+			Password:      GeneratedPassword,
+			Role:          string(role),
+			EmailVerified: r.IntN(100) < 90,
+			Disabled:      r.IntN(100) < 2,
+		}
+	}
+	return fixtures
+}
@@ -0,0 +1,224 @@
+// Package seed fills a development database with users described by
+// fixtures, either written by hand or generated.
+package seed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"log/slog"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Fixtures struct {
+	Users []User `json:"users" yaml:"users"`
+}
+
+// User is the state a user should end up in. Role defaults to user.
+type User struct {
+	Username      string `json:"username" yaml:"username"`
+	Email         string `json:"email" yaml:"email"`
+	Password      string `json:"password" yaml:"password"`
+	Role          string `json:"role" yaml:"role"`
+	Disabled      bool   `json:"disabled" yaml:"disabled"`
+	EmailVerified bool   `json:"email_verified" yaml:"email_verified"`
+}
+
+// Parse decodes fixtures from YAML or JSON, by the extension of name. Unknown
+// fields are an error so typos don't go unnoticed.
+func Parse(name string, data []byte) (*Fixtures, error) {
+	var fixtures Fixtures
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&fixtures); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&fixtures); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported fixture format %q", filepath.Ext(name))
+	}
+
+	seen := make(map[string]bool, len(fixtures.Users))
+	for i, user := range fixtures.Users {
+		if user.Username == "" || user.Email == "" || user.Password == "" {
+			return nil, fmt.Errorf("user %d in %s: username, email and password are required", i+1, name)
+		}
+		if user.Role != "" && !model.Role(user.Role).Valid() {
+			return nil, fmt.Errorf("user %s in %s: %w: %s", user.Username, name, model.ErrInvalidRole, user.Role)
+		}
+		if seen[user.Username] {
+			return nil, fmt.Errorf("user %s appears twice in %s", user.Username, name)
+		}
+		seen[user.Username] = true
+	}
+	return &fixtures, nil
+}
+
+type UserUsecase interface {
+	CreateUser(context.Context, *dto.CreateUserDto) (*dto.UserDto, error)
+	GetAllUsers(context.Context) ([]dto.UserDto, error)
+	UpdateUser(context.Context, *dto.UpdateUserDto) error
+	DisableUser(context.Context, int32) error
+	EnableUser(context.Context, int32) error
+	SetUserRole(context.Context, int32, model.Role) error
+	Authenticate(context.Context, string, string) (*model.Actor, error)
+}
+
+// EmailVerifier marks emails as verified without the emailed token.
+type EmailVerifier interface {
+	SetEmailVerified(context.Context, int32) error
+}
+
+// Report counts what Load did to each user.
+type Report struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Loader brings users in line with fixtures through the usecases, so they
+// are validated and audited like any other change.
+type Loader struct {
+	users    UserUsecase
+	verifier EmailVerifier
+	logger   *logger.MyLogger
+}
+
+func NewLoader(users UserUsecase, verifier EmailVerifier, logger *logger.MyLogger) (*Loader, error) {
+	if users == nil || verifier == nil || logger == nil {
+		return nil, errors.New("nil values in Loader constructor")
+	}
+	return &Loader{users, verifier, logger}, nil
+}
+
+// Load creates the users missing from the database and updates the ones that
+// differ from their fixture, matching them by username. Loading the same
+// fixtures again changes nothing, and users without a fixture are left
+// alone. A verified email is never unverified.
+func (l *Loader) Load(ctx context.Context, fixtures *Fixtures) (*Report, error) {
+	existing, err := l.users.GetAllUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	byUsername := make(map[string]dto.UserDto, len(existing))
+	for _, user := range existing {
+		byUsername[user.Username] = user
+	}
+
+	report := &Report{}
+	for _, fixture := range fixtures.Users {
+		if fixture.Role == "" {
+			fixture.Role = string(model.RoleUser)
+		}
+
+		current, ok := byUsername[fixture.Username]
+		if !ok {
+			created, err := l.users.CreateUser(ctx, &dto.CreateUserDto{
+				Username: fixture.Username,
+				Email:    fixture.Email,
+				Password: fixture.Password,
+			})
+			if err != nil {
+				return report, fmt.Errorf("failed to create user %s: %w", fixture.Username, err)
+			}
+			current = *created
+			report.Created++
+		}
+
+		changed, err := l.apply(ctx, current, fixture, !ok)
+		if err != nil {
+			return report, fmt.Errorf("failed to update user %s: %w", fixture.Username, err)
+		}
+		if ok && changed {
+			report.Updated++
+		} else if ok {
+			report.Unchanged++
+		}
+	}
+
+	l.logger.Info("loaded fixtures",
+		slog.Int("created", report.Created),
+		slog.Int("updated", report.Updated),
+		slog.Int("unchanged", report.Unchanged))
+	return report, nil
+}
+
+// apply makes the changes current needs to match fixture and reports whether
+// there were any. A user that was just created has the fixture's password
+// already.
+func (l *Loader) apply(ctx context.Context, current dto.UserDto, fixture User, created bool) (bool, error) {
+	changed := false
+	samePassword := created
+	if !created {
+		var err error
+		if samePassword, err = l.passwordMatches(ctx, fixture); err != nil {
+			return changed, err
+		}
+	}
+	if current.Email != fixture.Email || !samePassword {
+		update := &dto.UpdateUserDto{Id: current.Id}
+		if current.Email != fixture.Email {
+			update.Email = &fixture.Email
+		}
+		if !samePassword {
+			update.Password = &fixture.Password
+		}
+		if err := l.users.UpdateUser(ctx, update); err != nil {
+			return changed, err
+		}
+		// A new email has to be verified again.
+		current.EmailVerified = current.EmailVerified && current.Email == fixture.Email
+		changed = true
+	}
+	if current.Role != fixture.Role {
+		if err := l.users.SetUserRole(ctx, current.Id, model.Role(fixture.Role)); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	if current.Disabled != fixture.Disabled {
+		disable := l.users.EnableUser
+		if fixture.Disabled {
+			disable = l.users.DisableUser
+		}
+		if err := disable(ctx, current.Id); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	if fixture.EmailVerified && !current.EmailVerified {
+		if err := l.verifier.SetEmailVerified(ctx, current.Id); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// passwordMatches tells whether fixture's password is the user's. Only the
+// hash is stored, so it is checked the way a login would.
+func (l *Loader) passwordMatches(ctx context.Context, fixture User) (bool, error) {
+	_, err := l.users.Authenticate(ctx, fixture.Username, fixture.Password)
+	switch {
+	case err == nil, errors.Is(err, model.ErrAccountDisabled):
+		return true, nil
+	case errors.Is(err, model.ErrInvalidCredentials):
+		return false, nil
+	default:
+		return false, err
+	}
+}
@@ -0,0 +1,157 @@
+package seed_test
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/password"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/seed"
+	"ivanjabrony/refstudy/internal/testing/fakes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testcases := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{
+			name: "dev.yaml",
+			data: "users:\n  - username: ivan\n    email: ivan@example.com\n    password: secret\n    role: admin\n    email_verified: true\n",
+		},
+		{
+			name: "dev.json",
+			data: `{"users":[{"username":"ivan","email":"ivan@example.com","password":"secret"}]}`,
+		},
+		{
+			name: "typo.yaml",
+			data: "users:\n  - username: ivan\n    emial: ivan@example.com\n",
+			err:  "field emial not found",
+		},
+		{
+			name: "typo.json",
+			data: `{"users":[{"username":"ivan","emial":"ivan@example.com"}]}`,
+			err:  `unknown field "emial"`,
+		},
+		{
+			name: "incomplete.yaml",
+			data: "users:\n  - username: ivan\n",
+			err:  "user 1 in incomplete.yaml: username, email and password are required",
+		},
+		{
+			name: "role.yaml",
+			data: "users:\n  - {username: ivan, email: ivan@example.com, password: secret, role: root}\n",
+			err:  "invalid role",
+		},
+		{
+			name: "twice.yaml",
+			data: "users:\n  - {username: ivan, email: a@example.com, password: secret}\n  - {username: ivan, email: b@example.com, password: secret}\n",
+			err:  "user ivan appears twice in twice.yaml",
+		},
+		{
+			name: "dev.toml",
+			err:  `unsupported fixture format ".toml"`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			fixtures, err := seed.Parse(tc.name, []byte(tc.data))
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, fixtures.Users, 1)
+			require.Equal(t, "ivan@example.com", fixtures.Users[0].Email)
+		})
+	}
+}
+
+func TestParse_DevFixtures(t *testing.T) {
+	data, err := os.ReadFile("../../fixtures/dev.yaml")
+	require.NoError(t, err)
+	fixtures, err := seed.Parse("dev.yaml", data)
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures.Users)
+}
+
+func TestLoader_Load(t *testing.T) {
+	ctx := policy.WithActor(context.Background(), &model.Actor{Role: model.RoleAdmin, EmailVerified: true})
+	repo := fakes.NewUserRepository(model.User{Username: "other", Email: "other@example.com", Password: "secret"})
+	loader, err := seed.NewLoader(fakes.NewUserUsecase(repo), repo, logger.New(logger.Test, logger.LogFormatText))
+	require.NoError(t, err)
+
+	fixtures := &seed.Fixtures{Users: []seed.User{
+		{Username: "ivan", Email: "ivan@example.com", Password: "secret", Role: "admin", EmailVerified: true},
+		{Username: "petr", Email: "petr@example.com", Password: "secret", Disabled: true},
+	}}
+	report, err := loader.Load(ctx, fixtures)
+	require.NoError(t, err)
+	require.Equal(t, &seed.Report{Created: 2}, report)
+
+	ivan, err := repo.GetUserByUsername(ctx, "ivan")
+	require.NoError(t, err)
+	require.Equal(t, model.RoleAdmin, ivan.Role)
+	require.True(t, ivan.EmailVerified)
+	petr, err := repo.GetUserByUsername(ctx, "petr")
+	require.NoError(t, err)
+	require.True(t, petr.Disabled)
+
+	report, err = loader.Load(ctx, fixtures)
+	require.NoError(t, err)
+	require.Equal(t, &seed.Report{Unchanged: 2}, report)
+
+	fixtures.Users[0].Email = "new@example.com"
+	fixtures.Users[1].Disabled = false
+	report, err = loader.Load(ctx, fixtures)
+	require.NoError(t, err)
+	require.Equal(t, &seed.Report{Updated: 2}, report)
+	ivan, err = repo.GetUserByUsername(ctx, "ivan")
+	require.NoError(t, err)
+	require.Equal(t, "new@example.com", ivan.Email)
+	require.True(t, ivan.EmailVerified, "the fixture asks for a verified email")
+
+	fixtures.Users[1].Password = "changed"
+	report, err = loader.Load(ctx, fixtures)
+	require.NoError(t, err)
+	require.Equal(t, &seed.Report{Updated: 1, Unchanged: 1}, report)
+	petr, err = repo.GetUserByUsername(ctx, "petr")
+	require.NoError(t, err)
+	require.True(t, password.Check(petr.Password, "changed"), "passwords are stored hashed")
+
+	users, err := repo.GetAllUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 3, "users without fixtures are left alone")
+}
+
+func TestLoader_LoadUnauthorized(t *testing.T) {
+	repo := fakes.NewUserRepository()
+	loader, err := seed.NewLoader(fakes.NewUserUsecase(repo), repo, logger.New(logger.Test, logger.LogFormatText))
+	require.NoError(t, err)
+
+	_, err = loader.Load(context.Background(), seed.Generate(1, 1))
+	require.ErrorIs(t, err, policy.ErrUnauthenticated)
+}
+
+func TestGenerate(t *testing.T) {
+	fixtures := seed.Generate(42, 1000)
+	require.Equal(t, fixtures, seed.Generate(42, 1000))
+	require.Equal(t, fixtures.Users[:10], seed.Generate(42, 10).Users, "a larger count only adds users")
+	require.NotEqual(t, fixtures, seed.Generate(43, 1000))
+
+	roles := map[string]int{}
+	usernames := map[string]bool{}
+	for _, user := range fixtures.Users {
+		roles[user.Role]++
+		usernames[user.Username] = true
+	}
+	require.Len(t, usernames, 1000)
+	require.Len(t, roles, 3)
+	require.Greater(t, roles["user"], 900)
+}
@@ -0,0 +1,40 @@
+package fakes
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/policy"
+)
+
+// ForgedToken is the only account token AccountUsecase rejects.
+const ForgedToken = "forged"
+
+// AccountUsecase stands in for usecase.AccountUsecase without tokens or
+// emails: it accepts every token but ForgedToken and sends nothing.
+type AccountUsecase struct{}
+
+func (AccountUsecase) RequestEmailVerification(ctx context.Context) error {
+	if policy.ActorFromContext(ctx) == nil {
+		return policy.ErrUnauthenticated
+	}
+	return nil
+}
+
+func (AccountUsecase) VerifyEmail(_ context.Context, token string) error {
+	return checkToken(token)
+}
+
+func (AccountUsecase) ForgotPassword(context.Context, string) error {
+	return nil
+}
+
+func (AccountUsecase) ResetPassword(_ context.Context, token, _ string) error {
+	return checkToken(token)
+}
+
+func checkToken(token string) error {
+	if token == ForgedToken {
+		return model.ErrInvalidToken
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package fakes
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/model"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Transactor runs functions without a transaction, for usecases over
+// in-memory repositories that don't take part in one anyway.
+type Transactor struct{}
+
+func (Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// AuditRepository keeps audit records in memory and reads them like
+// repository.AuditRepository: newest first, filtered and paged.
+type AuditRepository struct {
+	mu      sync.Mutex
+	records []model.AuditRecord
+	now     func() time.Time
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{now: time.Now}
+}
+
+func (r *AuditRepository) CreateAuditRecord(_ context.Context, record *model.AuditRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record.Id = int64(len(r.records) + 1)
+	record.CreatedAt = r.now()
+	r.records = append(r.records, *record)
+	return nil
+}
+
+func (r *AuditRepository) GetAuditRecords(_ context.Context, filter model.AuditFilter) ([]model.AuditRecord, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matching []model.AuditRecord
+	for _, record := range slices.Backward(r.records) {
+		if matches(record, filter) {
+			matching = append(matching, record)
+		}
+	}
+
+	page := matching[min(filter.Offset, len(matching)):]
+	if filter.Limit > 0 {
+		page = page[:min(filter.Limit, len(page))]
+	}
+	return page, len(matching), nil
+}
+
+// Records returns every record written, oldest first.
+func (r *AuditRepository) Records() []model.AuditRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.records)
+}
+
+func matches(record model.AuditRecord, filter model.AuditFilter) bool {
+	switch {
+	case filter.ActorId != nil && (record.ActorId == nil || *record.ActorId != *filter.ActorId):
+		return false
+	case filter.Action != "" && string(record.Action) != filter.Action:
+		return false
+	case filter.ResourceType != "" && record.ResourceType != filter.ResourceType:
+		return false
+	case filter.ResourceId != "" && record.ResourceId != filter.ResourceId:
+		return false
+	case filter.From != nil && record.CreatedAt.Before(*filter.From):
+		return false
+	case filter.To != nil && !record.CreatedAt.Before(*filter.To):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package fakes
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"ivanjabrony/refstudy/internal/blob"
+	"slices"
+	"sync"
+)
+
+// BlobStore keeps blobs in memory for usecases storing pictures.
+type BlobStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func NewBlobStore() *BlobStore {
+	return &BlobStore{blobs: map[string][]byte{}}
+}
+
+func (s *BlobStore) Put(_ context.Context, key string, r io.Reader, _ int64, contentType string) (blob.Object, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return blob.Object{}, fmt.Errorf("failed to upload blob: %w", err)
+	}
+	sum := sha256.Sum256(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = data
+	return blob.Object{Key: key, Size: int64(len(data)), ContentType: contentType, SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+func (s *BlobStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("blob %q not found", key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *BlobStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.blobs, key)
+	return nil
+}
+
+// Keys returns the keys of the stored blobs, sorted.
+func (s *BlobStore) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.blobs))
+	for key := range s.blobs {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,158 @@
+package fakes_test
+
+import (
+	"context"
+	"errors"
+	v1 "ivanjabrony/refstudy/internal/controller/v1"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"ivanjabrony/refstudy/internal/password"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/testing/fakes"
+	"ivanjabrony/refstudy/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ usecase.UserRepository    = (*fakes.UserRepository)(nil)
+	_ usecase.AccountRepository = (*fakes.UserRepository)(nil)
+	_ usecase.AuditRepository   = (*fakes.AuditRepository)(nil)
+	_ usecase.Transactor        = fakes.Transactor{}
+	_ usecase.JobRepository     = (*fakes.JobRepository)(nil)
+	_ usecase.GalleryRepository = (*fakes.GalleryRepository)(nil)
+	_ usecase.BlobStore         = (*fakes.BlobStore)(nil)
+	_ v1.UserUsecase            = (*fakes.UserUsecase)(nil)
+	_ v1.AdminUsecase           = (*fakes.UserUsecase)(nil)
+	_ v1.AccountUsecase         = fakes.AccountUsecase{}
+)
+
+func requireConstraint(t *testing.T, err error, constraint string) {
+	t.Helper()
+
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr), "got %v", err)
+	require.Equal(t, constraint, pgErr.ConstraintName)
+}
+
+func TestUserRepository(t *testing.T) {
+	ctx := context.Background()
+	users := fakes.NewUserRepository(model.User{Id: 5, Username: "admin", Email: "admin@example.com", Role: model.RoleAdmin})
+
+	created, err := users.CreateUser(ctx, &model.User{Username: "ivan", Email: "ivan@example.com", Password: "12345678"})
+	require.NoError(t, err)
+	require.Equal(t, int32(6), created.Id)
+	require.Equal(t, model.RoleUser, created.Role)
+
+	_, err = users.CreateUser(ctx, &model.User{Username: "ivan", Email: "other@example.com"})
+	requireConstraint(t, err, "users_username_live_idx")
+	_, err = users.CreateUser(ctx, &model.User{Username: "other", Email: "ivan@example.com"})
+	requireConstraint(t, err, "users_email_live_idx")
+	taken, username, email := "admin", "ivan2", "new@example.com"
+	requireConstraint(t, users.UpdateUser(ctx, &model.UserUpdate{Id: 6, Username: &taken}), "users_username_live_idx")
+	requireConstraint(t, users.SetUserRole(ctx, 6, "root"), "users_role_check")
+
+	require.NoError(t, users.SetEmailVerified(ctx, 6))
+	require.NoError(t, users.UpdateUser(ctx, &model.UserUpdate{Id: 6, Username: &username}))
+	user, err := users.GetUserByUsername(ctx, "ivan2")
+	require.NoError(t, err)
+	require.True(t, user.EmailVerified)
+	require.Equal(t, "ivan@example.com", user.Email, "fields left out are kept")
+	require.NoError(t, users.UpdateUser(ctx, &model.UserUpdate{Id: 6, Email: &email}))
+	user, err = users.GetUserByEmail(ctx, "new@example.com")
+	require.NoError(t, err)
+	require.False(t, user.EmailVerified)
+
+	// Callers only ever get copies.
+	user.Username = "changed"
+	user, err = users.GetUserById(ctx, 6)
+	require.NoError(t, err)
+	require.Equal(t, "ivan2", user.Username)
+
+	_, err = users.GetUserById(ctx, 7)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	require.ErrorIs(t, users.SetUserDisabled(ctx, 7, true), model.ErrNotFound)
+
+	all, err := users.GetAllUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	require.Equal(t, int32(5), all[0].Id)
+}
+
+func TestUserRepository_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	users := fakes.NewUserRepository(model.User{Username: "ivan", Email: "ivan@example.com"})
+
+	require.NoError(t, users.DeleteUserById(ctx, 1))
+	require.ErrorIs(t, users.DeleteUserById(ctx, 1), model.ErrNotFound)
+	_, err := users.GetUserByUsername(ctx, "ivan")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	// Deleted users don't hold on to their username and email.
+	_, err = users.CreateUser(ctx, &model.User{Username: "ivan", Email: "ivan@example.com"})
+	require.NoError(t, err)
+	requireConstraint(t, users.RestoreUser(ctx, 1), "users_username_live_idx")
+	require.NoError(t, users.DeleteUserById(ctx, 2))
+	require.NoError(t, users.RestoreUser(ctx, 1))
+	require.ErrorIs(t, users.RestoreUser(ctx, 1), model.ErrNotFound)
+
+	purged, err := users.PurgeDeletedUsers(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+	require.ErrorIs(t, users.RestoreUser(ctx, 2), model.ErrNotFound)
+}
+
+func TestUserUsecase(t *testing.T) {
+	users := fakes.NewUserUsecase(fakes.NewUserRepository(
+		model.User{Username: "admin", Email: "admin@example.com", Password: password.MustHash("secret"), Role: model.RoleAdmin},
+		model.User{Username: "ivan", Email: "ivan@example.com", Password: password.MustHash("secret")},
+	))
+	ctx := context.Background()
+
+	created, err := users.CreateUser(ctx, &dto.CreateUserDto{Username: "petr", Email: "petr@example.com", Password: "secret"})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), created.Id)
+	_, err = users.GetAllUsers(ctx)
+	require.ErrorIs(t, err, policy.ErrUnauthenticated)
+
+	ivan, err := users.Authenticate(ctx, "ivan", "secret")
+	require.NoError(t, err)
+	asIvan := policy.WithActor(ctx, ivan)
+	require.ErrorIs(t, users.DeleteUserById(asIvan, 3), policy.ErrForbidden)
+	require.NoError(t, users.DeleteUserById(asIvan, 2))
+	_, err = users.Authenticate(ctx, "ivan", "secret")
+	require.ErrorIs(t, err, model.ErrInvalidCredentials)
+
+	admin, err := users.Authenticate(ctx, "admin", "secret")
+	require.NoError(t, err)
+	asAdmin := policy.WithActor(ctx, admin)
+	require.NoError(t, users.RestoreUser(asAdmin, 2))
+	all, err := users.GetAllUsers(asAdmin)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	// Changes are audited like with the real repositories.
+	records, total, err := users.Audit.GetAuditRecords(ctx, model.AuditFilter{ResourceId: "2", Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+	require.Equal(t, model.AuditRestore, records[0].Action)
+	require.Equal(t, admin.Id, *records[0].ActorId)
+}
+
+func TestJobRepository(t *testing.T) {
+	ctx := context.Background()
+	jobs := fakes.NewJobRepository(model.Job{Id: 3, Type: "purge", UniqueKey: "purge:1"})
+
+	_, err := jobs.CreateJob(ctx, &model.Job{Type: "purge", UniqueKey: "purge:1"})
+	require.ErrorIs(t, err, model.ErrAlreadyExists)
+	created, err := jobs.CreateJob(ctx, &model.Job{Type: "import"})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), created.Id)
+	require.Equal(t, model.JobQueued, created.Status)
+
+	_, err = jobs.GetJobById(ctx, 5)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
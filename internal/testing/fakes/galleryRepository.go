@@ -0,0 +1,228 @@
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"ivanjabrony/refstudy/internal/model"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// GalleryRepository keeps galleries and imports in memory for
+// usecase.GalleryUsecase.
+type GalleryRepository struct {
+	mu        sync.Mutex
+	galleries []model.Gallery
+	imports   map[int64]*model.GalleryImport
+	pictureId int32
+}
+
+// NewGalleryRepository returns a repository holding imports, which refer
+// to jobs by id.
+func NewGalleryRepository(imports ...model.GalleryImport) *GalleryRepository {
+	r := &GalleryRepository{imports: map[int64]*model.GalleryImport{}}
+	for _, galleryImport := range imports {
+		r.imports[galleryImport.JobId] = &galleryImport
+	}
+	return r
+}
+
+func (r *GalleryRepository) CreateGallery(_ context.Context, gallery *model.Gallery) (*model.Gallery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	gallery.Id = int32(len(r.galleries) + 1)
+	gallery.CreatedAt = time.Now()
+	for i := range gallery.Pictures {
+		r.pictureId++
+		gallery.Pictures[i].Id = r.pictureId
+		gallery.Pictures[i].GalleryId = gallery.Id
+		gallery.Pictures[i].CreatedAt = gallery.CreatedAt
+	}
+	gallery.CurrentSize = len(gallery.Pictures)
+	r.galleries = append(r.galleries, cloneGallery(*gallery))
+	return gallery, nil
+}
+
+func (r *GalleryRepository) GetGalleryById(_ context.Context, id int32) (*model.Gallery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id < 1 || int(id) > len(r.galleries) {
+		return nil, fmt.Errorf("gallery with id %d: %w", id, model.ErrNotFound)
+	}
+	gallery := r.galleries[id-1]
+	gallery.Pictures = nil
+	return &gallery, nil
+}
+
+func (r *GalleryRepository) GetGalleriesByIds(_ context.Context, ids []int32, visibility model.Visibility) ([]model.Gallery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var galleries []model.Gallery
+	for _, gallery := range r.galleries {
+		if slices.Contains(ids, gallery.Id) && visible(visibility, gallery) {
+			gallery.Pictures = nil
+			galleries = append(galleries, gallery)
+		}
+	}
+	return galleries, nil
+}
+
+func (r *GalleryRepository) GetGalleriesByOwners(_ context.Context, ownerIds []int32, visibility model.Visibility, limit int) ([]model.Gallery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var galleries []model.Gallery
+	counts := map[int32]int{}
+	for _, gallery := range r.galleries {
+		if slices.Contains(ownerIds, gallery.OwnerId) && visible(visibility, gallery) && counts[gallery.OwnerId] < limit {
+			counts[gallery.OwnerId]++
+			gallery.Pictures = nil
+			galleries = append(galleries, gallery)
+		}
+	}
+	slices.SortStableFunc(galleries, func(a, b model.Gallery) int { return int(a.OwnerId - b.OwnerId) })
+	return galleries, nil
+}
+
+func (r *GalleryRepository) GetPicturesByGalleries(_ context.Context, galleryIds []int32, visibility model.Visibility, limit int) ([]model.Picture, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var pictures []model.Picture
+	for _, gallery := range r.galleries {
+		if slices.Contains(galleryIds, gallery.Id) && visible(visibility, gallery) {
+			gallery = cloneGallery(gallery)
+			pictures = append(pictures, gallery.Pictures[:min(limit, len(gallery.Pictures))]...)
+		}
+	}
+	return pictures, nil
+}
+
+func (r *GalleryRepository) GetGalleryPictures(_ context.Context, galleryId int32) ([]model.Picture, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if galleryId < 1 || int(galleryId) > len(r.galleries) {
+		return nil, nil
+	}
+	return cloneGallery(r.galleries[galleryId-1]).Pictures, nil
+}
+
+// GetHashedPictures returns all pictures of the gallery: unlike in the
+// database, every picture stored here has its hash.
+func (r *GalleryRepository) GetHashedPictures(ctx context.Context, galleryId int32) ([]model.Picture, error) {
+	return r.GetGalleryPictures(ctx, galleryId)
+}
+
+// Search matches words by prefix like the database does, but ranks every hit
+// the same: galleries come first, then pictures, each by id.
+func (r *GalleryRepository) Search(_ context.Context, filter model.SearchFilter) ([]model.SearchHit, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	terms := words(filter.Query)
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+	var galleries, pictures []model.SearchHit
+	for _, gallery := range r.galleries {
+		if !visible(filter.Visibility, gallery) {
+			continue
+		}
+		if matchesTerms(terms, gallery.GalleryName, gallery.Description) {
+			galleries = append(galleries, model.SearchHit{
+				Kind: model.SearchGallery, Id: gallery.Id, GalleryId: gallery.Id, GalleryName: gallery.GalleryName,
+				Name: gallery.GalleryName, Description: gallery.Description, Tags: []string{}, Rank: 1,
+			})
+		}
+		for _, picture := range gallery.Pictures {
+			if matchesTerms(terms, append([]string{picture.Name}, picture.Tags...)...) {
+				pictures = append(pictures, model.SearchHit{
+					Kind: model.SearchPicture, Id: picture.Id, GalleryId: gallery.Id, GalleryName: gallery.GalleryName,
+					Name: picture.Name, Tags: slices.Clone(picture.Tags), Rank: 1,
+				})
+			}
+		}
+	}
+
+	hits := append(galleries, pictures...)
+	total := len(hits)
+	hits = hits[min(filter.Offset, total):]
+	return hits[:min(filter.Limit, len(hits))], total, nil
+}
+
+func (r *GalleryRepository) CreateGalleryImport(_ context.Context, galleryImport *model.GalleryImport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	galleryImport.CreatedAt = time.Now()
+	stored := *galleryImport
+	r.imports[galleryImport.JobId] = &stored
+	return nil
+}
+
+func (r *GalleryRepository) GetGalleryImport(_ context.Context, jobId int64) (*model.GalleryImport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	galleryImport, ok := r.imports[jobId]
+	if !ok {
+		return nil, fmt.Errorf("gallery import with id %d: %w", jobId, model.ErrNotFound)
+	}
+	copied := *galleryImport
+	return &copied, nil
+}
+
+func (r *GalleryRepository) FinishGalleryImport(_ context.Context, galleryImport *model.GalleryImport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.imports[galleryImport.JobId]
+	if !ok || stored.FinishedAt != nil {
+		return fmt.Errorf("unfinished gallery import with id %d: %w", galleryImport.JobId, model.ErrNotFound)
+	}
+	now := time.Now()
+	stored.Failures = slices.Clone(galleryImport.Failures)
+	stored.GalleryIds = slices.Clone(galleryImport.GalleryIds)
+	stored.FinishedAt = &now
+	galleryImport.FinishedAt = &now
+	return nil
+}
+
+func visible(visibility model.Visibility, gallery model.Gallery) bool {
+	return gallery.IsPublic || visibility.All || (visibility.ViewerId != nil && *visibility.ViewerId == gallery.OwnerId)
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchesTerms tells whether every term starts some word of texts.
+func matchesTerms(terms []string, texts ...string) bool {
+	var all []string
+	for _, text := range texts {
+		all = append(all, words(text)...)
+	}
+	for _, term := range terms {
+		if !slices.ContainsFunc(all, func(word string) bool { return strings.HasPrefix(word, term) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func cloneGallery(gallery model.Gallery) model.Gallery {
+	gallery.Pictures = slices.Clone(gallery.Pictures)
+	for i := range gallery.Pictures {
+		gallery.Pictures[i].Tags = slices.Clone(gallery.Pictures[i].Tags)
+	}
+	return gallery
+}
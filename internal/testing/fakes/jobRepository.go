@@ -0,0 +1,72 @@
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"ivanjabrony/refstudy/internal/model"
+	"sync"
+	"time"
+)
+
+// JobRepository keeps jobs in memory for usecase.JobUsecase. Nothing claims
+// or runs them.
+type JobRepository struct {
+	mu     sync.Mutex
+	jobs   map[int64]*model.Job
+	keys   map[string]bool
+	nextId int64
+}
+
+// NewJobRepository returns a repository holding jobs. Jobs without an id get
+// the next free one.
+func NewJobRepository(jobs ...model.Job) *JobRepository {
+	r := &JobRepository{jobs: map[int64]*model.Job{}, keys: map[string]bool{}}
+	for _, job := range jobs {
+		if job.Id == 0 {
+			r.nextId++
+			job.Id = r.nextId
+		}
+		r.nextId = max(r.nextId, job.Id)
+		r.store(job)
+	}
+	return r
+}
+
+// CreateJob returns model.ErrAlreadyExists for a job whose UniqueKey is
+// taken.
+func (r *JobRepository) CreateJob(_ context.Context, job *model.Job) (*model.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if job.UniqueKey != "" && r.keys[job.UniqueKey] {
+		return nil, fmt.Errorf("job %s: %w", job.UniqueKey, model.ErrAlreadyExists)
+	}
+
+	r.nextId++
+	created := *job
+	created.Id = r.nextId
+	created.Status = model.JobQueued
+	created.CreatedAt = time.Now()
+	created.UpdatedAt = created.CreatedAt
+	r.store(created)
+	return &created, nil
+}
+
+func (r *JobRepository) GetJobById(_ context.Context, id int64) (*model.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("job with id %d: %w", id, model.ErrNotFound)
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (r *JobRepository) store(job model.Job) {
+	r.jobs[job.Id] = &job
+	if job.UniqueKey != "" {
+		r.keys[job.UniqueKey] = true
+	}
+}
@@ -0,0 +1,343 @@
+// Package fakes holds in-memory implementations of the ports that behave
+// like the real ones, for tests that shouldn't need Postgres.
+package fakes
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"ivanjabrony/refstudy/internal/model"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// UserRepository keeps users in memory the way repository.UserRepository
+// keeps them in Postgres: deleted users are kept until purged, usernames and
+// emails are unique among live users, and unknown or deleted users are
+// model.ErrNotFound. Constraint violations fail with the *pgconn.PgError
+// Postgres would return.
+//
+// It doesn't take part in transactions, so nothing it does is rolled back.
+type UserRepository struct {
+	mu     sync.Mutex
+	users  map[int32]*userRow
+	nextId int32
+	now    func() time.Time
+}
+
+type userRow struct {
+	user      model.User
+	deletedAt time.Time
+}
+
+func (r *userRow) live() bool {
+	return r.deletedAt.IsZero()
+}
+
+// NewUserRepository returns a repository holding users. Users without an id
+// get the next free one.
+func NewUserRepository(users ...model.User) *UserRepository {
+	r := &UserRepository{users: map[int32]*userRow{}, now: time.Now}
+	for _, user := range users {
+		if user.Id == 0 {
+			r.nextId++
+			user.Id = r.nextId
+		}
+		if user.Role == "" {
+			user.Role = model.RoleUser
+		}
+		r.nextId = max(r.nextId, user.Id)
+		r.users[user.Id] = &userRow{user: user}
+	}
+	return r
+}
+
+func (r *UserRepository) CreateUser(_ context.Context, user *model.User) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(0, user.Username, user.Email); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	r.nextId++
+	user.Id = r.nextId
+	user.Role = model.RoleUser
+	r.users[user.Id] = &userRow{user: model.User{
+		Id:       user.Id,
+		Username: user.Username,
+		Email:    user.Email,
+		Password: user.Password,
+		Role:     user.Role,
+	}}
+	return user, nil
+}
+
+func (r *UserRepository) GetUserById(_ context.Context, id int32) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	row, err := r.live(id)
+	if err != nil {
+		return nil, err
+	}
+	user := row.user
+	return &user, nil
+}
+
+func (r *UserRepository) GetUserByUsername(_ context.Context, username string) (*model.User, error) {
+	return r.getUserBy("username", username, func(user *model.User) string { return user.Username })
+}
+
+func (r *UserRepository) GetUserCredentials(ctx context.Context, username string) (*model.User, error) {
+	return r.GetUserByUsername(ctx, username)
+}
+
+func (r *UserRepository) GetUserByEmail(_ context.Context, email string) (*model.User, error) {
+	return r.getUserBy("email", email, func(user *model.User) string { return user.Email })
+}
+
+func (r *UserRepository) getUserBy(column, value string, field func(*model.User) string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, row := range r.users {
+		if row.live() && field(&row.user) == value {
+			user := row.user
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("user with %s %s: %w", column, value, model.ErrNotFound)
+}
+
+// GetAllUsers returns the live users ordered by id.
+func (r *UserRepository) GetAllUsers(_ context.Context) ([]model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var users []model.User
+	for _, row := range r.users {
+		if row.live() {
+			users = append(users, row.user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Id < users[j].Id })
+	return users, nil
+}
+
+func (r *UserRepository) GetUsersByIds(_ context.Context, ids []int32) ([]model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var users []model.User
+	for id, row := range r.users {
+		if slices.Contains(ids, id) && row.live() {
+			user := row.user
+			user.Password = ""
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Id < users[j].Id })
+	return users, nil
+}
+
+// StreamUsers selects live users like the real repository, ordered by
+// keyset.Sort and id. fn runs after the lock is released, so it may call back
+// into the repository.
+func (r *UserRepository) StreamUsers(ctx context.Context, keyset model.Keyset, fn func(*model.User) error) error {
+	if !model.ValidUserSort(keyset.Sort) {
+		return fmt.Errorf("%w: %s", model.ErrInvalidSort, keyset.Sort)
+	}
+	key := func(user model.User) string {
+		if keyset.Sort == model.UserSortUsername {
+			return user.Username
+		}
+		return ""
+	}
+	// compare orders a before b like ORDER BY sort, id does.
+	compare := func(a, b model.User) int {
+		if c := strings.Compare(key(a), key(b)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Id, b.Id)
+	}
+
+	users, err := r.GetAllUsers(ctx)
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(users, compare)
+	if keyset.Backward {
+		slices.Reverse(users)
+	}
+
+	boundary := model.User{Id: keyset.AfterId, Username: keyset.After}
+	sent := 0
+	for i := range users {
+		if keyset.AfterId != 0 {
+			c := compare(users[i], boundary)
+			if !keyset.Backward && c <= 0 || keyset.Backward && c >= 0 {
+				continue
+			}
+		}
+		if keyset.Limit > 0 && sent == keyset.Limit {
+			break
+		}
+		if err := fn(&users[i]); err != nil {
+			return err
+		}
+		sent++
+	}
+	return nil
+}
+
+// UpdateUser changes only the fields update has. Changing the email drops
+// its verification.
+func (r *UserRepository) UpdateUser(_ context.Context, update *model.UserUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	row, err := r.live(update.Id)
+	if err != nil {
+		return err
+	}
+	user := row.user
+	if update.Username != nil {
+		user.Username = *update.Username
+	}
+	if update.Email != nil {
+		user.EmailVerified = user.EmailVerified && user.Email == *update.Email
+		user.Email = *update.Email
+	}
+	if update.Password != nil {
+		user.Password = *update.Password
+	}
+	if err := r.checkUnique(user.Id, user.Username, user.Email); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	row.user = user
+	return nil
+}
+
+func (r *UserRepository) DeleteUserById(_ context.Context, id int32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	row, err := r.live(id)
+	if err != nil {
+		return err
+	}
+	row.deletedAt = r.now()
+	return nil
+}
+
+func (r *UserRepository) RestoreUser(_ context.Context, id int32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	row, ok := r.users[id]
+	if !ok || row.live() {
+		return fmt.Errorf("deleted user with id %d: %w", id, model.ErrNotFound)
+	}
+	if err := r.checkUnique(id, row.user.Username, row.user.Email); err != nil {
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	row.deletedAt = time.Time{}
+	return nil
+}
+
+// PurgeDeletedUsers forgets users deleted before deletedBefore.
+func (r *UserRepository) PurgeDeletedUsers(_ context.Context, deletedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, row := range r.users {
+		if !row.live() && row.deletedAt.Before(deletedBefore) {
+			delete(r.users, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (r *UserRepository) SetUserDisabled(_ context.Context, id int32, disabled bool) error {
+	return r.update(id, func(user *model.User) { user.Disabled = disabled })
+}
+
+// SetUserRole fails like the role check constraint for unknown roles.
+func (r *UserRepository) SetUserRole(_ context.Context, id int32, role model.Role) error {
+	if !role.Valid() {
+		return fmt.Errorf("failed to execute query: %w", &pgconn.PgError{
+			Severity:       "ERROR",
+			Code:           "23514",
+			Message:        `new row for relation "users" violates check constraint "users_role_check"`,
+			TableName:      "users",
+			ConstraintName: "users_role_check",
+		})
+	}
+	return r.update(id, func(user *model.User) { user.Role = role })
+}
+
+func (r *UserRepository) SetUserPassword(_ context.Context, id int32, password string) error {
+	return r.update(id, func(user *model.User) { user.Password = password })
+}
+
+func (r *UserRepository) SetEmailVerified(_ context.Context, id int32) error {
+	return r.update(id, func(user *model.User) { user.EmailVerified = true })
+}
+
+func (r *UserRepository) update(id int32, change func(*model.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	row, err := r.live(id)
+	if err != nil {
+		return err
+	}
+	change(&row.user)
+	return nil
+}
+
+func (r *UserRepository) live(id int32) (*userRow, error) {
+	row, ok := r.users[id]
+	if !ok || !row.live() {
+		return nil, fmt.Errorf("user with id %d: %w", id, model.ErrNotFound)
+	}
+	return row, nil
+}
+
+// checkUnique fails like the unique indexes on live users would if a user
+// other than id had username or email.
+func (r *UserRepository) checkUnique(id int32, username, email string) error {
+	for _, row := range r.users {
+		if row.user.Id == id || !row.live() {
+			continue
+		}
+		if row.user.Username == username {
+			return uniqueViolation("users_username_live_idx", "username", username)
+		}
+		if row.user.Email == email {
+			return uniqueViolation("users_email_live_idx", "email", email)
+		}
+	}
+	return nil
+}
+
+func uniqueViolation(constraint, column, value string) *pgconn.PgError {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23505",
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		Detail:         fmt.Sprintf("Key (%s)=(%s) already exists.", column, value),
+		TableName:      "users",
+		ConstraintName: constraint,
+	}
+}
@@ -0,0 +1,39 @@
+package fakes
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/usecase"
+)
+
+// UserUsecase is the real usecase.UserUsecase over a UserRepository,
+// authorizing with policy.RolePolicy. It audits into Audit and sends no
+// emails. Like the real one it stores password hashes, so users given to
+// the repository need their Password hashed with password.Hash.
+type UserUsecase struct {
+	*usecase.UserUsecase
+	Users *UserRepository
+	Audit *AuditRepository
+}
+
+func NewUserUsecase(users *UserRepository) *UserUsecase {
+	audit := NewAuditRepository()
+	auditor, err := usecase.NewAuditor(Transactor{}, audit)
+	if err != nil {
+		panic(err)
+	}
+	uc, err := usecase.NewUserUsecase(users, auditor, noVerifier{}, policy.RolePolicy{}, logger.New(logger.Test, logger.LogFormatText))
+	if err != nil {
+		panic(err)
+	}
+
+	return &UserUsecase{UserUsecase: uc, Users: users, Audit: audit}
+}
+
+type noVerifier struct{}
+
+func (noVerifier) SendVerificationEmail(context.Context, *model.User) error {
+	return nil
+}
@@ -0,0 +1,380 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package blobmocks
+
+import (
+	context "context"
+	blob "ivanjabrony/refstudy/internal/blob"
+
+	io "io"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// Store is an autogenerated mock type for the Store type
+type Store struct {
+	mock.Mock
+}
+
+type Store_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Store) EXPECT() *Store_Expecter {
+	return &Store_Expecter{mock: &_m.Mock}
+}
+
+// Delete provides a mock function with given fields: ctx, key
+func (_m *Store) Delete(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Store_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type Store_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Store_Expecter) Delete(ctx interface{}, key interface{}) *Store_Delete_Call {
+	return &Store_Delete_Call{Call: _e.mock.On("Delete", ctx, key)}
+}
+
+func (_c *Store_Delete_Call) Run(run func(ctx context.Context, key string)) *Store_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Store_Delete_Call) Return(_a0 error) *Store_Delete_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_Delete_Call) RunAndReturn(run func(context.Context, string) error) *Store_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function with given fields: ctx, key
+func (_m *Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 io.ReadCloser
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (io.ReadCloser, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) io.ReadCloser); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type Store_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Store_Expecter) Get(ctx interface{}, key interface{}) *Store_Get_Call {
+	return &Store_Get_Call{Call: _e.mock.On("Get", ctx, key)}
+}
+
+func (_c *Store_Get_Call) Run(run func(ctx context.Context, key string)) *Store_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Store_Get_Call) Return(_a0 io.ReadCloser, _a1 error) *Store_Get_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_Get_Call) RunAndReturn(run func(context.Context, string) (io.ReadCloser, error)) *Store_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PresignGet provides a mock function with given fields: ctx, key, ttl
+func (_m *Store) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	ret := _m.Called(ctx, key, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PresignGet")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) (string, error)); ok {
+		return rf(ctx, key, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) string); ok {
+		r0 = rf(ctx, key, ttl)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(ctx, key, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_PresignGet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PresignGet'
+type Store_PresignGet_Call struct {
+	*mock.Call
+}
+
+// PresignGet is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - ttl time.Duration
+func (_e *Store_Expecter) PresignGet(ctx interface{}, key interface{}, ttl interface{}) *Store_PresignGet_Call {
+	return &Store_PresignGet_Call{Call: _e.mock.On("PresignGet", ctx, key, ttl)}
+}
+
+func (_c *Store_PresignGet_Call) Run(run func(ctx context.Context, key string, ttl time.Duration)) *Store_PresignGet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *Store_PresignGet_Call) Return(_a0 string, _a1 error) *Store_PresignGet_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_PresignGet_Call) RunAndReturn(run func(context.Context, string, time.Duration) (string, error)) *Store_PresignGet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PresignPut provides a mock function with given fields: ctx, key, ttl
+func (_m *Store) PresignPut(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	ret := _m.Called(ctx, key, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PresignPut")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) (string, error)); ok {
+		return rf(ctx, key, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) string); ok {
+		r0 = rf(ctx, key, ttl)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(ctx, key, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_PresignPut_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PresignPut'
+type Store_PresignPut_Call struct {
+	*mock.Call
+}
+
+// PresignPut is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - ttl time.Duration
+func (_e *Store_Expecter) PresignPut(ctx interface{}, key interface{}, ttl interface{}) *Store_PresignPut_Call {
+	return &Store_PresignPut_Call{Call: _e.mock.On("PresignPut", ctx, key, ttl)}
+}
+
+func (_c *Store_PresignPut_Call) Run(run func(ctx context.Context, key string, ttl time.Duration)) *Store_PresignPut_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *Store_PresignPut_Call) Return(_a0 string, _a1 error) *Store_PresignPut_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_PresignPut_Call) RunAndReturn(run func(context.Context, string, time.Duration) (string, error)) *Store_PresignPut_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Put provides a mock function with given fields: ctx, key, r, size, contentType
+func (_m *Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (blob.Object, error) {
+	ret := _m.Called(ctx, key, r, size, contentType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Put")
+	}
+
+	var r0 blob.Object
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, io.Reader, int64, string) (blob.Object, error)); ok {
+		return rf(ctx, key, r, size, contentType)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, io.Reader, int64, string) blob.Object); ok {
+		r0 = rf(ctx, key, r, size, contentType)
+	} else {
+		r0 = ret.Get(0).(blob.Object)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, io.Reader, int64, string) error); ok {
+		r1 = rf(ctx, key, r, size, contentType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_Put_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Put'
+type Store_Put_Call struct {
+	*mock.Call
+}
+
+// Put is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - r io.Reader
+//   - size int64
+//   - contentType string
+func (_e *Store_Expecter) Put(ctx interface{}, key interface{}, r interface{}, size interface{}, contentType interface{}) *Store_Put_Call {
+	return &Store_Put_Call{Call: _e.mock.On("Put", ctx, key, r, size, contentType)}
+}
+
+func (_c *Store_Put_Call) Run(run func(ctx context.Context, key string, r io.Reader, size int64, contentType string)) *Store_Put_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(io.Reader), args[3].(int64), args[4].(string))
+	})
+	return _c
+}
+
+func (_c *Store_Put_Call) Return(_a0 blob.Object, _a1 error) *Store_Put_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_Put_Call) RunAndReturn(run func(context.Context, string, io.Reader, int64, string) (blob.Object, error)) *Store_Put_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Stat provides a mock function with given fields: ctx, key
+func (_m *Store) Stat(ctx context.Context, key string) (blob.Object, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stat")
+	}
+
+	var r0 blob.Object
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (blob.Object, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) blob.Object); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(blob.Object)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_Stat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Stat'
+type Store_Stat_Call struct {
+	*mock.Call
+}
+
+// Stat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Store_Expecter) Stat(ctx interface{}, key interface{}) *Store_Stat_Call {
+	return &Store_Stat_Call{Call: _e.mock.On("Stat", ctx, key)}
+}
+
+func (_c *Store_Stat_Call) Run(run func(ctx context.Context, key string)) *Store_Stat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Store_Stat_Call) Return(_a0 blob.Object, _a1 error) *Store_Stat_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_Stat_Call) RunAndReturn(run func(context.Context, string) (blob.Object, error)) *Store_Stat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewStore creates a new instance of Store. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Store {
+	mock := &Store{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,98 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package controllermocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	model "ivanjabrony/refstudy/internal/model"
+)
+
+// Authenticator is an autogenerated mock type for the Authenticator type
+type Authenticator struct {
+	mock.Mock
+}
+
+type Authenticator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Authenticator) EXPECT() *Authenticator_Expecter {
+	return &Authenticator_Expecter{mock: &_m.Mock}
+}
+
+// Authenticate provides a mock function with given fields: ctx, username, password
+func (_m *Authenticator) Authenticate(ctx context.Context, username string, password string) (*model.Actor, error) {
+	ret := _m.Called(ctx, username, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authenticate")
+	}
+
+	var r0 *model.Actor
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*model.Actor, error)); ok {
+		return rf(ctx, username, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.Actor); ok {
+		r0 = rf(ctx, username, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Actor)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, username, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Authenticator_Authenticate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Authenticate'
+type Authenticator_Authenticate_Call struct {
+	*mock.Call
+}
+
+// Authenticate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - password string
+func (_e *Authenticator_Expecter) Authenticate(ctx interface{}, username interface{}, password interface{}) *Authenticator_Authenticate_Call {
+	return &Authenticator_Authenticate_Call{Call: _e.mock.On("Authenticate", ctx, username, password)}
+}
+
+func (_c *Authenticator_Authenticate_Call) Run(run func(ctx context.Context, username string, password string)) *Authenticator_Authenticate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Authenticator_Authenticate_Call) Return(_a0 *model.Actor, _a1 error) *Authenticator_Authenticate_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Authenticator_Authenticate_Call) RunAndReturn(run func(context.Context, string, string) (*model.Actor, error)) *Authenticator_Authenticate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAuthenticator creates a new instance of Authenticator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuthenticator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Authenticator {
+	mock := &Authenticator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,96 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package controllermocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	ratelimit "ivanjabrony/refstudy/internal/ratelimit"
+)
+
+// RateLimiter is an autogenerated mock type for the RateLimiter type
+type RateLimiter struct {
+	mock.Mock
+}
+
+type RateLimiter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *RateLimiter) EXPECT() *RateLimiter_Expecter {
+	return &RateLimiter_Expecter{mock: &_m.Mock}
+}
+
+// Take provides a mock function with given fields: ctx, key, limit
+func (_m *RateLimiter) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	ret := _m.Called(ctx, key, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Take")
+	}
+
+	var r0 ratelimit.Result
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ratelimit.Limit) (ratelimit.Result, error)); ok {
+		return rf(ctx, key, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ratelimit.Limit) ratelimit.Result); ok {
+		r0 = rf(ctx, key, limit)
+	} else {
+		r0 = ret.Get(0).(ratelimit.Result)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ratelimit.Limit) error); ok {
+		r1 = rf(ctx, key, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RateLimiter_Take_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Take'
+type RateLimiter_Take_Call struct {
+	*mock.Call
+}
+
+// Take is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - limit ratelimit.Limit
+func (_e *RateLimiter_Expecter) Take(ctx interface{}, key interface{}, limit interface{}) *RateLimiter_Take_Call {
+	return &RateLimiter_Take_Call{Call: _e.mock.On("Take", ctx, key, limit)}
+}
+
+func (_c *RateLimiter_Take_Call) Run(run func(ctx context.Context, key string, limit ratelimit.Limit)) *RateLimiter_Take_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(ratelimit.Limit))
+	})
+	return _c
+}
+
+func (_c *RateLimiter_Take_Call) Return(_a0 ratelimit.Result, _a1 error) *RateLimiter_Take_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RateLimiter_Take_Call) RunAndReturn(run func(context.Context, string, ratelimit.Limit) (ratelimit.Result, error)) *RateLimiter_Take_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewRateLimiter creates a new instance of RateLimiter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRateLimiter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RateLimiter {
+	mock := &RateLimiter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
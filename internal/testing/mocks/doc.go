@@ -0,0 +1,8 @@
+// Package mocks holds testify mocks of the ports, one package per package
+// declaring them, generated by mockery from .mockery.yaml at the repository
+// root. Regenerate them with "make generate-mocks" after changing a port;
+// the tests here fail to build while a mock no longer fits its port, and
+// fail when regenerating would change any mock ("make check-mocks").
+package mocks
+
+//go:generate sh -c "cd ../../.. && mockery"
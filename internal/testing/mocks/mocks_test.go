@@ -0,0 +1,107 @@
+package mocks_test
+
+import (
+	"bytes"
+	"io/fs"
+	"ivanjabrony/refstudy/internal/blob"
+	"ivanjabrony/refstudy/internal/cache"
+	"ivanjabrony/refstudy/internal/controller"
+	v1 "ivanjabrony/refstudy/internal/controller/v1"
+	"ivanjabrony/refstudy/internal/mailer"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"ivanjabrony/refstudy/internal/testing/mocks/blobmocks"
+	"ivanjabrony/refstudy/internal/testing/mocks/cachemocks"
+	"ivanjabrony/refstudy/internal/testing/mocks/controllermocks"
+	"ivanjabrony/refstudy/internal/testing/mocks/mailermocks"
+	"ivanjabrony/refstudy/internal/testing/mocks/ratelimitmocks"
+	"ivanjabrony/refstudy/internal/testing/mocks/usecasemocks"
+	"ivanjabrony/refstudy/internal/testing/mocks/v1mocks"
+	"ivanjabrony/refstudy/internal/testing/mocks/workermocks"
+	"ivanjabrony/refstudy/internal/usecase"
+	"ivanjabrony/refstudy/internal/worker"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// A mock that no longer implements its port fails the build here.
+var (
+	_ usecase.UserRepository    = (*usecasemocks.UserRepository)(nil)
+	_ usecase.AccountRepository = (*usecasemocks.AccountRepository)(nil)
+	_ usecase.TokenRepository   = (*usecasemocks.TokenRepository)(nil)
+	_ usecase.AuditRepository   = (*usecasemocks.AuditRepository)(nil)
+	_ usecase.JobRepository     = (*usecasemocks.JobRepository)(nil)
+	_ usecase.Transactor        = (*usecasemocks.Transactor)(nil)
+	_ usecase.EmailVerifier     = (*usecasemocks.EmailVerifier)(nil)
+
+	_ v1.UserUsecase    = (*v1mocks.UserUsecase)(nil)
+	_ v1.AdminUsecase   = (*v1mocks.AdminUsecase)(nil)
+	_ v1.AccountUsecase = (*v1mocks.AccountUsecase)(nil)
+	_ v1.AuditUsecase   = (*v1mocks.AuditUsecase)(nil)
+	_ v1.JobUsecase     = (*v1mocks.JobUsecase)(nil)
+
+	_ controller.Authenticator = (*controllermocks.Authenticator)(nil)
+	_ controller.RateLimiter   = (*controllermocks.RateLimiter)(nil)
+	_ worker.JobRepository     = (*workermocks.JobRepository)(nil)
+	_ ratelimit.Store          = (*ratelimitmocks.Store)(nil)
+	_ mailer.Mailer            = (*mailermocks.Mailer)(nil)
+	_ blob.Store               = (*blobmocks.Store)(nil)
+	_ cache.Cache              = (*cachemocks.Cache)(nil)
+)
+
+// TestMocksUpToDate regenerates the mocks into a scratch directory and
+// compares them with the committed ones. It needs mockery on the PATH.
+func TestMocksUpToDate(t *testing.T) {
+	mockery, err := exec.LookPath("mockery")
+	if err != nil {
+		t.Skip("mockery is not installed")
+	}
+
+	root, err := filepath.Abs("../../..")
+	require.NoError(t, err)
+	config, err := os.ReadFile(filepath.Join(root, ".mockery.yaml"))
+	require.NoError(t, err)
+
+	scratch := t.TempDir()
+	committedDir := []byte(`dir: "internal/testing/mocks/{{.PackageName}}mocks"`)
+	require.True(t, bytes.Contains(config, committedDir), ".mockery.yaml doesn't generate into internal/testing/mocks")
+	config = bytes.Replace(config, committedDir, []byte(`dir: "`+scratch+`/{{.PackageName}}mocks"`), 1)
+	configPath := filepath.Join(scratch, "mockery.yaml")
+	require.NoError(t, os.WriteFile(configPath, config, 0o644))
+
+	cmd := exec.Command(mockery, "--config", configPath)
+	cmd.Dir = root
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	generated := mockFiles(t, scratch)
+	committed := mockFiles(t, ".")
+	require.Equal(t, len(generated), len(committed), "mocks were added or removed, run: make generate-mocks")
+	for path, content := range generated {
+		require.Equal(t, content, committed[path], "%s is out of date, run: make generate-mocks", path)
+	}
+}
+
+// mockFiles reads the generated mocks under dir by their path relative to it.
+func mockFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Dir(path) == filepath.Clean(dir) || filepath.Ext(path) != ".go" {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(dir, path)
+		files[relative] = string(content)
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
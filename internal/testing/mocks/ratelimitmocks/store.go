@@ -0,0 +1,257 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package ratelimitmocks
+
+import (
+	context "context"
+	ratelimit "ivanjabrony/refstudy/internal/ratelimit"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Store is an autogenerated mock type for the Store type
+type Store struct {
+	mock.Mock
+}
+
+type Store_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Store) EXPECT() *Store_Expecter {
+	return &Store_Expecter{mock: &_m.Mock}
+}
+
+// Fail provides a mock function with given fields: ctx, key, lockout
+func (_m *Store) Fail(ctx context.Context, key string, lockout ratelimit.Lockout) (ratelimit.Lock, error) {
+	ret := _m.Called(ctx, key, lockout)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Fail")
+	}
+
+	var r0 ratelimit.Lock
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ratelimit.Lockout) (ratelimit.Lock, error)); ok {
+		return rf(ctx, key, lockout)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ratelimit.Lockout) ratelimit.Lock); ok {
+		r0 = rf(ctx, key, lockout)
+	} else {
+		r0 = ret.Get(0).(ratelimit.Lock)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ratelimit.Lockout) error); ok {
+		r1 = rf(ctx, key, lockout)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_Fail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Fail'
+type Store_Fail_Call struct {
+	*mock.Call
+}
+
+// Fail is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - lockout ratelimit.Lockout
+func (_e *Store_Expecter) Fail(ctx interface{}, key interface{}, lockout interface{}) *Store_Fail_Call {
+	return &Store_Fail_Call{Call: _e.mock.On("Fail", ctx, key, lockout)}
+}
+
+func (_c *Store_Fail_Call) Run(run func(ctx context.Context, key string, lockout ratelimit.Lockout)) *Store_Fail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(ratelimit.Lockout))
+	})
+	return _c
+}
+
+func (_c *Store_Fail_Call) Return(_a0 ratelimit.Lock, _a1 error) *Store_Fail_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_Fail_Call) RunAndReturn(run func(context.Context, string, ratelimit.Lockout) (ratelimit.Lock, error)) *Store_Fail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetLock provides a mock function with given fields: ctx, key
+func (_m *Store) GetLock(ctx context.Context, key string) (ratelimit.Lock, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLock")
+	}
+
+	var r0 ratelimit.Lock
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (ratelimit.Lock, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) ratelimit.Lock); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(ratelimit.Lock)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_GetLock_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLock'
+type Store_GetLock_Call struct {
+	*mock.Call
+}
+
+// GetLock is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Store_Expecter) GetLock(ctx interface{}, key interface{}) *Store_GetLock_Call {
+	return &Store_GetLock_Call{Call: _e.mock.On("GetLock", ctx, key)}
+}
+
+func (_c *Store_GetLock_Call) Run(run func(ctx context.Context, key string)) *Store_GetLock_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Store_GetLock_Call) Return(_a0 ratelimit.Lock, _a1 error) *Store_GetLock_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_GetLock_Call) RunAndReturn(run func(context.Context, string) (ratelimit.Lock, error)) *Store_GetLock_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResetLock provides a mock function with given fields: ctx, key
+func (_m *Store) ResetLock(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetLock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Store_ResetLock_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetLock'
+type Store_ResetLock_Call struct {
+	*mock.Call
+}
+
+// ResetLock is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Store_Expecter) ResetLock(ctx interface{}, key interface{}) *Store_ResetLock_Call {
+	return &Store_ResetLock_Call{Call: _e.mock.On("ResetLock", ctx, key)}
+}
+
+func (_c *Store_ResetLock_Call) Run(run func(ctx context.Context, key string)) *Store_ResetLock_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Store_ResetLock_Call) Return(_a0 error) *Store_ResetLock_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_ResetLock_Call) RunAndReturn(run func(context.Context, string) error) *Store_ResetLock_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Take provides a mock function with given fields: ctx, key, limit
+func (_m *Store) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	ret := _m.Called(ctx, key, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Take")
+	}
+
+	var r0 ratelimit.Result
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ratelimit.Limit) (ratelimit.Result, error)); ok {
+		return rf(ctx, key, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ratelimit.Limit) ratelimit.Result); ok {
+		r0 = rf(ctx, key, limit)
+	} else {
+		r0 = ret.Get(0).(ratelimit.Result)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ratelimit.Limit) error); ok {
+		r1 = rf(ctx, key, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_Take_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Take'
+type Store_Take_Call struct {
+	*mock.Call
+}
+
+// Take is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - limit ratelimit.Limit
+func (_e *Store_Expecter) Take(ctx interface{}, key interface{}, limit interface{}) *Store_Take_Call {
+	return &Store_Take_Call{Call: _e.mock.On("Take", ctx, key, limit)}
+}
+
+func (_c *Store_Take_Call) Run(run func(ctx context.Context, key string, limit ratelimit.Limit)) *Store_Take_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(ratelimit.Limit))
+	})
+	return _c
+}
+
+func (_c *Store_Take_Call) Return(_a0 ratelimit.Result, _a1 error) *Store_Take_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_Take_Call) RunAndReturn(run func(context.Context, string, ratelimit.Limit) (ratelimit.Result, error)) *Store_Take_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewStore creates a new instance of Store. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Store {
+	mock := &Store{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,250 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package usecasemocks
+
+import (
+	context "context"
+	model "ivanjabrony/refstudy/internal/model"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AccountRepository is an autogenerated mock type for the AccountRepository type
+type AccountRepository struct {
+	mock.Mock
+}
+
+type AccountRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AccountRepository) EXPECT() *AccountRepository_Expecter {
+	return &AccountRepository_Expecter{mock: &_m.Mock}
+}
+
+// GetUserByEmail provides a mock function with given fields: _a0, _a1
+func (_m *AccountRepository) GetUserByEmail(_a0 context.Context, _a1 string) (*model.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByEmail")
+	}
+
+	var r0 *model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.User, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AccountRepository_GetUserByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByEmail'
+type AccountRepository_GetUserByEmail_Call struct {
+	*mock.Call
+}
+
+// GetUserByEmail is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+func (_e *AccountRepository_Expecter) GetUserByEmail(_a0 interface{}, _a1 interface{}) *AccountRepository_GetUserByEmail_Call {
+	return &AccountRepository_GetUserByEmail_Call{Call: _e.mock.On("GetUserByEmail", _a0, _a1)}
+}
+
+func (_c *AccountRepository_GetUserByEmail_Call) Run(run func(_a0 context.Context, _a1 string)) *AccountRepository_GetUserByEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *AccountRepository_GetUserByEmail_Call) Return(_a0 *model.User, _a1 error) *AccountRepository_GetUserByEmail_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AccountRepository_GetUserByEmail_Call) RunAndReturn(run func(context.Context, string) (*model.User, error)) *AccountRepository_GetUserByEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserById provides a mock function with given fields: _a0, _a1
+func (_m *AccountRepository) GetUserById(_a0 context.Context, _a1 int32) (*model.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserById")
+	}
+
+	var r0 *model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) (*model.User, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) *model.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AccountRepository_GetUserById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserById'
+type AccountRepository_GetUserById_Call struct {
+	*mock.Call
+}
+
+// GetUserById is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+func (_e *AccountRepository_Expecter) GetUserById(_a0 interface{}, _a1 interface{}) *AccountRepository_GetUserById_Call {
+	return &AccountRepository_GetUserById_Call{Call: _e.mock.On("GetUserById", _a0, _a1)}
+}
+
+func (_c *AccountRepository_GetUserById_Call) Run(run func(_a0 context.Context, _a1 int32)) *AccountRepository_GetUserById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *AccountRepository_GetUserById_Call) Return(_a0 *model.User, _a1 error) *AccountRepository_GetUserById_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AccountRepository_GetUserById_Call) RunAndReturn(run func(context.Context, int32) (*model.User, error)) *AccountRepository_GetUserById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetEmailVerified provides a mock function with given fields: _a0, _a1
+func (_m *AccountRepository) SetEmailVerified(_a0 context.Context, _a1 int32) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetEmailVerified")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AccountRepository_SetEmailVerified_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetEmailVerified'
+type AccountRepository_SetEmailVerified_Call struct {
+	*mock.Call
+}
+
+// SetEmailVerified is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+func (_e *AccountRepository_Expecter) SetEmailVerified(_a0 interface{}, _a1 interface{}) *AccountRepository_SetEmailVerified_Call {
+	return &AccountRepository_SetEmailVerified_Call{Call: _e.mock.On("SetEmailVerified", _a0, _a1)}
+}
+
+func (_c *AccountRepository_SetEmailVerified_Call) Run(run func(_a0 context.Context, _a1 int32)) *AccountRepository_SetEmailVerified_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *AccountRepository_SetEmailVerified_Call) Return(_a0 error) *AccountRepository_SetEmailVerified_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AccountRepository_SetEmailVerified_Call) RunAndReturn(run func(context.Context, int32) error) *AccountRepository_SetEmailVerified_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetUserPassword provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AccountRepository) SetUserPassword(_a0 context.Context, _a1 int32, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AccountRepository_SetUserPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetUserPassword'
+type AccountRepository_SetUserPassword_Call struct {
+	*mock.Call
+}
+
+// SetUserPassword is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+//   - _a2 string
+func (_e *AccountRepository_Expecter) SetUserPassword(_a0 interface{}, _a1 interface{}, _a2 interface{}) *AccountRepository_SetUserPassword_Call {
+	return &AccountRepository_SetUserPassword_Call{Call: _e.mock.On("SetUserPassword", _a0, _a1, _a2)}
+}
+
+func (_c *AccountRepository_SetUserPassword_Call) Run(run func(_a0 context.Context, _a1 int32, _a2 string)) *AccountRepository_SetUserPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *AccountRepository_SetUserPassword_Call) Return(_a0 error) *AccountRepository_SetUserPassword_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AccountRepository_SetUserPassword_Call) RunAndReturn(run func(context.Context, int32, string) error) *AccountRepository_SetUserPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAccountRepository creates a new instance of AccountRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAccountRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AccountRepository {
+	mock := &AccountRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,150 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package usecasemocks
+
+import (
+	context "context"
+	model "ivanjabrony/refstudy/internal/model"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditRepository is an autogenerated mock type for the AuditRepository type
+type AuditRepository struct {
+	mock.Mock
+}
+
+type AuditRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AuditRepository) EXPECT() *AuditRepository_Expecter {
+	return &AuditRepository_Expecter{mock: &_m.Mock}
+}
+
+// CreateAuditRecord provides a mock function with given fields: _a0, _a1
+func (_m *AuditRepository) CreateAuditRecord(_a0 context.Context, _a1 *model.AuditRecord) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAuditRecord")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.AuditRecord) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AuditRepository_CreateAuditRecord_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAuditRecord'
+type AuditRepository_CreateAuditRecord_Call struct {
+	*mock.Call
+}
+
+// CreateAuditRecord is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *model.AuditRecord
+func (_e *AuditRepository_Expecter) CreateAuditRecord(_a0 interface{}, _a1 interface{}) *AuditRepository_CreateAuditRecord_Call {
+	return &AuditRepository_CreateAuditRecord_Call{Call: _e.mock.On("CreateAuditRecord", _a0, _a1)}
+}
+
+func (_c *AuditRepository_CreateAuditRecord_Call) Run(run func(_a0 context.Context, _a1 *model.AuditRecord)) *AuditRepository_CreateAuditRecord_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.AuditRecord))
+	})
+	return _c
+}
+
+func (_c *AuditRepository_CreateAuditRecord_Call) Return(_a0 error) *AuditRepository_CreateAuditRecord_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AuditRepository_CreateAuditRecord_Call) RunAndReturn(run func(context.Context, *model.AuditRecord) error) *AuditRepository_CreateAuditRecord_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAuditRecords provides a mock function with given fields: _a0, _a1
+func (_m *AuditRepository) GetAuditRecords(_a0 context.Context, _a1 model.AuditFilter) ([]model.AuditRecord, int, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAuditRecords")
+	}
+
+	var r0 []model.AuditRecord
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.AuditFilter) ([]model.AuditRecord, int, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.AuditFilter) []model.AuditRecord); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.AuditRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.AuditFilter) int); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, model.AuditFilter) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// AuditRepository_GetAuditRecords_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAuditRecords'
+type AuditRepository_GetAuditRecords_Call struct {
+	*mock.Call
+}
+
+// GetAuditRecords is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 model.AuditFilter
+func (_e *AuditRepository_Expecter) GetAuditRecords(_a0 interface{}, _a1 interface{}) *AuditRepository_GetAuditRecords_Call {
+	return &AuditRepository_GetAuditRecords_Call{Call: _e.mock.On("GetAuditRecords", _a0, _a1)}
+}
+
+func (_c *AuditRepository_GetAuditRecords_Call) Run(run func(_a0 context.Context, _a1 model.AuditFilter)) *AuditRepository_GetAuditRecords_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.AuditFilter))
+	})
+	return _c
+}
+
+func (_c *AuditRepository_GetAuditRecords_Call) Return(_a0 []model.AuditRecord, _a1 int, _a2 error) *AuditRepository_GetAuditRecords_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *AuditRepository_GetAuditRecords_Call) RunAndReturn(run func(context.Context, model.AuditFilter) ([]model.AuditRecord, int, error)) *AuditRepository_GetAuditRecords_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAuditRepository creates a new instance of AuditRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditRepository {
+	mock := &AuditRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
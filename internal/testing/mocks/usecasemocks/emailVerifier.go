@@ -0,0 +1,84 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package usecasemocks
+
+import (
+	context "context"
+	model "ivanjabrony/refstudy/internal/model"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// EmailVerifier is an autogenerated mock type for the EmailVerifier type
+type EmailVerifier struct {
+	mock.Mock
+}
+
+type EmailVerifier_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *EmailVerifier) EXPECT() *EmailVerifier_Expecter {
+	return &EmailVerifier_Expecter{mock: &_m.Mock}
+}
+
+// SendVerificationEmail provides a mock function with given fields: _a0, _a1
+func (_m *EmailVerifier) SendVerificationEmail(_a0 context.Context, _a1 *model.User) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendVerificationEmail")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.User) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EmailVerifier_SendVerificationEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SendVerificationEmail'
+type EmailVerifier_SendVerificationEmail_Call struct {
+	*mock.Call
+}
+
+// SendVerificationEmail is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *model.User
+func (_e *EmailVerifier_Expecter) SendVerificationEmail(_a0 interface{}, _a1 interface{}) *EmailVerifier_SendVerificationEmail_Call {
+	return &EmailVerifier_SendVerificationEmail_Call{Call: _e.mock.On("SendVerificationEmail", _a0, _a1)}
+}
+
+func (_c *EmailVerifier_SendVerificationEmail_Call) Run(run func(_a0 context.Context, _a1 *model.User)) *EmailVerifier_SendVerificationEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.User))
+	})
+	return _c
+}
+
+func (_c *EmailVerifier_SendVerificationEmail_Call) Return(_a0 error) *EmailVerifier_SendVerificationEmail_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *EmailVerifier_SendVerificationEmail_Call) RunAndReturn(run func(context.Context, *model.User) error) *EmailVerifier_SendVerificationEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewEmailVerifier creates a new instance of EmailVerifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewEmailVerifier(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *EmailVerifier {
+	mock := &EmailVerifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,674 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package usecasemocks
+
+import (
+	context "context"
+	model "ivanjabrony/refstudy/internal/model"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// GalleryRepository is an autogenerated mock type for the GalleryRepository type
+type GalleryRepository struct {
+	mock.Mock
+}
+
+type GalleryRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *GalleryRepository) EXPECT() *GalleryRepository_Expecter {
+	return &GalleryRepository_Expecter{mock: &_m.Mock}
+}
+
+// CreateGallery provides a mock function with given fields: _a0, _a1
+func (_m *GalleryRepository) CreateGallery(_a0 context.Context, _a1 *model.Gallery) (*model.Gallery, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateGallery")
+	}
+
+	var r0 *model.Gallery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Gallery) (*model.Gallery, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Gallery) *model.Gallery); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Gallery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Gallery) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GalleryRepository_CreateGallery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateGallery'
+type GalleryRepository_CreateGallery_Call struct {
+	*mock.Call
+}
+
+// CreateGallery is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *model.Gallery
+func (_e *GalleryRepository_Expecter) CreateGallery(_a0 interface{}, _a1 interface{}) *GalleryRepository_CreateGallery_Call {
+	return &GalleryRepository_CreateGallery_Call{Call: _e.mock.On("CreateGallery", _a0, _a1)}
+}
+
+func (_c *GalleryRepository_CreateGallery_Call) Run(run func(_a0 context.Context, _a1 *model.Gallery)) *GalleryRepository_CreateGallery_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.Gallery))
+	})
+	return _c
+}
+
+func (_c *GalleryRepository_CreateGallery_Call) Return(_a0 *model.Gallery, _a1 error) *GalleryRepository_CreateGallery_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GalleryRepository_CreateGallery_Call) RunAndReturn(run func(context.Context, *model.Gallery) (*model.Gallery, error)) *GalleryRepository_CreateGallery_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateGalleryImport provides a mock function with given fields: _a0, _a1
+func (_m *GalleryRepository) CreateGalleryImport(_a0 context.Context, _a1 *model.GalleryImport) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateGalleryImport")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.GalleryImport) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GalleryRepository_CreateGalleryImport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateGalleryImport'
+type GalleryRepository_CreateGalleryImport_Call struct {
+	*mock.Call
+}
+
+// CreateGalleryImport is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *model.GalleryImport
+func (_e *GalleryRepository_Expecter) CreateGalleryImport(_a0 interface{}, _a1 interface{}) *GalleryRepository_CreateGalleryImport_Call {
+	return &GalleryRepository_CreateGalleryImport_Call{Call: _e.mock.On("CreateGalleryImport", _a0, _a1)}
+}
+
+func (_c *GalleryRepository_CreateGalleryImport_Call) Run(run func(_a0 context.Context, _a1 *model.GalleryImport)) *GalleryRepository_CreateGalleryImport_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.GalleryImport))
+	})
+	return _c
+}
+
+func (_c *GalleryRepository_CreateGalleryImport_Call) Return(_a0 error) *GalleryRepository_CreateGalleryImport_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GalleryRepository_CreateGalleryImport_Call) RunAndReturn(run func(context.Context, *model.GalleryImport) error) *GalleryRepository_CreateGalleryImport_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FinishGalleryImport provides a mock function with given fields: _a0, _a1
+func (_m *GalleryRepository) FinishGalleryImport(_a0 context.Context, _a1 *model.GalleryImport) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishGalleryImport")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.GalleryImport) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GalleryRepository_FinishGalleryImport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FinishGalleryImport'
+type GalleryRepository_FinishGalleryImport_Call struct {
+	*mock.Call
+}
+
+// FinishGalleryImport is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *model.GalleryImport
+func (_e *GalleryRepository_Expecter) FinishGalleryImport(_a0 interface{}, _a1 interface{}) *GalleryRepository_FinishGalleryImport_Call {
+	return &GalleryRepository_FinishGalleryImport_Call{Call: _e.mock.On("FinishGalleryImport", _a0, _a1)}
+}
+
+func (_c *GalleryRepository_FinishGalleryImport_Call) Run(run func(_a0 context.Context, _a1 *model.GalleryImport)) *GalleryRepository_FinishGalleryImport_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.GalleryImport))
+	})
+	return _c
+}
+
+func (_c *GalleryRepository_FinishGalleryImport_Call) Return(_a0 error) *GalleryRepository_FinishGalleryImport_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GalleryRepository_FinishGalleryImport_Call) RunAndReturn(run func(context.Context, *model.GalleryImport) error) *GalleryRepository_FinishGalleryImport_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGalleriesByIds provides a mock function with given fields: _a0, _a1, _a2
+func (_m *GalleryRepository) GetGalleriesByIds(_a0 context.Context, _a1 []int32, _a2 model.Visibility) ([]model.Gallery, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGalleriesByIds")
+	}
+
+	var r0 []model.Gallery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int32, model.Visibility) ([]model.Gallery, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int32, model.Visibility) []model.Gallery); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Gallery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int32, model.Visibility) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GalleryRepository_GetGalleriesByIds_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGalleriesByIds'
+type GalleryRepository_GetGalleriesByIds_Call struct {
+	*mock.Call
+}
+
+// GetGalleriesByIds is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 []int32
+//   - _a2 model.Visibility
+func (_e *GalleryRepository_Expecter) GetGalleriesByIds(_a0 interface{}, _a1 interface{}, _a2 interface{}) *GalleryRepository_GetGalleriesByIds_Call {
+	return &GalleryRepository_GetGalleriesByIds_Call{Call: _e.mock.On("GetGalleriesByIds", _a0, _a1, _a2)}
+}
+
+func (_c *GalleryRepository_GetGalleriesByIds_Call) Run(run func(_a0 context.Context, _a1 []int32, _a2 model.Visibility)) *GalleryRepository_GetGalleriesByIds_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]int32), args[2].(model.Visibility))
+	})
+	return _c
+}
+
+func (_c *GalleryRepository_GetGalleriesByIds_Call) Return(_a0 []model.Gallery, _a1 error) *GalleryRepository_GetGalleriesByIds_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GalleryRepository_GetGalleriesByIds_Call) RunAndReturn(run func(context.Context, []int32, model.Visibility) ([]model.Gallery, error)) *GalleryRepository_GetGalleriesByIds_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGalleriesByOwners provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *GalleryRepository) GetGalleriesByOwners(_a0 context.Context, _a1 []int32, _a2 model.Visibility, _a3 int) ([]model.Gallery, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGalleriesByOwners")
+	}
+
+	var r0 []model.Gallery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int32, model.Visibility, int) ([]model.Gallery, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int32, model.Visibility, int) []model.Gallery); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Gallery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int32, model.Visibility, int) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GalleryRepository_GetGalleriesByOwners_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGalleriesByOwners'
+type GalleryRepository_GetGalleriesByOwners_Call struct {
+	*mock.Call
+}
+
+// GetGalleriesByOwners is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 []int32
+//   - _a2 model.Visibility
+//   - _a3 int
+func (_e *GalleryRepository_Expecter) GetGalleriesByOwners(_a0 interface{}, _a1 interface{}, _a2 interface{}, _a3 interface{}) *GalleryRepository_GetGalleriesByOwners_Call {
+	return &GalleryRepository_GetGalleriesByOwners_Call{Call: _e.mock.On("GetGalleriesByOwners", _a0, _a1, _a2, _a3)}
+}
+
+func (_c *GalleryRepository_GetGalleriesByOwners_Call) Run(run func(_a0 context.Context, _a1 []int32, _a2 model.Visibility, _a3 int)) *GalleryRepository_GetGalleriesByOwners_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]int32), args[2].(model.Visibility), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *GalleryRepository_GetGalleriesByOwners_Call) Return(_a0 []model.Gallery, _a1 error) *GalleryRepository_GetGalleriesByOwners_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GalleryRepository_GetGalleriesByOwners_Call) RunAndReturn(run func(context.Context, []int32, model.Visibility, int) ([]model.Gallery, error)) *GalleryRepository_GetGalleriesByOwners_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGalleryById provides a mock function with given fields: _a0, _a1
+func (_m *GalleryRepository) GetGalleryById(_a0 context.Context, _a1 int32) (*model.Gallery, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGalleryById")
+	}
+
+	var r0 *model.Gallery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) (*model.Gallery, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) *model.Gallery); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Gallery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GalleryRepository_GetGalleryById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGalleryById'
+type GalleryRepository_GetGalleryById_Call struct {
+	*mock.Call
+}
+
+// GetGalleryById is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+func (_e *GalleryRepository_Expecter) GetGalleryById(_a0 interface{}, _a1 interface{}) *GalleryRepository_GetGalleryById_Call {
+	return &GalleryRepository_GetGalleryById_Call{Call: _e.mock.On("GetGalleryById", _a0, _a1)}
+}
+
+func (_c *GalleryRepository_GetGalleryById_Call) Run(run func(_a0 context.Context, _a1 int32)) *GalleryRepository_GetGalleryById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *GalleryRepository_GetGalleryById_Call) Return(_a0 *model.Gallery, _a1 error) *GalleryRepository_GetGalleryById_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GalleryRepository_GetGalleryById_Call) RunAndReturn(run func(context.Context, int32) (*model.Gallery, error)) *GalleryRepository_GetGalleryById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGalleryImport provides a mock function with given fields: _a0, _a1
+func (_m *GalleryRepository) GetGalleryImport(_a0 context.Context, _a1 int64) (*model.GalleryImport, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGalleryImport")
+	}
+
+	var r0 *model.GalleryImport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*model.GalleryImport, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *model.GalleryImport); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.GalleryImport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GalleryRepository_GetGalleryImport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGalleryImport'
+type GalleryRepository_GetGalleryImport_Call struct {
+	*mock.Call
+}
+
+// GetGalleryImport is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int64
+func (_e *GalleryRepository_Expecter) GetGalleryImport(_a0 interface{}, _a1 interface{}) *GalleryRepository_GetGalleryImport_Call {
+	return &GalleryRepository_GetGalleryImport_Call{Call: _e.mock.On("GetGalleryImport", _a0, _a1)}
+}
+
+func (_c *GalleryRepository_GetGalleryImport_Call) Run(run func(_a0 context.Context, _a1 int64)) *GalleryRepository_GetGalleryImport_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *GalleryRepository_GetGalleryImport_Call) Return(_a0 *model.GalleryImport, _a1 error) *GalleryRepository_GetGalleryImport_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GalleryRepository_GetGalleryImport_Call) RunAndReturn(run func(context.Context, int64) (*model.GalleryImport, error)) *GalleryRepository_GetGalleryImport_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGalleryPictures provides a mock function with given fields: _a0, _a1
+func (_m *GalleryRepository) GetGalleryPictures(_a0 context.Context, _a1 int32) ([]model.Picture, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGalleryPictures")
+	}
+
+	var r0 []model.Picture
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) ([]model.Picture, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) []model.Picture); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Picture)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GalleryRepository_GetGalleryPictures_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGalleryPictures'
+type GalleryRepository_GetGalleryPictures_Call struct {
+	*mock.Call
+}
+
+// GetGalleryPictures is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+func (_e *GalleryRepository_Expecter) GetGalleryPictures(_a0 interface{}, _a1 interface{}) *GalleryRepository_GetGalleryPictures_Call {
+	return &GalleryRepository_GetGalleryPictures_Call{Call: _e.mock.On("GetGalleryPictures", _a0, _a1)}
+}
+
+func (_c *GalleryRepository_GetGalleryPictures_Call) Run(run func(_a0 context.Context, _a1 int32)) *GalleryRepository_GetGalleryPictures_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *GalleryRepository_GetGalleryPictures_Call) Return(_a0 []model.Picture, _a1 error) *GalleryRepository_GetGalleryPictures_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GalleryRepository_GetGalleryPictures_Call) RunAndReturn(run func(context.Context, int32) ([]model.Picture, error)) *GalleryRepository_GetGalleryPictures_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetHashedPictures provides a mock function with given fields: _a0, _a1
+func (_m *GalleryRepository) GetHashedPictures(_a0 context.Context, _a1 int32) ([]model.Picture, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetHashedPictures")
+	}
+
+	var r0 []model.Picture
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) ([]model.Picture, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) []model.Picture); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Picture)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GalleryRepository_GetHashedPictures_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetHashedPictures'
+type GalleryRepository_GetHashedPictures_Call struct {
+	*mock.Call
+}
+
+// GetHashedPictures is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+func (_e *GalleryRepository_Expecter) GetHashedPictures(_a0 interface{}, _a1 interface{}) *GalleryRepository_GetHashedPictures_Call {
+	return &GalleryRepository_GetHashedPictures_Call{Call: _e.mock.On("GetHashedPictures", _a0, _a1)}
+}
+
+func (_c *GalleryRepository_GetHashedPictures_Call) Run(run func(_a0 context.Context, _a1 int32)) *GalleryRepository_GetHashedPictures_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *GalleryRepository_GetHashedPictures_Call) Return(_a0 []model.Picture, _a1 error) *GalleryRepository_GetHashedPictures_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GalleryRepository_GetHashedPictures_Call) RunAndReturn(run func(context.Context, int32) ([]model.Picture, error)) *GalleryRepository_GetHashedPictures_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPicturesByGalleries provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *GalleryRepository) GetPicturesByGalleries(_a0 context.Context, _a1 []int32, _a2 model.Visibility, _a3 int) ([]model.Picture, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPicturesByGalleries")
+	}
+
+	var r0 []model.Picture
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int32, model.Visibility, int) ([]model.Picture, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int32, model.Visibility, int) []model.Picture); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Picture)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int32, model.Visibility, int) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GalleryRepository_GetPicturesByGalleries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPicturesByGalleries'
+type GalleryRepository_GetPicturesByGalleries_Call struct {
+	*mock.Call
+}
+
+// GetPicturesByGalleries is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 []int32
+//   - _a2 model.Visibility
+//   - _a3 int
+func (_e *GalleryRepository_Expecter) GetPicturesByGalleries(_a0 interface{}, _a1 interface{}, _a2 interface{}, _a3 interface{}) *GalleryRepository_GetPicturesByGalleries_Call {
+	return &GalleryRepository_GetPicturesByGalleries_Call{Call: _e.mock.On("GetPicturesByGalleries", _a0, _a1, _a2, _a3)}
+}
+
+func (_c *GalleryRepository_GetPicturesByGalleries_Call) Run(run func(_a0 context.Context, _a1 []int32, _a2 model.Visibility, _a3 int)) *GalleryRepository_GetPicturesByGalleries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]int32), args[2].(model.Visibility), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *GalleryRepository_GetPicturesByGalleries_Call) Return(_a0 []model.Picture, _a1 error) *GalleryRepository_GetPicturesByGalleries_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GalleryRepository_GetPicturesByGalleries_Call) RunAndReturn(run func(context.Context, []int32, model.Visibility, int) ([]model.Picture, error)) *GalleryRepository_GetPicturesByGalleries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Search provides a mock function with given fields: _a0, _a1
+func (_m *GalleryRepository) Search(_a0 context.Context, _a1 model.SearchFilter) ([]model.SearchHit, int, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []model.SearchHit
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.SearchFilter) ([]model.SearchHit, int, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.SearchFilter) []model.SearchHit); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.SearchHit)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.SearchFilter) int); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, model.SearchFilter) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GalleryRepository_Search_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Search'
+type GalleryRepository_Search_Call struct {
+	*mock.Call
+}
+
+// Search is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 model.SearchFilter
+func (_e *GalleryRepository_Expecter) Search(_a0 interface{}, _a1 interface{}) *GalleryRepository_Search_Call {
+	return &GalleryRepository_Search_Call{Call: _e.mock.On("Search", _a0, _a1)}
+}
+
+func (_c *GalleryRepository_Search_Call) Run(run func(_a0 context.Context, _a1 model.SearchFilter)) *GalleryRepository_Search_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.SearchFilter))
+	})
+	return _c
+}
+
+func (_c *GalleryRepository_Search_Call) Return(_a0 []model.SearchHit, _a1 int, _a2 error) *GalleryRepository_Search_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GalleryRepository_Search_Call) RunAndReturn(run func(context.Context, model.SearchFilter) ([]model.SearchHit, int, error)) *GalleryRepository_Search_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewGalleryRepository creates a new instance of GalleryRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewGalleryRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *GalleryRepository {
+	mock := &GalleryRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
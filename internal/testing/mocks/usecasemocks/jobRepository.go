@@ -0,0 +1,155 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package usecasemocks
+
+import (
+	context "context"
+	model "ivanjabrony/refstudy/internal/model"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// JobRepository is an autogenerated mock type for the JobRepository type
+type JobRepository struct {
+	mock.Mock
+}
+
+type JobRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *JobRepository) EXPECT() *JobRepository_Expecter {
+	return &JobRepository_Expecter{mock: &_m.Mock}
+}
+
+// CreateJob provides a mock function with given fields: _a0, _a1
+func (_m *JobRepository) CreateJob(_a0 context.Context, _a1 *model.Job) (*model.Job, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateJob")
+	}
+
+	var r0 *model.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Job) (*model.Job, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Job) *model.Job); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Job) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// JobRepository_CreateJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateJob'
+type JobRepository_CreateJob_Call struct {
+	*mock.Call
+}
+
+// CreateJob is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *model.Job
+func (_e *JobRepository_Expecter) CreateJob(_a0 interface{}, _a1 interface{}) *JobRepository_CreateJob_Call {
+	return &JobRepository_CreateJob_Call{Call: _e.mock.On("CreateJob", _a0, _a1)}
+}
+
+func (_c *JobRepository_CreateJob_Call) Run(run func(_a0 context.Context, _a1 *model.Job)) *JobRepository_CreateJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.Job))
+	})
+	return _c
+}
+
+func (_c *JobRepository_CreateJob_Call) Return(_a0 *model.Job, _a1 error) *JobRepository_CreateJob_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *JobRepository_CreateJob_Call) RunAndReturn(run func(context.Context, *model.Job) (*model.Job, error)) *JobRepository_CreateJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetJobById provides a mock function with given fields: _a0, _a1
+func (_m *JobRepository) GetJobById(_a0 context.Context, _a1 int64) (*model.Job, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetJobById")
+	}
+
+	var r0 *model.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*model.Job, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *model.Job); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// JobRepository_GetJobById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetJobById'
+type JobRepository_GetJobById_Call struct {
+	*mock.Call
+}
+
+// GetJobById is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int64
+func (_e *JobRepository_Expecter) GetJobById(_a0 interface{}, _a1 interface{}) *JobRepository_GetJobById_Call {
+	return &JobRepository_GetJobById_Call{Call: _e.mock.On("GetJobById", _a0, _a1)}
+}
+
+func (_c *JobRepository_GetJobById_Call) Run(run func(_a0 context.Context, _a1 int64)) *JobRepository_GetJobById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *JobRepository_GetJobById_Call) Return(_a0 *model.Job, _a1 error) *JobRepository_GetJobById_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *JobRepository_GetJobById_Call) RunAndReturn(run func(context.Context, int64) (*model.Job, error)) *JobRepository_GetJobById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewJobRepository creates a new instance of JobRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewJobRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *JobRepository {
+	mock := &JobRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
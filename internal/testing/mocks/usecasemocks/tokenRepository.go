@@ -0,0 +1,133 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package usecasemocks
+
+import (
+	context "context"
+	model "ivanjabrony/refstudy/internal/model"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// TokenRepository is an autogenerated mock type for the TokenRepository type
+type TokenRepository struct {
+	mock.Mock
+}
+
+type TokenRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *TokenRepository) EXPECT() *TokenRepository_Expecter {
+	return &TokenRepository_Expecter{mock: &_m.Mock}
+}
+
+// ConsumeUserToken provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *TokenRepository) ConsumeUserToken(_a0 context.Context, _a1 string, _a2 int32, _a3 model.TokenPurpose) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeUserToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int32, model.TokenPurpose) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TokenRepository_ConsumeUserToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConsumeUserToken'
+type TokenRepository_ConsumeUserToken_Call struct {
+	*mock.Call
+}
+
+// ConsumeUserToken is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+//   - _a2 int32
+//   - _a3 model.TokenPurpose
+func (_e *TokenRepository_Expecter) ConsumeUserToken(_a0 interface{}, _a1 interface{}, _a2 interface{}, _a3 interface{}) *TokenRepository_ConsumeUserToken_Call {
+	return &TokenRepository_ConsumeUserToken_Call{Call: _e.mock.On("ConsumeUserToken", _a0, _a1, _a2, _a3)}
+}
+
+func (_c *TokenRepository_ConsumeUserToken_Call) Run(run func(_a0 context.Context, _a1 string, _a2 int32, _a3 model.TokenPurpose)) *TokenRepository_ConsumeUserToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int32), args[3].(model.TokenPurpose))
+	})
+	return _c
+}
+
+func (_c *TokenRepository_ConsumeUserToken_Call) Return(_a0 error) *TokenRepository_ConsumeUserToken_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *TokenRepository_ConsumeUserToken_Call) RunAndReturn(run func(context.Context, string, int32, model.TokenPurpose) error) *TokenRepository_ConsumeUserToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateUserToken provides a mock function with given fields: _a0, _a1
+func (_m *TokenRepository) CreateUserToken(_a0 context.Context, _a1 *model.UserToken) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUserToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.UserToken) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TokenRepository_CreateUserToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateUserToken'
+type TokenRepository_CreateUserToken_Call struct {
+	*mock.Call
+}
+
+// CreateUserToken is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *model.UserToken
+func (_e *TokenRepository_Expecter) CreateUserToken(_a0 interface{}, _a1 interface{}) *TokenRepository_CreateUserToken_Call {
+	return &TokenRepository_CreateUserToken_Call{Call: _e.mock.On("CreateUserToken", _a0, _a1)}
+}
+
+func (_c *TokenRepository_CreateUserToken_Call) Run(run func(_a0 context.Context, _a1 *model.UserToken)) *TokenRepository_CreateUserToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.UserToken))
+	})
+	return _c
+}
+
+func (_c *TokenRepository_CreateUserToken_Call) Return(_a0 error) *TokenRepository_CreateUserToken_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *TokenRepository_CreateUserToken_Call) RunAndReturn(run func(context.Context, *model.UserToken) error) *TokenRepository_CreateUserToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewTokenRepository creates a new instance of TokenRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTokenRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TokenRepository {
+	mock := &TokenRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,734 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package usecasemocks
+
+import (
+	context "context"
+	model "ivanjabrony/refstudy/internal/model"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// UserRepository is an autogenerated mock type for the UserRepository type
+type UserRepository struct {
+	mock.Mock
+}
+
+type UserRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *UserRepository) EXPECT() *UserRepository_Expecter {
+	return &UserRepository_Expecter{mock: &_m.Mock}
+}
+
+// CreateUser provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) CreateUser(_a0 context.Context, _a1 *model.User) (*model.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUser")
+	}
+
+	var r0 *model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.User) (*model.User, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.User) *model.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.User) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserRepository_CreateUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateUser'
+type UserRepository_CreateUser_Call struct {
+	*mock.Call
+}
+
+// CreateUser is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *model.User
+func (_e *UserRepository_Expecter) CreateUser(_a0 interface{}, _a1 interface{}) *UserRepository_CreateUser_Call {
+	return &UserRepository_CreateUser_Call{Call: _e.mock.On("CreateUser", _a0, _a1)}
+}
+
+func (_c *UserRepository_CreateUser_Call) Run(run func(_a0 context.Context, _a1 *model.User)) *UserRepository_CreateUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.User))
+	})
+	return _c
+}
+
+func (_c *UserRepository_CreateUser_Call) Return(_a0 *model.User, _a1 error) *UserRepository_CreateUser_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserRepository_CreateUser_Call) RunAndReturn(run func(context.Context, *model.User) (*model.User, error)) *UserRepository_CreateUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteUserById provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) DeleteUserById(_a0 context.Context, _a1 int32) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUserById")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserRepository_DeleteUserById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteUserById'
+type UserRepository_DeleteUserById_Call struct {
+	*mock.Call
+}
+
+// DeleteUserById is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+func (_e *UserRepository_Expecter) DeleteUserById(_a0 interface{}, _a1 interface{}) *UserRepository_DeleteUserById_Call {
+	return &UserRepository_DeleteUserById_Call{Call: _e.mock.On("DeleteUserById", _a0, _a1)}
+}
+
+func (_c *UserRepository_DeleteUserById_Call) Run(run func(_a0 context.Context, _a1 int32)) *UserRepository_DeleteUserById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *UserRepository_DeleteUserById_Call) Return(_a0 error) *UserRepository_DeleteUserById_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UserRepository_DeleteUserById_Call) RunAndReturn(run func(context.Context, int32) error) *UserRepository_DeleteUserById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAllUsers provides a mock function with given fields: _a0
+func (_m *UserRepository) GetAllUsers(_a0 context.Context) ([]model.User, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllUsers")
+	}
+
+	var r0 []model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]model.User, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []model.User); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserRepository_GetAllUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllUsers'
+type UserRepository_GetAllUsers_Call struct {
+	*mock.Call
+}
+
+// GetAllUsers is a helper method to define mock.On call
+//   - _a0 context.Context
+func (_e *UserRepository_Expecter) GetAllUsers(_a0 interface{}) *UserRepository_GetAllUsers_Call {
+	return &UserRepository_GetAllUsers_Call{Call: _e.mock.On("GetAllUsers", _a0)}
+}
+
+func (_c *UserRepository_GetAllUsers_Call) Run(run func(_a0 context.Context)) *UserRepository_GetAllUsers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *UserRepository_GetAllUsers_Call) Return(_a0 []model.User, _a1 error) *UserRepository_GetAllUsers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserRepository_GetAllUsers_Call) RunAndReturn(run func(context.Context) ([]model.User, error)) *UserRepository_GetAllUsers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserById provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) GetUserById(_a0 context.Context, _a1 int32) (*model.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserById")
+	}
+
+	var r0 *model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) (*model.User, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) *model.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserRepository_GetUserById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserById'
+type UserRepository_GetUserById_Call struct {
+	*mock.Call
+}
+
+// GetUserById is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+func (_e *UserRepository_Expecter) GetUserById(_a0 interface{}, _a1 interface{}) *UserRepository_GetUserById_Call {
+	return &UserRepository_GetUserById_Call{Call: _e.mock.On("GetUserById", _a0, _a1)}
+}
+
+func (_c *UserRepository_GetUserById_Call) Run(run func(_a0 context.Context, _a1 int32)) *UserRepository_GetUserById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *UserRepository_GetUserById_Call) Return(_a0 *model.User, _a1 error) *UserRepository_GetUserById_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserRepository_GetUserById_Call) RunAndReturn(run func(context.Context, int32) (*model.User, error)) *UserRepository_GetUserById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByUsername provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) GetUserByUsername(_a0 context.Context, _a1 string) (*model.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByUsername")
+	}
+
+	var r0 *model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.User, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserRepository_GetUserByUsername_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByUsername'
+type UserRepository_GetUserByUsername_Call struct {
+	*mock.Call
+}
+
+// GetUserByUsername is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+func (_e *UserRepository_Expecter) GetUserByUsername(_a0 interface{}, _a1 interface{}) *UserRepository_GetUserByUsername_Call {
+	return &UserRepository_GetUserByUsername_Call{Call: _e.mock.On("GetUserByUsername", _a0, _a1)}
+}
+
+func (_c *UserRepository_GetUserByUsername_Call) Run(run func(_a0 context.Context, _a1 string)) *UserRepository_GetUserByUsername_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *UserRepository_GetUserByUsername_Call) Return(_a0 *model.User, _a1 error) *UserRepository_GetUserByUsername_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserRepository_GetUserByUsername_Call) RunAndReturn(run func(context.Context, string) (*model.User, error)) *UserRepository_GetUserByUsername_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserCredentials provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) GetUserCredentials(_a0 context.Context, _a1 string) (*model.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserCredentials")
+	}
+
+	var r0 *model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.User, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserRepository_GetUserCredentials_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserCredentials'
+type UserRepository_GetUserCredentials_Call struct {
+	*mock.Call
+}
+
+// GetUserCredentials is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+func (_e *UserRepository_Expecter) GetUserCredentials(_a0 interface{}, _a1 interface{}) *UserRepository_GetUserCredentials_Call {
+	return &UserRepository_GetUserCredentials_Call{Call: _e.mock.On("GetUserCredentials", _a0, _a1)}
+}
+
+func (_c *UserRepository_GetUserCredentials_Call) Run(run func(_a0 context.Context, _a1 string)) *UserRepository_GetUserCredentials_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *UserRepository_GetUserCredentials_Call) Return(_a0 *model.User, _a1 error) *UserRepository_GetUserCredentials_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserRepository_GetUserCredentials_Call) RunAndReturn(run func(context.Context, string) (*model.User, error)) *UserRepository_GetUserCredentials_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUsersByIds provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) GetUsersByIds(_a0 context.Context, _a1 []int32) ([]model.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUsersByIds")
+	}
+
+	var r0 []model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int32) ([]model.User, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int32) []model.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int32) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserRepository_GetUsersByIds_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUsersByIds'
+type UserRepository_GetUsersByIds_Call struct {
+	*mock.Call
+}
+
+// GetUsersByIds is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 []int32
+func (_e *UserRepository_Expecter) GetUsersByIds(_a0 interface{}, _a1 interface{}) *UserRepository_GetUsersByIds_Call {
+	return &UserRepository_GetUsersByIds_Call{Call: _e.mock.On("GetUsersByIds", _a0, _a1)}
+}
+
+func (_c *UserRepository_GetUsersByIds_Call) Run(run func(_a0 context.Context, _a1 []int32)) *UserRepository_GetUsersByIds_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]int32))
+	})
+	return _c
+}
+
+func (_c *UserRepository_GetUsersByIds_Call) Return(_a0 []model.User, _a1 error) *UserRepository_GetUsersByIds_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserRepository_GetUsersByIds_Call) RunAndReturn(run func(context.Context, []int32) ([]model.User, error)) *UserRepository_GetUsersByIds_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PurgeDeletedUsers provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) PurgeDeletedUsers(_a0 context.Context, _a1 time.Time) (int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeDeletedUsers")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserRepository_PurgeDeletedUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PurgeDeletedUsers'
+type UserRepository_PurgeDeletedUsers_Call struct {
+	*mock.Call
+}
+
+// PurgeDeletedUsers is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 time.Time
+func (_e *UserRepository_Expecter) PurgeDeletedUsers(_a0 interface{}, _a1 interface{}) *UserRepository_PurgeDeletedUsers_Call {
+	return &UserRepository_PurgeDeletedUsers_Call{Call: _e.mock.On("PurgeDeletedUsers", _a0, _a1)}
+}
+
+func (_c *UserRepository_PurgeDeletedUsers_Call) Run(run func(_a0 context.Context, _a1 time.Time)) *UserRepository_PurgeDeletedUsers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *UserRepository_PurgeDeletedUsers_Call) Return(_a0 int64, _a1 error) *UserRepository_PurgeDeletedUsers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserRepository_PurgeDeletedUsers_Call) RunAndReturn(run func(context.Context, time.Time) (int64, error)) *UserRepository_PurgeDeletedUsers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RestoreUser provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) RestoreUser(_a0 context.Context, _a1 int32) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserRepository_RestoreUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestoreUser'
+type UserRepository_RestoreUser_Call struct {
+	*mock.Call
+}
+
+// RestoreUser is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+func (_e *UserRepository_Expecter) RestoreUser(_a0 interface{}, _a1 interface{}) *UserRepository_RestoreUser_Call {
+	return &UserRepository_RestoreUser_Call{Call: _e.mock.On("RestoreUser", _a0, _a1)}
+}
+
+func (_c *UserRepository_RestoreUser_Call) Run(run func(_a0 context.Context, _a1 int32)) *UserRepository_RestoreUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *UserRepository_RestoreUser_Call) Return(_a0 error) *UserRepository_RestoreUser_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UserRepository_RestoreUser_Call) RunAndReturn(run func(context.Context, int32) error) *UserRepository_RestoreUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetUserDisabled provides a mock function with given fields: _a0, _a1, _a2
+func (_m *UserRepository) SetUserDisabled(_a0 context.Context, _a1 int32, _a2 bool) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserDisabled")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, bool) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserRepository_SetUserDisabled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetUserDisabled'
+type UserRepository_SetUserDisabled_Call struct {
+	*mock.Call
+}
+
+// SetUserDisabled is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+//   - _a2 bool
+func (_e *UserRepository_Expecter) SetUserDisabled(_a0 interface{}, _a1 interface{}, _a2 interface{}) *UserRepository_SetUserDisabled_Call {
+	return &UserRepository_SetUserDisabled_Call{Call: _e.mock.On("SetUserDisabled", _a0, _a1, _a2)}
+}
+
+func (_c *UserRepository_SetUserDisabled_Call) Run(run func(_a0 context.Context, _a1 int32, _a2 bool)) *UserRepository_SetUserDisabled_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32), args[2].(bool))
+	})
+	return _c
+}
+
+func (_c *UserRepository_SetUserDisabled_Call) Return(_a0 error) *UserRepository_SetUserDisabled_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UserRepository_SetUserDisabled_Call) RunAndReturn(run func(context.Context, int32, bool) error) *UserRepository_SetUserDisabled_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetUserRole provides a mock function with given fields: _a0, _a1, _a2
+func (_m *UserRepository) SetUserRole(_a0 context.Context, _a1 int32, _a2 model.Role) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, model.Role) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserRepository_SetUserRole_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetUserRole'
+type UserRepository_SetUserRole_Call struct {
+	*mock.Call
+}
+
+// SetUserRole is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+//   - _a2 model.Role
+func (_e *UserRepository_Expecter) SetUserRole(_a0 interface{}, _a1 interface{}, _a2 interface{}) *UserRepository_SetUserRole_Call {
+	return &UserRepository_SetUserRole_Call{Call: _e.mock.On("SetUserRole", _a0, _a1, _a2)}
+}
+
+func (_c *UserRepository_SetUserRole_Call) Run(run func(_a0 context.Context, _a1 int32, _a2 model.Role)) *UserRepository_SetUserRole_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32), args[2].(model.Role))
+	})
+	return _c
+}
+
+func (_c *UserRepository_SetUserRole_Call) Return(_a0 error) *UserRepository_SetUserRole_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UserRepository_SetUserRole_Call) RunAndReturn(run func(context.Context, int32, model.Role) error) *UserRepository_SetUserRole_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamUsers provides a mock function with given fields: _a0, _a1, _a2
+func (_m *UserRepository) StreamUsers(_a0 context.Context, _a1 model.Keyset, _a2 func(*model.User) error) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamUsers")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Keyset, func(*model.User) error) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserRepository_StreamUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamUsers'
+type UserRepository_StreamUsers_Call struct {
+	*mock.Call
+}
+
+// StreamUsers is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 model.Keyset
+//   - _a2 func(*model.User) error
+func (_e *UserRepository_Expecter) StreamUsers(_a0 interface{}, _a1 interface{}, _a2 interface{}) *UserRepository_StreamUsers_Call {
+	return &UserRepository_StreamUsers_Call{Call: _e.mock.On("StreamUsers", _a0, _a1, _a2)}
+}
+
+func (_c *UserRepository_StreamUsers_Call) Run(run func(_a0 context.Context, _a1 model.Keyset, _a2 func(*model.User) error)) *UserRepository_StreamUsers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.Keyset), args[2].(func(*model.User) error))
+	})
+	return _c
+}
+
+func (_c *UserRepository_StreamUsers_Call) Return(_a0 error) *UserRepository_StreamUsers_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UserRepository_StreamUsers_Call) RunAndReturn(run func(context.Context, model.Keyset, func(*model.User) error) error) *UserRepository_StreamUsers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateUser provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) UpdateUser(_a0 context.Context, _a1 *model.UserUpdate) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.UserUpdate) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserRepository_UpdateUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateUser'
+type UserRepository_UpdateUser_Call struct {
+	*mock.Call
+}
+
+// UpdateUser is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *model.UserUpdate
+func (_e *UserRepository_Expecter) UpdateUser(_a0 interface{}, _a1 interface{}) *UserRepository_UpdateUser_Call {
+	return &UserRepository_UpdateUser_Call{Call: _e.mock.On("UpdateUser", _a0, _a1)}
+}
+
+func (_c *UserRepository_UpdateUser_Call) Run(run func(_a0 context.Context, _a1 *model.UserUpdate)) *UserRepository_UpdateUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.UserUpdate))
+	})
+	return _c
+}
+
+func (_c *UserRepository_UpdateUser_Call) Return(_a0 error) *UserRepository_UpdateUser_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UserRepository_UpdateUser_Call) RunAndReturn(run func(context.Context, *model.UserUpdate) error) *UserRepository_UpdateUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewUserRepository creates a new instance of UserRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewUserRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UserRepository {
+	mock := &UserRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
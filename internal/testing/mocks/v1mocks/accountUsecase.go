@@ -0,0 +1,224 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package v1mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AccountUsecase is an autogenerated mock type for the AccountUsecase type
+type AccountUsecase struct {
+	mock.Mock
+}
+
+type AccountUsecase_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AccountUsecase) EXPECT() *AccountUsecase_Expecter {
+	return &AccountUsecase_Expecter{mock: &_m.Mock}
+}
+
+// ForgotPassword provides a mock function with given fields: ctx, email
+func (_m *AccountUsecase) ForgotPassword(ctx context.Context, email string) error {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ForgotPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AccountUsecase_ForgotPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ForgotPassword'
+type AccountUsecase_ForgotPassword_Call struct {
+	*mock.Call
+}
+
+// ForgotPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+func (_e *AccountUsecase_Expecter) ForgotPassword(ctx interface{}, email interface{}) *AccountUsecase_ForgotPassword_Call {
+	return &AccountUsecase_ForgotPassword_Call{Call: _e.mock.On("ForgotPassword", ctx, email)}
+}
+
+func (_c *AccountUsecase_ForgotPassword_Call) Run(run func(ctx context.Context, email string)) *AccountUsecase_ForgotPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *AccountUsecase_ForgotPassword_Call) Return(_a0 error) *AccountUsecase_ForgotPassword_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AccountUsecase_ForgotPassword_Call) RunAndReturn(run func(context.Context, string) error) *AccountUsecase_ForgotPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RequestEmailVerification provides a mock function with given fields: ctx
+func (_m *AccountUsecase) RequestEmailVerification(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequestEmailVerification")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AccountUsecase_RequestEmailVerification_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RequestEmailVerification'
+type AccountUsecase_RequestEmailVerification_Call struct {
+	*mock.Call
+}
+
+// RequestEmailVerification is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *AccountUsecase_Expecter) RequestEmailVerification(ctx interface{}) *AccountUsecase_RequestEmailVerification_Call {
+	return &AccountUsecase_RequestEmailVerification_Call{Call: _e.mock.On("RequestEmailVerification", ctx)}
+}
+
+func (_c *AccountUsecase_RequestEmailVerification_Call) Run(run func(ctx context.Context)) *AccountUsecase_RequestEmailVerification_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *AccountUsecase_RequestEmailVerification_Call) Return(_a0 error) *AccountUsecase_RequestEmailVerification_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AccountUsecase_RequestEmailVerification_Call) RunAndReturn(run func(context.Context) error) *AccountUsecase_RequestEmailVerification_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResetPassword provides a mock function with given fields: ctx, token, password
+func (_m *AccountUsecase) ResetPassword(ctx context.Context, token string, password string) error {
+	ret := _m.Called(ctx, token, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, token, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AccountUsecase_ResetPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetPassword'
+type AccountUsecase_ResetPassword_Call struct {
+	*mock.Call
+}
+
+// ResetPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - password string
+func (_e *AccountUsecase_Expecter) ResetPassword(ctx interface{}, token interface{}, password interface{}) *AccountUsecase_ResetPassword_Call {
+	return &AccountUsecase_ResetPassword_Call{Call: _e.mock.On("ResetPassword", ctx, token, password)}
+}
+
+func (_c *AccountUsecase_ResetPassword_Call) Run(run func(ctx context.Context, token string, password string)) *AccountUsecase_ResetPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *AccountUsecase_ResetPassword_Call) Return(_a0 error) *AccountUsecase_ResetPassword_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AccountUsecase_ResetPassword_Call) RunAndReturn(run func(context.Context, string, string) error) *AccountUsecase_ResetPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// VerifyEmail provides a mock function with given fields: ctx, token
+func (_m *AccountUsecase) VerifyEmail(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyEmail")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AccountUsecase_VerifyEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'VerifyEmail'
+type AccountUsecase_VerifyEmail_Call struct {
+	*mock.Call
+}
+
+// VerifyEmail is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+func (_e *AccountUsecase_Expecter) VerifyEmail(ctx interface{}, token interface{}) *AccountUsecase_VerifyEmail_Call {
+	return &AccountUsecase_VerifyEmail_Call{Call: _e.mock.On("VerifyEmail", ctx, token)}
+}
+
+func (_c *AccountUsecase_VerifyEmail_Call) Run(run func(ctx context.Context, token string)) *AccountUsecase_VerifyEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *AccountUsecase_VerifyEmail_Call) Return(_a0 error) *AccountUsecase_VerifyEmail_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AccountUsecase_VerifyEmail_Call) RunAndReturn(run func(context.Context, string) error) *AccountUsecase_VerifyEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAccountUsecase creates a new instance of AccountUsecase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAccountUsecase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AccountUsecase {
+	mock := &AccountUsecase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,239 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package v1mocks
+
+import (
+	context "context"
+	dto "ivanjabrony/refstudy/internal/model/dto"
+
+	mock "github.com/stretchr/testify/mock"
+
+	model "ivanjabrony/refstudy/internal/model"
+)
+
+// AdminUsecase is an autogenerated mock type for the AdminUsecase type
+type AdminUsecase struct {
+	mock.Mock
+}
+
+type AdminUsecase_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AdminUsecase) EXPECT() *AdminUsecase_Expecter {
+	return &AdminUsecase_Expecter{mock: &_m.Mock}
+}
+
+// DisableUser provides a mock function with given fields: ctx, id
+func (_m *AdminUsecase) DisableUser(ctx context.Context, id int32) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DisableUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AdminUsecase_DisableUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DisableUser'
+type AdminUsecase_DisableUser_Call struct {
+	*mock.Call
+}
+
+// DisableUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int32
+func (_e *AdminUsecase_Expecter) DisableUser(ctx interface{}, id interface{}) *AdminUsecase_DisableUser_Call {
+	return &AdminUsecase_DisableUser_Call{Call: _e.mock.On("DisableUser", ctx, id)}
+}
+
+func (_c *AdminUsecase_DisableUser_Call) Run(run func(ctx context.Context, id int32)) *AdminUsecase_DisableUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *AdminUsecase_DisableUser_Call) Return(_a0 error) *AdminUsecase_DisableUser_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AdminUsecase_DisableUser_Call) RunAndReturn(run func(context.Context, int32) error) *AdminUsecase_DisableUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// EnableUser provides a mock function with given fields: ctx, id
+func (_m *AdminUsecase) EnableUser(ctx context.Context, id int32) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnableUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AdminUsecase_EnableUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableUser'
+type AdminUsecase_EnableUser_Call struct {
+	*mock.Call
+}
+
+// EnableUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int32
+func (_e *AdminUsecase_Expecter) EnableUser(ctx interface{}, id interface{}) *AdminUsecase_EnableUser_Call {
+	return &AdminUsecase_EnableUser_Call{Call: _e.mock.On("EnableUser", ctx, id)}
+}
+
+func (_c *AdminUsecase_EnableUser_Call) Run(run func(ctx context.Context, id int32)) *AdminUsecase_EnableUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *AdminUsecase_EnableUser_Call) Return(_a0 error) *AdminUsecase_EnableUser_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AdminUsecase_EnableUser_Call) RunAndReturn(run func(context.Context, int32) error) *AdminUsecase_EnableUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAllUsers provides a mock function with given fields: ctx
+func (_m *AdminUsecase) GetAllUsers(ctx context.Context) ([]dto.UserDto, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllUsers")
+	}
+
+	var r0 []dto.UserDto
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]dto.UserDto, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []dto.UserDto); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.UserDto)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AdminUsecase_GetAllUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllUsers'
+type AdminUsecase_GetAllUsers_Call struct {
+	*mock.Call
+}
+
+// GetAllUsers is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *AdminUsecase_Expecter) GetAllUsers(ctx interface{}) *AdminUsecase_GetAllUsers_Call {
+	return &AdminUsecase_GetAllUsers_Call{Call: _e.mock.On("GetAllUsers", ctx)}
+}
+
+func (_c *AdminUsecase_GetAllUsers_Call) Run(run func(ctx context.Context)) *AdminUsecase_GetAllUsers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *AdminUsecase_GetAllUsers_Call) Return(_a0 []dto.UserDto, _a1 error) *AdminUsecase_GetAllUsers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AdminUsecase_GetAllUsers_Call) RunAndReturn(run func(context.Context) ([]dto.UserDto, error)) *AdminUsecase_GetAllUsers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetUserRole provides a mock function with given fields: ctx, id, role
+func (_m *AdminUsecase) SetUserRole(ctx context.Context, id int32, role model.Role) error {
+	ret := _m.Called(ctx, id, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, model.Role) error); ok {
+		r0 = rf(ctx, id, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AdminUsecase_SetUserRole_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetUserRole'
+type AdminUsecase_SetUserRole_Call struct {
+	*mock.Call
+}
+
+// SetUserRole is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int32
+//   - role model.Role
+func (_e *AdminUsecase_Expecter) SetUserRole(ctx interface{}, id interface{}, role interface{}) *AdminUsecase_SetUserRole_Call {
+	return &AdminUsecase_SetUserRole_Call{Call: _e.mock.On("SetUserRole", ctx, id, role)}
+}
+
+func (_c *AdminUsecase_SetUserRole_Call) Run(run func(ctx context.Context, id int32, role model.Role)) *AdminUsecase_SetUserRole_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32), args[2].(model.Role))
+	})
+	return _c
+}
+
+func (_c *AdminUsecase_SetUserRole_Call) Return(_a0 error) *AdminUsecase_SetUserRole_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AdminUsecase_SetUserRole_Call) RunAndReturn(run func(context.Context, int32, model.Role) error) *AdminUsecase_SetUserRole_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAdminUsecase creates a new instance of AdminUsecase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAdminUsecase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AdminUsecase {
+	mock := &AdminUsecase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
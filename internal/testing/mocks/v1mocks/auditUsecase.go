@@ -0,0 +1,105 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package v1mocks
+
+import (
+	context "context"
+	dto "ivanjabrony/refstudy/internal/model/dto"
+
+	mock "github.com/stretchr/testify/mock"
+
+	model "ivanjabrony/refstudy/internal/model"
+)
+
+// AuditUsecase is an autogenerated mock type for the AuditUsecase type
+type AuditUsecase struct {
+	mock.Mock
+}
+
+type AuditUsecase_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AuditUsecase) EXPECT() *AuditUsecase_Expecter {
+	return &AuditUsecase_Expecter{mock: &_m.Mock}
+}
+
+// GetAuditRecords provides a mock function with given fields: ctx, filter
+func (_m *AuditUsecase) GetAuditRecords(ctx context.Context, filter model.AuditFilter) ([]dto.AuditRecordDto, int, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAuditRecords")
+	}
+
+	var r0 []dto.AuditRecordDto
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.AuditFilter) ([]dto.AuditRecordDto, int, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.AuditFilter) []dto.AuditRecordDto); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.AuditRecordDto)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.AuditFilter) int); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, model.AuditFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// AuditUsecase_GetAuditRecords_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAuditRecords'
+type AuditUsecase_GetAuditRecords_Call struct {
+	*mock.Call
+}
+
+// GetAuditRecords is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter model.AuditFilter
+func (_e *AuditUsecase_Expecter) GetAuditRecords(ctx interface{}, filter interface{}) *AuditUsecase_GetAuditRecords_Call {
+	return &AuditUsecase_GetAuditRecords_Call{Call: _e.mock.On("GetAuditRecords", ctx, filter)}
+}
+
+func (_c *AuditUsecase_GetAuditRecords_Call) Run(run func(ctx context.Context, filter model.AuditFilter)) *AuditUsecase_GetAuditRecords_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.AuditFilter))
+	})
+	return _c
+}
+
+func (_c *AuditUsecase_GetAuditRecords_Call) Return(_a0 []dto.AuditRecordDto, _a1 int, _a2 error) *AuditUsecase_GetAuditRecords_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *AuditUsecase_GetAuditRecords_Call) RunAndReturn(run func(context.Context, model.AuditFilter) ([]dto.AuditRecordDto, int, error)) *AuditUsecase_GetAuditRecords_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAuditUsecase creates a new instance of AuditUsecase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditUsecase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditUsecase {
+	mock := &AuditUsecase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
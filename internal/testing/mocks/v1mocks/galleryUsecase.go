@@ -0,0 +1,333 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package v1mocks
+
+import (
+	context "context"
+	io "io"
+	dto "ivanjabrony/refstudy/internal/model/dto"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// GalleryUsecase is an autogenerated mock type for the GalleryUsecase type
+type GalleryUsecase struct {
+	mock.Mock
+}
+
+type GalleryUsecase_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *GalleryUsecase) EXPECT() *GalleryUsecase_Expecter {
+	return &GalleryUsecase_Expecter{mock: &_m.Mock}
+}
+
+// ExportGallery provides a mock function with given fields: ctx, id, w
+func (_m *GalleryUsecase) ExportGallery(ctx context.Context, id int32, w io.Writer) error {
+	ret := _m.Called(ctx, id, w)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportGallery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, io.Writer) error); ok {
+		r0 = rf(ctx, id, w)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GalleryUsecase_ExportGallery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExportGallery'
+type GalleryUsecase_ExportGallery_Call struct {
+	*mock.Call
+}
+
+// ExportGallery is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int32
+//   - w io.Writer
+func (_e *GalleryUsecase_Expecter) ExportGallery(ctx interface{}, id interface{}, w interface{}) *GalleryUsecase_ExportGallery_Call {
+	return &GalleryUsecase_ExportGallery_Call{Call: _e.mock.On("ExportGallery", ctx, id, w)}
+}
+
+func (_c *GalleryUsecase_ExportGallery_Call) Run(run func(ctx context.Context, id int32, w io.Writer)) *GalleryUsecase_ExportGallery_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32), args[2].(io.Writer))
+	})
+	return _c
+}
+
+func (_c *GalleryUsecase_ExportGallery_Call) Return(_a0 error) *GalleryUsecase_ExportGallery_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GalleryUsecase_ExportGallery_Call) RunAndReturn(run func(context.Context, int32, io.Writer) error) *GalleryUsecase_ExportGallery_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindDuplicates provides a mock function with given fields: ctx, id, threshold
+func (_m *GalleryUsecase) FindDuplicates(ctx context.Context, id int32, threshold int) ([]dto.DuplicateClusterDto, error) {
+	ret := _m.Called(ctx, id, threshold)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindDuplicates")
+	}
+
+	var r0 []dto.DuplicateClusterDto
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int) ([]dto.DuplicateClusterDto, error)); ok {
+		return rf(ctx, id, threshold)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int) []dto.DuplicateClusterDto); ok {
+		r0 = rf(ctx, id, threshold)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.DuplicateClusterDto)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32, int) error); ok {
+		r1 = rf(ctx, id, threshold)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GalleryUsecase_FindDuplicates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindDuplicates'
+type GalleryUsecase_FindDuplicates_Call struct {
+	*mock.Call
+}
+
+// FindDuplicates is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int32
+//   - threshold int
+func (_e *GalleryUsecase_Expecter) FindDuplicates(ctx interface{}, id interface{}, threshold interface{}) *GalleryUsecase_FindDuplicates_Call {
+	return &GalleryUsecase_FindDuplicates_Call{Call: _e.mock.On("FindDuplicates", ctx, id, threshold)}
+}
+
+func (_c *GalleryUsecase_FindDuplicates_Call) Run(run func(ctx context.Context, id int32, threshold int)) *GalleryUsecase_FindDuplicates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *GalleryUsecase_FindDuplicates_Call) Return(_a0 []dto.DuplicateClusterDto, _a1 error) *GalleryUsecase_FindDuplicates_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GalleryUsecase_FindDuplicates_Call) RunAndReturn(run func(context.Context, int32, int) ([]dto.DuplicateClusterDto, error)) *GalleryUsecase_FindDuplicates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGalleryImport provides a mock function with given fields: ctx, id
+func (_m *GalleryUsecase) GetGalleryImport(ctx context.Context, id int64) (*dto.GalleryImportDto, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGalleryImport")
+	}
+
+	var r0 *dto.GalleryImportDto
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*dto.GalleryImportDto, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *dto.GalleryImportDto); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.GalleryImportDto)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GalleryUsecase_GetGalleryImport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGalleryImport'
+type GalleryUsecase_GetGalleryImport_Call struct {
+	*mock.Call
+}
+
+// GetGalleryImport is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *GalleryUsecase_Expecter) GetGalleryImport(ctx interface{}, id interface{}) *GalleryUsecase_GetGalleryImport_Call {
+	return &GalleryUsecase_GetGalleryImport_Call{Call: _e.mock.On("GetGalleryImport", ctx, id)}
+}
+
+func (_c *GalleryUsecase_GetGalleryImport_Call) Run(run func(ctx context.Context, id int64)) *GalleryUsecase_GetGalleryImport_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *GalleryUsecase_GetGalleryImport_Call) Return(_a0 *dto.GalleryImportDto, _a1 error) *GalleryUsecase_GetGalleryImport_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GalleryUsecase_GetGalleryImport_Call) RunAndReturn(run func(context.Context, int64) (*dto.GalleryImportDto, error)) *GalleryUsecase_GetGalleryImport_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ImportGalleries provides a mock function with given fields: ctx, file, size
+func (_m *GalleryUsecase) ImportGalleries(ctx context.Context, file io.Reader, size int64) (*dto.GalleryImportDto, error) {
+	ret := _m.Called(ctx, file, size)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportGalleries")
+	}
+
+	var r0 *dto.GalleryImportDto
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, io.Reader, int64) (*dto.GalleryImportDto, error)); ok {
+		return rf(ctx, file, size)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, io.Reader, int64) *dto.GalleryImportDto); ok {
+		r0 = rf(ctx, file, size)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.GalleryImportDto)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, io.Reader, int64) error); ok {
+		r1 = rf(ctx, file, size)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GalleryUsecase_ImportGalleries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportGalleries'
+type GalleryUsecase_ImportGalleries_Call struct {
+	*mock.Call
+}
+
+// ImportGalleries is a helper method to define mock.On call
+//   - ctx context.Context
+//   - file io.Reader
+//   - size int64
+func (_e *GalleryUsecase_Expecter) ImportGalleries(ctx interface{}, file interface{}, size interface{}) *GalleryUsecase_ImportGalleries_Call {
+	return &GalleryUsecase_ImportGalleries_Call{Call: _e.mock.On("ImportGalleries", ctx, file, size)}
+}
+
+func (_c *GalleryUsecase_ImportGalleries_Call) Run(run func(ctx context.Context, file io.Reader, size int64)) *GalleryUsecase_ImportGalleries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(io.Reader), args[2].(int64))
+	})
+	return _c
+}
+
+func (_c *GalleryUsecase_ImportGalleries_Call) Return(_a0 *dto.GalleryImportDto, _a1 error) *GalleryUsecase_ImportGalleries_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GalleryUsecase_ImportGalleries_Call) RunAndReturn(run func(context.Context, io.Reader, int64) (*dto.GalleryImportDto, error)) *GalleryUsecase_ImportGalleries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Search provides a mock function with given fields: ctx, query, limit, offset
+func (_m *GalleryUsecase) Search(ctx context.Context, query string, limit int, offset int) ([]dto.SearchHitDto, int, error) {
+	ret := _m.Called(ctx, query, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []dto.SearchHitDto
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]dto.SearchHitDto, int, error)); ok {
+		return rf(ctx, query, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []dto.SearchHitDto); ok {
+		r0 = rf(ctx, query, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.SearchHitDto)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = rf(ctx, query, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = rf(ctx, query, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GalleryUsecase_Search_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Search'
+type GalleryUsecase_Search_Call struct {
+	*mock.Call
+}
+
+// Search is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+//   - limit int
+//   - offset int
+func (_e *GalleryUsecase_Expecter) Search(ctx interface{}, query interface{}, limit interface{}, offset interface{}) *GalleryUsecase_Search_Call {
+	return &GalleryUsecase_Search_Call{Call: _e.mock.On("Search", ctx, query, limit, offset)}
+}
+
+func (_c *GalleryUsecase_Search_Call) Run(run func(ctx context.Context, query string, limit int, offset int)) *GalleryUsecase_Search_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *GalleryUsecase_Search_Call) Return(_a0 []dto.SearchHitDto, _a1 int, _a2 error) *GalleryUsecase_Search_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GalleryUsecase_Search_Call) RunAndReturn(run func(context.Context, string, int, int) ([]dto.SearchHitDto, int, error)) *GalleryUsecase_Search_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewGalleryUsecase creates a new instance of GalleryUsecase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewGalleryUsecase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *GalleryUsecase {
+	mock := &GalleryUsecase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,96 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package v1mocks
+
+import (
+	context "context"
+	dto "ivanjabrony/refstudy/internal/model/dto"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// JobUsecase is an autogenerated mock type for the JobUsecase type
+type JobUsecase struct {
+	mock.Mock
+}
+
+type JobUsecase_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *JobUsecase) EXPECT() *JobUsecase_Expecter {
+	return &JobUsecase_Expecter{mock: &_m.Mock}
+}
+
+// GetJobById provides a mock function with given fields: ctx, id
+func (_m *JobUsecase) GetJobById(ctx context.Context, id int64) (*dto.JobDto, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetJobById")
+	}
+
+	var r0 *dto.JobDto
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*dto.JobDto, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *dto.JobDto); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.JobDto)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// JobUsecase_GetJobById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetJobById'
+type JobUsecase_GetJobById_Call struct {
+	*mock.Call
+}
+
+// GetJobById is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *JobUsecase_Expecter) GetJobById(ctx interface{}, id interface{}) *JobUsecase_GetJobById_Call {
+	return &JobUsecase_GetJobById_Call{Call: _e.mock.On("GetJobById", ctx, id)}
+}
+
+func (_c *JobUsecase_GetJobById_Call) Run(run func(ctx context.Context, id int64)) *JobUsecase_GetJobById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *JobUsecase_GetJobById_Call) Return(_a0 *dto.JobDto, _a1 error) *JobUsecase_GetJobById_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *JobUsecase_GetJobById_Call) RunAndReturn(run func(context.Context, int64) (*dto.JobDto, error)) *JobUsecase_GetJobById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewJobUsecase creates a new instance of JobUsecase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewJobUsecase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *JobUsecase {
+	mock := &JobUsecase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
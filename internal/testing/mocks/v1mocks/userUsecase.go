@@ -0,0 +1,470 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package v1mocks
+
+import (
+	context "context"
+	dto "ivanjabrony/refstudy/internal/model/dto"
+
+	mock "github.com/stretchr/testify/mock"
+
+	model "ivanjabrony/refstudy/internal/model"
+)
+
+// UserUsecase is an autogenerated mock type for the UserUsecase type
+type UserUsecase struct {
+	mock.Mock
+}
+
+type UserUsecase_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *UserUsecase) EXPECT() *UserUsecase_Expecter {
+	return &UserUsecase_Expecter{mock: &_m.Mock}
+}
+
+// CreateUser provides a mock function with given fields: ctx, _a1
+func (_m *UserUsecase) CreateUser(ctx context.Context, _a1 *dto.CreateUserDto) (*dto.UserDto, error) {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUser")
+	}
+
+	var r0 *dto.UserDto
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.CreateUserDto) (*dto.UserDto, error)); ok {
+		return rf(ctx, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.CreateUserDto) *dto.UserDto); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.UserDto)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.CreateUserDto) error); ok {
+		r1 = rf(ctx, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserUsecase_CreateUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateUser'
+type UserUsecase_CreateUser_Call struct {
+	*mock.Call
+}
+
+// CreateUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 *dto.CreateUserDto
+func (_e *UserUsecase_Expecter) CreateUser(ctx interface{}, _a1 interface{}) *UserUsecase_CreateUser_Call {
+	return &UserUsecase_CreateUser_Call{Call: _e.mock.On("CreateUser", ctx, _a1)}
+}
+
+func (_c *UserUsecase_CreateUser_Call) Run(run func(ctx context.Context, _a1 *dto.CreateUserDto)) *UserUsecase_CreateUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*dto.CreateUserDto))
+	})
+	return _c
+}
+
+func (_c *UserUsecase_CreateUser_Call) Return(_a0 *dto.UserDto, _a1 error) *UserUsecase_CreateUser_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserUsecase_CreateUser_Call) RunAndReturn(run func(context.Context, *dto.CreateUserDto) (*dto.UserDto, error)) *UserUsecase_CreateUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteUserById provides a mock function with given fields: _a0, _a1
+func (_m *UserUsecase) DeleteUserById(_a0 context.Context, _a1 int32) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUserById")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserUsecase_DeleteUserById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteUserById'
+type UserUsecase_DeleteUserById_Call struct {
+	*mock.Call
+}
+
+// DeleteUserById is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+func (_e *UserUsecase_Expecter) DeleteUserById(_a0 interface{}, _a1 interface{}) *UserUsecase_DeleteUserById_Call {
+	return &UserUsecase_DeleteUserById_Call{Call: _e.mock.On("DeleteUserById", _a0, _a1)}
+}
+
+func (_c *UserUsecase_DeleteUserById_Call) Run(run func(_a0 context.Context, _a1 int32)) *UserUsecase_DeleteUserById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *UserUsecase_DeleteUserById_Call) Return(_a0 error) *UserUsecase_DeleteUserById_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UserUsecase_DeleteUserById_Call) RunAndReturn(run func(context.Context, int32) error) *UserUsecase_DeleteUserById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAllUsers provides a mock function with given fields: ctx
+func (_m *UserUsecase) GetAllUsers(ctx context.Context) ([]dto.UserDto, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllUsers")
+	}
+
+	var r0 []dto.UserDto
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]dto.UserDto, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []dto.UserDto); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.UserDto)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserUsecase_GetAllUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllUsers'
+type UserUsecase_GetAllUsers_Call struct {
+	*mock.Call
+}
+
+// GetAllUsers is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *UserUsecase_Expecter) GetAllUsers(ctx interface{}) *UserUsecase_GetAllUsers_Call {
+	return &UserUsecase_GetAllUsers_Call{Call: _e.mock.On("GetAllUsers", ctx)}
+}
+
+func (_c *UserUsecase_GetAllUsers_Call) Run(run func(ctx context.Context)) *UserUsecase_GetAllUsers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *UserUsecase_GetAllUsers_Call) Return(_a0 []dto.UserDto, _a1 error) *UserUsecase_GetAllUsers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserUsecase_GetAllUsers_Call) RunAndReturn(run func(context.Context) ([]dto.UserDto, error)) *UserUsecase_GetAllUsers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserById provides a mock function with given fields: ctx, id
+func (_m *UserUsecase) GetUserById(ctx context.Context, id int32) (*dto.UserDto, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserById")
+	}
+
+	var r0 *dto.UserDto
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) (*dto.UserDto, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) *dto.UserDto); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.UserDto)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserUsecase_GetUserById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserById'
+type UserUsecase_GetUserById_Call struct {
+	*mock.Call
+}
+
+// GetUserById is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int32
+func (_e *UserUsecase_Expecter) GetUserById(ctx interface{}, id interface{}) *UserUsecase_GetUserById_Call {
+	return &UserUsecase_GetUserById_Call{Call: _e.mock.On("GetUserById", ctx, id)}
+}
+
+func (_c *UserUsecase_GetUserById_Call) Run(run func(ctx context.Context, id int32)) *UserUsecase_GetUserById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *UserUsecase_GetUserById_Call) Return(_a0 *dto.UserDto, _a1 error) *UserUsecase_GetUserById_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserUsecase_GetUserById_Call) RunAndReturn(run func(context.Context, int32) (*dto.UserDto, error)) *UserUsecase_GetUserById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUsersPage provides a mock function with given fields: ctx, keyset
+func (_m *UserUsecase) GetUsersPage(ctx context.Context, keyset model.Keyset) ([]dto.UserDto, bool, error) {
+	ret := _m.Called(ctx, keyset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUsersPage")
+	}
+
+	var r0 []dto.UserDto
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Keyset) ([]dto.UserDto, bool, error)); ok {
+		return rf(ctx, keyset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.Keyset) []dto.UserDto); ok {
+		r0 = rf(ctx, keyset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.UserDto)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.Keyset) bool); ok {
+		r1 = rf(ctx, keyset)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, model.Keyset) error); ok {
+		r2 = rf(ctx, keyset)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// UserUsecase_GetUsersPage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUsersPage'
+type UserUsecase_GetUsersPage_Call struct {
+	*mock.Call
+}
+
+// GetUsersPage is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keyset model.Keyset
+func (_e *UserUsecase_Expecter) GetUsersPage(ctx interface{}, keyset interface{}) *UserUsecase_GetUsersPage_Call {
+	return &UserUsecase_GetUsersPage_Call{Call: _e.mock.On("GetUsersPage", ctx, keyset)}
+}
+
+func (_c *UserUsecase_GetUsersPage_Call) Run(run func(ctx context.Context, keyset model.Keyset)) *UserUsecase_GetUsersPage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.Keyset))
+	})
+	return _c
+}
+
+func (_c *UserUsecase_GetUsersPage_Call) Return(_a0 []dto.UserDto, _a1 bool, _a2 error) *UserUsecase_GetUsersPage_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *UserUsecase_GetUsersPage_Call) RunAndReturn(run func(context.Context, model.Keyset) ([]dto.UserDto, bool, error)) *UserUsecase_GetUsersPage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RestoreUser provides a mock function with given fields: _a0, _a1
+func (_m *UserUsecase) RestoreUser(_a0 context.Context, _a1 int32) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserUsecase_RestoreUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestoreUser'
+type UserUsecase_RestoreUser_Call struct {
+	*mock.Call
+}
+
+// RestoreUser is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 int32
+func (_e *UserUsecase_Expecter) RestoreUser(_a0 interface{}, _a1 interface{}) *UserUsecase_RestoreUser_Call {
+	return &UserUsecase_RestoreUser_Call{Call: _e.mock.On("RestoreUser", _a0, _a1)}
+}
+
+func (_c *UserUsecase_RestoreUser_Call) Run(run func(_a0 context.Context, _a1 int32)) *UserUsecase_RestoreUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int32))
+	})
+	return _c
+}
+
+func (_c *UserUsecase_RestoreUser_Call) Return(_a0 error) *UserUsecase_RestoreUser_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UserUsecase_RestoreUser_Call) RunAndReturn(run func(context.Context, int32) error) *UserUsecase_RestoreUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamUsers provides a mock function with given fields: ctx, keyset, fn
+func (_m *UserUsecase) StreamUsers(ctx context.Context, keyset model.Keyset, fn func(*dto.UserDto) error) error {
+	ret := _m.Called(ctx, keyset, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamUsers")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Keyset, func(*dto.UserDto) error) error); ok {
+		r0 = rf(ctx, keyset, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserUsecase_StreamUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamUsers'
+type UserUsecase_StreamUsers_Call struct {
+	*mock.Call
+}
+
+// StreamUsers is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keyset model.Keyset
+//   - fn func(*dto.UserDto) error
+func (_e *UserUsecase_Expecter) StreamUsers(ctx interface{}, keyset interface{}, fn interface{}) *UserUsecase_StreamUsers_Call {
+	return &UserUsecase_StreamUsers_Call{Call: _e.mock.On("StreamUsers", ctx, keyset, fn)}
+}
+
+func (_c *UserUsecase_StreamUsers_Call) Run(run func(ctx context.Context, keyset model.Keyset, fn func(*dto.UserDto) error)) *UserUsecase_StreamUsers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.Keyset), args[2].(func(*dto.UserDto) error))
+	})
+	return _c
+}
+
+func (_c *UserUsecase_StreamUsers_Call) Return(_a0 error) *UserUsecase_StreamUsers_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UserUsecase_StreamUsers_Call) RunAndReturn(run func(context.Context, model.Keyset, func(*dto.UserDto) error) error) *UserUsecase_StreamUsers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateUser provides a mock function with given fields: _a0, _a1
+func (_m *UserUsecase) UpdateUser(_a0 context.Context, _a1 *dto.UpdateUserDto) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.UpdateUserDto) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserUsecase_UpdateUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateUser'
+type UserUsecase_UpdateUser_Call struct {
+	*mock.Call
+}
+
+// UpdateUser is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *dto.UpdateUserDto
+func (_e *UserUsecase_Expecter) UpdateUser(_a0 interface{}, _a1 interface{}) *UserUsecase_UpdateUser_Call {
+	return &UserUsecase_UpdateUser_Call{Call: _e.mock.On("UpdateUser", _a0, _a1)}
+}
+
+func (_c *UserUsecase_UpdateUser_Call) Run(run func(_a0 context.Context, _a1 *dto.UpdateUserDto)) *UserUsecase_UpdateUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*dto.UpdateUserDto))
+	})
+	return _c
+}
+
+func (_c *UserUsecase_UpdateUser_Call) Return(_a0 error) *UserUsecase_UpdateUser_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UserUsecase_UpdateUser_Call) RunAndReturn(run func(context.Context, *dto.UpdateUserDto) error) *UserUsecase_UpdateUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewUserUsecase creates a new instance of UserUsecase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewUserUsecase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UserUsecase {
+	mock := &UserUsecase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,449 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package workermocks
+
+import (
+	context "context"
+	model "ivanjabrony/refstudy/internal/model"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// JobRepository is an autogenerated mock type for the JobRepository type
+type JobRepository struct {
+	mock.Mock
+}
+
+type JobRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *JobRepository) EXPECT() *JobRepository_Expecter {
+	return &JobRepository_Expecter{mock: &_m.Mock}
+}
+
+// BuryJob provides a mock function with given fields: ctx, job, lastError
+func (_m *JobRepository) BuryJob(ctx context.Context, job *model.Job, lastError string) error {
+	ret := _m.Called(ctx, job, lastError)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BuryJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Job, string) error); ok {
+		r0 = rf(ctx, job, lastError)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// JobRepository_BuryJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BuryJob'
+type JobRepository_BuryJob_Call struct {
+	*mock.Call
+}
+
+// BuryJob is a helper method to define mock.On call
+//   - ctx context.Context
+//   - job *model.Job
+//   - lastError string
+func (_e *JobRepository_Expecter) BuryJob(ctx interface{}, job interface{}, lastError interface{}) *JobRepository_BuryJob_Call {
+	return &JobRepository_BuryJob_Call{Call: _e.mock.On("BuryJob", ctx, job, lastError)}
+}
+
+func (_c *JobRepository_BuryJob_Call) Run(run func(ctx context.Context, job *model.Job, lastError string)) *JobRepository_BuryJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.Job), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *JobRepository_BuryJob_Call) Return(_a0 error) *JobRepository_BuryJob_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *JobRepository_BuryJob_Call) RunAndReturn(run func(context.Context, *model.Job, string) error) *JobRepository_BuryJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ClaimJob provides a mock function with given fields: ctx, types
+func (_m *JobRepository) ClaimJob(ctx context.Context, types []string) (*model.Job, error) {
+	ret := _m.Called(ctx, types)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimJob")
+	}
+
+	var r0 *model.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) (*model.Job, error)); ok {
+		return rf(ctx, types)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) *model.Job); ok {
+		r0 = rf(ctx, types)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, types)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// JobRepository_ClaimJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimJob'
+type JobRepository_ClaimJob_Call struct {
+	*mock.Call
+}
+
+// ClaimJob is a helper method to define mock.On call
+//   - ctx context.Context
+//   - types []string
+func (_e *JobRepository_Expecter) ClaimJob(ctx interface{}, types interface{}) *JobRepository_ClaimJob_Call {
+	return &JobRepository_ClaimJob_Call{Call: _e.mock.On("ClaimJob", ctx, types)}
+}
+
+func (_c *JobRepository_ClaimJob_Call) Run(run func(ctx context.Context, types []string)) *JobRepository_ClaimJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *JobRepository_ClaimJob_Call) Return(_a0 *model.Job, _a1 error) *JobRepository_ClaimJob_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *JobRepository_ClaimJob_Call) RunAndReturn(run func(context.Context, []string) (*model.Job, error)) *JobRepository_ClaimJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CompleteJob provides a mock function with given fields: ctx, job
+func (_m *JobRepository) CompleteJob(ctx context.Context, job *model.Job) error {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompleteJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Job) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// JobRepository_CompleteJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CompleteJob'
+type JobRepository_CompleteJob_Call struct {
+	*mock.Call
+}
+
+// CompleteJob is a helper method to define mock.On call
+//   - ctx context.Context
+//   - job *model.Job
+func (_e *JobRepository_Expecter) CompleteJob(ctx interface{}, job interface{}) *JobRepository_CompleteJob_Call {
+	return &JobRepository_CompleteJob_Call{Call: _e.mock.On("CompleteJob", ctx, job)}
+}
+
+func (_c *JobRepository_CompleteJob_Call) Run(run func(ctx context.Context, job *model.Job)) *JobRepository_CompleteJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.Job))
+	})
+	return _c
+}
+
+func (_c *JobRepository_CompleteJob_Call) Return(_a0 error) *JobRepository_CompleteJob_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *JobRepository_CompleteJob_Call) RunAndReturn(run func(context.Context, *model.Job) error) *JobRepository_CompleteJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HeartbeatJob provides a mock function with given fields: ctx, job
+func (_m *JobRepository) HeartbeatJob(ctx context.Context, job *model.Job) error {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HeartbeatJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Job) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// JobRepository_HeartbeatJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HeartbeatJob'
+type JobRepository_HeartbeatJob_Call struct {
+	*mock.Call
+}
+
+// HeartbeatJob is a helper method to define mock.On call
+//   - ctx context.Context
+//   - job *model.Job
+func (_e *JobRepository_Expecter) HeartbeatJob(ctx interface{}, job interface{}) *JobRepository_HeartbeatJob_Call {
+	return &JobRepository_HeartbeatJob_Call{Call: _e.mock.On("HeartbeatJob", ctx, job)}
+}
+
+func (_c *JobRepository_HeartbeatJob_Call) Run(run func(ctx context.Context, job *model.Job)) *JobRepository_HeartbeatJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.Job))
+	})
+	return _c
+}
+
+func (_c *JobRepository_HeartbeatJob_Call) Return(_a0 error) *JobRepository_HeartbeatJob_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *JobRepository_HeartbeatJob_Call) RunAndReturn(run func(context.Context, *model.Job) error) *JobRepository_HeartbeatJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReleaseJob provides a mock function with given fields: ctx, job, lastError
+func (_m *JobRepository) ReleaseJob(ctx context.Context, job *model.Job, lastError string) error {
+	ret := _m.Called(ctx, job, lastError)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Job, string) error); ok {
+		r0 = rf(ctx, job, lastError)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// JobRepository_ReleaseJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseJob'
+type JobRepository_ReleaseJob_Call struct {
+	*mock.Call
+}
+
+// ReleaseJob is a helper method to define mock.On call
+//   - ctx context.Context
+//   - job *model.Job
+//   - lastError string
+func (_e *JobRepository_Expecter) ReleaseJob(ctx interface{}, job interface{}, lastError interface{}) *JobRepository_ReleaseJob_Call {
+	return &JobRepository_ReleaseJob_Call{Call: _e.mock.On("ReleaseJob", ctx, job, lastError)}
+}
+
+func (_c *JobRepository_ReleaseJob_Call) Run(run func(ctx context.Context, job *model.Job, lastError string)) *JobRepository_ReleaseJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.Job), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *JobRepository_ReleaseJob_Call) Return(_a0 error) *JobRepository_ReleaseJob_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *JobRepository_ReleaseJob_Call) RunAndReturn(run func(context.Context, *model.Job, string) error) *JobRepository_ReleaseJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RequeueStaleJobs provides a mock function with given fields: ctx, staleAfter
+func (_m *JobRepository) RequeueStaleJobs(ctx context.Context, staleAfter time.Duration) (int64, int64, error) {
+	ret := _m.Called(ctx, staleAfter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequeueStaleJobs")
+	}
+
+	var r0 int64
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) (int64, int64, error)); ok {
+		return rf(ctx, staleAfter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) int64); ok {
+		r0 = rf(ctx, staleAfter)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration) int64); ok {
+		r1 = rf(ctx, staleAfter)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, time.Duration) error); ok {
+		r2 = rf(ctx, staleAfter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// JobRepository_RequeueStaleJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RequeueStaleJobs'
+type JobRepository_RequeueStaleJobs_Call struct {
+	*mock.Call
+}
+
+// RequeueStaleJobs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - staleAfter time.Duration
+func (_e *JobRepository_Expecter) RequeueStaleJobs(ctx interface{}, staleAfter interface{}) *JobRepository_RequeueStaleJobs_Call {
+	return &JobRepository_RequeueStaleJobs_Call{Call: _e.mock.On("RequeueStaleJobs", ctx, staleAfter)}
+}
+
+func (_c *JobRepository_RequeueStaleJobs_Call) Run(run func(ctx context.Context, staleAfter time.Duration)) *JobRepository_RequeueStaleJobs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *JobRepository_RequeueStaleJobs_Call) Return(requeued int64, buried int64, err error) *JobRepository_RequeueStaleJobs_Call {
+	_c.Call.Return(requeued, buried, err)
+	return _c
+}
+
+func (_c *JobRepository_RequeueStaleJobs_Call) RunAndReturn(run func(context.Context, time.Duration) (int64, int64, error)) *JobRepository_RequeueStaleJobs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetryJob provides a mock function with given fields: ctx, job, runAt, lastError
+func (_m *JobRepository) RetryJob(ctx context.Context, job *model.Job, runAt time.Time, lastError string) error {
+	ret := _m.Called(ctx, job, runAt, lastError)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Job, time.Time, string) error); ok {
+		r0 = rf(ctx, job, runAt, lastError)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// JobRepository_RetryJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetryJob'
+type JobRepository_RetryJob_Call struct {
+	*mock.Call
+}
+
+// RetryJob is a helper method to define mock.On call
+//   - ctx context.Context
+//   - job *model.Job
+//   - runAt time.Time
+//   - lastError string
+func (_e *JobRepository_Expecter) RetryJob(ctx interface{}, job interface{}, runAt interface{}, lastError interface{}) *JobRepository_RetryJob_Call {
+	return &JobRepository_RetryJob_Call{Call: _e.mock.On("RetryJob", ctx, job, runAt, lastError)}
+}
+
+func (_c *JobRepository_RetryJob_Call) Run(run func(ctx context.Context, job *model.Job, runAt time.Time, lastError string)) *JobRepository_RetryJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.Job), args[2].(time.Time), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *JobRepository_RetryJob_Call) Return(_a0 error) *JobRepository_RetryJob_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *JobRepository_RetryJob_Call) RunAndReturn(run func(context.Context, *model.Job, time.Time, string) error) *JobRepository_RetryJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateJobProgress provides a mock function with given fields: ctx, job, progress
+func (_m *JobRepository) UpdateJobProgress(ctx context.Context, job *model.Job, progress int32) error {
+	ret := _m.Called(ctx, job, progress)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateJobProgress")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Job, int32) error); ok {
+		r0 = rf(ctx, job, progress)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// JobRepository_UpdateJobProgress_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateJobProgress'
+type JobRepository_UpdateJobProgress_Call struct {
+	*mock.Call
+}
+
+// UpdateJobProgress is a helper method to define mock.On call
+//   - ctx context.Context
+//   - job *model.Job
+//   - progress int32
+func (_e *JobRepository_Expecter) UpdateJobProgress(ctx interface{}, job interface{}, progress interface{}) *JobRepository_UpdateJobProgress_Call {
+	return &JobRepository_UpdateJobProgress_Call{Call: _e.mock.On("UpdateJobProgress", ctx, job, progress)}
+}
+
+func (_c *JobRepository_UpdateJobProgress_Call) Run(run func(ctx context.Context, job *model.Job, progress int32)) *JobRepository_UpdateJobProgress_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.Job), args[2].(int32))
+	})
+	return _c
+}
+
+func (_c *JobRepository_UpdateJobProgress_Call) Return(_a0 error) *JobRepository_UpdateJobProgress_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *JobRepository_UpdateJobProgress_Call) RunAndReturn(run func(context.Context, *model.Job, int32) error) *JobRepository_UpdateJobProgress_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewJobRepository creates a new instance of JobRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewJobRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *JobRepository {
+	mock := &JobRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
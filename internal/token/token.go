@@ -0,0 +1,109 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed = errors.New("malformed token")
+	ErrSignature = errors.New("invalid token signature")
+	ErrExpired   = errors.New("token expired")
+)
+
+type Claims struct {
+	Id        string    `json:"jti"`
+	UserId    int32     `json:"sub"`
+	Email     string    `json:"email"`
+	Purpose   string    `json:"purpose"`
+	ExpiresAt time.Time `json:"exp"`
+}
+
+// Signer issues and checks HMAC-SHA256 signed tokens of the form
+// base64url(claims).base64url(signature).
+type Signer struct {
+	secret []byte
+	now    func() time.Time
+}
+
+func NewSigner(secret []byte) (*Signer, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("token secret must be at least 32 bytes")
+	}
+
+	return &Signer{secret: secret, now: time.Now}, nil
+}
+
+// Issue creates a token for userId at email valid for ttl and returns it
+// together with its claims. The claims id is random, so it can be stored to
+// make the token single-use.
+func (s *Signer) Issue(userId int32, email, purpose string, ttl time.Duration) (string, Claims, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", Claims{}, fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	claims := Claims{
+		Id:        hex.EncodeToString(id),
+		UserId:    userId,
+		Email:     email,
+		Purpose:   purpose,
+		ExpiresAt: s.now().UTC().Add(ttl).Truncate(time.Second),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", Claims{}, fmt.Errorf("failed to encode token: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded)), claims, nil
+}
+
+// Verify checks the signature, expiry and purpose of token and returns its
+// claims.
+func (s *Signer) Verify(token, purpose string) (Claims, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return Claims{}, ErrMalformed
+	}
+
+	given, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	if !hmac.Equal(given, s.sign(encoded)) {
+		return Claims{}, ErrSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	if claims.Purpose != purpose {
+		return Claims{}, ErrSignature
+	}
+	if !s.now().Before(claims.ExpiresAt) {
+		return Claims{}, ErrExpired
+	}
+
+	return claims, nil
+}
+
+func (s *Signer) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,50 @@
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestNewSigner(t *testing.T) {
+	_, err := NewSigner([]byte("short"))
+	require.Error(t, err)
+}
+
+func TestSigner(t *testing.T) {
+	signer, err := NewSigner(secret)
+	require.NoError(t, err)
+
+	token, issued, err := signer.Issue(7, "ivan@example.com", "verify_email", time.Hour)
+	require.NoError(t, err)
+	require.Len(t, issued.Id, 32)
+
+	claims, err := signer.Verify(token, "verify_email")
+	require.NoError(t, err)
+	require.Equal(t, issued, claims)
+	require.Equal(t, int32(7), claims.UserId)
+	require.Equal(t, "ivan@example.com", claims.Email)
+
+	_, err = signer.Verify(token, "reset_password")
+	require.ErrorIs(t, err, ErrSignature)
+
+	other, err := NewSigner([]byte("fedcba9876543210fedcba9876543210"))
+	require.NoError(t, err)
+	_, err = other.Verify(token, "verify_email")
+	require.ErrorIs(t, err, ErrSignature)
+
+	payload, signature, _ := strings.Cut(token, ".")
+	_, err = signer.Verify(payload+"x."+signature, "verify_email")
+	require.ErrorIs(t, err, ErrSignature)
+
+	_, err = signer.Verify("garbage", "verify_email")
+	require.ErrorIs(t, err, ErrMalformed)
+
+	signer.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = signer.Verify(token, "verify_email")
+	require.ErrorIs(t, err, ErrExpired)
+}
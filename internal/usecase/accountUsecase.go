@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/mailer"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/password"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/token"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+type AccountRepository interface {
+	GetUserById(context.Context, int32) (*model.User, error)
+	GetUserByEmail(context.Context, string) (*model.User, error)
+	SetUserPassword(context.Context, int32, string) error
+	SetEmailVerified(context.Context, int32) error
+}
+
+type TokenRepository interface {
+	CreateUserToken(context.Context, *model.UserToken) error
+	ConsumeUserToken(context.Context, string, int32, model.TokenPurpose) error
+}
+
+type AccountConfig struct {
+	// FrontendURL serves the pages the emailed links open. Its /verify-email
+	// and /reset-password pages read the token from the query and post it
+	// to the API.
+	FrontendURL     string
+	VerificationTTL time.Duration
+	ResetTTL        time.Duration
+}
+
+type AccountUsecase struct {
+	users  AccountRepository
+	tokens TokenRepository
+	signer *token.Signer
+	mailer mailer.Mailer
+	audit  *Auditor
+	cfg    AccountConfig
+	logger *logger.MyLogger
+}
+
+func NewAccountUsecase(
+	users AccountRepository,
+	tokens TokenRepository,
+	signer *token.Signer,
+	mailer mailer.Mailer,
+	audit *Auditor,
+	cfg AccountConfig,
+	logger *logger.MyLogger,
+) (*AccountUsecase, error) {
+	if users == nil || tokens == nil || signer == nil || mailer == nil || audit == nil {
+		return nil, errors.New("nil values in AccountUsecase constructor")
+	}
+	return &AccountUsecase{users, tokens, signer, mailer, audit, cfg, logger}, nil
+}
+
+// SendVerificationEmail mails user a link to verify their address, unless
+// it is verified already.
+func (uc AccountUsecase) SendVerificationEmail(ctx context.Context, user *model.User) error {
+	if user.EmailVerified {
+		return nil
+	}
+
+	link, err := uc.issue(ctx, user, model.TokenVerifyEmail, uc.cfg.VerificationTTL, "/verify-email")
+	if err != nil {
+		return err
+	}
+
+	return uc.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nplease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+			user.Username, link, uc.cfg.VerificationTTL),
+	})
+}
+
+// RequestEmailVerification sends a new verification email to the
+// authenticated caller.
+func (uc AccountUsecase) RequestEmailVerification(ctx context.Context) error {
+	actor := policy.ActorFromContext(ctx)
+	if actor == nil {
+		return policy.ErrUnauthenticated
+	}
+
+	user, err := uc.users.GetUserById(ctx, actor.Id)
+	if err != nil {
+		return err
+	}
+
+	return uc.SendVerificationEmail(ctx, user)
+}
+
+// VerifyEmail marks the address the verification token was sent to as
+// verified, unless the account has changed its email since.
+func (uc AccountUsecase) VerifyEmail(ctx context.Context, verificationToken string) error {
+	claims, err := uc.signer.Verify(verificationToken, string(model.TokenVerifyEmail))
+	if err != nil {
+		return fmt.Errorf("%w: %w", model.ErrInvalidToken, err)
+	}
+
+	return auditUserChange(ctx, uc.audit, uc.users, model.AuditUpdate, claims.UserId, func(ctx context.Context) error {
+		if err := uc.consume(ctx, claims, model.TokenVerifyEmail); err != nil {
+			return err
+		}
+		return uc.users.SetEmailVerified(ctx, claims.UserId)
+	})
+}
+
+// ForgotPassword mails a password reset link to the account registered with
+// email. To not reveal which addresses are registered it succeeds silently
+// when there is no such account.
+func (uc AccountUsecase) ForgotPassword(ctx context.Context, email string) error {
+	user, err := uc.users.GetUserByEmail(ctx, email)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Disabled {
+		uc.logger.Info("password reset requested for disabled account", slog.Int("user_id", int(user.Id)))
+		return nil
+	}
+
+	link, err := uc.issue(ctx, user, model.TokenResetPassword, uc.cfg.ResetTTL, "/reset-password")
+	if err != nil {
+		return err
+	}
+
+	return uc.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nsomebody asked to reset the password of your account. If it was you, open the link below:\n\n%s\n\nThe link expires in %s. If you didn't ask for it, you can ignore this email.\n",
+			user.Username, link, uc.cfg.ResetTTL),
+	})
+}
+
+// ResetPassword sets a new password for the account the reset token was
+// issued to. Following the link proves control of the mailbox, so the email
+// address counts as verified afterwards. Like verification tokens, reset
+// tokens stop working when the account changes its email.
+func (uc AccountUsecase) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	claims, err := uc.signer.Verify(resetToken, string(model.TokenResetPassword))
+	if err != nil {
+		return fmt.Errorf("%w: %w", model.ErrInvalidToken, err)
+	}
+	hash, err := password.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return auditUserChange(ctx, uc.audit, uc.users, model.AuditUpdate, claims.UserId, func(ctx context.Context) error {
+		if err := uc.consume(ctx, claims, model.TokenResetPassword); err != nil {
+			return err
+		}
+		if err := uc.users.SetUserPassword(ctx, claims.UserId, hash); err != nil {
+			return err
+		}
+		return uc.users.SetEmailVerified(ctx, claims.UserId)
+	})
+}
+
+// consume uses up the token with claims, which has to have been issued for
+// the user's current email.
+func (uc AccountUsecase) consume(ctx context.Context, claims token.Claims, purpose model.TokenPurpose) error {
+	if err := uc.tokens.ConsumeUserToken(ctx, claims.Id, claims.UserId, purpose); err != nil {
+		return err
+	}
+
+	user, err := uc.users.GetUserById(ctx, claims.UserId)
+	if err != nil {
+		return err
+	}
+	if user.Email != claims.Email {
+		return fmt.Errorf("%w: issued for another email", model.ErrInvalidToken)
+	}
+	return nil
+}
+
+// issue creates and stores a token for user and returns the link carrying
+// it.
+func (uc AccountUsecase) issue(ctx context.Context, user *model.User, purpose model.TokenPurpose, ttl time.Duration, path string) (string, error) {
+	signed, claims, err := uc.signer.Issue(user.Id, user.Email, string(purpose), ttl)
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.tokens.CreateUserToken(ctx, &model.UserToken{
+		Id:        claims.Id,
+		UserId:    user.Id,
+		Purpose:   purpose,
+		ExpiresAt: claims.ExpiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return uc.cfg.FrontendURL + path + "?" + url.Values{"token": {signed}}.Encode(), nil
+}
@@ -0,0 +1,176 @@
+package usecase_test
+
+import (
+	"context"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/mailer"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/password"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/token"
+	"ivanjabrony/refstudy/internal/usecase"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAccounts struct {
+	users map[int32]*model.User
+}
+
+func (m *memoryAccounts) GetUserById(_ context.Context, id int32) (*model.User, error) {
+	user, ok := m.users[id]
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (m *memoryAccounts) GetUserByEmail(_ context.Context, email string) (*model.User, error) {
+	for _, user := range m.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (m *memoryAccounts) SetUserPassword(_ context.Context, id int32, password string) error {
+	m.users[id].Password = password
+	return nil
+}
+
+func (m *memoryAccounts) SetEmailVerified(_ context.Context, id int32) error {
+	m.users[id].EmailVerified = true
+	return nil
+}
+
+type memoryTokens struct {
+	tokens map[string]*model.UserToken
+	used   map[string]bool
+}
+
+func (m *memoryTokens) CreateUserToken(_ context.Context, t *model.UserToken) error {
+	m.tokens[t.Id] = t
+	return nil
+}
+
+func (m *memoryTokens) ConsumeUserToken(_ context.Context, id string, userId int32, purpose model.TokenPurpose) error {
+	found := false
+	for _, t := range m.tokens {
+		if t.UserId != userId || t.Purpose != purpose || m.used[t.Id] {
+			continue
+		}
+		m.used[t.Id] = true
+		if t.Id == id && time.Now().Before(t.ExpiresAt) {
+			found = true
+		}
+	}
+	if !found {
+		return model.ErrInvalidToken
+	}
+	return nil
+}
+
+var linkToken = regexp.MustCompile(`http://localhost:3000/(?:verify-email|reset-password)\?token=(\S+)`)
+
+func tokenFromMail(t *testing.T, msg mailer.Message) string {
+	match := linkToken.FindStringSubmatch(msg.Body)
+	require.Len(t, match, 2)
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
+
+func newAccountUsecase(t *testing.T) (*usecase.AccountUsecase, *memoryAccounts, *mailer.MemoryMailer) {
+	users := &memoryAccounts{users: map[int32]*model.User{
+		1: {Id: 1, Username: "ivan", Email: "ivan@example.com", Password: "old", Role: model.RoleUser},
+	}}
+	signer, err := token.NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	mails := mailer.NewMemoryMailer()
+
+	service, err := usecase.NewAccountUsecase(
+		users,
+		&memoryTokens{tokens: map[string]*model.UserToken{}, used: map[string]bool{}},
+		signer,
+		mails,
+		newAuditor(),
+		usecase.AccountConfig{FrontendURL: "http://localhost:3000", VerificationTTL: time.Hour, ResetTTL: time.Hour},
+		&logger.MyLogger{},
+	)
+	require.NoError(t, err)
+
+	return service, users, mails
+}
+
+func TestAccountUsecase_VerifyEmail(t *testing.T) {
+	service, users, mails := newAccountUsecase(t)
+
+	err := service.RequestEmailVerification(context.Background())
+	require.ErrorIs(t, err, policy.ErrUnauthenticated)
+
+	ctx := policy.WithActor(context.Background(), &model.Actor{Id: 1, Role: model.RoleUser})
+	require.NoError(t, service.RequestEmailVerification(ctx))
+	require.Len(t, mails.Messages(), 1)
+	msg := mails.Messages()[0]
+	require.Equal(t, "ivan@example.com", msg.To)
+
+	err = service.ResetPassword(context.Background(), tokenFromMail(t, msg), "new")
+	require.ErrorIs(t, err, model.ErrInvalidToken)
+
+	require.NoError(t, service.VerifyEmail(context.Background(), tokenFromMail(t, msg)))
+	require.True(t, users.users[1].EmailVerified)
+
+	err = service.VerifyEmail(context.Background(), tokenFromMail(t, msg))
+	require.ErrorIs(t, err, model.ErrInvalidToken)
+
+	require.NoError(t, service.RequestEmailVerification(ctx))
+	require.Len(t, mails.Messages(), 1)
+}
+
+func TestAccountUsecase_VerifyChangedEmail(t *testing.T) {
+	service, users, mails := newAccountUsecase(t)
+	ctx := policy.WithActor(context.Background(), &model.Actor{Id: 1, Role: model.RoleUser})
+
+	require.NoError(t, service.RequestEmailVerification(ctx))
+	require.NoError(t, service.ForgotPassword(ctx, "ivan@example.com"))
+	verification, reset := tokenFromMail(t, mails.Messages()[0]), tokenFromMail(t, mails.Messages()[1])
+	users.users[1].Email = "other@example.com"
+
+	err := service.VerifyEmail(context.Background(), verification)
+	require.ErrorIs(t, err, model.ErrInvalidToken)
+	require.False(t, users.users[1].EmailVerified, "a token for the old address doesn't verify the new one")
+
+	err = service.ResetPassword(context.Background(), reset, "new")
+	require.ErrorIs(t, err, model.ErrInvalidToken)
+	require.Equal(t, "old", users.users[1].Password)
+}
+
+func TestAccountUsecase_ResetPassword(t *testing.T) {
+	service, users, mails := newAccountUsecase(t)
+	ctx := context.Background()
+
+	require.NoError(t, service.ForgotPassword(ctx, "nobody@example.com"))
+	require.Empty(t, mails.Messages())
+
+	require.NoError(t, service.ForgotPassword(ctx, "ivan@example.com"))
+	require.NoError(t, service.ForgotPassword(ctx, "ivan@example.com"))
+	require.Len(t, mails.Messages(), 2)
+	first, second := tokenFromMail(t, mails.Messages()[0]), tokenFromMail(t, mails.Messages()[1])
+
+	require.NoError(t, service.ResetPassword(ctx, second, "new"))
+	require.True(t, password.Check(users.users[1].Password, "new"), "the new password is stored hashed")
+	require.True(t, users.users[1].EmailVerified)
+
+	err := service.ResetPassword(ctx, first, "other")
+	require.ErrorIs(t, err, model.ErrInvalidToken)
+	err = service.ResetPassword(ctx, "forged.token", "other")
+	require.ErrorIs(t, err, model.ErrInvalidToken)
+	require.True(t, password.Check(users.users[1].Password, "new"))
+}
@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/mapper"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/requestid"
+	"reflect"
+)
+
+const redacted = "[REDACTED]"
+
+var redactedFields = map[string]bool{
+	"password": true,
+}
+
+type AuditRepository interface {
+	CreateAuditRecord(context.Context, *model.AuditRecord) error
+	GetAuditRecords(context.Context, model.AuditFilter) ([]model.AuditRecord, int, error)
+}
+
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Auditor writes audit records for mutating usecase calls. Changes made
+// inside WithinTx and the record written by Record commit or roll back
+// together.
+type Auditor struct {
+	Transactor
+	repo AuditRepository
+}
+
+func NewAuditor(tx Transactor, repo AuditRepository) (*Auditor, error) {
+	if tx == nil || repo == nil {
+		return nil, errors.New("nil values in Auditor constructor")
+	}
+	return &Auditor{tx, repo}, nil
+}
+
+// Record stores who performed action on the resource and which fields it
+// changed. before is nil for creations and after is nil for deletions;
+// secrets are kept out of the diff.
+func (a *Auditor) Record(ctx context.Context, action model.AuditAction, resourceType string, resourceId any, before, after any) error {
+	changes, err := diff(before, after)
+	if err != nil {
+		return fmt.Errorf("failed to diff audited resource: %w", err)
+	}
+
+	record := &model.AuditRecord{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceId:   fmt.Sprint(resourceId),
+		Changes:      changes,
+		RequestId:    requestid.FromContext(ctx),
+	}
+	if actor := policy.ActorFromContext(ctx); actor != nil {
+		if actor.Id != 0 {
+			record.ActorId = &actor.Id
+		}
+		record.ActorRole = string(actor.Role)
+	}
+
+	return a.repo.CreateAuditRecord(ctx, record)
+}
+
+func diff(before, after any) (map[string]model.FieldChange, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]model.FieldChange)
+	for name, value := range beforeFields {
+		if other, ok := afterFields[name]; !ok || !reflect.DeepEqual(value, other) {
+			changes[name] = model.FieldChange{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = model.FieldChange{After: value}
+		}
+	}
+
+	for name, change := range changes {
+		if redactedFields[name] {
+			if change.Before != nil {
+				change.Before = redacted
+			}
+			if change.After != nil {
+				change.After = redacted
+			}
+			changes[name] = change
+		}
+	}
+
+	return changes, nil
+}
+
+func fields(v any) (map[string]any, error) {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+type AuditUsecase struct {
+	AuditRepository
+	policy policy.Policy
+	logger *logger.MyLogger
+}
+
+func NewAuditUsecase(repo AuditRepository, policy policy.Policy, logger *logger.MyLogger) (*AuditUsecase, error) {
+	if repo == nil || policy == nil {
+		return nil, errors.New("nil values in AuditUsecase constructor")
+	}
+	return &AuditUsecase{repo, policy, logger}, nil
+}
+
+func (uc AuditUsecase) GetAuditRecords(ctx context.Context, filter model.AuditFilter) ([]dto.AuditRecordDto, int, error) {
+	err := policy.Authorize(ctx, uc.policy, policy.ActionList, policy.Resource{Type: policy.ResourceAudit})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	records, total, err := uc.AuditRepository.GetAuditRecords(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return mapper.MapToManyAuditRecordDto(records...), total, nil
+}
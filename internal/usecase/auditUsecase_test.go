@@ -0,0 +1,117 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/requestid"
+	"ivanjabrony/refstudy/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type passthroughTx struct{}
+
+func (passthroughTx) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+type memoryAudit struct {
+	records []model.AuditRecord
+}
+
+func (m *memoryAudit) CreateAuditRecord(_ context.Context, record *model.AuditRecord) error {
+	m.records = append(m.records, *record)
+	return nil
+}
+
+func (m *memoryAudit) GetAuditRecords(context.Context, model.AuditFilter) ([]model.AuditRecord, int, error) {
+	return m.records, len(m.records), nil
+}
+
+func newAuditor() *usecase.Auditor {
+	auditor, _ := usecase.NewAuditor(passthroughTx{}, &memoryAudit{})
+	return auditor
+}
+
+func TestAuditor_Record(t *testing.T) {
+	audit := &memoryAudit{}
+	auditor, err := usecase.NewAuditor(passthroughTx{}, audit)
+	require.NoError(t, err)
+
+	ctx := policy.WithActor(context.Background(), &model.Actor{Id: 7, Role: model.RoleAdmin})
+	ctx = requestid.WithRequestId(ctx, "req-1")
+
+	before := &model.User{Id: 1, Username: "ivan", Email: "old@example.com", Password: "old", Role: model.RoleUser}
+	after := &model.User{Id: 1, Username: "ivan", Email: "new@example.com", Password: "new", Role: model.RoleUser}
+	require.NoError(t, auditor.Record(ctx, model.AuditUpdate, "user", int32(1), before, after))
+
+	require.Len(t, audit.records, 1)
+	record := audit.records[0]
+	require.Equal(t, int32(7), *record.ActorId)
+	require.Equal(t, "admin", record.ActorRole)
+	require.Equal(t, "req-1", record.RequestId)
+	require.Equal(t, "1", record.ResourceId)
+	require.Equal(t, map[string]model.FieldChange{
+		"email":    {Before: "old@example.com", After: "new@example.com"},
+		"password": {Before: "[REDACTED]", After: "[REDACTED]"},
+	}, record.Changes)
+
+	require.NoError(t, auditor.Record(context.Background(), model.AuditDelete, "user", int32(1), before, (*model.User)(nil)))
+	record = audit.records[1]
+	require.Nil(t, record.ActorId)
+	require.Equal(t, model.FieldChange{Before: "ivan"}, record.Changes["username"])
+	require.Equal(t, model.FieldChange{Before: "[REDACTED]"}, record.Changes["password"])
+
+	// Operators have a role but no account.
+	ctx = policy.WithActor(context.Background(), &model.Actor{Role: model.RoleAdmin})
+	require.NoError(t, auditor.Record(ctx, model.AuditPurge, "user", "", nil, map[string]int64{"purged": 2}))
+	record = audit.records[2]
+	require.Nil(t, record.ActorId)
+	require.Equal(t, "admin", record.ActorRole)
+}
+
+func TestUserUsecase_AuditsInTransaction(t *testing.T) {
+	ctx := policy.WithActor(context.Background(), &model.Actor{Id: 1, Role: model.RoleAdmin})
+	audit := &memoryAudit{}
+	auditor, err := usecase.NewAuditor(passthroughTx{}, audit)
+	require.NoError(t, err)
+
+	storage := new(mockUserStorage)
+	storage.On("GetUserById", mock.Anything, int32(2)).Return(&model.User{Id: 2, Role: model.RoleUser}, nil).Once()
+	storage.On("SetUserRole", mock.Anything, int32(2), model.RoleModerator).Return(nil)
+	storage.On("GetUserById", mock.Anything, int32(2)).Return(&model.User{Id: 2, Role: model.RoleModerator}, nil).Once()
+	service, err := usecase.NewUserUsecase(storage, auditor, noVerification{}, policy.RolePolicy{}, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	require.NoError(t, service.SetUserRole(ctx, 2, model.RoleModerator))
+	require.Len(t, audit.records, 1)
+	require.Equal(t, model.FieldChange{Before: "user", After: "moderator"}, audit.records[0].Changes["role"])
+
+	failing := new(mockUserStorage)
+	failing.On("GetUserById", mock.Anything, int32(3)).Return(&model.User{Id: 3}, nil)
+	failing.On("SetUserDisabled", mock.Anything, int32(3), true).Return(errors.New("db is down"))
+	service, err = usecase.NewUserUsecase(failing, auditor, noVerification{}, policy.RolePolicy{}, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	require.Error(t, service.DisableUser(ctx, 3))
+	require.Len(t, audit.records, 1)
+}
+
+func TestAuditUsecase_GetAuditRecords(t *testing.T) {
+	service, err := usecase.NewAuditUsecase(&memoryAudit{}, policy.RolePolicy{}, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	_, _, err = service.GetAuditRecords(context.Background(), model.AuditFilter{})
+	require.ErrorIs(t, err, policy.ErrUnauthenticated)
+
+	ctx := policy.WithActor(context.Background(), &model.Actor{Id: 1, Role: model.RoleAdmin})
+	records, total, err := service.GetAuditRecords(ctx, model.AuditFilter{})
+	require.NoError(t, err)
+	require.Empty(t, records)
+	require.Zero(t, total)
+}
@@ -0,0 +1,391 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"ivanjabrony/refstudy/internal/archive"
+	"ivanjabrony/refstudy/internal/blob"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/mapper"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"ivanjabrony/refstudy/internal/phash"
+	"ivanjabrony/refstudy/internal/policy"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+)
+
+// GalleryImportJob is the type of the jobs importing archives into
+// galleries. Their id is the id of the import.
+const GalleryImportJob = "gallery_import"
+
+type GalleryRepository interface {
+	CreateGallery(context.Context, *model.Gallery) (*model.Gallery, error)
+	GetGalleryById(context.Context, int32) (*model.Gallery, error)
+	GetGalleriesByIds(context.Context, []int32, model.Visibility) ([]model.Gallery, error)
+	GetGalleriesByOwners(context.Context, []int32, model.Visibility, int) ([]model.Gallery, error)
+	GetPicturesByGalleries(context.Context, []int32, model.Visibility, int) ([]model.Picture, error)
+	GetGalleryPictures(context.Context, int32) ([]model.Picture, error)
+	GetHashedPictures(context.Context, int32) ([]model.Picture, error)
+	Search(context.Context, model.SearchFilter) ([]model.SearchHit, int, error)
+	CreateGalleryImport(context.Context, *model.GalleryImport) error
+	GetGalleryImport(context.Context, int64) (*model.GalleryImport, error)
+	FinishGalleryImport(context.Context, *model.GalleryImport) error
+}
+
+// BlobStore is the part of blob.Store galleries use.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (blob.Object, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type GalleryUsecase struct {
+	GalleryRepository
+	jobs   JobRepository
+	blobs  BlobStore
+	tx     Transactor
+	policy policy.Policy
+	logger *logger.MyLogger
+}
+
+func NewGalleryUsecase(repo GalleryRepository, jobs JobRepository, blobs BlobStore, tx Transactor, policy policy.Policy, logger *logger.MyLogger) (*GalleryUsecase, error) {
+	if repo == nil || jobs == nil || blobs == nil || tx == nil || policy == nil {
+		return nil, errors.New("nil values in GalleryUsecase constructor")
+	}
+	return &GalleryUsecase{repo, jobs, blobs, tx, policy, logger}, nil
+}
+
+// ImportGalleries stores file, a ZIP archive of size bytes, and enqueues a job
+// turning it into galleries of the caller; see archive.ReadImport for the
+// layout. The returned import tells how far the job got.
+func (uc GalleryUsecase) ImportGalleries(ctx context.Context, file io.Reader, size int64) (*dto.GalleryImportDto, error) {
+	err := policy.Authorize(ctx, uc.policy, policy.ActionCreate, policy.Resource{Type: policy.ResourceGallery})
+	if err != nil {
+		return nil, err
+	}
+	actor := policy.ActorFromContext(ctx)
+
+	key, err := randomKey("imports/", ".zip")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uc.blobs.Put(ctx, key, file, size, "application/zip"); err != nil {
+		return nil, err
+	}
+
+	var created *model.Job
+	galleryImport := &model.GalleryImport{OwnerId: actor.Id, ArchiveKey: key}
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		job, err := newJob(GalleryImportJob, "", struct{}{})
+		if err != nil {
+			return err
+		}
+		job, err = uc.jobs.CreateJob(ctx, job)
+		if err != nil {
+			return err
+		}
+		created = job
+		galleryImport.JobId = job.Id
+		return uc.GalleryRepository.CreateGalleryImport(ctx, galleryImport)
+	})
+	if err != nil {
+		uc.deleteBlob(ctx, key)
+		return nil, err
+	}
+
+	return mapper.MapToGalleryImportDto(galleryImport, created), nil
+}
+
+// ExportGallery writes gallery id with its pictures to w as a ZIP archive
+// that ImportGalleries takes back; see archive.WriteExport. Nothing is
+// written unless the caller may read the gallery.
+func (uc GalleryUsecase) ExportGallery(ctx context.Context, id int32, w io.Writer) error {
+	gallery, err := uc.GalleryRepository.GetGalleryById(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = policy.Authorize(ctx, uc.policy, policy.ActionRead, policy.Resource{
+		Type:     policy.ResourceGallery,
+		Id:       gallery.Id,
+		OwnerId:  gallery.OwnerId,
+		IsPublic: gallery.IsPublic,
+	})
+	if err != nil {
+		return err
+	}
+	gallery.Pictures, err = uc.GalleryRepository.GetGalleryPictures(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return archive.WriteExport(w, gallery, func(picture model.Picture) (io.ReadCloser, error) {
+		return uc.blobs.Get(ctx, picture.Path)
+	})
+}
+
+// FindDuplicates groups the pictures of gallery id that look alike: a
+// picture joins a cluster when its perceptual hash is at most threshold bits
+// away from that of another member. Pictures without a hash are left out.
+func (uc GalleryUsecase) FindDuplicates(ctx context.Context, id int32, threshold int) ([]dto.DuplicateClusterDto, error) {
+	gallery, err := uc.GalleryRepository.GetGalleryById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = policy.Authorize(ctx, uc.policy, policy.ActionRead, policy.Resource{
+		Type:     policy.ResourceGallery,
+		Id:       gallery.Id,
+		OwnerId:  gallery.OwnerId,
+		IsPublic: gallery.IsPublic,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pictures, err := uc.GalleryRepository.GetHashedPictures(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]phash.Hashed, len(pictures))
+	byId := make(map[int32]*model.Picture, len(pictures))
+	for i := range pictures {
+		hashes[i] = phash.Hashed{Id: pictures[i].Id, Hash: pictures[i].Hash}
+		byId[pictures[i].Id] = &pictures[i]
+	}
+
+	clusters := phash.Cluster(hashes, threshold)
+	result := make([]dto.DuplicateClusterDto, 0, len(clusters))
+	for _, ids := range clusters {
+		cluster := dto.DuplicateClusterDto{Pictures: make([]dto.PictureDto, 0, len(ids))}
+		for _, pictureId := range ids {
+			cluster.Pictures = append(cluster.Pictures, *mapper.MapToPictureDto(byId[pictureId]))
+		}
+		result = append(result, cluster)
+	}
+
+	return result, nil
+}
+
+// Search finds the galleries and pictures whose names, descriptions or tags
+// match query, among those the caller may read, and returns the page of
+// them picked by limit and offset with the total number of matches.
+func (uc GalleryUsecase) Search(ctx context.Context, query string, limit, offset int) ([]dto.SearchHitDto, int, error) {
+	filter := model.SearchFilter{Query: query, Visibility: uc.visibility(ctx), Limit: limit, Offset: offset}
+
+	hits, total, err := uc.GalleryRepository.Search(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return mapper.MapToManySearchHitDto(hits...), total, nil
+}
+
+// GetGalleriesByIds returns the galleries of ids the caller may read,
+// ordered by id. The others are left out instead of failing the batch.
+func (uc GalleryUsecase) GetGalleriesByIds(ctx context.Context, ids []int32) ([]dto.GalleryDto, error) {
+	galleries, err := uc.GalleryRepository.GetGalleriesByIds(ctx, ids, uc.visibility(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapToManyGalleryDto(galleries...), nil
+}
+
+// GetGalleriesByOwners returns the first galleries by id of each of
+// ownerIds, up to first each, that the caller may read.
+func (uc GalleryUsecase) GetGalleriesByOwners(ctx context.Context, ownerIds []int32, first int) ([]dto.GalleryDto, error) {
+	galleries, err := uc.GalleryRepository.GetGalleriesByOwners(ctx, ownerIds, uc.visibility(ctx), first)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapToManyGalleryDto(galleries...), nil
+}
+
+// GetPicturesByGalleries returns the first pictures by id of each of
+// galleryIds, up to first each, leaving out the galleries the caller may
+// not read.
+func (uc GalleryUsecase) GetPicturesByGalleries(ctx context.Context, galleryIds []int32, first int) ([]dto.PictureDto, error) {
+	pictures, err := uc.GalleryRepository.GetPicturesByGalleries(ctx, galleryIds, uc.visibility(ctx), first)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapToManyPictureDto(pictures...), nil
+}
+
+// GetGalleryImport returns an import along with the state of its job. Only
+// the importing user and those who may read their private galleries see it.
+func (uc GalleryUsecase) GetGalleryImport(ctx context.Context, id int64) (*dto.GalleryImportDto, error) {
+	if policy.ActorFromContext(ctx) == nil {
+		return nil, policy.ErrUnauthenticated
+	}
+
+	galleryImport, err := uc.GalleryRepository.GetGalleryImport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = policy.Authorize(ctx, uc.policy, policy.ActionRead, policy.Resource{Type: policy.ResourceGallery, OwnerId: galleryImport.OwnerId})
+	if err != nil {
+		return nil, err
+	}
+	job, err := uc.jobs.GetJobById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapToGalleryImportDto(galleryImport, job), nil
+}
+
+// RunGalleryImport runs the import of job id: it uploads the pictures of the
+// archive and then creates the galleries in one transaction. Pictures go to
+// keys derived from the import, so a retried run overwrites what an earlier
+// one uploaded instead of leaving copies behind. Files that can't be
+// imported, or an archive that can't be read at all, are recorded as
+// failures of a finished import rather than failing the job.
+func (uc GalleryUsecase) RunGalleryImport(ctx context.Context, id int64, progress func(ctx context.Context, progress int32) error) error {
+	galleryImport, err := uc.GalleryRepository.GetGalleryImport(ctx, id)
+	if err != nil {
+		return err
+	}
+	if galleryImport.FinishedAt != nil {
+		return nil
+	}
+
+	file, size, err := uc.download(ctx, galleryImport.ArchiveKey)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	result, err := archive.ReadImport(file, size)
+	if err != nil {
+		galleryImport.Failures = []model.ImportFailure{{Reason: err.Error()}}
+		return uc.finishImport(ctx, galleryImport, nil)
+	}
+
+	total := 0
+	for _, gallery := range result.Galleries {
+		total += len(gallery.Pictures)
+	}
+	done := 0
+	var reported int32
+	for i := range result.Galleries {
+		gallery := &result.Galleries[i]
+		gallery.OwnerId = galleryImport.OwnerId
+		for j := range gallery.Pictures {
+			picture := &gallery.Pictures[j]
+			picture.Path, err = uc.uploadPicture(ctx, result, id, done, picture.Path)
+			if err != nil {
+				return err
+			}
+
+			// The last percent is left for creating the galleries.
+			done++
+			if percent := int32(done * 99 / total); percent > reported {
+				reported = percent
+				if err := progress(ctx, percent); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	galleryImport.Failures = result.Failures
+	return uc.finishImport(ctx, galleryImport, result.Galleries)
+}
+
+// visibility returns the galleries the caller may list.
+func (uc GalleryUsecase) visibility(ctx context.Context) model.Visibility {
+	actor := policy.ActorFromContext(ctx)
+	if actor == nil {
+		return model.Visibility{}
+	}
+	// Listing the private galleries of nobody in particular means listing
+	// those of everybody.
+	return model.Visibility{
+		ViewerId: &actor.Id,
+		All:      uc.policy.Can(actor, policy.ActionList, policy.Resource{Type: policy.ResourceGallery}),
+	}
+}
+
+func (uc GalleryUsecase) finishImport(ctx context.Context, galleryImport *model.GalleryImport, galleries []model.Gallery) error {
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		for i := range galleries {
+			created, err := uc.GalleryRepository.CreateGallery(ctx, &galleries[i])
+			if err != nil {
+				return err
+			}
+			galleryImport.GalleryIds = append(galleryImport.GalleryIds, created.Id)
+		}
+		return uc.GalleryRepository.FinishGalleryImport(ctx, galleryImport)
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.deleteBlob(ctx, galleryImport.ArchiveKey)
+	return nil
+}
+
+// uploadPicture copies picture name of the archive to the blob store as the
+// n-th picture of import id and returns its key.
+func (uc GalleryUsecase) uploadPicture(ctx context.Context, result *archive.Import, id int64, n int, name string) (string, error) {
+	src, err := result.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	key := fmt.Sprintf("pictures/import-%d/%d%s", id, n, strings.ToLower(path.Ext(name)))
+	if _, err := uc.blobs.Put(ctx, key, src, -1, ""); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// download copies blob key to a temporary file, since archives are read
+// at random and blobs only in sequence. The caller removes the file.
+func (uc GalleryUsecase) download(ctx context.Context, key string) (*os.File, int64, error) {
+	src, err := uc.blobs.Get(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer src.Close()
+
+	file, err := os.CreateTemp("", "gallery-import-*.zip")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	size, err := io.Copy(file, src)
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, 0, fmt.Errorf("failed to download archive: %w", err)
+	}
+
+	return file, size, nil
+}
+
+// deleteBlob removes a blob nothing refers to anymore. Failing to is only
+// logged: it costs space, not correctness.
+func (uc GalleryUsecase) deleteBlob(ctx context.Context, key string) {
+	if err := uc.blobs.Delete(ctx, key); err != nil {
+		uc.logger.WrapError("failed to delete blob", err, slog.String("key", key))
+	}
+}
+
+func randomKey(prefix, suffix string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return prefix + hex.EncodeToString(buf) + suffix, nil
+}
@@ -0,0 +1,284 @@
+package usecase_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"ivanjabrony/refstudy/internal/phash"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/testing/fakes"
+	"ivanjabrony/refstudy/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func pngBytes(t *testing.T, width, height int, shade uint8) []byte {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetGray(x, y, color.Gray{Y: shade + uint8(x*y)})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func zipBytes(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+type galleryFixture struct {
+	usecase   *usecase.GalleryUsecase
+	galleries *fakes.GalleryRepository
+	blobs     *fakes.BlobStore
+}
+
+func newGalleryFixture(t *testing.T) galleryFixture {
+	galleries, blobs := fakes.NewGalleryRepository(), fakes.NewBlobStore()
+	uc, err := usecase.NewGalleryUsecase(galleries, fakes.NewJobRepository(), blobs, passthroughTx{}, policy.RolePolicy{}, logger.New(logger.Test, logger.LogFormatText))
+	require.NoError(t, err)
+	return galleryFixture{uc, galleries, blobs}
+}
+
+func TestGalleryUsecase_Import(t *testing.T) {
+	owner := policy.WithActor(context.Background(), &model.Actor{Id: 3, Role: model.RoleUser})
+	f := newGalleryFixture(t)
+
+	left := pngBytes(t, 32, 24, 0)
+	data := zipBytes(t, map[string][]byte{
+		"hands/gesture, quick/left.png": left,
+		"hands/right.png":               pngBytes(t, 16, 16, 100),
+		"hands/notes.txt":               []byte("notes"),
+	})
+	started, err := f.usecase.ImportGalleries(owner, bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Equal(t, string(model.JobQueued), started.Status)
+	require.Len(t, f.blobs.Keys(), 1, "the archive waits for the job")
+
+	var progress []int32
+	err = f.usecase.RunGalleryImport(context.Background(), started.Id, func(_ context.Context, p int32) error {
+		progress = append(progress, p)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int32{49, 99}, progress)
+
+	finished, err := f.usecase.GetGalleryImport(owner, started.Id)
+	require.NoError(t, err)
+	require.NotNil(t, finished.FinishedAt)
+	require.Len(t, finished.Failures, 1)
+	require.Equal(t, "hands/notes.txt", finished.Failures[0].Path)
+	require.Len(t, finished.GalleryIds, 1)
+	require.Equal(t, []string{"pictures/import-1/0.png", "pictures/import-1/1.png"}, f.blobs.Keys(), "the archive is deleted")
+
+	gallery, err := f.galleries.GetGalleryById(context.Background(), finished.GalleryIds[0])
+	require.NoError(t, err)
+	require.Equal(t, int32(3), gallery.OwnerId)
+	require.Equal(t, "hands", gallery.GalleryName)
+	pictures, err := f.galleries.GetGalleryPictures(context.Background(), gallery.Id)
+	require.NoError(t, err)
+	require.Len(t, pictures, 2)
+	require.Equal(t, []string{"gesture, quick"}, pictures[0].Tags, "tags may contain commas")
+	require.Equal(t, "pictures/import-1/0.png", pictures[0].Path)
+	hash, err := phash.DHashFromReader(bytes.NewReader(left))
+	require.NoError(t, err)
+	require.Equal(t, hash, pictures[0].Hash, "pictures are hashed on import")
+
+	// A retried job finds the import finished and doesn't create the
+	// galleries again.
+	require.NoError(t, f.usecase.RunGalleryImport(context.Background(), started.Id, func(context.Context, int32) error { return nil }))
+	_, err = f.galleries.GetGalleryById(context.Background(), 2)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func TestGalleryUsecase_ImportInvalidArchive(t *testing.T) {
+	owner := policy.WithActor(context.Background(), &model.Actor{Id: 3, Role: model.RoleUser})
+	f := newGalleryFixture(t)
+
+	started, err := f.usecase.ImportGalleries(owner, bytes.NewReader([]byte("not a zip")), 9)
+	require.NoError(t, err)
+	require.NoError(t, f.usecase.RunGalleryImport(context.Background(), started.Id, func(context.Context, int32) error { return nil }))
+
+	finished, err := f.usecase.GetGalleryImport(owner, started.Id)
+	require.NoError(t, err)
+	require.NotNil(t, finished.FinishedAt)
+	require.Len(t, finished.Failures, 1)
+	require.Contains(t, finished.Failures[0].Reason, "failed to open archive")
+	require.Empty(t, finished.GalleryIds)
+	require.Empty(t, f.blobs.Keys())
+}
+
+func TestGalleryUsecase_GetGalleryImportAccess(t *testing.T) {
+	owner := policy.WithActor(context.Background(), &model.Actor{Id: 3, Role: model.RoleUser})
+	f := newGalleryFixture(t)
+
+	_, err := f.usecase.ImportGalleries(context.Background(), bytes.NewReader(nil), 0)
+	require.ErrorIs(t, err, policy.ErrUnauthenticated)
+
+	started, err := f.usecase.ImportGalleries(owner, bytes.NewReader(nil), 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		actor *model.Actor
+		err   error
+	}{
+		{name: "anonymous", err: policy.ErrUnauthenticated},
+		{name: "other user", actor: &model.Actor{Id: 4, Role: model.RoleUser}, err: policy.ErrForbidden},
+		{name: "moderator", actor: &model.Actor{Id: 5, Role: model.RoleModerator}},
+		{name: "owner", actor: &model.Actor{Id: 3, Role: model.RoleUser}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.actor != nil {
+				ctx = policy.WithActor(ctx, tt.actor)
+			}
+			_, err := f.usecase.GetGalleryImport(ctx, started.Id)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGalleryUsecase_ExportRoundTrip(t *testing.T) {
+	owner := policy.WithActor(context.Background(), &model.Actor{Id: 3, Role: model.RoleUser})
+	f := newGalleryFixture(t)
+
+	data := zipBytes(t, map[string][]byte{
+		"hands/gesture/left.png": pngBytes(t, 32, 24, 0),
+		"hands/right.png":        pngBytes(t, 16, 16, 100),
+	})
+	started, err := f.usecase.ImportGalleries(owner, bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.NoError(t, f.usecase.RunGalleryImport(context.Background(), started.Id, func(context.Context, int32) error { return nil }))
+
+	var export bytes.Buffer
+	err = f.usecase.ExportGallery(policy.WithActor(context.Background(), &model.Actor{Id: 4, Role: model.RoleUser}), 1, &export)
+	require.ErrorIs(t, err, policy.ErrForbidden)
+	require.Zero(t, export.Len(), "nothing is written for a refused export")
+	require.ErrorIs(t, f.usecase.ExportGallery(owner, 2, &export), model.ErrNotFound)
+
+	require.NoError(t, f.usecase.ExportGallery(owner, 1, &export))
+	reimported, err := f.usecase.ImportGalleries(owner, bytes.NewReader(export.Bytes()), int64(export.Len()))
+	require.NoError(t, err)
+	require.NoError(t, f.usecase.RunGalleryImport(context.Background(), reimported.Id, func(context.Context, int32) error { return nil }))
+
+	finished, err := f.usecase.GetGalleryImport(owner, reimported.Id)
+	require.NoError(t, err)
+	require.Empty(t, finished.Failures)
+	require.Equal(t, []int32{2}, finished.GalleryIds)
+	pictures, err := f.galleries.GetGalleryPictures(context.Background(), 2)
+	require.NoError(t, err)
+	require.Len(t, pictures, 2)
+	require.Equal(t, []string{"gesture"}, pictures[0].Tags)
+}
+
+func TestGalleryUsecase_FindDuplicates(t *testing.T) {
+	owner := policy.WithActor(context.Background(), &model.Actor{Id: 3, Role: model.RoleUser})
+	f := newGalleryFixture(t)
+	_, err := f.galleries.CreateGallery(context.Background(), &model.Gallery{
+		OwnerId: 3,
+		Pictures: []model.Picture{
+			{Name: "a", Hash: 0b0000},
+			{Name: "b", Hash: 0xff00},
+			{Name: "c", Hash: 0b0111},
+			{Name: "d", Hash: 0xff01},
+			{Name: "e", Hash: 0x00ff_0000},
+		},
+	})
+	require.NoError(t, err)
+
+	names := func(clusters []dto.DuplicateClusterDto) [][]string {
+		result := [][]string{}
+		for _, cluster := range clusters {
+			var names []string
+			for _, picture := range cluster.Pictures {
+				names = append(names, picture.Name)
+			}
+			result = append(result, names)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name      string
+		threshold int
+		want      [][]string
+	}{
+		{"identical only", 0, [][]string{}},
+		{"one bit", 1, [][]string{{"b", "d"}}},
+		{"three bits", 3, [][]string{{"a", "c"}, {"b", "d"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters, err := f.usecase.FindDuplicates(owner, 1, tt.threshold)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, names(clusters))
+		})
+	}
+
+	_, err = f.usecase.FindDuplicates(policy.WithActor(context.Background(), &model.Actor{Id: 4, Role: model.RoleUser}), 1, 4)
+	require.ErrorIs(t, err, policy.ErrForbidden)
+	_, err = f.usecase.FindDuplicates(owner, 2, 4)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func TestGalleryUsecase_SearchVisibility(t *testing.T) {
+	f := newGalleryFixture(t)
+	for _, gallery := range []model.Gallery{
+		{OwnerId: 3, GalleryName: "hands, private"},
+		{OwnerId: 4, GalleryName: "hands, public", IsPublic: true},
+	} {
+		_, err := f.galleries.CreateGallery(context.Background(), &gallery)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name  string
+		actor *model.Actor
+		want  []int32
+	}{
+		{"anonymous", nil, []int32{2}},
+		{"owner", &model.Actor{Id: 3, Role: model.RoleUser}, []int32{1, 2}},
+		{"other user", &model.Actor{Id: 4, Role: model.RoleUser}, []int32{2}},
+		{"moderator", &model.Actor{Id: 5, Role: model.RoleModerator}, []int32{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.actor != nil {
+				ctx = policy.WithActor(ctx, tt.actor)
+			}
+			hits, total, err := f.usecase.Search(ctx, "hand", 10, 0)
+			require.NoError(t, err)
+			require.Equal(t, len(tt.want), total)
+			var ids []int32
+			for _, hit := range hits {
+				ids = append(ids, hit.Id)
+			}
+			require.Equal(t, tt.want, ids)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/mapper"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"ivanjabrony/refstudy/internal/policy"
+	"time"
+)
+
+const defaultMaxAttempts = 5
+
+type JobRepository interface {
+	CreateJob(context.Context, *model.Job) (*model.Job, error)
+	GetJobById(context.Context, int64) (*model.Job, error)
+}
+
+type JobUsecase struct {
+	JobRepository
+	policy policy.Policy
+	logger *logger.MyLogger
+}
+
+func NewJobUsecase(repo JobRepository, policy policy.Policy, logger *logger.MyLogger) (*JobUsecase, error) {
+	if repo == nil || policy == nil {
+		return nil, errors.New("nil values in JobUsecase constructor")
+	}
+	return &JobUsecase{repo, policy, logger}, nil
+}
+
+// EnqueueJob stores a job of the given type for the worker pool. The payload
+// is encoded as JSON and handed to the handler registered for jobType.
+func (uc JobUsecase) EnqueueJob(ctx context.Context, jobType string, payload any) (*dto.JobDto, error) {
+	return uc.enqueue(ctx, jobType, "", payload)
+}
+
+// EnqueueUniqueJob is EnqueueJob for a job that must be enqueued at most
+// once per key, like a scheduled job all replicas enqueue. It returns
+// model.ErrAlreadyExists when a job with key was enqueued before.
+func (uc JobUsecase) EnqueueUniqueJob(ctx context.Context, jobType, key string, payload any) (*dto.JobDto, error) {
+	return uc.enqueue(ctx, jobType, key, payload)
+}
+
+func (uc JobUsecase) enqueue(ctx context.Context, jobType, key string, payload any) (*dto.JobDto, error) {
+	job, err := newJob(jobType, key, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err = uc.JobRepository.CreateJob(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapToJobDto(job), nil
+}
+
+// newJob returns a job of jobType due now, for usecases that enqueue jobs
+// as part of their own changes.
+func newJob(jobType, key string, payload any) (*model.Job, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	return &model.Job{
+		Type:        jobType,
+		Payload:     encoded,
+		MaxAttempts: defaultMaxAttempts,
+		RunAt:       time.Now(),
+		UniqueKey:   key,
+	}, nil
+}
+
+func (uc JobUsecase) GetJobById(ctx context.Context, id int64) (*dto.JobDto, error) {
+	err := policy.Authorize(ctx, uc.policy, policy.ActionRead, policy.Resource{Type: policy.ResourceJob})
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := uc.JobRepository.GetJobById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapToJobDto(job), nil
+}
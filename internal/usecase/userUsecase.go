@@ -3,43 +3,138 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"ivanjabrony/refstudy/internal/logger"
 	"ivanjabrony/refstudy/internal/mapper"
 	"ivanjabrony/refstudy/internal/model"
 	"ivanjabrony/refstudy/internal/model/dto"
+	"ivanjabrony/refstudy/internal/password"
+	"ivanjabrony/refstudy/internal/policy"
+	"log/slog"
+	"slices"
+	"time"
 )
 
 type UserRepository interface {
 	CreateUser(context.Context, *model.User) (*model.User, error)
 	GetUserById(context.Context, int32) (*model.User, error)
+	GetUserByUsername(context.Context, string) (*model.User, error)
+	// GetUserCredentials is GetUserByUsername for checking credentials.
+	// Unlike the other lookups it always returns the password hash.
+	GetUserCredentials(context.Context, string) (*model.User, error)
 	GetAllUsers(context.Context) ([]model.User, error)
-	UpdateUser(context.Context, *model.User) error
+	GetUsersByIds(context.Context, []int32) ([]model.User, error)
+	StreamUsers(context.Context, model.Keyset, func(*model.User) error) error
+	UpdateUser(context.Context, *model.UserUpdate) error
 	DeleteUserById(context.Context, int32) error
+	SetUserDisabled(context.Context, int32, bool) error
+	SetUserRole(context.Context, int32, model.Role) error
+	RestoreUser(context.Context, int32) error
+	PurgeDeletedUsers(context.Context, time.Time) (int64, error)
 }
 
+type EmailVerifier interface {
+	SendVerificationEmail(context.Context, *model.User) error
+}
+
+const auditResourceUser = "user"
+
 type UserUsecase struct {
 	UserRepository
-	logger *logger.MyLogger
+	audit    *Auditor
+	verifier EmailVerifier
+	policy   policy.Policy
+	logger   *logger.MyLogger
 }
 
-func NewUserUsecase(repo UserRepository, logger *logger.MyLogger) (*UserUsecase, error) {
-	if repo == nil {
+func NewUserUsecase(repo UserRepository, audit *Auditor, verifier EmailVerifier, policy policy.Policy, logger *logger.MyLogger) (*UserUsecase, error) {
+	if repo == nil || audit == nil || verifier == nil || policy == nil {
 		return nil, errors.New("nil values in UserUsecase constructor")
 	}
-	return &UserUsecase{repo, logger}, nil
+	return &UserUsecase{repo, audit, verifier, policy, logger}, nil
 }
 
 func (uc UserUsecase) CreateUser(ctx context.Context, dto *dto.CreateUserDto) (*dto.UserDto, error) {
-	user := mapper.MapFromCreateUserDto(dto)
-	user, err := uc.UserRepository.CreateUser(ctx, user)
+	if err := uc.authorize(ctx, policy.ActionCreate, 0); err != nil {
+		return nil, err
+	}
+
+	user, err := newUser(dto)
 	if err != nil {
 		return nil, err
 	}
+	err = uc.audit.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = uc.UserRepository.CreateUser(ctx, user)
+		if err != nil {
+			return err
+		}
+		return uc.audit.Record(ctx, model.AuditCreate, auditResourceUser, user.Id, nil, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The account is usable without a verified address, so a failed email
+	// doesn't fail the signup; the user can ask for another one.
+	if err := uc.verifier.SendVerificationEmail(ctx, user); err != nil {
+		uc.logger.WrapError("failed to send verification email", err, slog.Int("user_id", int(user.Id)))
+	}
 
 	return mapper.MapToUserDto(user), nil
 }
 
+// CreateAdmin creates a user with the admin role, in one transaction so a
+// failure can't leave a plain user behind. It sends no verification email:
+// whoever may grant the role vouches for the address, and admins aren't
+// restricted without a verified one anyway.
+func (uc UserUsecase) CreateAdmin(ctx context.Context, dto *dto.CreateUserDto) (*dto.UserDto, error) {
+	if err := uc.authorize(ctx, policy.ActionGrant, 0); err != nil {
+		return nil, err
+	}
+
+	user, err := newUser(dto)
+	if err != nil {
+		return nil, err
+	}
+	err = uc.audit.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = uc.UserRepository.CreateUser(ctx, user)
+		if err != nil {
+			return err
+		}
+		if err := uc.UserRepository.SetUserRole(ctx, user.Id, model.RoleAdmin); err != nil {
+			return err
+		}
+		user.Role = model.RoleAdmin
+		return uc.audit.Record(ctx, model.AuditCreate, auditResourceUser, user.Id, nil, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapToUserDto(user), nil
+}
+
+// newUser maps dto to the user to store, with its password hashed.
+func newUser(dto *dto.CreateUserDto) (*model.User, error) {
+	user := mapper.MapFromCreateUserDto(dto)
+	if user.Username == "" || user.Email == "" {
+		return nil, fmt.Errorf("%w: empty username or email", model.ErrInvalidUser)
+	}
+	hash, err := password.Hash(user.Password)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = hash
+	return user, nil
+}
+
 func (uc UserUsecase) GetUserById(ctx context.Context, id int32) (*dto.UserDto, error) {
+	if err := uc.authorize(ctx, policy.ActionRead, id); err != nil {
+		return nil, err
+	}
+
 	user, err := uc.UserRepository.GetUserById(ctx, id)
 	if err != nil {
 		return nil, err
@@ -49,6 +144,10 @@ func (uc UserUsecase) GetUserById(ctx context.Context, id int32) (*dto.UserDto,
 }
 
 func (uc UserUsecase) GetAllUsers(ctx context.Context) ([]dto.UserDto, error) {
+	if err := uc.authorize(ctx, policy.ActionList, 0); err != nil {
+		return nil, err
+	}
+
 	users, err := uc.UserRepository.GetAllUsers(ctx)
 	if err != nil {
 		return nil, err
@@ -57,11 +156,251 @@ func (uc UserUsecase) GetAllUsers(ctx context.Context) ([]dto.UserDto, error) {
 	return mapper.MapToManyUserDto(users...), err
 }
 
+// GetUsersByIds returns the users of ids the caller may read, ordered by id.
+// The others are left out instead of failing the whole batch.
+func (uc UserUsecase) GetUsersByIds(ctx context.Context, ids []int32) ([]dto.UserDto, error) {
+	actor := policy.ActorFromContext(ctx)
+	readable := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		if uc.policy.Can(actor, policy.ActionRead, policy.Resource{Type: policy.ResourceUser, Id: id, OwnerId: id}) {
+			readable = append(readable, id)
+		}
+	}
+	if len(readable) == 0 {
+		return []dto.UserDto{}, nil
+	}
+
+	users, err := uc.UserRepository.GetUsersByIds(ctx, readable)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapToManyUserDto(users...), nil
+}
+
+// GetUsersPage returns up to keyset.Limit users in ascending order, also when
+// paging backward, and whether there are more users in keyset's direction.
+func (uc UserUsecase) GetUsersPage(ctx context.Context, keyset model.Keyset) ([]dto.UserDto, bool, error) {
+	if err := uc.authorize(ctx, policy.ActionList, 0); err != nil {
+		return nil, false, err
+	}
+	if err := checkUserSelection(keyset); err != nil {
+		return nil, false, err
+	}
+
+	// One extra user tells whether there is another page.
+	limit := keyset.Limit
+	keyset.Limit++
+	users := make([]dto.UserDto, 0, keyset.Limit)
+	err := uc.UserRepository.StreamUsers(ctx, keyset, func(user *model.User) error {
+		users = append(users, *mapper.MapToUserDto(user))
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	more := len(users) > limit
+	if more {
+		users = users[:limit]
+	}
+	if keyset.Backward {
+		slices.Reverse(users)
+	}
+	return users, more, nil
+}
+
+// StreamUsers calls fn with every user keyset selects as it is read.
+func (uc UserUsecase) StreamUsers(ctx context.Context, keyset model.Keyset, fn func(*dto.UserDto) error) error {
+	if err := uc.authorize(ctx, policy.ActionList, 0); err != nil {
+		return err
+	}
+	if err := checkUserSelection(keyset); err != nil {
+		return err
+	}
+
+	return uc.UserRepository.StreamUsers(ctx, keyset, func(user *model.User) error {
+		return fn(mapper.MapToUserDto(user))
+	})
+}
+
+// checkUserSelection rejects the fields and relations keyset reads that
+// users don't have.
+func checkUserSelection(keyset model.Keyset) error {
+	if err := keyset.Fields.Check(model.UserFields...); err != nil {
+		return err
+	}
+	if err := keyset.Include.Check(model.UserRelations...); err != nil {
+		return fmt.Errorf("cannot include relation: %w", err)
+	}
+	return nil
+}
+
 func (uc UserUsecase) UpdateUser(ctx context.Context, dto *dto.UpdateUserDto) error {
-	user := mapper.MapFromUpdateUserDto(dto)
-	return uc.UserRepository.UpdateUser(ctx, user)
+	if err := uc.authorize(ctx, policy.ActionUpdate, dto.Id); err != nil {
+		return err
+	}
+
+	update := mapper.MapFromUpdateUserDto(dto)
+	if err := update.Check(); err != nil {
+		return err
+	}
+	if update.Password != nil {
+		hash, err := password.Hash(*update.Password)
+		if err != nil {
+			return err
+		}
+		update.Password = &hash
+	}
+	return uc.audited(ctx, model.AuditUpdate, dto.Id, func(ctx context.Context) error {
+		return uc.UserRepository.UpdateUser(ctx, update)
+	})
 }
 
 func (uc UserUsecase) DeleteUserById(ctx context.Context, id int32) error {
-	return uc.UserRepository.DeleteUserById(ctx, id)
+	if err := uc.authorize(ctx, policy.ActionDelete, id); err != nil {
+		return err
+	}
+
+	return uc.audited(ctx, model.AuditDelete, id, func(ctx context.Context) error {
+		return uc.UserRepository.DeleteUserById(ctx, id)
+	})
+}
+
+func (uc UserUsecase) RestoreUser(ctx context.Context, id int32) error {
+	if err := uc.authorize(ctx, policy.ActionRestore, id); err != nil {
+		return err
+	}
+
+	return uc.audited(ctx, model.AuditRestore, id, func(ctx context.Context) error {
+		return uc.UserRepository.RestoreUser(ctx, id)
+	})
+}
+
+// PurgeDeletedUsers permanently removes users deleted more than retention
+// ago. It is meant for the background purge and doesn't check the caller.
+func (uc UserUsecase) PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error) {
+	var purged int64
+	err := uc.audit.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		purged, err = uc.UserRepository.PurgeDeletedUsers(ctx, time.Now().Add(-retention))
+		if err != nil || purged == 0 {
+			return err
+		}
+		return uc.audit.Record(ctx, model.AuditPurge, auditResourceUser, "", nil, map[string]int64{"purged": purged})
+	})
+	if err != nil {
+		return 0, err
+	}
+	if purged > 0 {
+		uc.logger.Info("purged deleted users", slog.Int64("count", purged))
+	}
+
+	return purged, nil
+}
+
+func (uc UserUsecase) DisableUser(ctx context.Context, id int32) error {
+	if err := uc.authorize(ctx, policy.ActionDisable, id); err != nil {
+		return err
+	}
+
+	return uc.audited(ctx, model.AuditUpdate, id, func(ctx context.Context) error {
+		return uc.UserRepository.SetUserDisabled(ctx, id, true)
+	})
+}
+
+func (uc UserUsecase) EnableUser(ctx context.Context, id int32) error {
+	if err := uc.authorize(ctx, policy.ActionDisable, id); err != nil {
+		return err
+	}
+
+	return uc.audited(ctx, model.AuditUpdate, id, func(ctx context.Context) error {
+		return uc.UserRepository.SetUserDisabled(ctx, id, false)
+	})
+}
+
+func (uc UserUsecase) SetUserRole(ctx context.Context, id int32, role model.Role) error {
+	if err := uc.authorize(ctx, policy.ActionGrant, id); err != nil {
+		return err
+	}
+	if !role.Valid() {
+		return fmt.Errorf("%w: %s", model.ErrInvalidRole, role)
+	}
+
+	return uc.audited(ctx, model.AuditUpdate, id, func(ctx context.Context) error {
+		return uc.UserRepository.SetUserRole(ctx, id, role)
+	})
+}
+
+// Authenticate checks the credentials against the stored password hash and
+// returns the actor requests made with them are attributed to.
+func (uc UserUsecase) Authenticate(ctx context.Context, username, secret string) (*model.Actor, error) {
+	if username == "" || secret == "" {
+		password.CheckMissing(secret)
+		return nil, model.ErrInvalidCredentials
+	}
+
+	user, err := uc.UserRepository.GetUserCredentials(ctx, username)
+	if errors.Is(err, model.ErrNotFound) {
+		password.CheckMissing(secret)
+		return nil, model.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !password.Check(user.Password, secret) {
+		return nil, model.ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return nil, model.ErrAccountDisabled
+	}
+
+	return &model.Actor{Id: user.Id, Role: user.Role, EmailVerified: user.EmailVerified}, nil
+}
+
+func (uc UserUsecase) audited(ctx context.Context, action model.AuditAction, id int32, change func(ctx context.Context) error) error {
+	return auditUserChange(ctx, uc.audit, uc.UserRepository, action, id, change)
+}
+
+// auditUserChange runs change on user id in a transaction together with an
+// audit record of the user's state before and after it.
+func auditUserChange(
+	ctx context.Context,
+	audit *Auditor,
+	users interface {
+		GetUserById(context.Context, int32) (*model.User, error)
+	},
+	action model.AuditAction,
+	id int32,
+	change func(ctx context.Context) error,
+) error {
+	return audit.WithinTx(ctx, func(ctx context.Context) error {
+		var before, after *model.User
+		var err error
+
+		if action != model.AuditRestore {
+			if before, err = users.GetUserById(ctx, id); err != nil {
+				return err
+			}
+		}
+		if err := change(ctx); err != nil {
+			return err
+		}
+		if action != model.AuditDelete {
+			if after, err = users.GetUserById(ctx, id); err != nil {
+				return err
+			}
+		}
+
+		return audit.Record(ctx, action, auditResourceUser, id, before, after)
+	})
+}
+
+func (uc UserUsecase) authorize(ctx context.Context, action policy.Action, id int32) error {
+	return policy.Authorize(ctx, uc.policy, action, policy.Resource{
+		Type:    policy.ResourceUser,
+		Id:      id,
+		OwnerId: id,
+	})
 }
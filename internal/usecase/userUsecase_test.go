@@ -6,40 +6,23 @@ import (
 	"ivanjabrony/refstudy/internal/logger"
 	"ivanjabrony/refstudy/internal/model"
 	"ivanjabrony/refstudy/internal/model/dto"
+	"ivanjabrony/refstudy/internal/password"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/testing/mocks/usecasemocks"
 	"ivanjabrony/refstudy/internal/usecase"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
-type mockUserStorage struct {
-	mock.Mock
-}
-
-func (m *mockUserStorage) CreateUser(ctx context.Context, user *model.User) (*model.User, error) {
-	args := m.Called(ctx, user)
-	return args.Get(0).(*model.User), args.Error(1)
-}
-
-func (m *mockUserStorage) GetUserById(ctx context.Context, id int32) (*model.User, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(*model.User), args.Error(1)
-}
-
-func (m *mockUserStorage) GetAllUsers(ctx context.Context) ([]model.User, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]model.User), args.Error(1)
-}
+type mockUserStorage = usecasemocks.UserRepository
 
-func (m *mockUserStorage) UpdateUser(ctx context.Context, user *model.User) error {
-	args := m.Called(ctx, user)
-	return args.Error(1)
-}
+type noVerification struct{}
 
-func (m *mockUserStorage) DeleteUserById(ctx context.Context, id int32) error {
-	args := m.Called(ctx, id)
-	return args.Error(1)
+func (noVerification) SendVerificationEmail(context.Context, *model.User) error {
+	return nil
 }
 
 func TestNewUserUsecase(t *testing.T) {
@@ -65,7 +48,7 @@ func TestNewUserUsecase(t *testing.T) {
 
 	for _, testcase := range testcases {
 		t.Run(testcase.name, func(t *testing.T) {
-			service, err := usecase.NewUserUsecase(testcase.repository, &logger.MyLogger{})
+			service, err := usecase.NewUserUsecase(testcase.repository, newAuditor(), noVerification{}, policy.RolePolicy{}, &logger.MyLogger{})
 			if err != nil {
 				require.Error(t, testcase.err)
 				require.ErrorContains(t, err, testcase.err.Error())
@@ -80,20 +63,18 @@ func TestNewUserUsecase(t *testing.T) {
 }
 
 func TestUserUsecase_GetUser(t *testing.T) {
-	ctx := context.Background()
-	password := "password"
+	ctx := policy.WithActor(context.Background(), &model.Actor{Id: 1, Role: model.RoleUser})
 	user_id := int32(1)
 	storagedUser := &model.User{
 		Id:       1,
 		Username: "ivan",
 		Email:    "test@example.com",
-		Password: password,
+		Password: password.MustHash("password"),
 	}
 	expectedUser := &dto.UserDto{
 		Id:       1,
 		Username: "ivan",
 		Email:    "test@example.com",
-		Password: password,
 	}
 
 	storage1 := new(mockUserStorage)
@@ -122,7 +103,7 @@ func TestUserUsecase_GetUser(t *testing.T) {
 	} {
 		t.Run(testcase.name, func(t *testing.T) {
 			// arrange
-			service, _ := usecase.NewUserUsecase(testcase.storage, &logger.MyLogger{})
+			service, _ := usecase.NewUserUsecase(testcase.storage, newAuditor(), noVerification{}, policy.RolePolicy{}, &logger.MyLogger{})
 
 			// act
 			user, err := service.GetUserById(ctx, user_id)
@@ -140,22 +121,19 @@ func TestUserUsecase_GetUser(t *testing.T) {
 
 func TestUserUsecase_CreateUser(t *testing.T) {
 	ctx := context.Background()
-	password := "password"
 	payload := dto.CreateUserDto{
 		Username: "ivan",
 		Email:    "test@example.com",
-		Password: password,
-	}
-	userToStorage := &model.User{
-		Username: "ivan",
-		Email:    "test@example.com",
-		Password: password,
+		Password: "password",
 	}
+	userToStorage := mock.MatchedBy(func(user *model.User) bool {
+		return user.Username == "ivan" && user.Email == "test@example.com" && password.Check(user.Password, "password")
+	})
 	storagedUser := &model.User{
 		Id:       1,
 		Username: "ivan",
 		Email:    "test@example.com",
-		Password: password,
+		Password: password.MustHash("password"),
 	}
 
 	for _, testcase := range []struct {
@@ -173,7 +151,6 @@ func TestUserUsecase_CreateUser(t *testing.T) {
 				Id:       1,
 				Username: "ivan",
 				Email:    "test@example.com",
-				Password: password,
 			},
 			err: nil,
 		},
@@ -191,6 +168,9 @@ func TestUserUsecase_CreateUser(t *testing.T) {
 			testcase.storageSetup(storage)
 			service, _ := usecase.NewUserUsecase(
 				storage,
+				newAuditor(),
+				noVerification{},
+				policy.RolePolicy{},
 				&logger.MyLogger{},
 			)
 
@@ -207,3 +187,323 @@ func TestUserUsecase_CreateUser(t *testing.T) {
 		})
 	}
 }
+
+// recordingTx remembers what the function run within it failed with, which
+// a real transaction would roll back on.
+type recordingTx struct {
+	err error
+}
+
+func (tx *recordingTx) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	tx.err = fn(ctx)
+	return tx.err
+}
+
+type failingVerification struct {
+	t *testing.T
+}
+
+func (v failingVerification) SendVerificationEmail(context.Context, *model.User) error {
+	v.t.Error("no verification email is sent")
+	return nil
+}
+
+func TestUserUsecase_CreateAdmin(t *testing.T) {
+	admin := policy.WithActor(context.Background(), &model.Actor{Role: model.RoleAdmin})
+	payload := dto.CreateUserDto{Username: "root", Email: "root@example.com", Password: "password"}
+	created := func() *model.User {
+		return &model.User{Id: 1, Username: "root", Email: "root@example.com", Role: model.RoleUser}
+	}
+
+	for _, testcase := range []struct {
+		name         string
+		ctx          context.Context
+		storageSetup func(*mockUserStorage)
+		expectedUser *dto.UserDto
+		err          error
+	}{
+		{
+			name: "success",
+			ctx:  admin,
+			storageSetup: func(m *mockUserStorage) {
+				m.On("CreateUser", mock.Anything, mock.Anything).Return(created(), nil)
+				m.On("SetUserRole", mock.Anything, int32(1), model.RoleAdmin).Return(nil)
+			},
+			expectedUser: &dto.UserDto{Id: 1, Username: "root", Email: "root@example.com", Role: "admin"},
+		},
+		{
+			name: "role not granted",
+			ctx:  admin,
+			storageSetup: func(m *mockUserStorage) {
+				m.On("CreateUser", mock.Anything, mock.Anything).Return(created(), nil)
+				m.On("SetUserRole", mock.Anything, int32(1), model.RoleAdmin).Return(errors.New("error"))
+			},
+			err: errors.New("error"),
+		},
+		{
+			name:         "not an admin",
+			ctx:          policy.WithActor(context.Background(), &model.Actor{Id: 2, Role: model.RoleUser}),
+			storageSetup: func(*mockUserStorage) {},
+			err:          policy.ErrForbidden,
+		},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			storage := new(mockUserStorage)
+			testcase.storageSetup(storage)
+			tx := &recordingTx{}
+			auditor, err := usecase.NewAuditor(tx, &memoryAudit{})
+			require.NoError(t, err)
+			service, err := usecase.NewUserUsecase(storage, auditor, failingVerification{t}, policy.RolePolicy{}, &logger.MyLogger{})
+			require.NoError(t, err)
+
+			user, err := service.CreateAdmin(testcase.ctx, &payload)
+
+			if testcase.err != nil {
+				require.Error(t, err)
+				require.Nil(t, user)
+				if errors.Is(testcase.err, policy.ErrForbidden) {
+					require.ErrorIs(t, err, policy.ErrForbidden)
+				} else {
+					require.Error(t, tx.err, "the user is created and promoted in one transaction")
+				}
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, testcase.expectedUser, user)
+			}
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserUsecase_Authorization(t *testing.T) {
+	owner := policy.WithActor(context.Background(), &model.Actor{Id: 1, Role: model.RoleUser})
+	stranger := policy.WithActor(context.Background(), &model.Actor{Id: 2, Role: model.RoleUser})
+	admin := policy.WithActor(context.Background(), &model.Actor{Id: 3, Role: model.RoleAdmin})
+
+	for _, testcase := range []struct {
+		name string
+		ctx  context.Context
+		call func(context.Context, *usecase.UserUsecase) error
+		err  error
+	}{
+		{
+			name: "anonymous can't list users",
+			ctx:  context.Background(),
+			call: func(ctx context.Context, uc *usecase.UserUsecase) error {
+				_, err := uc.GetAllUsers(ctx)
+				return err
+			},
+			err: policy.ErrUnauthenticated,
+		},
+		{
+			name: "user can't list users",
+			ctx:  owner,
+			call: func(ctx context.Context, uc *usecase.UserUsecase) error {
+				_, err := uc.GetAllUsers(ctx)
+				return err
+			},
+			err: policy.ErrForbidden,
+		},
+		{
+			name: "user can't delete someone else",
+			ctx:  stranger,
+			call: func(ctx context.Context, uc *usecase.UserUsecase) error {
+				return uc.DeleteUserById(ctx, 1)
+			},
+			err: policy.ErrForbidden,
+		},
+		{
+			name: "user can't read someone else",
+			ctx:  stranger,
+			call: func(ctx context.Context, uc *usecase.UserUsecase) error {
+				_, err := uc.GetUserById(ctx, 1)
+				return err
+			},
+			err: policy.ErrForbidden,
+		},
+		{
+			name: "anonymous can't read users",
+			ctx:  context.Background(),
+			call: func(ctx context.Context, uc *usecase.UserUsecase) error {
+				_, err := uc.GetUserById(ctx, 1)
+				return err
+			},
+			err: policy.ErrUnauthenticated,
+		},
+		{
+			name: "user can read themselves",
+			ctx:  owner,
+			call: func(ctx context.Context, uc *usecase.UserUsecase) error {
+				_, err := uc.GetUserById(ctx, 1)
+				return err
+			},
+		},
+		{
+			name: "user can't disable accounts",
+			ctx:  owner,
+			call: func(ctx context.Context, uc *usecase.UserUsecase) error {
+				return uc.DisableUser(ctx, 1)
+			},
+			err: policy.ErrForbidden,
+		},
+		{
+			name: "admin can disable accounts",
+			ctx:  admin,
+			call: func(ctx context.Context, uc *usecase.UserUsecase) error {
+				return uc.DisableUser(ctx, 1)
+			},
+		},
+		{
+			name: "user can't restore themselves",
+			ctx:  owner,
+			call: func(ctx context.Context, uc *usecase.UserUsecase) error {
+				return uc.RestoreUser(ctx, 1)
+			},
+			err: policy.ErrForbidden,
+		},
+		{
+			name: "admin can restore users",
+			ctx:  admin,
+			call: func(ctx context.Context, uc *usecase.UserUsecase) error {
+				return uc.RestoreUser(ctx, 1)
+			},
+		},
+		{
+			name: "admin can't grant unknown role",
+			ctx:  admin,
+			call: func(ctx context.Context, uc *usecase.UserUsecase) error {
+				return uc.SetUserRole(ctx, 1, "superuser")
+			},
+			err: model.ErrInvalidRole,
+		},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			storage := new(mockUserStorage)
+			storage.On("GetUserById", mock.Anything, int32(1)).Return(&model.User{Id: 1}, nil).Maybe()
+			storage.On("SetUserDisabled", mock.Anything, int32(1), true).Return(nil).Maybe()
+			storage.On("RestoreUser", mock.Anything, int32(1)).Return(nil).Maybe()
+			service, err := usecase.NewUserUsecase(storage, newAuditor(), noVerification{}, policy.RolePolicy{}, &logger.MyLogger{})
+			require.NoError(t, err)
+
+			err = testcase.call(testcase.ctx, service)
+			if testcase.err != nil {
+				require.ErrorIs(t, err, testcase.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserUsecase_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	storage := new(mockUserStorage)
+	hash := password.MustHash("secret")
+	storage.On("GetUserCredentials", ctx, "ivan").
+		Return(&model.User{Id: 1, Username: "ivan", Password: hash, Role: model.RoleModerator}, nil)
+	storage.On("GetUserCredentials", ctx, "banned").
+		Return(&model.User{Id: 2, Username: "banned", Password: hash, Disabled: true}, nil)
+	storage.On("GetUserCredentials", ctx, "ghost").
+		Return((*model.User)(nil), model.ErrNotFound)
+	service, err := usecase.NewUserUsecase(storage, newAuditor(), noVerification{}, policy.RolePolicy{}, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	actor, err := service.Authenticate(ctx, "ivan", "secret")
+	require.NoError(t, err)
+	require.Equal(t, &model.Actor{Id: 1, Role: model.RoleModerator}, actor)
+
+	_, err = service.Authenticate(ctx, "ivan", "wrong")
+	require.ErrorIs(t, err, model.ErrInvalidCredentials)
+
+	storage.On("GetUserCredentials", ctx, "legacy").
+		Return(&model.User{Id: 3, Username: "legacy", Password: "secret"}, nil)
+	_, err = service.Authenticate(ctx, "legacy", "secret")
+	require.ErrorIs(t, err, model.ErrInvalidCredentials, "a plaintext password isn't a hash")
+
+	_, err = service.Authenticate(ctx, "ghost", "secret")
+	require.ErrorIs(t, err, model.ErrInvalidCredentials)
+
+	_, err = service.Authenticate(ctx, "", "")
+	require.ErrorIs(t, err, model.ErrInvalidCredentials)
+
+	_, err = service.Authenticate(ctx, "banned", "secret")
+	require.ErrorIs(t, err, model.ErrAccountDisabled)
+}
+
+func TestUserUsecase_UpdateAndDelete(t *testing.T) {
+	ctx := policy.WithActor(context.Background(), &model.Actor{Id: 1, Role: model.RoleUser})
+	username := "ivan2"
+	storage := usecasemocks.NewUserRepository(t)
+	storage.EXPECT().GetUserById(mock.Anything, int32(1)).Return(&model.User{Id: 1, Username: "ivan"}, nil)
+	storage.EXPECT().UpdateUser(mock.Anything, &model.UserUpdate{Id: 1, Username: &username}).Return(nil).Once()
+	storage.EXPECT().DeleteUserById(mock.Anything, int32(1)).Return(model.ErrNotFound).Once()
+	service, err := usecase.NewUserUsecase(storage, newAuditor(), noVerification{}, policy.RolePolicy{}, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	require.NoError(t, service.UpdateUser(ctx, &dto.UpdateUserDto{Id: 1, Username: &username}))
+	empty := ""
+	require.ErrorIs(t, service.UpdateUser(ctx, &dto.UpdateUserDto{Id: 1, Username: &empty}), model.ErrInvalidUser)
+	require.ErrorIs(t, service.UpdateUser(ctx, &dto.UpdateUserDto{Id: 1, Password: &empty}), model.ErrInvalidUser)
+	require.ErrorIs(t, service.DeleteUserById(ctx, 1), model.ErrNotFound)
+}
+
+func TestUserUsecase_Selection(t *testing.T) {
+	ctx := policy.WithActor(context.Background(), &model.Actor{Id: 1, Role: model.RoleAdmin})
+	keyset := model.Keyset{Sort: model.UserSortId, Limit: 10, Fields: model.Fields{"username"}}
+	storage := usecasemocks.NewUserRepository(t)
+	storage.EXPECT().StreamUsers(mock.Anything, model.Keyset{Sort: model.UserSortId, Limit: 11, Fields: model.Fields{"username"}}, mock.Anything).Return(nil).Once()
+	service, err := usecase.NewUserUsecase(storage, newAuditor(), noVerification{}, policy.RolePolicy{}, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	_, _, err = service.GetUsersPage(ctx, keyset)
+	require.NoError(t, err)
+
+	keyset.Fields = model.Fields{"username", "password"}
+	err = service.StreamUsers(ctx, keyset, func(*dto.UserDto) error { return nil })
+	require.ErrorIs(t, err, model.ErrInvalidField)
+
+	keyset.Fields = model.Fields{"username", "name"}
+	_, _, err = service.GetUsersPage(ctx, keyset)
+	require.ErrorIs(t, err, model.ErrInvalidField)
+
+	keyset.Fields, keyset.Include = nil, model.Fields{"pictures"}
+	err = service.StreamUsers(ctx, keyset, func(*dto.UserDto) error { return nil })
+	require.ErrorIs(t, err, model.ErrInvalidField)
+}
+
+func TestUserUsecase_GetUsersByIds(t *testing.T) {
+	user := policy.WithActor(context.Background(), &model.Actor{Id: 2, Role: model.RoleUser})
+	moderator := policy.WithActor(context.Background(), &model.Actor{Id: 3, Role: model.RoleModerator})
+	storage := usecasemocks.NewUserRepository(t)
+	storage.EXPECT().GetUsersByIds(mock.Anything, []int32{2}).Return([]model.User{{Id: 2, Username: "ivan"}}, nil).Once()
+	storage.EXPECT().GetUsersByIds(mock.Anything, []int32{1, 2}).Return([]model.User{{Id: 1, Username: "admin"}, {Id: 2, Username: "ivan"}}, nil).Once()
+	service, err := usecase.NewUserUsecase(storage, newAuditor(), noVerification{}, policy.RolePolicy{}, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	users, err := service.GetUsersByIds(user, []int32{1, 2})
+	require.NoError(t, err)
+	require.Equal(t, []dto.UserDto{{Id: 2, Username: "ivan"}}, users)
+
+	users, err = service.GetUsersByIds(moderator, []int32{1, 2})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+
+	users, err = service.GetUsersByIds(context.Background(), []int32{1, 2})
+	require.NoError(t, err)
+	require.Empty(t, users)
+}
+
+func TestUserUsecase_PurgeDeletedUsers(t *testing.T) {
+	ctx := context.Background()
+	storage := new(mockUserStorage)
+	storage.On("PurgeDeletedUsers", ctx, mock.MatchedBy(func(deletedBefore time.Time) bool {
+		return time.Since(deletedBefore) >= 24*time.Hour
+	})).Return(int64(0), nil)
+	service, err := usecase.NewUserUsecase(storage, newAuditor(), noVerification{}, policy.RolePolicy{}, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	purged, err := service.PurgeDeletedUsers(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	require.Zero(t, purged)
+	storage.AssertExpectations(t)
+}
@@ -0,0 +1,355 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+type JobRepository interface {
+	ClaimJob(ctx context.Context, types []string) (*model.Job, error)
+	CompleteJob(ctx context.Context, job *model.Job) error
+	RetryJob(ctx context.Context, job *model.Job, runAt time.Time, lastError string) error
+	ReleaseJob(ctx context.Context, job *model.Job, lastError string) error
+	HeartbeatJob(ctx context.Context, job *model.Job) error
+	BuryJob(ctx context.Context, job *model.Job, lastError string) error
+	UpdateJobProgress(ctx context.Context, job *model.Job, progress int32) error
+	RequeueStaleJobs(ctx context.Context, staleAfter time.Duration) (requeued, buried int64, err error)
+}
+
+// ProgressFunc lets a handler report how far it got, in percent.
+type ProgressFunc func(ctx context.Context, progress int32) error
+
+type HandlerFunc func(ctx context.Context, job *model.Job, progress ProgressFunc) error
+
+type Config struct {
+	Concurrency  int
+	PollInterval time.Duration
+	// StaleAfter is how long a running job may go without a heartbeat
+	// before it is requeued, or buried once out of attempts. Running jobs
+	// send one every third of it, but at most every millisecond, and stale
+	// jobs are looked for every half of it. Zero disables both.
+	StaleAfter time.Duration
+	Backoff    func(attempt int32) time.Duration
+}
+
+type Pool struct {
+	repo      JobRepository
+	logger    *logger.MyLogger
+	cfg       Config
+	slots     chan struct{}
+	mu        sync.Mutex
+	handlers  map[string]*registration
+	schedules []schedule
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+type schedule struct {
+	interval time.Duration
+	fn       func(context.Context) error
+}
+
+type registration struct {
+	handle  HandlerFunc
+	limit   int
+	running int
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error as not worth retrying: the job goes straight to
+// the dead state regardless of the attempts left.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+func NewPool(repo JobRepository, logger *logger.MyLogger, cfg Config) (*Pool, error) {
+	if repo == nil || logger == nil {
+		return nil, errors.New("nil values in Pool constructor")
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	if cfg.StaleAfter < 0 {
+		cfg.StaleAfter = 0
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Backoff == nil {
+		cfg.Backoff = ExponentialBackoff(5*time.Second, 10*time.Minute)
+	}
+
+	return &Pool{
+		repo:     repo,
+		logger:   logger,
+		cfg:      cfg,
+		slots:    make(chan struct{}, cfg.Concurrency),
+		handlers: make(map[string]*registration),
+	}, nil
+}
+
+func ExponentialBackoff(base, maxDelay time.Duration) func(int32) time.Duration {
+	return func(attempt int32) time.Duration {
+		delay := base
+		for i := int32(1); i < attempt && delay < maxDelay; i++ {
+			delay *= 2
+		}
+		return min(delay, maxDelay)
+	}
+}
+
+// Register adds a handler for jobType. At most limit jobs of that type run
+// at the same time, zero means only the pool concurrency applies.
+func (p *Pool) Register(jobType string, limit int, handler HandlerFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.handlers[jobType] = &registration{handle: handler, limit: limit}
+}
+
+// Handle registers a handler that receives the job payload decoded into T.
+// Payloads that don't decode fail the job permanently.
+func Handle[T any](p *Pool, jobType string, limit int, fn func(ctx context.Context, payload T, progress ProgressFunc) error) {
+	p.Register(jobType, limit, func(ctx context.Context, job *model.Job, progress ProgressFunc) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("failed to decode payload: %w", err))
+		}
+		return fn(ctx, payload, progress)
+	})
+}
+
+// Every runs fn each interval while the pool is started, typically to
+// enqueue a recurring job. Enqueueing rather than doing the work directly
+// keeps retries and the job history in one place.
+func (p *Pool) Every(interval time.Duration, fn func(context.Context) error) {
+	p.schedules = append(p.schedules, schedule{interval, fn})
+}
+
+func (p *Pool) Start(ctx context.Context) error {
+	for _, s := range p.schedules {
+		if s.interval <= 0 {
+			return fmt.Errorf("schedule interval must be positive, got %s", s.interval)
+		}
+	}
+
+	schedules := p.schedules
+	if p.cfg.StaleAfter > 0 {
+		if err := p.requeueStale(ctx); err != nil {
+			return err
+		}
+		// Other replicas may die at any time, not only before this one
+		// starts.
+		schedules = append(slices.Clip(schedules), schedule{max(p.cfg.StaleAfter/2, time.Millisecond), p.requeueStale})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.dispatch(ctx)
+	}()
+
+	for _, s := range schedules {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.runSchedule(ctx, s)
+		}()
+	}
+
+	return nil
+}
+
+func (p *Pool) requeueStale(ctx context.Context) error {
+	requeued, buried, err := p.repo.RequeueStaleJobs(ctx, p.cfg.StaleAfter)
+	if err != nil {
+		return fmt.Errorf("failed to requeue stale jobs: %w", err)
+	}
+	if requeued > 0 {
+		p.logger.Info("requeued stale jobs", slog.Int64("count", requeued))
+	}
+	if buried > 0 {
+		p.logger.Info("buried stale jobs out of attempts", slog.Int64("count", buried))
+	}
+	return nil
+}
+
+func (p *Pool) runSchedule(ctx context.Context, s schedule) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.fn(ctx); err != nil && ctx.Err() == nil {
+				p.logger.WrapError("scheduled task failed", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops claiming new jobs and waits for the running ones. Handlers see
+// their context cancelled, and jobs interrupted this way are put back into
+// the queue without using up an attempt.
+func (p *Pool) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+func (p *Pool) dispatch(ctx context.Context) {
+	for {
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		job := p.claim(ctx)
+		if job == nil {
+			<-p.slots
+			select {
+			case <-time.After(p.cfg.PollInterval):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			defer func() { <-p.slots }()
+			p.run(ctx, job)
+		}()
+	}
+}
+
+func (p *Pool) claim(ctx context.Context) *model.Job {
+	p.mu.Lock()
+	types := make([]string, 0, len(p.handlers))
+	for jobType, r := range p.handlers {
+		if r.limit == 0 || r.running < r.limit {
+			types = append(types, jobType)
+		}
+	}
+	p.mu.Unlock()
+
+	if len(types) == 0 {
+		return nil
+	}
+
+	job, err := p.repo.ClaimJob(ctx, types)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.WrapError("failed to claim job", err)
+		}
+		return nil
+	}
+	if job != nil {
+		p.mu.Lock()
+		p.handlers[job.Type].running++
+		p.mu.Unlock()
+	}
+
+	return job
+}
+
+func (p *Pool) run(ctx context.Context, job *model.Job) {
+	p.mu.Lock()
+	r := p.handlers[job.Type]
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		r.running--
+		p.mu.Unlock()
+	}()
+
+	progress := func(ctx context.Context, progress int32) error {
+		return p.repo.UpdateJobProgress(ctx, job, min(max(progress, 0), 100))
+	}
+
+	if p.cfg.StaleAfter > 0 {
+		stop := p.heartbeat(ctx, job)
+		defer stop()
+	}
+	err := safeCall(ctx, r.handle, job, progress)
+
+	updateCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	var permanent permanentError
+	switch {
+	case err == nil:
+		err = p.repo.CompleteJob(updateCtx, job)
+	case ctx.Err() != nil:
+		err = p.repo.ReleaseJob(updateCtx, job, "interrupted by shutdown")
+	case errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts:
+		p.logger.WrapError("job failed permanently", err, slog.Int64("id", job.Id), slog.String("type", job.Type))
+		err = p.repo.BuryJob(updateCtx, job, err.Error())
+	default:
+		p.logger.WrapError("job failed, retrying", err, slog.Int64("id", job.Id), slog.String("type", job.Type))
+		err = p.repo.RetryJob(updateCtx, job, time.Now().Add(p.cfg.Backoff(job.Attempts)), err.Error())
+	}
+	if err != nil {
+		p.logger.WrapError("failed to update job state", err, slog.Int64("id", job.Id))
+	}
+}
+
+// heartbeat keeps job from going stale until the returned func is called.
+func (p *Pool) heartbeat(ctx context.Context, job *model.Job) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(max(p.cfg.StaleAfter/3, time.Millisecond))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := p.repo.HeartbeatJob(ctx, job); err != nil && ctx.Err() == nil {
+					p.logger.WrapError("failed to send job heartbeat", err, slog.Int64("id", job.Id))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func safeCall(ctx context.Context, handle HandlerFunc, job *model.Job, progress ProgressFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	return handle(ctx, job, progress)
+}
@@ -0,0 +1,342 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/worker"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryJobs struct {
+	mu         sync.Mutex
+	jobs       []*model.Job
+	heartbeats atomic.Int32
+	requeues   atomic.Int32
+}
+
+func (m *memoryJobs) add(jobType, payload string, maxAttempts int32) *model.Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := &model.Job{
+		Id:          int64(len(m.jobs) + 1),
+		Type:        jobType,
+		Payload:     []byte(payload),
+		Status:      model.JobQueued,
+		MaxAttempts: maxAttempts,
+		RunAt:       time.Now(),
+	}
+	m.jobs = append(m.jobs, job)
+	return job
+}
+
+func (m *memoryJobs) get(id int64) model.Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.jobs[id-1]
+}
+
+func (m *memoryJobs) ClaimJob(_ context.Context, types []string) (*model.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.Status == model.JobQueued && !job.RunAt.After(time.Now()) && slices.Contains(types, job.Type) {
+			job.Status = model.JobRunning
+			job.Attempts++
+			job.ClaimToken = strconv.Itoa(int(job.Attempts))
+			claimed := *job
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryJobs) CompleteJob(_ context.Context, claimed *model.Job) error {
+	return m.update(claimed, func(job *model.Job) {
+		job.Status = model.JobSucceeded
+		job.Progress = 100
+	})
+}
+
+func (m *memoryJobs) RetryJob(_ context.Context, claimed *model.Job, runAt time.Time, lastError string) error {
+	return m.update(claimed, func(job *model.Job) {
+		job.Status = model.JobQueued
+		job.RunAt = runAt
+		job.LastError = lastError
+	})
+}
+
+func (m *memoryJobs) BuryJob(_ context.Context, claimed *model.Job, lastError string) error {
+	return m.update(claimed, func(job *model.Job) {
+		job.Status = model.JobDead
+		job.LastError = lastError
+	})
+}
+
+func (m *memoryJobs) UpdateJobProgress(_ context.Context, claimed *model.Job, progress int32) error {
+	return m.update(claimed, func(job *model.Job) { job.Progress = progress })
+}
+
+func (m *memoryJobs) ReleaseJob(_ context.Context, claimed *model.Job, lastError string) error {
+	return m.update(claimed, func(job *model.Job) {
+		job.Status = model.JobQueued
+		job.Attempts--
+		job.LastError = lastError
+	})
+}
+
+func (m *memoryJobs) HeartbeatJob(_ context.Context, claimed *model.Job) error {
+	m.heartbeats.Add(1)
+	return m.update(claimed, func(*model.Job) {})
+}
+
+func (m *memoryJobs) RequeueStaleJobs(context.Context, time.Duration) (int64, int64, error) {
+	m.requeues.Add(1)
+	return 0, 0, nil
+}
+
+// requeue puts back job id as RequeueStaleJobs would.
+func (m *memoryJobs) requeue(id int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[id-1].Status = model.JobQueued
+}
+
+func (m *memoryJobs) update(claimed *model.Job, fn func(*model.Job)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := m.jobs[claimed.Id-1]
+	if job.Status != model.JobRunning || job.ClaimToken != claimed.ClaimToken {
+		return fmt.Errorf("running job with id %d and this claim: %w", claimed.Id, model.ErrNotFound)
+	}
+	fn(job)
+	return nil
+}
+
+func newPool(t *testing.T, repo *memoryJobs, concurrency int) *worker.Pool {
+	pool, err := worker.NewPool(repo, logger.New(logger.Test, logger.LogFormatText), worker.Config{
+		Concurrency:  concurrency,
+		PollInterval: time.Millisecond,
+		Backoff:      func(int32) time.Duration { return 0 },
+	})
+	require.NoError(t, err)
+	return pool
+}
+
+func waitForStatus(t *testing.T, repo *memoryJobs, id int64, status model.JobStatus) model.Job {
+	require.Eventually(t, func() bool {
+		return repo.get(id).Status == status
+	}, 2*time.Second, time.Millisecond)
+	return repo.get(id)
+}
+
+func TestNewPool(t *testing.T) {
+	_, err := worker.NewPool(nil, logger.New(logger.Test, logger.LogFormatText), worker.Config{})
+	require.Error(t, err)
+}
+
+func TestPoolRunsTypedHandler(t *testing.T) {
+	repo := &memoryJobs{}
+	job := repo.add("greet", `{"name":"ivan"}`, 3)
+	pool := newPool(t, repo, 2)
+
+	got := make(chan string, 1)
+	worker.Handle(pool, "greet", 0, func(ctx context.Context, payload struct{ Name string }, progress worker.ProgressFunc) error {
+		require.NoError(t, progress(ctx, 50))
+		got <- payload.Name
+		return nil
+	})
+
+	require.NoError(t, pool.Start(context.Background()))
+	defer pool.Stop()
+
+	require.Equal(t, "ivan", <-got)
+	done := waitForStatus(t, repo, job.Id, model.JobSucceeded)
+	require.Equal(t, int32(100), done.Progress)
+}
+
+func TestPoolRetriesAndBuries(t *testing.T) {
+	repo := &memoryJobs{}
+	flaky := repo.add("flaky", `{}`, 3)
+	broken := repo.add("broken", `{}`, 3)
+	undecodable := repo.add("typed", `not json`, 3)
+	pool := newPool(t, repo, 1)
+
+	var calls atomic.Int32
+	pool.Register("flaky", 0, func(context.Context, *model.Job, worker.ProgressFunc) error {
+		if calls.Add(1) < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+	pool.Register("broken", 0, func(context.Context, *model.Job, worker.ProgressFunc) error {
+		panic("boom")
+	})
+	worker.Handle(pool, "typed", 0, func(context.Context, struct{}, worker.ProgressFunc) error {
+		return nil
+	})
+
+	require.NoError(t, pool.Start(context.Background()))
+	defer pool.Stop()
+
+	done := waitForStatus(t, repo, flaky.Id, model.JobSucceeded)
+	require.Equal(t, int32(3), done.Attempts)
+
+	dead := waitForStatus(t, repo, broken.Id, model.JobDead)
+	require.Equal(t, int32(3), dead.Attempts)
+	require.Contains(t, dead.LastError, "handler panicked: boom")
+
+	dead = waitForStatus(t, repo, undecodable.Id, model.JobDead)
+	require.Equal(t, int32(1), dead.Attempts)
+	require.Contains(t, dead.LastError, "failed to decode payload")
+}
+
+func TestPoolRespectsTypeLimit(t *testing.T) {
+	repo := &memoryJobs{}
+	for range 6 {
+		repo.add("slow", `{}`, 1)
+	}
+	pool := newPool(t, repo, 4)
+
+	var running, peak atomic.Int32
+	pool.Register("slow", 2, func(context.Context, *model.Job, worker.ProgressFunc) error {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if current <= old || peak.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	require.NoError(t, pool.Start(context.Background()))
+	defer pool.Stop()
+
+	for id := int64(1); id <= 6; id++ {
+		waitForStatus(t, repo, id, model.JobSucceeded)
+	}
+	require.Equal(t, int32(2), peak.Load())
+}
+
+func TestPoolStopRequeuesInterruptedJobs(t *testing.T) {
+	repo := &memoryJobs{}
+	job := repo.add("endless", `{}`, 5)
+	pool := newPool(t, repo, 1)
+
+	started := make(chan struct{})
+	pool.Register("endless", 0, func(ctx context.Context, _ *model.Job, _ worker.ProgressFunc) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	require.NoError(t, pool.Start(context.Background()))
+	<-started
+	pool.Stop()
+
+	interrupted := repo.get(job.Id)
+	require.Equal(t, model.JobQueued, interrupted.Status)
+	require.Equal(t, "interrupted by shutdown", interrupted.LastError)
+	require.Zero(t, interrupted.Attempts, "an interrupted attempt doesn't count")
+}
+
+func TestPoolLeavesReclaimedJobsAlone(t *testing.T) {
+	repo := &memoryJobs{}
+	job := repo.add("slow", `{}`, 5)
+	pool := newPool(t, repo, 2)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls atomic.Int32
+	pool.Register("slow", 0, func(context.Context, *model.Job, worker.ProgressFunc) error {
+		if calls.Add(1) > 1 {
+			return nil
+		}
+		close(started)
+		<-release
+		return errors.New("too late")
+	})
+
+	require.NoError(t, pool.Start(context.Background()))
+	<-started
+	repo.requeue(job.Id)
+	waitForStatus(t, repo, job.Id, model.JobSucceeded)
+	close(release)
+	pool.Stop()
+
+	done := repo.get(job.Id)
+	require.Equal(t, model.JobSucceeded, done.Status, "the stale attempt can't touch the new one")
+	require.Empty(t, done.LastError)
+}
+
+func TestPoolKeepsRunningJobsFresh(t *testing.T) {
+	repo := &memoryJobs{}
+	repo.add("slow", `{}`, 5)
+	pool, err := worker.NewPool(repo, logger.New(logger.Test, logger.LogFormatText), worker.Config{
+		PollInterval: time.Millisecond,
+		StaleAfter:   3 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	pool.Register("slow", 0, func(ctx context.Context, _ *model.Job, _ worker.ProgressFunc) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	require.NoError(t, pool.Start(context.Background()))
+	<-started
+	require.Eventually(t, func() bool {
+		return repo.heartbeats.Load() >= 3 && repo.requeues.Load() >= 3
+	}, time.Second, time.Millisecond, "heartbeats are sent and stale jobs looked for while running")
+	pool.Stop()
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := worker.ExponentialBackoff(time.Second, 10*time.Second)
+
+	require.Equal(t, time.Second, backoff(1))
+	require.Equal(t, 2*time.Second, backoff(2))
+	require.Equal(t, 8*time.Second, backoff(4))
+	require.Equal(t, 10*time.Second, backoff(10))
+}
+
+func TestPoolEvery(t *testing.T) {
+	pool := newPool(t, &memoryJobs{}, 1)
+
+	var ticks atomic.Int32
+	pool.Every(time.Millisecond, func(context.Context) error {
+		ticks.Add(1)
+		return nil
+	})
+
+	require.NoError(t, pool.Start(context.Background()))
+	require.Eventually(t, func() bool { return ticks.Load() >= 3 }, time.Second, time.Millisecond)
+	pool.Stop()
+
+	stopped := ticks.Load()
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, stopped, ticks.Load())
+}
+
+func TestPoolEveryRejectsNonPositiveInterval(t *testing.T) {
+	pool := newPool(t, &memoryJobs{}, 1)
+	pool.Every(0, func(context.Context) error { return nil })
+
+	require.Error(t, pool.Start(context.Background()))
+}
@@ -0,0 +1,2 @@
+-- Hashed passwords can't be turned back into the passwords they came from.
+DROP EXTENSION IF EXISTS pgcrypto;
@@ -0,0 +1,5 @@
+-- Passwords used to be stored as given. Replace them with the bcrypt hashes
+-- the application checks logins against; rows holding a hash are left alone.
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+UPDATE users SET password = crypt(password, gen_salt('bf', 10)) WHERE password !~ '^\$2[aby]\$';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS jobs_unique_key_idx;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS unique_key;
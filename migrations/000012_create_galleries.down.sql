@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS gallery_imports;
+DROP TABLE IF EXISTS pictures;
+DROP TABLE IF EXISTS galleries;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS galleries (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    owner_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    is_public BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS galleries_owner_idx ON galleries (owner_id);
+
+CREATE TABLE IF NOT EXISTS pictures (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    gallery_id BIGINT NOT NULL REFERENCES galleries (id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    path VARCHAR(1024) NOT NULL,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS pictures_gallery_idx ON pictures (gallery_id, id);
+
+-- An import is the job that runs it; the row keeps what the job reports.
+CREATE TABLE IF NOT EXISTS gallery_imports (
+    job_id BIGINT PRIMARY KEY REFERENCES jobs (id) ON DELETE CASCADE,
+    owner_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    archive_key VARCHAR(1024) NOT NULL,
+    failures JSONB NOT NULL DEFAULT '[]',
+    gallery_ids BIGINT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
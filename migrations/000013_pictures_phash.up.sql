@@ -0,0 +1,4 @@
+-- The perceptual hash of the contents as stored in Go's uint64, reinterpreted
+-- as signed. Pictures stored before hashing have none and are left out of
+-- comparisons.
+ALTER TABLE pictures ADD COLUMN IF NOT EXISTS phash BIGINT;
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS pictures_search_update ON pictures;
+DROP TRIGGER IF EXISTS galleries_search_update ON galleries;
+ALTER TABLE pictures DROP COLUMN IF EXISTS search;
+ALTER TABLE galleries DROP COLUMN IF EXISTS search;
+DROP FUNCTION IF EXISTS pictures_search_update();
+DROP FUNCTION IF EXISTS galleries_search_update();
+DROP FUNCTION IF EXISTS picture_search_vector(TEXT, TEXT[]);
+DROP FUNCTION IF EXISTS gallery_search_vector(TEXT, TEXT);
@@ -0,0 +1,44 @@
+-- Names weigh most, then tags, then descriptions. The 'simple' configuration
+-- doesn't stem, so a prefix of a name matches whatever language it is in.
+CREATE OR REPLACE FUNCTION gallery_search_vector(name TEXT, description TEXT) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('simple', name), 'A') ||
+        setweight(to_tsvector('simple', description), 'C');
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION picture_search_vector(name TEXT, tags TEXT[]) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('simple', name), 'A') ||
+        setweight(to_tsvector('simple', array_to_string(tags, ' ')), 'B');
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION galleries_search_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search := gallery_search_vector(NEW.name, NEW.description);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION pictures_search_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search := picture_search_vector(NEW.name, NEW.tags);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE galleries ADD COLUMN IF NOT EXISTS search tsvector;
+UPDATE galleries SET search = gallery_search_vector(name, description);
+ALTER TABLE galleries ALTER COLUMN search SET NOT NULL;
+CREATE INDEX IF NOT EXISTS galleries_search_idx ON galleries USING GIN (search);
+
+ALTER TABLE pictures ADD COLUMN IF NOT EXISTS search tsvector;
+UPDATE pictures SET search = picture_search_vector(name, tags);
+ALTER TABLE pictures ALTER COLUMN search SET NOT NULL;
+CREATE INDEX IF NOT EXISTS pictures_search_idx ON pictures USING GIN (search);
+
+-- The triggers keep the vectors current whatever writes the rows.
+CREATE TRIGGER galleries_search_update
+    BEFORE INSERT OR UPDATE OF name, description ON galleries
+    FOR EACH ROW EXECUTE FUNCTION galleries_search_update();
+
+CREATE TRIGGER pictures_search_update
+    BEFORE INSERT OR UPDATE OF name, tags ON pictures
+    FOR EACH ROW EXECUTE FUNCTION pictures_search_update();
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestEmailVerification mails the authenticated user a new verification
+// link.
+func (c *Client) RequestEmailVerification(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodPost, "/auth/verify-email/resend", nil, nil, nil)
+	return err
+}
+
+// VerifyEmail confirms an email with the token from a verification link; it
+// needs no credentials.
+func (c *Client) VerifyEmail(ctx context.Context, token string) error {
+	body := struct {
+		Token string `json:"token"`
+	}{Token: token}
+	_, err := c.do(ctx, http.MethodPost, "/auth/verify-email", nil, body, nil)
+	return err
+}
+
+// ForgotPassword mails a reset link if email belongs to a user; it succeeds
+// either way.
+func (c *Client) ForgotPassword(ctx context.Context, email string) error {
+	body := struct {
+		Email string `json:"email"`
+	}{Email: email}
+	_, err := c.do(ctx, http.MethodPost, "/auth/forgot-password", nil, body, nil)
+	return err
+}
+
+func (c *Client) ResetPassword(ctx context.Context, token, password string) error {
+	body := struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}{Token: token, Password: password}
+	_, err := c.do(ctx, http.MethodPost, "/auth/reset-password", nil, body, nil)
+	return err
+}
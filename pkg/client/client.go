@@ -0,0 +1,241 @@
+// Package client is a Go client for the refstudy HTTP API.
+//
+//	c, err := client.New(client.Config{
+//		BaseURL:  "http://localhost:8080/api/v1",
+//		Username: "ivan",
+//		Password: "12345678",
+//	})
+//	user, err := c.GetUser(ctx, 1)
+//
+// Failed requests return an *Error, which matches the sentinel errors of this
+// package with errors.Is. Idempotent requests are retried with backoff when
+// the server is unavailable or rate limits them.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+type Config struct {
+	// BaseURL is where the API version is served, e.g.
+	// http://localhost:8080/api/v1.
+	BaseURL string
+	// Username and Password authenticate every request with HTTP Basic
+	// auth. Without a username requests are anonymous.
+	Username string
+	Password string
+	// HTTPClient sends the requests; nil uses http.DefaultClient.
+	HTTPClient *http.Client
+	// MaxRetries is how many times an idempotent request is retried; zero
+	// uses 3 and a negative value disables retries.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the exponential backoff between
+	// retries; zero uses 100ms and 5s. A Retry-After longer than MaxBackoff
+	// isn't waited for.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+type Client struct {
+	baseURL    *url.URL
+	username   string
+	password   string
+	http       *http.Client
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func New(cfg Config) (*Client, error) {
+	if cfg.BaseURL == "" {
+		return nil, errors.New("empty base URL in client constructor")
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+
+	c := &Client{
+		baseURL:    baseURL,
+		username:   cfg.Username,
+		password:   cfg.Password,
+		http:       cfg.HTTPClient,
+		maxRetries: cfg.MaxRetries,
+		minBackoff: cfg.MinBackoff,
+		maxBackoff: cfg.MaxBackoff,
+	}
+	if c.http == nil {
+		c.http = http.DefaultClient
+	}
+	if c.maxRetries == 0 {
+		c.maxRetries = defaultMaxRetries
+	}
+	if c.minBackoff <= 0 {
+		c.minBackoff = defaultMinBackoff
+	}
+	if c.maxBackoff <= 0 {
+		c.maxBackoff = defaultMaxBackoff
+	}
+	return c, nil
+}
+
+// WithCredentials returns a copy of the client that authenticates as
+// username.
+func (c *Client) WithCredentials(username, password string) *Client {
+	clone := *c
+	clone.username = username
+	clone.password = password
+	return &clone
+}
+
+// envelope is the body of every successful response.
+type envelope struct {
+	Data json.RawMessage `json:"data"`
+	Meta *Meta           `json:"meta"`
+}
+
+// do sends a request with body encoded as JSON, decodes the data of the
+// response into out when given and returns its page metadata, if any.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) (*Meta, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	target := c.baseURL.JoinPath(path)
+	target.RawQuery = query.Encode()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, target.String(), payload)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			defer resp.Body.Close()
+			return decode(resp, out)
+		}
+
+		if err == nil {
+			err = readError(resp)
+		}
+		wait, retry := c.backoff(method, attempt, err)
+		if !retry {
+			return nil, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, target string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	return resp, nil
+}
+
+func decode(resp *http.Response, out any) (*Meta, error) {
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, nil
+	}
+
+	var body envelope
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if err := json.Unmarshal(body.Data, out); err != nil {
+		return nil, fmt.Errorf("failed to decode response data: %w", err)
+	}
+	return body.Meta, nil
+}
+
+// backoff decides whether a failed attempt is retried and how long to wait
+// before. Only idempotent methods are retried, after network errors, rate
+// limiting and the statuses of an unavailable server.
+func (c *Client) backoff(method string, attempt int, err error) (time.Duration, bool) {
+	if attempt >= c.maxRetries || !idempotent(method) {
+		return 0, false
+	}
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, false
+		}
+		return c.jitter(attempt), true
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+	default:
+		return 0, false
+	}
+	if apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, apiErr.RetryAfter <= c.maxBackoff
+	}
+	return c.jitter(attempt), true
+}
+
+// jitter is the exponential backoff for attempt with full jitter.
+func (c *Client) jitter(attempt int) time.Duration {
+	ceiling := c.minBackoff << min(attempt, 30)
+	if ceiling <= 0 || ceiling > c.maxBackoff {
+		ceiling = c.maxBackoff
+	}
+	return c.minBackoff/2 + rand.N(ceiling-c.minBackoff/2+1)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func pathId[T int32 | int64](id T) string {
+	return strconv.FormatInt(int64(id), 10)
+}
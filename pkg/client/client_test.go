@@ -0,0 +1,423 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"ivanjabrony/refstudy/internal/controller"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"ivanjabrony/refstudy/internal/password"
+	"ivanjabrony/refstudy/internal/testing/fakes"
+	"ivanjabrony/refstudy/internal/testing/mocks/v1mocks"
+	"ivanjabrony/refstudy/pkg/client"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type server struct {
+	url     string
+	job     *v1mocks.JobUsecase
+	audit   *v1mocks.AuditUsecase
+	account *v1mocks.AccountUsecase
+}
+
+// newServer serves the API from fake users, starting with the admin "admin",
+// and from mocks for everything else. wrap, if given, sits in front of the
+// router.
+func newServer(t *testing.T, wrap func(http.Handler) http.Handler) *server {
+	t.Helper()
+
+	users := fakes.NewUserUsecase(fakes.NewUserRepository(model.User{
+		Username: "admin",
+		Email:    "admin@example.com",
+		Password: password.MustHash("12345678"),
+		Role:     model.RoleAdmin,
+	}))
+	s := &server{
+		job:     v1mocks.NewJobUsecase(t),
+		audit:   v1mocks.NewAuditUsecase(t),
+		account: v1mocks.NewAccountUsecase(t),
+	}
+
+	gin.SetMode(gin.TestMode)
+	var handler http.Handler = controller.SetupRouter(
+		logger.New(logger.Test, logger.LogFormatText),
+		controller.Usecases{
+			User:          users,
+			Admin:         users,
+			Job:           s.job,
+			Audit:         s.audit,
+			Account:       s.account,
+			Authenticator: users,
+		},
+		controller.Options{},
+		validator.New(validator.WithRequiredStructEnabled()),
+	)
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	s.url = srv.URL + "/api/v1"
+	return s
+}
+
+func newClient(t *testing.T, url, username string) *client.Client {
+	t.Helper()
+
+	c, err := client.New(client.Config{
+		BaseURL:    url,
+		Username:   username,
+		Password:   "12345678",
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func signup(t *testing.T, c *client.Client, username string) *client.User {
+	t.Helper()
+
+	user, err := c.CreateUser(context.Background(), client.CreateUser{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "12345678",
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func TestNew(t *testing.T) {
+	_, err := client.New(client.Config{})
+	require.Error(t, err)
+	_, err = client.New(client.Config{BaseURL: "://"})
+	require.Error(t, err)
+}
+
+func TestClient_Users(t *testing.T) {
+	ctx := context.Background()
+	s := newServer(t, nil)
+	admin := newClient(t, s.url, "admin")
+	ivan := newClient(t, s.url, "ivan")
+
+	created := signup(t, newClient(t, s.url, ""), "ivan")
+	require.Equal(t, "ivan", created.Username)
+	require.Equal(t, client.RoleUser, created.Role)
+	_, err := newClient(t, s.url, "").CreateUser(ctx, client.CreateUser{Username: "ivan", Email: "other@example.com", Password: "12345678"})
+	require.ErrorIs(t, err, client.ErrConflict, "the username is taken")
+	require.NotErrorIs(t, err, client.ErrBadRequest)
+
+	user, err := ivan.GetUser(ctx, created.Id)
+	require.NoError(t, err)
+	require.Equal(t, created, user)
+
+	// Users reach the SDK without their password, so there is nothing to leak.
+	req, err := http.NewRequest(http.MethodGet, s.url+"/users/"+strconv.Itoa(int(created.Id)), nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("ivan", "12345678")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var raw struct {
+		Data map[string]any `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&raw))
+	require.Equal(t, "ivan", raw.Data["username"])
+	require.NotContains(t, raw.Data, "password")
+
+	username, email, password := "ivan2", "ivan2@example.com", "87654321"
+	require.NoError(t, ivan.UpdateUser(ctx, client.UpdateUser{Id: created.Id, Username: &username, Email: &email, Password: &password}))
+	ivan = ivan.WithCredentials("ivan2", "87654321")
+	user, err = ivan.GetUser(ctx, created.Id)
+	require.NoError(t, err)
+	require.Equal(t, "ivan2@example.com", user.Email)
+
+	require.NoError(t, ivan.DeleteUser(ctx, created.Id))
+	_, err = admin.GetUser(ctx, created.Id)
+	require.ErrorIs(t, err, client.ErrNotFound)
+	require.NoError(t, admin.RestoreUser(ctx, created.Id))
+	_, err = admin.GetUser(ctx, created.Id)
+	require.NoError(t, err)
+}
+
+func TestClient_Pagination(t *testing.T) {
+	ctx := context.Background()
+	s := newServer(t, nil)
+	anonymous := newClient(t, s.url, "")
+	for _, name := range []string{"a", "b", "c", "d"} {
+		signup(t, anonymous, name)
+	}
+	admin := newClient(t, s.url, "admin")
+
+	page, err := admin.ListUsers(ctx, client.PageOptions{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, client.Meta{Page: 2, PageSize: 2, Total: 5, TotalPages: 3}, page.Meta)
+	require.Equal(t, "b", page.Items[0].Username)
+
+	var names []string
+	for user, err := range admin.Users(ctx, 2) {
+		require.NoError(t, err)
+		names = append(names, user.Username)
+	}
+	require.Equal(t, []string{"admin", "a", "b", "c", "d"}, names)
+
+	// Breaking out of the loop stops fetching.
+	names = nil
+	for user, err := range admin.Users(ctx, 2) {
+		require.NoError(t, err)
+		names = append(names, user.Username)
+		if len(names) == 3 {
+			break
+		}
+	}
+	require.Equal(t, []string{"admin", "a", "b"}, names)
+
+	for _, err := range newClient(t, s.url, "nobody").Users(ctx, 2) {
+		require.ErrorIs(t, err, client.ErrUnauthorized)
+	}
+}
+
+func TestClient_Cursor(t *testing.T) {
+	ctx := context.Background()
+	s := newServer(t, nil)
+	anonymous := newClient(t, s.url, "")
+	for _, name := range []string{"d", "b", "c", "a"} {
+		signup(t, anonymous, name)
+	}
+	admin := newClient(t, s.url, "admin")
+
+	page, err := admin.ListUsersByCursor(ctx, client.CursorOptions{PageSize: 2, Sort: client.UserSortUsername})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "admin"}, usernames(page.Items))
+	require.Empty(t, page.Meta.PrevCursor)
+
+	page, err = admin.ListUsersByCursor(ctx, client.CursorOptions{Cursor: page.Meta.NextCursor, PageSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "c"}, usernames(page.Items), "the cursor keeps the order")
+
+	back, err := admin.ListUsersByCursor(ctx, client.CursorOptions{Cursor: page.Meta.PrevCursor, PageSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "admin"}, usernames(back.Items))
+
+	_, err = admin.ListUsersByCursor(ctx, client.CursorOptions{Cursor: "forged"})
+	require.ErrorIs(t, err, client.ErrBadRequest)
+}
+
+func usernames(users []client.User) []string {
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Username
+	}
+	return names
+}
+
+func TestClient_Admin(t *testing.T) {
+	ctx := context.Background()
+	s := newServer(t, nil)
+	admin := newClient(t, s.url, "admin")
+	ivan := signup(t, newClient(t, s.url, ""), "ivan")
+
+	require.NoError(t, admin.SetUserRole(ctx, ivan.Id, client.RoleModerator))
+	require.NoError(t, admin.DisableUser(ctx, ivan.Id))
+	users, err := admin.AdminListUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	require.Equal(t, client.RoleModerator, users[1].Role)
+	require.True(t, users[1].Disabled)
+
+	_, err = newClient(t, s.url, "ivan").GetUser(ctx, ivan.Id)
+	require.ErrorIs(t, err, client.ErrUnauthorized)
+	require.NoError(t, admin.EnableUser(ctx, ivan.Id))
+	require.ErrorIs(t, newClient(t, s.url, "ivan").DisableUser(ctx, 1), client.ErrForbidden)
+	require.ErrorIs(t, admin.SetUserRole(ctx, ivan.Id, "root"), client.ErrBadRequest)
+}
+
+func TestClient_AuditRecords(t *testing.T) {
+	ctx := context.Background()
+	s := newServer(t, nil)
+	actor := int32(1)
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.audit.EXPECT().
+		GetAuditRecords(mock.Anything, mock.MatchedBy(func(filter model.AuditFilter) bool {
+			return *filter.ActorId == actor && filter.Action == "update" && filter.From.Equal(from) && filter.To == nil
+		})).
+		RunAndReturn(func(_ context.Context, filter model.AuditFilter) ([]dto.AuditRecordDto, int, error) {
+			records := []dto.AuditRecordDto{}
+			for id := filter.Offset; id < min(filter.Offset+filter.Limit, 3); id++ {
+				records = append(records, dto.AuditRecordDto{Id: int64(id + 1), ActorId: &actor, Action: "update"})
+			}
+			return records, 3, nil
+		})
+
+	var ids []int64
+	filter := client.AuditFilter{ActorId: &actor, Action: "update", From: from}
+	for record, err := range newClient(t, s.url, "admin").AuditRecords(ctx, filter, 2) {
+		require.NoError(t, err)
+		ids = append(ids, record.Id)
+	}
+	require.Equal(t, []int64{1, 2, 3}, ids)
+}
+
+func TestClient_Account(t *testing.T) {
+	ctx := context.Background()
+	s := newServer(t, nil)
+	anonymous := newClient(t, s.url, "")
+	s.account.EXPECT().VerifyEmail(mock.Anything, "good").Return(nil)
+	s.account.EXPECT().VerifyEmail(mock.Anything, "bad").Return(model.ErrInvalidToken)
+	s.account.EXPECT().ForgotPassword(mock.Anything, "ivan@example.com").Return(nil)
+	s.account.EXPECT().ResetPassword(mock.Anything, "good", "87654321").Return(nil)
+	s.account.EXPECT().RequestEmailVerification(mock.Anything).Return(nil)
+
+	require.NoError(t, anonymous.VerifyEmail(ctx, "good"))
+	require.ErrorIs(t, anonymous.VerifyEmail(ctx, "bad"), client.ErrBadRequest)
+	require.NoError(t, anonymous.ForgotPassword(ctx, "ivan@example.com"))
+	require.NoError(t, anonymous.ResetPassword(ctx, "good", "87654321"))
+	require.NoError(t, newClient(t, s.url, "admin").RequestEmailVerification(ctx))
+}
+
+func TestClient_GetJob(t *testing.T) {
+	ctx := context.Background()
+	s := newServer(t, nil)
+	s.job.EXPECT().GetJobById(mock.Anything, int64(7)).Return(&dto.JobDto{Id: 7, Type: "import", Status: "running", Progress: 40}, nil)
+	s.job.EXPECT().GetJobById(mock.Anything, int64(8)).Return(nil, model.ErrNotFound)
+
+	admin := newClient(t, s.url, "admin")
+	job, err := admin.GetJob(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, int32(40), job.Progress)
+	_, err = admin.GetJob(ctx, 8)
+	require.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestClient_Error(t *testing.T) {
+	s := newServer(t, nil)
+
+	_, err := newClient(t, s.url, "nobody").GetUser(context.Background(), 1)
+	var apiErr *client.Error
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	require.Equal(t, "Unauthorized", apiErr.Title)
+	require.Equal(t, "/api/v1/users/1", apiErr.Instance)
+	require.NotEmpty(t, apiErr.RequestId)
+	require.ErrorIs(t, err, client.ErrUnauthorized)
+	require.NotErrorIs(t, err, client.ErrForbidden)
+}
+
+// failing fails the first failures requests with status.
+func failing(failures int32, status int, header http.Header, requests *atomic.Int32) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) <= failures {
+				for key, values := range header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte("upstream unavailable"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	testcases := []struct {
+		name     string
+		failures int32
+		status   int
+		header   http.Header
+		call     func(*client.Client) error
+		requests int32
+		err      error
+	}{
+		{
+			name:     "idempotent requests are retried",
+			failures: 2,
+			status:   http.StatusServiceUnavailable,
+			call:     func(c *client.Client) error { _, err := c.GetUser(context.Background(), 1); return err },
+			requests: 3,
+		},
+		{
+			name:     "retries give up",
+			failures: 10,
+			status:   http.StatusBadGateway,
+			call:     func(c *client.Client) error { return c.DeleteUser(context.Background(), 2) },
+			requests: 4,
+			err:      client.ErrServer,
+		},
+		{
+			name:     "rate limits are waited out",
+			failures: 1,
+			status:   http.StatusTooManyRequests,
+			call:     func(c *client.Client) error { _, err := c.AdminListUsers(context.Background()); return err },
+			requests: 2,
+		},
+		{
+			name:     "too long rate limits aren't",
+			failures: 1,
+			status:   http.StatusTooManyRequests,
+			header:   http.Header{"Retry-After": {"60"}},
+			call:     func(c *client.Client) error { _, err := c.AdminListUsers(context.Background()); return err },
+			requests: 1,
+			err:      client.ErrRateLimited,
+		},
+		{
+			name:     "other requests are not retried",
+			failures: 1,
+			status:   http.StatusServiceUnavailable,
+			call:     func(c *client.Client) error { return c.RestoreUser(context.Background(), 1) },
+			requests: 1,
+			err:      client.ErrServer,
+		},
+		{
+			name:     "client errors are not retried",
+			failures: 1,
+			status:   http.StatusNotFound,
+			call:     func(c *client.Client) error { _, err := c.GetUser(context.Background(), 1); return err },
+			requests: 1,
+			err:      client.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests atomic.Int32
+			s := newServer(t, failing(tc.failures, tc.status, tc.header, &requests))
+
+			err := tc.call(newClient(t, s.url, "admin"))
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.requests, requests.Load())
+		})
+	}
+}
+
+func TestClient_RetryCanceled(t *testing.T) {
+	var requests atomic.Int32
+	s := newServer(t, failing(10, http.StatusServiceUnavailable, nil, &requests))
+	c, err := client.New(client.Config{BaseURL: s.url, MinBackoff: time.Hour, MaxBackoff: time.Hour})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.GetUser(ctx, 1)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorIs(t, err, client.ErrServer)
+	require.Equal(t, int32(1), requests.Load())
+}
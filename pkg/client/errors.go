@@ -0,0 +1,115 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The server's failures by status; an *Error matches the one for its status
+// with errors.Is.
+var (
+	ErrBadRequest       = errors.New("bad request")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrForbidden        = errors.New("forbidden")
+	ErrNotFound         = errors.New("not found")
+	ErrConflict         = errors.New("conflict")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrRateLimited      = errors.New("rate limited")
+	ErrServer           = errors.New("server error")
+)
+
+// Error is a failed response, decoded from the problem document the server
+// sends with it.
+type Error struct {
+	StatusCode int
+	Type       string
+	Title      string
+	Detail     string
+	Instance   string
+	RequestId  string
+	// RetryAfter is when a rate limited or locked out client may try again.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%d %s", e.StatusCode, e.Title)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.RequestId != "" {
+		msg += " (request " + e.RequestId + ")"
+	}
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrMethodNotAllowed:
+		return e.StatusCode == http.StatusMethodNotAllowed
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+// problem is the RFC 7807 document the server fails with.
+type problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail"`
+	Instance  string `json:"instance"`
+	RequestId string `json:"request_id"`
+}
+
+// readError turns a failed response into an *Error. Bodies that aren't
+// problem documents, e.g. from a proxy, become the detail.
+func readError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		Title:      http.StatusText(resp.StatusCode),
+		RequestId:  resp.Header.Get("X-Request-Id"),
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return apiErr
+	}
+	var p problem
+	if json.Unmarshal(body, &p) != nil || p.Status == 0 {
+		apiErr.Detail = string(body)
+		return apiErr
+	}
+
+	apiErr.Type = p.Type
+	apiErr.Title = p.Title
+	apiErr.Detail = p.Detail
+	apiErr.Instance = p.Instance
+	if p.RequestId != "" {
+		apiErr.RequestId = p.RequestId
+	}
+	return apiErr
+}
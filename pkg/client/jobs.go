@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type Job struct {
+	Id          int64     `json:"id"`
+	Type        string    `json:"type"`
+	Status      string    `json:"status"`
+	Attempts    int32     `json:"attempts"`
+	MaxAttempts int32     `json:"max_attempts"`
+	Progress    int32     `json:"progress"`
+	LastError   string    `json:"last_error,omitempty"`
+	RunAt       time.Time `json:"run_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (c *Client) GetJob(ctx context.Context, id int64) (*Job, error) {
+	var job Job
+	if _, err := c.do(ctx, http.MethodGet, "/jobs/"+pathId(id), nil, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
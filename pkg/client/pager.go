@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Meta describes the page of a paginated response. Pages picked by number
+// have Page, Total and TotalPages; pages picked by cursor have the cursors of
+// their neighbours instead, empty at either end of the list.
+type Meta struct {
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	Total      int    `json:"total"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+}
+
+// Page is one page of a paginated listing.
+type Page[T any] struct {
+	Items []T
+	Meta  Meta
+}
+
+// PageOptions pick a page; zero values use the server's defaults of the
+// first page and 10 items.
+type PageOptions struct {
+	Page     int
+	PageSize int
+}
+
+func (o PageOptions) query(query url.Values) url.Values {
+	if query == nil {
+		query = url.Values{}
+	}
+	if o.Page > 0 {
+		query.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(o.PageSize))
+	}
+	return query
+}
+
+// CursorOptions pick a page by cursor. An empty Cursor is the first page;
+// Sort is only needed there, as cursors remember it.
+type CursorOptions struct {
+	Cursor   string
+	PageSize int
+	Sort     string
+}
+
+func (o CursorOptions) query() url.Values {
+	query := url.Values{"cursor": {o.Cursor}}
+	if o.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(o.PageSize))
+	}
+	if o.Sort != "" && o.Cursor == "" {
+		query.Set("sort", o.Sort)
+	}
+	return query
+}
+
+// all iterates over the items of every page from the first one on, fetching
+// pages of pageSize as the loop reaches them. It stops after yielding the
+// first error.
+func all[T any](ctx context.Context, pageSize int, fetch func(context.Context, PageOptions) (*Page[T], error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page := 1; ; page++ {
+			got, err := fetch(ctx, PageOptions{Page: page, PageSize: pageSize})
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range got.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(got.Items) == 0 || page >= got.Meta.TotalPages {
+				return
+			}
+		}
+	}
+}
+
+// allByCursor is all for listings paged by cursor, which don't skip or
+// repeat items when the list changes during the loop.
+func allByCursor[T any](ctx context.Context, opts CursorOptions, fetch func(context.Context, CursorOptions) (*Page[T], error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			got, err := fetch(ctx, opts)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range got.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if got.Meta.NextCursor == "" {
+				return
+			}
+			opts.Cursor = got.Meta.NextCursor
+		}
+	}
+}
+
+func getPage[T any](ctx context.Context, c *Client, path string, query url.Values, opts PageOptions) (*Page[T], error) {
+	return fetchPage[T](ctx, c, path, opts.query(query))
+}
+
+func getCursorPage[T any](ctx context.Context, c *Client, path string, opts CursorOptions) (*Page[T], error) {
+	return fetchPage[T](ctx, c, path, opts.query())
+}
+
+func fetchPage[T any](ctx context.Context, c *Client, path string, query url.Values) (*Page[T], error) {
+	var items []T
+	meta, err := c.do(ctx, http.MethodGet, path, query, nil, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{Items: items}
+	if meta != nil {
+		page.Meta = *meta
+	}
+	return page, nil
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+)
+
+// Orders of users listed by cursor.
+const (
+	UserSortId       = "id"
+	UserSortUsername = "username"
+)
+
+type User struct {
+	Id            int32  `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	Disabled      bool   `json:"disabled"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+type CreateUser struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// UpdateUser changes the fields that aren't nil.
+type UpdateUser struct {
+	Id       int32   `json:"id"`
+	Username *string `json:"username,omitempty"`
+	Email    *string `json:"email,omitempty"`
+	Password *string `json:"password,omitempty"`
+}
+
+// CreateUser signs up a new user; it needs no credentials.
+func (c *Client) CreateUser(ctx context.Context, create CreateUser) (*User, error) {
+	var user User
+	if _, err := c.do(ctx, http.MethodPost, "/users", nil, create, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) GetUser(ctx context.Context, id int32) (*User, error) {
+	var user User
+	if _, err := c.do(ctx, http.MethodGet, "/users/"+pathId(id), nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) ListUsers(ctx context.Context, opts PageOptions) (*Page[User], error) {
+	return getPage[User](ctx, c, "/users", nil, opts)
+}
+
+// ListUsersByCursor lists a page of users starting at opts.Cursor; follow
+// Meta.NextCursor and Meta.PrevCursor to its neighbours.
+func (c *Client) ListUsersByCursor(ctx context.Context, opts CursorOptions) (*Page[User], error) {
+	return getCursorPage[User](ctx, c, "/users", opts)
+}
+
+// Users iterates over all users by id, fetching pageSize of them at a time.
+func (c *Client) Users(ctx context.Context, pageSize int) iter.Seq2[User, error] {
+	return allByCursor(ctx, CursorOptions{PageSize: pageSize}, c.ListUsersByCursor)
+}
+
+func (c *Client) UpdateUser(ctx context.Context, update UpdateUser) error {
+	_, err := c.do(ctx, http.MethodPut, "/users", nil, update, nil)
+	return err
+}
+
+func (c *Client) DeleteUser(ctx context.Context, id int32) error {
+	_, err := c.do(ctx, http.MethodDelete, "/users/"+pathId(id), nil, nil, nil)
+	return err
+}
+
+func (c *Client) RestoreUser(ctx context.Context, id int32) error {
+	_, err := c.do(ctx, http.MethodPost, "/users/"+pathId(id)+"/restore", nil, nil, nil)
+	return err
+}
@@ -0,0 +1,236 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"ivanjabrony/refstudy/internal/controller"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/mailer"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/model/dto"
+	"ivanjabrony/refstudy/internal/policy"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"ivanjabrony/refstudy/internal/repository"
+	"ivanjabrony/refstudy/internal/token"
+	"ivanjabrony/refstudy/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+)
+
+// stack is the HTTP API wired the way the app does it, over a pool that is
+// rolled back when the test ends.
+type stack struct {
+	router *gin.Engine
+	mails  *mailer.MemoryMailer
+	users  *repository.UserRepository
+}
+
+func newStack(t *testing.T) *stack {
+	t.Helper()
+
+	pool := begin(t)
+	log := logger.New(logger.Test, logger.LogFormatText)
+	users, err := repository.NewUserRepository(pool, log)
+	require.NoError(t, err)
+	jobs, err := repository.NewJobRepository(pool, log)
+	require.NoError(t, err)
+	audit, err := repository.NewAuditRepository(pool, log)
+	require.NoError(t, err)
+	tokens, err := repository.NewTokenRepository(pool, log)
+	require.NoError(t, err)
+	limits, err := repository.NewRateLimitRepository(pool, log)
+	require.NoError(t, err)
+	tx, err := repository.NewTransactor(pool)
+	require.NoError(t, err)
+
+	policy := policy.VerifiedPolicy{Policy: policy.RolePolicy{}, Access: policy.UnverifiedFull}
+	auditor, err := usecase.NewAuditor(tx, audit)
+	require.NoError(t, err)
+	signer, err := token.NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	mails := mailer.NewMemoryMailer()
+	account, err := usecase.NewAccountUsecase(users, tokens, signer, mails, auditor, usecase.AccountConfig{
+		FrontendURL:     "http://localhost:3000",
+		VerificationTTL: time.Hour,
+		ResetTTL:        time.Hour,
+	}, log)
+	require.NoError(t, err)
+	user, err := usecase.NewUserUsecase(users, auditor, account, policy, log)
+	require.NoError(t, err)
+	job, err := usecase.NewJobUsecase(jobs, policy, log)
+	require.NoError(t, err)
+	auditUsecase, err := usecase.NewAuditUsecase(audit, policy, log)
+	require.NoError(t, err)
+	guard, err := ratelimit.NewGuard(user, limits, ratelimit.Lockout{
+		Threshold: 3,
+		Base:      time.Minute,
+		Max:       time.Hour,
+		Window:    time.Hour,
+	})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := controller.SetupRouter(
+		log,
+		controller.Usecases{
+			User:          user,
+			Admin:         user,
+			Job:           job,
+			Audit:         auditUsecase,
+			Account:       account,
+			Authenticator: guard,
+		},
+		controller.Options{},
+		validator.New(validator.WithRequiredStructEnabled()),
+	)
+	return &stack{router: router, mails: mails, users: users}
+}
+
+// do sends a request as username with the password "12345678", or
+// anonymously when username is empty, and decodes the data of the response
+// into out if given.
+func (s *stack) do(t *testing.T, method, path, username, body string, out any) int {
+	t.Helper()
+
+	req := httptest.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if username != "" {
+		req.SetBasicAuth(username, "12345678")
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	if out != nil && w.Code < http.StatusBadRequest {
+		var envelope struct {
+			Data json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope), w.Body.String())
+		require.NoError(t, json.Unmarshal(envelope.Data, out))
+	}
+	return w.Code
+}
+
+func (s *stack) signup(t *testing.T, username string) dto.UserDto {
+	t.Helper()
+
+	var user dto.UserDto
+	body := `{"username":"` + username + `","email":"` + username + `@example.com","password":"12345678"}`
+	require.Equal(t, http.StatusCreated, s.do(t, http.MethodPost, "/users", "", body, &user))
+	return user
+}
+
+func (s *stack) promote(t *testing.T, id int32) {
+	t.Helper()
+
+	require.NoError(t, s.users.SetUserRole(context.Background(), id, model.RoleAdmin))
+}
+
+func itoa(id int32) string {
+	return strconv.Itoa(int(id))
+}
+
+var tokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+func (s *stack) lastToken(t *testing.T, to string) string {
+	t.Helper()
+
+	messages := s.mails.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].To != to {
+			continue
+		}
+		match := tokenPattern.FindStringSubmatch(messages[i].Body)
+		require.NotNil(t, match, messages[i].Body)
+		token, err := url.QueryUnescape(match[1])
+		require.NoError(t, err)
+		return token
+	}
+	t.Fatalf("no mail was sent to %s", to)
+	return ""
+}
+
+func TestHTTP_Users(t *testing.T) {
+	s := newStack(t)
+
+	ivan := s.signup(t, "ivan")
+	require.Equal(t, "user", ivan.Role)
+
+	var got dto.UserDto
+	require.Equal(t, http.StatusOK, s.do(t, http.MethodGet, "/users/"+itoa(ivan.Id), "ivan", "", &got))
+	require.Equal(t, "ivan@example.com", got.Email)
+	require.Equal(t, http.StatusUnauthorized, s.do(t, http.MethodGet, "/users/"+itoa(ivan.Id), "nobody", "", nil))
+
+	other := s.signup(t, "petr")
+	require.Equal(t, http.StatusForbidden, s.do(t, http.MethodGet, "/users/"+itoa(other.Id), "ivan", "", nil))
+	require.Equal(t, http.StatusUnauthorized, s.do(t, http.MethodGet, "/users/"+itoa(other.Id), "", "", nil))
+	require.Equal(t, http.StatusForbidden, s.do(t, http.MethodDelete, "/users/"+itoa(other.Id), "ivan", "", nil))
+	require.Equal(t, http.StatusForbidden, s.do(t, http.MethodGet, "/admin/users", "ivan", "", nil))
+
+	s.promote(t, ivan.Id)
+	var list []dto.UserDto
+	require.Equal(t, http.StatusOK, s.do(t, http.MethodGet, "/admin/users", "ivan", "", &list))
+	require.Len(t, list, 2)
+
+	require.Equal(t, http.StatusNoContent, s.do(t, http.MethodDelete, "/users/"+itoa(other.Id), "ivan", "", nil))
+	require.Equal(t, http.StatusNotFound, s.do(t, http.MethodGet, "/users/"+itoa(other.Id), "ivan", "", nil))
+	require.Equal(t, http.StatusUnauthorized, s.do(t, http.MethodGet, "/users/"+itoa(other.Id), "petr", "", nil))
+	require.Equal(t, http.StatusNoContent, s.do(t, http.MethodPost, "/users/"+itoa(other.Id)+"/restore", "ivan", "", nil))
+	require.Equal(t, http.StatusOK, s.do(t, http.MethodGet, "/users/"+itoa(other.Id), "petr", "", nil))
+
+	var records []dto.AuditRecordDto
+	require.Equal(t, http.StatusOK, s.do(t, http.MethodGet, "/admin/audit?resource_type=user&resource_id="+itoa(other.Id), "ivan", "", &records))
+	actions := make([]string, len(records))
+	for i, record := range records {
+		actions[i] = record.Action
+	}
+	require.Equal(t, []string{"restore", "delete", "create"}, actions)
+}
+
+func TestHTTP_Account(t *testing.T) {
+	s := newStack(t)
+
+	ivan := s.signup(t, "ivan")
+	require.False(t, ivan.EmailVerified)
+	verify := s.lastToken(t, "ivan@example.com")
+	require.Equal(t, http.StatusNoContent, s.do(t, http.MethodPost, "/auth/verify-email", "", `{"token":"`+verify+`"}`, nil))
+	require.Equal(t, http.StatusBadRequest, s.do(t, http.MethodPost, "/auth/verify-email", "", `{"token":"`+verify+`"}`, nil))
+
+	var got dto.UserDto
+	require.Equal(t, http.StatusOK, s.do(t, http.MethodGet, "/users/"+itoa(ivan.Id), "ivan", "", &got))
+	require.True(t, got.EmailVerified)
+
+	require.Equal(t, http.StatusAccepted, s.do(t, http.MethodPost, "/auth/forgot-password", "", `{"email":"ivan@example.com"}`, nil))
+	reset := s.lastToken(t, "ivan@example.com")
+	require.Equal(t, http.StatusNoContent, s.do(t, http.MethodPost, "/auth/reset-password", "", `{"token":"`+reset+`","password":"87654321"}`, nil))
+	require.Equal(t, http.StatusUnauthorized, s.do(t, http.MethodGet, "/users/"+itoa(ivan.Id), "ivan", "", nil))
+}
+
+func TestHTTP_Lockout(t *testing.T) {
+	s := newStack(t)
+	s.signup(t, "ivan")
+
+	for range 3 {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.SetBasicAuth("ivan", "wrong")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// Locked out, even with the right password.
+	require.Equal(t, http.StatusTooManyRequests, s.do(t, http.MethodGet, "/users", "ivan", "", nil))
+}
@@ -0,0 +1,413 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"ivanjabrony/refstudy/internal/logger"
+	"ivanjabrony/refstudy/internal/model"
+	"ivanjabrony/refstudy/internal/ratelimit"
+	"ivanjabrony/refstudy/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func newUser(name string) *model.User {
+	return &model.User{Username: name, Email: name + "@example.com", Password: "12345678"}
+}
+
+func TestUserRepository(t *testing.T) {
+	ctx := context.Background()
+	users, err := repository.NewUserRepository(begin(t), &logger.MyLogger{})
+	require.NoError(t, err)
+
+	created, err := users.CreateUser(ctx, newUser("ivan"))
+	require.NoError(t, err)
+	require.NotZero(t, created.Id)
+
+	user, err := users.GetUserById(ctx, created.Id)
+	require.NoError(t, err)
+	require.Equal(t, "ivan", user.Username)
+	require.Equal(t, model.RoleUser, user.Role)
+	require.False(t, user.Disabled)
+	require.False(t, user.EmailVerified)
+
+	require.NoError(t, users.SetEmailVerified(ctx, created.Id))
+	require.NoError(t, users.SetUserRole(ctx, created.Id, model.RoleModerator))
+	username := "ivan2"
+	require.NoError(t, users.UpdateUser(ctx, &model.UserUpdate{Id: user.Id, Username: &username}))
+	user, err = users.GetUserByUsername(ctx, "ivan2")
+	require.NoError(t, err)
+	require.Equal(t, model.RoleModerator, user.Role)
+	require.True(t, user.EmailVerified, "keeping the email keeps it verified")
+	require.Equal(t, "12345678", user.Password, "fields left out are kept")
+
+	email := "other@example.com"
+	require.NoError(t, users.UpdateUser(ctx, &model.UserUpdate{Id: user.Id, Email: &email}))
+	user, err = users.GetUserByEmail(ctx, "other@example.com")
+	require.NoError(t, err)
+	require.False(t, user.EmailVerified, "a new email has to be verified again")
+
+	_, err = users.GetUserById(ctx, created.Id+1)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	require.ErrorIs(t, users.SetUserRole(ctx, created.Id+1, model.RoleAdmin), model.ErrNotFound)
+
+	all, err := users.GetAllUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+}
+
+func TestUserRepository_Constraints(t *testing.T) {
+	ctx := context.Background()
+	users, err := repository.NewUserRepository(begin(t), &logger.MyLogger{})
+	require.NoError(t, err)
+
+	created, err := users.CreateUser(ctx, newUser("ivan"))
+	require.NoError(t, err)
+
+	// A failed statement only rolls back its own savepoint, so the test can
+	// go on after each of these.
+	var pgErr *pgconn.PgError
+	_, err = users.CreateUser(ctx, &model.User{Username: "ivan", Email: "other@example.com", Password: "12345678"})
+	require.True(t, errors.As(err, &pgErr))
+	require.Equal(t, "23505", pgErr.Code)
+	require.Equal(t, "users_username_live_idx", pgErr.ConstraintName)
+
+	_, err = users.CreateUser(ctx, &model.User{Username: "other", Email: "ivan@example.com", Password: "12345678"})
+	require.True(t, errors.As(err, &pgErr))
+	require.Equal(t, "users_email_live_idx", pgErr.ConstraintName)
+
+	err = users.SetUserRole(ctx, created.Id, model.Role("root"))
+	require.True(t, errors.As(err, &pgErr))
+	require.Equal(t, "23514", pgErr.Code)
+
+	// Only live users have to be unique.
+	require.NoError(t, users.DeleteUserById(ctx, created.Id))
+	_, err = users.CreateUser(ctx, newUser("ivan"))
+	require.NoError(t, err)
+	require.Error(t, users.RestoreUser(ctx, created.Id))
+}
+
+func TestUserRepository_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	users, err := repository.NewUserRepository(begin(t), &logger.MyLogger{})
+	require.NoError(t, err)
+
+	created, err := users.CreateUser(ctx, newUser("ivan"))
+	require.NoError(t, err)
+	require.NoError(t, users.DeleteUserById(ctx, created.Id))
+	require.ErrorIs(t, users.DeleteUserById(ctx, created.Id), model.ErrNotFound)
+	_, err = users.GetUserById(ctx, created.Id)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	_, err = users.GetUserByUsername(ctx, "ivan")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	require.NoError(t, users.RestoreUser(ctx, created.Id))
+	require.ErrorIs(t, users.RestoreUser(ctx, created.Id), model.ErrNotFound)
+	_, err = users.GetUserById(ctx, created.Id)
+	require.NoError(t, err)
+
+	require.NoError(t, users.DeleteUserById(ctx, created.Id))
+	purged, err := users.PurgeDeletedUsers(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, purged)
+	purged, err = users.PurgeDeletedUsers(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+	require.ErrorIs(t, users.RestoreUser(ctx, created.Id), model.ErrNotFound)
+}
+
+func TestUserRepository_Keyset(t *testing.T) {
+	ctx := context.Background()
+	users, err := repository.NewUserRepository(begin(t), &logger.MyLogger{})
+	require.NoError(t, err)
+
+	for _, name := range []string{"d", "b", "c", "a"} {
+		_, err := users.CreateUser(ctx, newUser(name))
+		require.NoError(t, err)
+	}
+	b, err := users.GetUserByUsername(ctx, "b")
+	require.NoError(t, err)
+
+	stream := func(keyset model.Keyset) []string {
+		var names []string
+		require.NoError(t, users.StreamUsers(ctx, keyset, func(user *model.User) error {
+			names = append(names, user.Username)
+			return nil
+		}))
+		return names
+	}
+	require.Equal(t, []string{"d", "b", "c", "a"}, stream(model.Keyset{Sort: model.UserSortId}))
+	require.Equal(t, []string{"c", "d"}, stream(model.Keyset{Sort: model.UserSortUsername, After: "b", AfterId: b.Id}))
+	require.Equal(t, []string{"a"}, stream(model.Keyset{Sort: model.UserSortUsername, After: "b", AfterId: b.Id, Backward: true}))
+	require.Equal(t, []string{"c"}, stream(model.Keyset{Sort: model.UserSortId, AfterId: b.Id, Limit: 1}))
+}
+
+func TestTransactor_RollsBack(t *testing.T) {
+	ctx := context.Background()
+	pool := begin(t)
+	users, err := repository.NewUserRepository(pool, &logger.MyLogger{})
+	require.NoError(t, err)
+	audit, err := repository.NewAuditRepository(pool, &logger.MyLogger{})
+	require.NoError(t, err)
+	tx, err := repository.NewTransactor(pool)
+	require.NoError(t, err)
+
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		user, err := users.CreateUser(ctx, newUser("ivan"))
+		if err != nil {
+			return err
+		}
+		err = audit.CreateAuditRecord(ctx, &model.AuditRecord{
+			ActorRole:    "admin",
+			Action:       model.AuditCreate,
+			ResourceType: "user",
+			ResourceId:   "1",
+			Changes:      map[string]model.FieldChange{"username": {After: user.Username}},
+		})
+		if err != nil {
+			return err
+		}
+		return errors.New("give up")
+	})
+	require.EqualError(t, err, "give up")
+
+	_, err = users.GetUserByUsername(ctx, "ivan")
+	require.ErrorIs(t, err, model.ErrNotFound)
+	_, total, err := audit.GetAuditRecords(ctx, model.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Zero(t, total)
+}
+
+func TestAuditRepository(t *testing.T) {
+	ctx := context.Background()
+	audit, err := repository.NewAuditRepository(begin(t), &logger.MyLogger{})
+	require.NoError(t, err)
+
+	actor := int32(1)
+	for i, action := range []model.AuditAction{model.AuditCreate, model.AuditUpdate, model.AuditDelete} {
+		record := &model.AuditRecord{
+			ActorId:      &actor,
+			ActorRole:    "admin",
+			Action:       action,
+			ResourceType: "user",
+			ResourceId:   "2",
+			Changes:      map[string]model.FieldChange{"username": {Before: "ivan", After: i}},
+			RequestId:    "req-1",
+		}
+		require.NoError(t, audit.CreateAuditRecord(ctx, record))
+		require.NotZero(t, record.Id)
+	}
+
+	records, total, err := audit.GetAuditRecords(ctx, model.AuditFilter{Action: string(model.AuditUpdate), Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	require.Equal(t, model.AuditUpdate, records[0].Action)
+	require.Equal(t, &actor, records[0].ActorId)
+	require.Equal(t, model.FieldChange{Before: "ivan", After: float64(1)}, records[0].Changes["username"])
+
+	records, total, err = audit.GetAuditRecords(ctx, model.AuditFilter{ResourceType: "user", Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	require.Equal(t, 3, total)
+	require.Len(t, records, 1)
+}
+
+func TestJobRepository(t *testing.T) {
+	ctx := context.Background()
+	jobs, err := repository.NewJobRepository(begin(t), &logger.MyLogger{})
+	require.NoError(t, err)
+
+	// now() is when the test's transaction began, so jobs have to be due
+	// before that to be claimed.
+	due := time.Now().Add(-time.Minute)
+	first, err := jobs.CreateJob(ctx, &model.Job{Type: "import", Payload: []byte(`{"id":1}`), MaxAttempts: 3, RunAt: due})
+	require.NoError(t, err)
+	_, err = jobs.CreateJob(ctx, &model.Job{Type: "import", Payload: []byte(`{}`), MaxAttempts: 3, RunAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = jobs.CreateJob(ctx, &model.Job{Type: "export", Payload: []byte(`{}`), MaxAttempts: 3, RunAt: due})
+	require.NoError(t, err)
+
+	claimed, err := jobs.ClaimJob(ctx, []string{"import"})
+	require.NoError(t, err)
+	require.Equal(t, first.Id, claimed.Id)
+	require.NotEmpty(t, claimed.ClaimToken)
+	require.Equal(t, model.JobRunning, claimed.Status)
+	require.Equal(t, int32(1), claimed.Attempts)
+	require.JSONEq(t, `{"id":1}`, string(claimed.Payload))
+
+	none, err := jobs.ClaimJob(ctx, []string{"import"})
+	require.NoError(t, err)
+	require.Nil(t, none, "the other import isn't due yet")
+
+	require.NoError(t, jobs.UpdateJobProgress(ctx, claimed, 50))
+	require.NoError(t, jobs.RetryJob(ctx, claimed, due, "connection reset"))
+	job, err := jobs.GetJobById(ctx, first.Id)
+	require.NoError(t, err)
+	require.Equal(t, model.JobQueued, job.Status)
+	require.Equal(t, "connection reset", job.LastError)
+
+	stale := claimed
+	claimed, err = jobs.ClaimJob(ctx, []string{"import"})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), claimed.Attempts)
+	require.NotEqual(t, stale.ClaimToken, claimed.ClaimToken)
+	require.ErrorIs(t, jobs.HeartbeatJob(ctx, stale), model.ErrNotFound, "an old claim can't touch the new attempt")
+	require.NoError(t, jobs.HeartbeatJob(ctx, claimed))
+	require.NoError(t, jobs.ReleaseJob(ctx, claimed, "interrupted by shutdown"))
+	claimed, err = jobs.ClaimJob(ctx, []string{"import"})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), claimed.Attempts, "a released job keeps its attempts")
+	require.NoError(t, jobs.CompleteJob(ctx, claimed))
+	job, err = jobs.GetJobById(ctx, first.Id)
+	require.NoError(t, err)
+	require.Equal(t, model.JobSucceeded, job.Status)
+
+	_, err = jobs.GetJobById(ctx, first.Id+100)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	unique := &model.Job{Type: "purge", Payload: []byte(`{}`), MaxAttempts: 3, RunAt: due, UniqueKey: "purge:1"}
+	_, err = jobs.CreateJob(ctx, unique)
+	require.NoError(t, err)
+	_, err = jobs.CreateJob(ctx, unique)
+	require.ErrorIs(t, err, model.ErrAlreadyExists)
+}
+
+func TestRateLimitRepository(t *testing.T) {
+	ctx := context.Background()
+	limits, err := repository.NewRateLimitRepository(begin(t), &logger.MyLogger{})
+	require.NoError(t, err)
+
+	limit := ratelimit.Limit{Requests: 2, Per: time.Hour}
+	for range 2 {
+		result, err := limits.Take(ctx, "ip:1", limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+	}
+	result, err := limits.Take(ctx, "ip:1", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+
+	lockout := ratelimit.Lockout{Threshold: 2, Base: time.Minute, Max: time.Hour, Window: time.Hour}
+	lock, err := limits.GetLock(ctx, "login:ivan")
+	require.NoError(t, err)
+	require.Zero(t, lock.Failures)
+	_, err = limits.Fail(ctx, "login:ivan", lockout)
+	require.NoError(t, err)
+	lock, err = limits.Fail(ctx, "login:ivan", lockout)
+	require.NoError(t, err)
+	require.Equal(t, 2, lock.Failures)
+	require.False(t, lock.Until.IsZero())
+
+	require.NoError(t, limits.ResetLock(ctx, "login:ivan"))
+	lock, err = limits.GetLock(ctx, "login:ivan")
+	require.NoError(t, err)
+	require.Zero(t, lock.Failures)
+}
+
+func TestGalleryRepository_Search(t *testing.T) {
+	ctx := context.Background()
+	db := begin(t)
+	users, err := repository.NewUserRepository(db, &logger.MyLogger{})
+	require.NoError(t, err)
+	galleries, err := repository.NewGalleryRepository(db, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	owner, err := users.CreateUser(ctx, newUser("ivan"))
+	require.NoError(t, err)
+	private, err := galleries.CreateGallery(ctx, &model.Gallery{
+		OwnerId: owner.Id, GalleryName: "Hand studies", Description: "gestures",
+		Pictures: []model.Picture{
+			{Name: "left", Path: "pictures/1.png", Tags: []string{"anatomy", "hands, left"}, Hash: ^uint64(0), Width: 1, Height: 1},
+			{Name: "open-palm", Path: "pictures/2.png", Width: 1, Height: 1},
+		},
+	})
+	require.NoError(t, err)
+	_, err = galleries.CreateGallery(ctx, &model.Gallery{
+		OwnerId: owner.Id, GalleryName: "Feet", Description: "hands come later", IsPublic: true,
+	})
+	require.NoError(t, err)
+
+	hashed, err := galleries.GetHashedPictures(ctx, private.Id)
+	require.NoError(t, err)
+	require.Len(t, hashed, 2)
+	require.Equal(t, ^uint64(0), hashed[0].Hash)
+
+	search := func(query string, viewer *int32) ([]model.SearchHit, int) {
+		t.Helper()
+		hits, total, err := galleries.Search(ctx, model.SearchFilter{Query: query, Visibility: model.Visibility{ViewerId: viewer}, Limit: 10})
+		require.NoError(t, err)
+		return hits, total
+	}
+
+	hits, total := search("hand", &owner.Id)
+	require.Equal(t, 3, total)
+	require.Equal(t, "Hand studies", hits[0].Name, "names rank above descriptions")
+	require.Equal(t, model.SearchGallery, hits[0].Kind)
+
+	hits, _ = search("pal", &owner.Id)
+	require.Len(t, hits, 1)
+	require.Equal(t, model.SearchPicture, hits[0].Kind)
+	require.Equal(t, private.Id, hits[0].GalleryId)
+
+	hits, total = search("hand", nil)
+	require.Equal(t, 1, total, "private galleries are left out")
+	require.Equal(t, "Feet", hits[0].Name)
+
+	_, total = search("anatomy left", &owner.Id)
+	require.Equal(t, 1, total)
+}
+
+func TestGalleryRepository_Batches(t *testing.T) {
+	ctx := context.Background()
+	db := begin(t)
+	users, err := repository.NewUserRepository(db, &logger.MyLogger{})
+	require.NoError(t, err)
+	galleries, err := repository.NewGalleryRepository(db, &logger.MyLogger{})
+	require.NoError(t, err)
+
+	ivan, err := users.CreateUser(ctx, newUser("ivan"))
+	require.NoError(t, err)
+	petr, err := users.CreateUser(ctx, newUser("petr"))
+	require.NoError(t, err)
+	var ids []int32
+	for _, gallery := range []model.Gallery{
+		{OwnerId: ivan.Id, GalleryName: "hands", IsPublic: true, Pictures: []model.Picture{
+			{Name: "left", Path: "pictures/1.png", Width: 1, Height: 1},
+			{Name: "right", Path: "pictures/2.png", Width: 1, Height: 1},
+		}},
+		{OwnerId: ivan.Id, GalleryName: "feet", IsPublic: true},
+		{OwnerId: petr.Id, GalleryName: "faces", Pictures: []model.Picture{
+			{Name: "smile", Path: "pictures/3.png", Width: 1, Height: 1},
+		}},
+	} {
+		created, err := galleries.CreateGallery(ctx, &gallery)
+		require.NoError(t, err)
+		ids = append(ids, created.Id)
+	}
+
+	found, err := users.GetUsersByIds(ctx, []int32{petr.Id, ivan.Id})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	require.Equal(t, "ivan", found[0].Username)
+	require.Empty(t, found[0].Password)
+
+	byIds, err := galleries.GetGalleriesByIds(ctx, ids, model.Visibility{})
+	require.NoError(t, err)
+	require.Len(t, byIds, 2, "private galleries are left out")
+
+	owned, err := galleries.GetGalleriesByOwners(ctx, []int32{ivan.Id, petr.Id}, model.Visibility{ViewerId: &petr.Id}, 1)
+	require.NoError(t, err)
+	require.Len(t, owned, 2)
+	require.Equal(t, "hands", owned[0].GalleryName)
+	require.Equal(t, 2, owned[0].CurrentSize)
+	require.Equal(t, "faces", owned[1].GalleryName)
+
+	pictures, err := galleries.GetPicturesByGalleries(ctx, ids, model.Visibility{}, 1)
+	require.NoError(t, err)
+	require.Len(t, pictures, 1)
+	require.Equal(t, "left", pictures[0].Name)
+}